@@ -10,13 +10,18 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	ariesapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api"
 	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/context"
@@ -29,6 +34,7 @@ import (
 	ariesmemstorage "github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 	vdripkg "github.com/hyperledger/aries-framework-go/pkg/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/vdri/httpbinding"
+	"github.com/piprate/json-gold/ld"
 	"github.com/rs/cors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -41,8 +47,12 @@ import (
 	"github.com/trustbloc/trustbloc-did-method/pkg/vdri/trustbloc"
 
 	"github.com/trustbloc/edge-service/internal/cryptosetup"
+	"github.com/trustbloc/edge-service/pkg/doc/jsonld"
+	"github.com/trustbloc/edge-service/pkg/job"
 	"github.com/trustbloc/edge-service/pkg/restapi/vc"
 	"github.com/trustbloc/edge-service/pkg/restapi/vc/operation"
+	"github.com/trustbloc/edge-service/pkg/vdri/cache"
+	"github.com/trustbloc/edge-service/pkg/vdri/pinned"
 )
 
 const (
@@ -132,6 +142,118 @@ const (
 		" Alternatively, this can be set with the following environment variable: " + tlsCACertsEnvKey
 	tlsCACertsEnvKey = "VC_REST_TLS_CACERTS"
 
+	clockSkewFlagName  = "clock-skew"
+	clockSkewFlagUsage = "Clock skew tolerated when checking credential validity period (e.g. 30s, 5m)." +
+		" Defaults to 0 if not set. Alternatively, this can be set with the following environment variable: " +
+		clockSkewEnvKey
+	clockSkewEnvKey = "VC_REST_CLOCK_SKEW"
+
+	challengeExpiryFlagName  = "challenge-expiry"
+	challengeExpiryFlagUsage = "Period the presentation challenges issued by the verifier are valid for (e.g. 30s, 5m)." +
+		" Defaults to 5m if not set. Alternatively, this can be set with the following environment variable: " +
+		challengeExpiryEnvKey
+	challengeExpiryEnvKey = "VC_REST_CHALLENGE_EXPIRY"
+
+	batchConcurrencyFlagName  = "batch-concurrency"
+	batchConcurrencyFlagUsage = "Number of the batch items verified or signed concurrently." +
+		" Defaults to 10 if not set. Alternatively, this can be set with the following environment variable: " +
+		batchConcurrencyEnvKey
+	batchConcurrencyEnvKey = "VC_REST_BATCH_CONCURRENCY"
+
+	pinnedSchemasFlagName  = "pinned-schemas"
+	pinnedSchemasFlagUsage = "Comma-Separated list of paths to the local copies of credential JSON schemas," +
+		" each schema is identified by its $id and is used instead of fetching the remote one." +
+		" Alternatively, this can be set with the following environment variable: " + pinnedSchemasEnvKey
+	pinnedSchemasEnvKey = "VC_REST_PINNED_SCHEMAS"
+
+	schemaAllowedHostsFlagName  = "schema-allowed-hosts"
+	schemaAllowedHostsFlagUsage = "Comma-Separated list of the hosts credential JSON schemas are fetched from." +
+		" If not set, schemas are fetched from any public https host." +
+		" Alternatively, this can be set with the following environment variable: " + schemaAllowedHostsEnvKey
+	schemaAllowedHostsEnvKey = "VC_REST_SCHEMA_ALLOWED_HOSTS"
+
+	webhookAllowedHostsFlagName  = "webhook-allowed-hosts"
+	webhookAllowedHostsFlagUsage = "Comma-Separated list of the hosts the profile webhooks are notified at." +
+		" If not set, webhooks are notified at any public https host." +
+		" Alternatively, this can be set with the following environment variable: " + webhookAllowedHostsEnvKey
+	webhookAllowedHostsEnvKey = "VC_REST_WEBHOOK_ALLOWED_HOSTS"
+
+	didCacheTTLFlagName  = "did-cache-ttl"
+	didCacheTTLFlagUsage = "Period the resolved DID documents are cached for (e.g. 30s, 5m), 0 disables caching." +
+		" Defaults to 5m if not set. Alternatively, this can be set with the following environment variable: " +
+		didCacheTTLEnvKey
+	didCacheTTLEnvKey = "VC_REST_DID_CACHE_TTL"
+
+	didCacheMethodTTLsFlagName  = "did-cache-method-ttls"
+	didCacheMethodTTLsFlagUsage = "Comma-Separated list of DID method TTLs overriding the DID cache TTL" +
+		" (e.g. key=24h,trustbloc=1m). Alternatively, this can be set with the following environment variable: " +
+		didCacheMethodTTLsEnvKey
+	didCacheMethodTTLsEnvKey = "VC_REST_DID_CACHE_METHOD_TTLS"
+
+	didCacheNegativeTTLFlagName  = "did-cache-negative-ttl"
+	didCacheNegativeTTLFlagUsage = "Period the DIDs which weren't found are cached for (e.g. 30s, 5m)," +
+		" 0 disables negative caching. Defaults to 30s if not set." +
+		" Alternatively, this can be set with the following environment variable: " + didCacheNegativeTTLEnvKey
+	didCacheNegativeTTLEnvKey = "VC_REST_DID_CACHE_NEGATIVE_TTL"
+
+	didCacheSizeFlagName  = "did-cache-size"
+	didCacheSizeFlagUsage = "Max number of the cached DID documents, the least recently used ones are evicted." +
+		" Defaults to 1000 if not set. Alternatively, this can be set with the following environment variable: " +
+		didCacheSizeEnvKey
+	didCacheSizeEnvKey = "VC_REST_DID_CACHE_SIZE"
+
+	offlineDirFlagName  = "offline-dir"
+	offlineDirFlagUsage = "Directory of the pinned DID documents (dids/*.json) and JSON-LD contexts (contexts/*.json)" +
+		" used instead of the network, enables the offline mode." +
+		" Alternatively, this can be set with the following environment variable: " + offlineDirEnvKey
+	offlineDirEnvKey = "VC_REST_OFFLINE_DIR"
+
+	offlineFallbackFlagName  = "offline-fallback"
+	offlineFallbackFlagUsage = "Resolve the DIDs and JSON-LD contexts which aren't pinned over the network in the" +
+		" offline mode. Possible values [true] [false]. Defaults to false if not set." +
+		" Alternatively, this can be set with the following environment variable: " + offlineFallbackEnvKey
+	offlineFallbackEnvKey = "VC_REST_OFFLINE_FALLBACK"
+
+	jsonldContextsFlagName  = "jsonld-contexts"
+	jsonldContextsFlagUsage = "Comma-Separated list of paths to the JSON-LD context files registered in addition to" +
+		" the embedded contexts, every file holds the context URL and the context document:" +
+		` {"documentURL": "https://...", "document": {"@context": ...}}.` +
+		" Alternatively, this can be set with the following environment variable: " + jsonldContextsEnvKey
+	jsonldContextsEnvKey = "VC_REST_JSONLD_CONTEXTS"
+
+	jsonldStrictFlagName  = "jsonld-strict"
+	jsonldStrictFlagUsage = "Reject the JSON-LD contexts which are neither embedded nor registered instead of" +
+		" fetching them. Possible values [true] [false]. Defaults to false if not set." +
+		" Alternatively, this can be set with the following environment variable: " + jsonldStrictEnvKey
+	jsonldStrictEnvKey = "VC_REST_JSONLD_STRICT"
+
+	jobMaxAttemptsFlagName  = "job-max-attempts"
+	jobMaxAttemptsFlagUsage = "Number of attempts to run the asynchronous job and to deliver its webhook." +
+		" Defaults to 5 if not set. Alternatively, this can be set with the following environment variable: " +
+		jobMaxAttemptsEnvKey
+	jobMaxAttemptsEnvKey = "VC_REST_JOB_MAX_ATTEMPTS"
+
+	jobBackoffFlagName  = "job-backoff"
+	jobBackoffFlagUsage = "Delay before the first retry of the failed asynchronous job (e.g. 1s, 1m)," +
+		" the delay is doubled with every retry. Defaults to 1s if not set." +
+		" Alternatively, this can be set with the following environment variable: " + jobBackoffEnvKey
+	jobBackoffEnvKey = "VC_REST_JOB_BACKOFF"
+
+	jobWebhookTimeoutFlagName  = "job-webhook-timeout"
+	jobWebhookTimeoutFlagUsage = "Timeout of the webhook request notifying the asynchronous job (e.g. 10s)." +
+		" Defaults to 10s if not set. Alternatively, this can be set with the following environment variable: " +
+		jobWebhookTimeoutEnvKey
+	jobWebhookTimeoutEnvKey = "VC_REST_JOB_WEBHOOK_TIMEOUT"
+
+	jobInstanceIDFlagName  = "job-instance-id"
+	jobInstanceIDFlagUsage = "ID of the instance tracking its pending asynchronous jobs, the instances sharing" +
+		" the database must have distinct IDs. Alternatively, this can be set with the following environment" +
+		" variable: " + jobInstanceIDEnvKey
+	jobInstanceIDEnvKey = "VC_REST_JOB_INSTANCE_ID"
+
+	offlineDIDsDir     = "dids"
+	offlineContextsDir = "contexts"
+
 	databaseTypeMemOption     = "mem"
 	databaseTypeCouchDBOption = "couchdb"
 
@@ -167,6 +289,23 @@ type vcRestParameters struct {
 	dbParameters         *dbParameters
 	tlsSystemCertPool    bool
 	tlsCACerts           []string
+	clockSkew            time.Duration
+	challengeExpiry      time.Duration
+	batchConcurrency     int
+	pinnedSchemas        map[string][]byte
+	schemaAllowedHosts   []string
+	webhookAllowedHosts  []string
+	didCacheOpts         []cache.Option
+	offline              *offlineParameters
+	jsonldContexts       []*ld.RemoteDocument
+	jsonldStrict         bool
+	jobOpts              []job.Option
+}
+
+type offlineParameters struct {
+	dids     []*did.Doc
+	contexts []*ld.RemoteDocument
+	fallback bool
 }
 
 type dbParameters struct {
@@ -263,7 +402,7 @@ func getVCRestParameters(cmd *cobra.Command) (*vcRestParameters, error) {
 		return nil, err
 	}
 
-	return &vcRestParameters{
+	parameters := &vcRestParameters{
 		hostURL:              hostURL,
 		edvURL:               edvURL,
 		blocDomain:           blocDomain,
@@ -273,7 +412,327 @@ func getVCRestParameters(cmd *cobra.Command) (*vcRestParameters, error) {
 		dbParameters:         dbParams,
 		tlsSystemCertPool:    tlsSystemCertPool,
 		tlsCACerts:           tlsCACerts,
-	}, nil
+	}
+
+	if err := setVerifierParameters(cmd, parameters); err != nil {
+		return nil, err
+	}
+
+	parameters.jobOpts, err = getJobOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return parameters, nil
+}
+
+func getJobOptions(cmd *cobra.Command) ([]job.Option, error) {
+	var opts []job.Option
+
+	maxAttempts, err := cmdutils.GetUserSetVarFromString(cmd, jobMaxAttemptsFlagName, jobMaxAttemptsEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxAttempts != "" {
+		n, err := strconv.Atoi(maxAttempts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid job max attempts: %w", err)
+		}
+
+		opts = append(opts, job.WithMaxAttempts(n))
+	}
+
+	backoff, err := getDuration(cmd, jobBackoffFlagName, jobBackoffEnvKey, "job backoff")
+	if err != nil {
+		return nil, err
+	}
+
+	if backoff != 0 {
+		opts = append(opts, job.WithBackoff(backoff))
+	}
+
+	webhookTimeout, err := getDuration(cmd, jobWebhookTimeoutFlagName, jobWebhookTimeoutEnvKey, "job webhook timeout")
+	if err != nil {
+		return nil, err
+	}
+
+	if webhookTimeout != 0 {
+		opts = append(opts, job.WithWebhookTimeout(webhookTimeout))
+	}
+
+	instanceID, err := cmdutils.GetUserSetVarFromString(cmd, jobInstanceIDFlagName, jobInstanceIDEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if instanceID != "" {
+		opts = append(opts, job.WithInstanceID(instanceID))
+	}
+
+	return opts, nil
+}
+
+// setVerifierParameters sets the parameters tuning the credential and presentation verification
+func setVerifierParameters(cmd *cobra.Command, parameters *vcRestParameters) error {
+	var err error
+
+	parameters.clockSkew, err = getDuration(cmd, clockSkewFlagName, clockSkewEnvKey, "clock skew")
+	if err != nil {
+		return err
+	}
+
+	parameters.challengeExpiry, err = getDuration(cmd, challengeExpiryFlagName, challengeExpiryEnvKey,
+		"challenge expiry")
+	if err != nil {
+		return err
+	}
+
+	parameters.pinnedSchemas, err = getPinnedSchemas(cmd)
+	if err != nil {
+		return err
+	}
+
+	parameters.schemaAllowedHosts, err = cmdutils.GetUserSetVarFromArrayString(cmd, schemaAllowedHostsFlagName,
+		schemaAllowedHostsEnvKey, true)
+	if err != nil {
+		return err
+	}
+
+	parameters.webhookAllowedHosts, err = cmdutils.GetUserSetVarFromArrayString(cmd, webhookAllowedHostsFlagName,
+		webhookAllowedHostsEnvKey, true)
+	if err != nil {
+		return err
+	}
+
+	parameters.didCacheOpts, err = getDIDCacheOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	parameters.offline, err = getOfflineParameters(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := setJSONLDParameters(cmd, parameters); err != nil {
+		return err
+	}
+
+	batchConcurrency, err := cmdutils.GetUserSetVarFromString(cmd, batchConcurrencyFlagName,
+		batchConcurrencyEnvKey, true)
+	if err != nil {
+		return err
+	}
+
+	if batchConcurrency != "" {
+		parameters.batchConcurrency, err = strconv.Atoi(batchConcurrency)
+		if err != nil {
+			return fmt.Errorf("invalid batch concurrency: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func getDuration(cmd *cobra.Command, flagName, envKey, name string) (time.Duration, error) {
+	durationString, err := cmdutils.GetUserSetVarFromString(cmd, flagName, envKey, true)
+	if err != nil {
+		return 0, err
+	}
+
+	if durationString == "" {
+		return 0, nil
+	}
+
+	duration, err := time.ParseDuration(durationString)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return duration, nil
+}
+
+func getDIDCacheOptions(cmd *cobra.Command) ([]cache.Option, error) {
+	var opts []cache.Option
+
+	for _, ttl := range []struct {
+		flagName, envKey, name string
+		option                 func(time.Duration) cache.Option
+	}{
+		{didCacheTTLFlagName, didCacheTTLEnvKey, "DID cache TTL", cache.WithTTL},
+		{didCacheNegativeTTLFlagName, didCacheNegativeTTLEnvKey, "DID cache negative TTL", cache.WithNegativeTTL},
+	} {
+		durationString, err := cmdutils.GetUserSetVarFromString(cmd, ttl.flagName, ttl.envKey, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if durationString == "" {
+			continue
+		}
+
+		duration, err := time.ParseDuration(durationString)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", ttl.name, err)
+		}
+
+		opts = append(opts, ttl.option(duration))
+	}
+
+	methodTTLOpts, err := getDIDCacheMethodTTLs(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, methodTTLOpts...)
+
+	size, err := cmdutils.GetUserSetVarFromString(cmd, didCacheSizeFlagName, didCacheSizeEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if size != "" {
+		maxSize, err := strconv.Atoi(size)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DID cache size: %w", err)
+		}
+
+		opts = append(opts, cache.WithMaxSize(maxSize))
+	}
+
+	return opts, nil
+}
+
+func getDIDCacheMethodTTLs(cmd *cobra.Command) ([]cache.Option, error) {
+	methodTTLs, err := cmdutils.GetUserSetVarFromArrayString(cmd, didCacheMethodTTLsFlagName,
+		didCacheMethodTTLsEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []cache.Option
+
+	for _, methodTTL := range methodTTLs {
+		i := strings.Index(methodTTL, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("invalid DID cache method TTL %s: expected method=duration", methodTTL)
+		}
+
+		duration, err := time.ParseDuration(methodTTL[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid DID cache method TTL %s: %w", methodTTL, err)
+		}
+
+		opts = append(opts, cache.WithMethodTTL(methodTTL[:i], duration))
+	}
+
+	return opts, nil
+}
+
+func setJSONLDParameters(cmd *cobra.Command, parameters *vcRestParameters) error {
+	paths, err := cmdutils.GetUserSetVarFromArrayString(cmd, jsonldContextsFlagName, jsonldContextsEnvKey, true)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		c, err := jsonld.ReadContext(path)
+		if err != nil {
+			return err
+		}
+
+		parameters.jsonldContexts = append(parameters.jsonldContexts, c)
+	}
+
+	strict, err := cmdutils.GetUserSetVarFromString(cmd, jsonldStrictFlagName, jsonldStrictEnvKey, true)
+	if err != nil {
+		return err
+	}
+
+	if strict != "" {
+		parameters.jsonldStrict, err = strconv.ParseBool(strict)
+		if err != nil {
+			return fmt.Errorf("invalid JSON-LD strict: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func getOfflineParameters(cmd *cobra.Command) (*offlineParameters, error) {
+	dir, err := cmdutils.GetUserSetVarFromString(cmd, offlineDirFlagName, offlineDirEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir == "" {
+		return nil, nil
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("invalid offline dir: %w", err)
+	}
+
+	offline := &offlineParameters{}
+
+	fallback, err := cmdutils.GetUserSetVarFromString(cmd, offlineFallbackFlagName, offlineFallbackEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if fallback != "" {
+		offline.fallback, err = strconv.ParseBool(fallback)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offline fallback: %w", err)
+		}
+	}
+
+	offline.dids, err = pinned.ReadDir(filepath.Join(dir, offlineDIDsDir))
+	if err != nil {
+		return nil, err
+	}
+
+	offline.contexts, err = jsonld.ReadContexts(filepath.Join(dir, offlineContextsDir))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Offline mode: %d pinned DID documents, %d pinned contexts", len(offline.dids), len(offline.contexts))
+
+	return offline, nil
+}
+
+func getPinnedSchemas(cmd *cobra.Command) (map[string][]byte, error) {
+	paths, err := cmdutils.GetUserSetVarFromArrayString(cmd, pinnedSchemasFlagName, pinnedSchemasEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := make(map[string][]byte)
+
+	for _, path := range paths {
+		schemaBytes, err := ioutil.ReadFile(path) // nolint: gosec
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pinned schema %s: %w", path, err)
+		}
+
+		schema := struct {
+			ID string `json:"$id"`
+		}{}
+
+		if err := json.Unmarshal(schemaBytes, &schema); err != nil {
+			return nil, fmt.Errorf("invalid pinned schema %s: %w", path, err)
+		}
+
+		if schema.ID == "" {
+			return nil, fmt.Errorf("pinned schema %s has no $id", path)
+		}
+
+		schemas[schema.ID] = schemaBytes
+	}
+
+	return schemas, nil
 }
 
 func getMode(cmd *cobra.Command) (string, error) {
@@ -383,6 +842,24 @@ func createFlags(startCmd *cobra.Command) {
 	startCmd.Flags().StringP(tlsSystemCertPoolFlagName, "", "",
 		tlsSystemCertPoolFlagUsage)
 	startCmd.Flags().StringArrayP(tlsCACertsFlagName, "", []string{}, tlsCACertsFlagUsage)
+	startCmd.Flags().StringP(clockSkewFlagName, "", "", clockSkewFlagUsage)
+	startCmd.Flags().StringP(challengeExpiryFlagName, "", "", challengeExpiryFlagUsage)
+	startCmd.Flags().StringP(batchConcurrencyFlagName, "", "", batchConcurrencyFlagUsage)
+	startCmd.Flags().StringArrayP(pinnedSchemasFlagName, "", []string{}, pinnedSchemasFlagUsage)
+	startCmd.Flags().StringArrayP(schemaAllowedHostsFlagName, "", []string{}, schemaAllowedHostsFlagUsage)
+	startCmd.Flags().StringArrayP(webhookAllowedHostsFlagName, "", []string{}, webhookAllowedHostsFlagUsage)
+	startCmd.Flags().StringP(didCacheTTLFlagName, "", "", didCacheTTLFlagUsage)
+	startCmd.Flags().StringArrayP(didCacheMethodTTLsFlagName, "", []string{}, didCacheMethodTTLsFlagUsage)
+	startCmd.Flags().StringP(didCacheNegativeTTLFlagName, "", "", didCacheNegativeTTLFlagUsage)
+	startCmd.Flags().StringP(didCacheSizeFlagName, "", "", didCacheSizeFlagUsage)
+	startCmd.Flags().StringP(offlineDirFlagName, "", "", offlineDirFlagUsage)
+	startCmd.Flags().StringP(offlineFallbackFlagName, "", "", offlineFallbackFlagUsage)
+	startCmd.Flags().StringArrayP(jsonldContextsFlagName, "", []string{}, jsonldContextsFlagUsage)
+	startCmd.Flags().StringP(jsonldStrictFlagName, "", "", jsonldStrictFlagUsage)
+	startCmd.Flags().StringP(jobMaxAttemptsFlagName, "", "", jobMaxAttemptsFlagUsage)
+	startCmd.Flags().StringP(jobBackoffFlagName, "", "", jobBackoffFlagUsage)
+	startCmd.Flags().StringP(jobWebhookTimeoutFlagName, "", "", jobWebhookTimeoutFlagUsage)
+	startCmd.Flags().StringP(jobInstanceIDFlagName, "", "", jobInstanceIDFlagUsage)
 }
 
 func startEdgeService(parameters *vcRestParameters, srv server) error {
@@ -402,7 +879,7 @@ func startEdgeService(parameters *vcRestParameters, srv server) error {
 	}
 
 	// Create VDRI
-	vdri, err := createVDRI(parameters.universalResolverURL, legacyKMS, &tls.Config{RootCAs: rootCAs})
+	vdri, err := createDIDCache(parameters, legacyKMS, &tls.Config{RootCAs: rootCAs})
 	if err != nil {
 		return err
 	}
@@ -417,16 +894,28 @@ func startEdgeService(parameters *vcRestParameters, srv server) error {
 		return err
 	}
 
+	documentLoader := createDocumentLoader(parameters)
+
 	vcService, err := vc.New(&operation.Config{StoreProvider: edgeServiceProvs.provider,
-		KMSSecretsProvider: edgeServiceProvs.kmsSecretsProvider,
-		EDVClient:          edv.New(parameters.edvURL, edv.WithTLSConfig(&tls.Config{RootCAs: rootCAs})),
-		KeyManager:         localKMS,
-		Crypto:             crypto,
-		VDRI:               vdri,
-		HostURL:            externalHostURL,
-		Mode:               parameters.mode,
-		Domain:             parameters.blocDomain,
-		TLSConfig:          &tls.Config{RootCAs: rootCAs}})
+		KMSSecretsProvider:  edgeServiceProvs.kmsSecretsProvider,
+		EDVClient:           edv.New(parameters.edvURL, edv.WithTLSConfig(&tls.Config{RootCAs: rootCAs})),
+		KeyManager:          localKMS,
+		Crypto:              crypto,
+		VDRI:                vdri,
+		DIDCache:            vdri,
+		HostURL:             externalHostURL,
+		Mode:                parameters.mode,
+		Domain:              parameters.blocDomain,
+		TLSConfig:           &tls.Config{RootCAs: rootCAs},
+		ClockSkew:           parameters.clockSkew,
+		ChallengeExpiry:     parameters.challengeExpiry,
+		BatchConcurrency:    parameters.batchConcurrency,
+		PinnedSchemas:       parameters.pinnedSchemas,
+		SchemaAllowedHosts:  parameters.schemaAllowedHosts,
+		WebhookAllowedHosts: parameters.webhookAllowedHosts,
+		DocumentLoader:      documentLoader,
+		Offline:             parameters.offline != nil && !parameters.offline.fallback,
+		JobOptions:          parameters.jobOpts})
 	if err != nil {
 		return err
 	}
@@ -459,6 +948,44 @@ func (k kmsProvider) SecretLock() secretlock.Service {
 	return k.secretLockService
 }
 
+// createDIDCache creates the caching DID resolver in front of the network or, in the offline mode,
+// the pinned DID documents
+func createDIDCache(parameters *vcRestParameters, kms legacykms.KMS, tlsConfig *tls.Config) (*cache.Registry, error) {
+	registry, err := createVDRI(parameters.universalResolverURL, kms, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if parameters.offline != nil {
+		var fallback vdriapi.Registry
+
+		if parameters.offline.fallback {
+			fallback = registry
+		}
+
+		registry = pinned.New(parameters.offline.dids, fallback)
+	}
+
+	return cache.New(registry, parameters.didCacheOpts...), nil
+}
+
+// createDocumentLoader creates the JSON-LD document loader of the embedded, registered and, in the offline mode,
+// pinned contexts. Other contexts are fetched unless the strict or the offline mode is on.
+func createDocumentLoader(parameters *vcRestParameters) *jsonld.DocumentLoader {
+	opts := []jsonld.Option{jsonld.WithEmbeddedContexts(), jsonld.WithContexts(parameters.jsonldContexts...)}
+
+	offline := parameters.offline
+	if offline != nil {
+		opts = append(opts, jsonld.WithContexts(offline.contexts...))
+	}
+
+	if !parameters.jsonldStrict && (offline == nil || offline.fallback) {
+		opts = append(opts, jsonld.WithFallback(verifiable.CachingJSONLDLoader()))
+	}
+
+	return jsonld.NewDocumentLoader(opts...)
+}
+
 func createVDRI(universalResolver string, kms legacykms.KMS, tlsConfig *tls.Config) (vdriapi.Registry, error) {
 	var opts []vdripkg.Option
 
@@ -7,15 +7,21 @@ package startcmd
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/edge-service/pkg/doc/jsonld"
 )
 
 type mockServer struct{}
@@ -180,7 +186,8 @@ func TestStartCmdValidArgs(t *testing.T) {
 
 	args := []string{"--" + hostURLFlagName, "localhost:8080", "--" + edvURLFlagName,
 		"localhost:8081", "--" + blocDomainFlagName, "domain", "--" + databaseTypeFlagName, databaseTypeMemOption,
-		"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption}
+		"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption, "--" + clockSkewFlagName, "30s",
+		"--" + challengeExpiryFlagName, "1m", "--" + batchConcurrencyFlagName, "5"}
 	startCmd.SetArgs(args)
 
 	err := startCmd.Execute()
@@ -357,11 +364,355 @@ func TestTLSSystemCertPoolInvalidArgsEnvVar(t *testing.T) {
 	defer unsetEnvVars(t)
 	require.NoError(t, os.Setenv(tlsSystemCertPoolEnvKey, "wrongvalue"))
 
+	defer func() { require.NoError(t, os.Unsetenv(tlsSystemCertPoolEnvKey)) }()
+
 	err := startCmd.Execute()
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "invalid syntax")
 }
 
+func TestPinnedSchemas(t *testing.T) {
+	validSchema := writeTempFile(t, `{"$id": "https://example.com/schemas/degree.json", "type": "object"}`)
+	defer func() { require.NoError(t, os.Remove(validSchema)) }()
+
+	noIDSchema := writeTempFile(t, `{"type": "object"}`)
+	defer func() { require.NoError(t, os.Remove(noIDSchema)) }()
+
+	invalidSchema := writeTempFile(t, `invalid`)
+	defer func() { require.NoError(t, os.Remove(invalidSchema)) }()
+
+	tests := []struct {
+		name string
+		path string
+		err  string
+	}{
+		{name: "success", path: validSchema},
+		{name: "file not found", path: "/not/existing/schema.json", err: "failed to read pinned schema"},
+		{name: "schema without $id", path: noIDSchema, err: "has no $id"},
+		{name: "invalid schema", path: invalidSchema, err: "invalid pinned schema"},
+	}
+
+	for _, tc := range tests {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs([]string{"--" + hostURLFlagName, "localhost:8080", "--" + edvURLFlagName,
+			"localhost:8081", "--" + blocDomainFlagName, "domain", "--" + databaseTypeFlagName, databaseTypeMemOption,
+			"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption, "--" + pinnedSchemasFlagName, tc.path})
+
+		err := startCmd.Execute()
+		if tc.err == "" {
+			require.NoError(t, err, tc.name)
+
+			continue
+		}
+
+		require.Error(t, err, tc.name)
+		require.Contains(t, err.Error(), tc.err, tc.name)
+	}
+}
+
+func TestSchemaAllowedHosts(t *testing.T) {
+	startCmd := GetStartCmd(&mockServer{})
+
+	startCmd.SetArgs([]string{"--" + hostURLFlagName, "localhost:8080", "--" + edvURLFlagName,
+		"localhost:8081", "--" + blocDomainFlagName, "domain", "--" + databaseTypeFlagName, databaseTypeMemOption,
+		"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+		"--" + schemaAllowedHostsFlagName, "schemas.example.com,w3id.org"})
+
+	require.NoError(t, startCmd.Execute())
+
+	hosts, err := startCmd.Flags().GetStringArray(schemaAllowedHostsFlagName)
+	require.NoError(t, err)
+	require.Equal(t, []string{"schemas.example.com,w3id.org"}, hosts)
+}
+
+func TestWebhookAllowedHosts(t *testing.T) {
+	startCmd := GetStartCmd(&mockServer{})
+
+	startCmd.SetArgs([]string{"--" + hostURLFlagName, "localhost:8080", "--" + edvURLFlagName,
+		"localhost:8081", "--" + blocDomainFlagName, "domain", "--" + databaseTypeFlagName, databaseTypeMemOption,
+		"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+		"--" + webhookAllowedHostsFlagName, "webhooks.example.com"})
+
+	require.NoError(t, startCmd.Execute())
+
+	hosts, err := startCmd.Flags().GetStringArray(webhookAllowedHostsFlagName)
+	require.NoError(t, err)
+	require.Equal(t, []string{"webhooks.example.com"}, hosts)
+}
+
+func TestClockSkewInvalidArgsEnvVar(t *testing.T) {
+	startCmd := GetStartCmd(&mockServer{})
+
+	setEnvVars(t, databaseTypeMemOption)
+
+	defer unsetEnvVars(t)
+	require.NoError(t, os.Setenv(clockSkewEnvKey, "5 minutes"))
+
+	defer func() { require.NoError(t, os.Unsetenv(clockSkewEnvKey)) }()
+
+	err := startCmd.Execute()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid clock skew")
+}
+
+func TestBatchConcurrencyInvalidArgsEnvVar(t *testing.T) {
+	startCmd := GetStartCmd(&mockServer{})
+
+	setEnvVars(t, databaseTypeMemOption)
+
+	defer unsetEnvVars(t)
+	require.NoError(t, os.Setenv(batchConcurrencyEnvKey, "ten"))
+
+	defer func() { require.NoError(t, os.Unsetenv(batchConcurrencyEnvKey)) }()
+
+	err := startCmd.Execute()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid batch concurrency")
+}
+
+func TestDIDCacheInvalidArgsEnvVar(t *testing.T) {
+	tests := []struct {
+		envKey string
+		value  string
+		errMsg string
+	}{
+		{didCacheTTLEnvKey, "5 minutes", "invalid DID cache TTL"},
+		{didCacheNegativeTTLEnvKey, "-", "invalid DID cache negative TTL"},
+		{didCacheMethodTTLsEnvKey, "key", "invalid DID cache method TTL key: expected method=duration"},
+		{didCacheMethodTTLsEnvKey, "key=1 day", "invalid DID cache method TTL key=1 day"},
+		{didCacheSizeEnvKey, "large", "invalid DID cache size"},
+	}
+
+	for _, tc := range tests {
+		startCmd := GetStartCmd(&mockServer{})
+
+		setEnvVars(t, databaseTypeMemOption)
+		require.NoError(t, os.Setenv(tc.envKey, tc.value))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), tc.errMsg)
+
+		unsetEnvVars(t)
+		require.NoError(t, os.Unsetenv(tc.envKey))
+	}
+}
+
+func TestJobInvalidArgsEnvVar(t *testing.T) {
+	tests := []struct {
+		envKey string
+		value  string
+		errMsg string
+	}{
+		{jobMaxAttemptsEnvKey, "many", "invalid job max attempts"},
+		{jobBackoffEnvKey, "1 second", "invalid job backoff"},
+		{jobWebhookTimeoutEnvKey, "1 second", "invalid job webhook timeout"},
+	}
+
+	for _, tc := range tests {
+		startCmd := GetStartCmd(&mockServer{})
+
+		setEnvVars(t, databaseTypeMemOption)
+		require.NoError(t, os.Setenv(tc.envKey, tc.value))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), tc.errMsg)
+
+		unsetEnvVars(t)
+		require.NoError(t, os.Unsetenv(tc.envKey))
+	}
+}
+
+func TestOfflineMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "offline")
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, offlineDIDsDir), 0700))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, offlineContextsDir), 0700))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, offlineDIDsDir, "issuer.json"),
+		[]byte(`{"@context": ["https://w3id.org/did/v1"], "id": "did:example:issuer"}`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, offlineContextsDir, "examples.json"),
+		[]byte(`{"documentURL": "https://example.com/context", "document": {"@context": {"@vocab": "ex:"}}}`), 0600))
+
+	t.Run("test offline mode without fallback", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs([]string{"--" + hostURLFlagName, "localhost:8080", "--" + edvURLFlagName,
+			"localhost:8081", "--" + blocDomainFlagName, "domain", "--" + databaseTypeFlagName, databaseTypeMemOption,
+			"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption, "--" + offlineDirFlagName, dir})
+
+		require.NoError(t, startCmd.Execute())
+
+		offline, err := getOfflineParameters(startCmd)
+		require.NoError(t, err)
+		require.False(t, offline.fallback)
+
+		registry, err := createDIDCache(&vcRestParameters{offline: offline}, nil, &tls.Config{})
+		require.NoError(t, err)
+
+		doc, err := registry.Resolve("did:example:issuer")
+		require.NoError(t, err)
+		require.Equal(t, "did:example:issuer", doc.ID)
+
+		_, err = registry.Resolve("did:example:holder")
+		require.True(t, errors.Is(err, vdriapi.ErrNotFound))
+
+		loader := createDocumentLoader(&vcRestParameters{offline: offline})
+
+		_, err = loader.LoadDocument("https://example.com/context")
+		require.NoError(t, err)
+
+		_, err = loader.LoadDocument("https://www.w3.org/2018/credentials/examples/v1")
+		require.Error(t, err)
+	})
+
+	t.Run("test offline mode with fallback", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		setEnvVars(t, databaseTypeMemOption)
+		require.NoError(t, os.Setenv(offlineDirEnvKey, dir))
+		require.NoError(t, os.Setenv(offlineFallbackEnvKey, "true"))
+
+		defer func() {
+			unsetEnvVars(t)
+			require.NoError(t, os.Unsetenv(offlineDirEnvKey))
+			require.NoError(t, os.Unsetenv(offlineFallbackEnvKey))
+		}()
+
+		require.NoError(t, startCmd.Execute())
+
+		offline, err := getOfflineParameters(startCmd)
+		require.NoError(t, err)
+		require.True(t, offline.fallback)
+
+		_, err = createDocumentLoader(&vcRestParameters{offline: offline}).LoadDocument(
+			"https://www.w3.org/2018/credentials/v1")
+		require.NoError(t, err)
+	})
+}
+
+func TestOfflineModeInvalidArgsEnvVar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "offline")
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, offlineDIDsDir), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, offlineDIDsDir, "issuer.json"), []byte("{}"), 0600))
+
+	tests := []struct {
+		dir      string
+		fallback string
+		errMsg   string
+	}{
+		{filepath.Join(dir, "missing"), "", "invalid offline dir"},
+		{dir, "yes", "invalid offline fallback"},
+		{dir, "", "invalid pinned DID document"},
+	}
+
+	for _, tc := range tests {
+		startCmd := GetStartCmd(&mockServer{})
+
+		setEnvVars(t, databaseTypeMemOption)
+		require.NoError(t, os.Setenv(offlineDirEnvKey, tc.dir))
+		require.NoError(t, os.Setenv(offlineFallbackEnvKey, tc.fallback))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), tc.errMsg)
+
+		unsetEnvVars(t)
+		require.NoError(t, os.Unsetenv(offlineDirEnvKey))
+		require.NoError(t, os.Unsetenv(offlineFallbackEnvKey))
+	}
+}
+
+func TestJSONLDContexts(t *testing.T) {
+	file, err := ioutil.TempFile("", "context*.json")
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, os.Remove(file.Name())) }()
+
+	_, err = file.WriteString(`{"documentURL": "https://example.com/context", "document": {"@context": {}}}`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	startCmd := GetStartCmd(&mockServer{})
+
+	startCmd.SetArgs([]string{"--" + hostURLFlagName, "localhost:8080", "--" + edvURLFlagName,
+		"localhost:8081", "--" + blocDomainFlagName, "domain", "--" + databaseTypeFlagName, databaseTypeMemOption,
+		"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption, "--" + jsonldContextsFlagName, file.Name(),
+		"--" + jsonldStrictFlagName, "true"})
+
+	require.NoError(t, startCmd.Execute())
+
+	parameters := &vcRestParameters{}
+	require.NoError(t, setJSONLDParameters(startCmd, parameters))
+	require.True(t, parameters.jsonldStrict)
+	require.Len(t, parameters.jsonldContexts, 1)
+
+	loader := createDocumentLoader(parameters)
+
+	for _, u := range []string{"https://example.com/context", jsonld.CredentialsV1Context,
+		jsonld.CredentialsV2Context, jsonld.DataIntegrityV1Context, jsonld.JSONWebSignature2020Context,
+		jsonld.ExamplesV1Context, jsonld.DIDConfigurationV1Context, jsonld.RefreshServiceV1Context} {
+		_, err = loader.LoadDocument(u)
+		require.NoError(t, err)
+	}
+
+	_, err = loader.LoadDocument("https://example.com/other")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "context https://example.com/other is not registered")
+
+	// the loader is given to the signature suites, the default HTTP client is not changed
+	require.Nil(t, http.DefaultClient.Transport)
+}
+
+func TestJSONLDInvalidArgsEnvVar(t *testing.T) {
+	tests := []struct {
+		envKey string
+		value  string
+		errMsg string
+	}{
+		{jsonldStrictEnvKey, "yes", "invalid JSON-LD strict"},
+		{jsonldContextsEnvKey, "missing.json", "failed to read context missing.json"},
+	}
+
+	for _, tc := range tests {
+		startCmd := GetStartCmd(&mockServer{})
+
+		setEnvVars(t, databaseTypeMemOption)
+		require.NoError(t, os.Setenv(tc.envKey, tc.value))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), tc.errMsg)
+
+		unsetEnvVars(t)
+		require.NoError(t, os.Unsetenv(tc.envKey))
+	}
+}
+
+func TestChallengeExpiryInvalidArgsEnvVar(t *testing.T) {
+	startCmd := GetStartCmd(&mockServer{})
+
+	setEnvVars(t, databaseTypeMemOption)
+
+	defer unsetEnvVars(t)
+	require.NoError(t, os.Setenv(challengeExpiryEnvKey, "5 minutes"))
+
+	defer func() { require.NoError(t, os.Unsetenv(challengeExpiryEnvKey)) }()
+
+	err := startCmd.Execute()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid challenge expiry")
+}
+
 func setEnvVars(t *testing.T, databaseType string) {
 	err := os.Setenv(hostURLEnvKey, "localhost:8080")
 	require.NoError(t, err)
@@ -408,3 +759,16 @@ func checkFlagPropertiesCorrect(t *testing.T, cmd *cobra.Command, flagName, flag
 	flagAnnotations := flag.Annotations
 	require.Nil(t, flagAnnotations)
 }
+
+func writeTempFile(t *testing.T, content string) string {
+	t.Helper()
+
+	f, err := ioutil.TempFile("", "schema")
+	require.NoError(t, err)
+
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	return f.Name()
+}
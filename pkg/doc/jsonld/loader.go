@@ -0,0 +1,119 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonld
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// Option configures the document loader.
+type Option func(l *DocumentLoader)
+
+// WithContexts registers the contexts served by the loader.
+func WithContexts(contexts ...*ld.RemoteDocument) Option {
+	return func(l *DocumentLoader) {
+		for _, c := range contexts {
+			l.contexts[c.DocumentURL] = c
+		}
+	}
+}
+
+// WithEmbeddedContexts registers the contexts shipped with the service.
+func WithEmbeddedContexts() Option {
+	return WithContexts(EmbeddedContexts()...)
+}
+
+// WithFallback sets the loader of the contexts which aren't registered (e.g. the remote loader).
+func WithFallback(loader ld.DocumentLoader) Option {
+	return func(l *DocumentLoader) {
+		l.fallback = loader
+	}
+}
+
+// DocumentLoader is the JSON-LD document loader serving the registered contexts, the contexts which aren't
+// registered are loaded by the fallback loader or rejected when there is no fallback.
+type DocumentLoader struct {
+	contexts map[string]*ld.RemoteDocument
+	fallback ld.DocumentLoader
+}
+
+// NewDocumentLoader returns new document loader.
+func NewDocumentLoader(opts ...Option) *DocumentLoader {
+	l := &DocumentLoader{contexts: make(map[string]*ld.RemoteDocument)}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// LoadDocument returns the registered context or the one loaded by the fallback loader.
+func (l *DocumentLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+	if c, ok := l.contexts[u]; ok {
+		return c, nil
+	}
+
+	if l.fallback == nil {
+		return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, fmt.Sprintf("context %s is not registered", u))
+	}
+
+	return l.fallback.LoadDocument(u)
+}
+
+// contextFile is the context with its URL, e.g. {"documentURL": "https://...", "document": {"@context": ...}}
+type contextFile struct {
+	DocumentURL string      `json:"documentURL"`
+	Document    interface{} `json:"document"`
+}
+
+// ReadContexts reads the contexts from the JSON files of the directory, every file holds
+// the context URL and the context document.
+func ReadContexts(dir string) ([]*ld.RemoteDocument, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json*"))
+	if err != nil {
+		return nil, err
+	}
+
+	contexts := make([]*ld.RemoteDocument, 0, len(paths))
+
+	for _, path := range paths {
+		c, err := ReadContext(path)
+		if err != nil {
+			return nil, err
+		}
+
+		contexts = append(contexts, c)
+	}
+
+	return contexts, nil
+}
+
+// ReadContext reads the context with its URL from the JSON file.
+func ReadContext(path string) (*ld.RemoteDocument, error) {
+	fileBytes, err := ioutil.ReadFile(path) // nolint: gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read context %s: %w", path, err)
+	}
+
+	c := &contextFile{}
+
+	if err := json.Unmarshal(fileBytes, c); err != nil {
+		return nil, fmt.Errorf("invalid context %s: %w", path, err)
+	}
+
+	if c.DocumentURL == "" || c.Document == nil {
+		return nil, fmt.Errorf("invalid context %s: documentURL and document are required", path)
+	}
+
+	return &ld.RemoteDocument{DocumentURL: c.DocumentURL, Document: c.Document}, nil
+}
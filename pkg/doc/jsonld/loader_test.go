@@ -0,0 +1,259 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonld
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLoader struct {
+	docs map[string]*ld.RemoteDocument
+}
+
+func (l *mockLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+	if doc, ok := l.docs[u]; ok {
+		return doc, nil
+	}
+
+	return nil, errors.New("not found")
+}
+
+func TestDocumentLoader(t *testing.T) {
+	pinned := &ld.RemoteDocument{DocumentURL: "https://example.com/pinned", Document: map[string]interface{}{}}
+	remote := &ld.RemoteDocument{DocumentURL: "https://example.com/remote", Document: map[string]interface{}{}}
+
+	t.Run("test without fallback", func(t *testing.T) {
+		l := NewDocumentLoader(WithContexts(pinned))
+
+		doc, err := l.LoadDocument(pinned.DocumentURL)
+		require.NoError(t, err)
+		require.Equal(t, pinned, doc)
+
+		_, err = l.LoadDocument(remote.DocumentURL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "context https://example.com/remote is not registered")
+	})
+
+	t.Run("test with fallback", func(t *testing.T) {
+		l := NewDocumentLoader(WithContexts(pinned), WithFallback(&mockLoader{
+			docs: map[string]*ld.RemoteDocument{remote.DocumentURL: remote},
+		}))
+
+		doc, err := l.LoadDocument(pinned.DocumentURL)
+		require.NoError(t, err)
+		require.Equal(t, pinned, doc)
+
+		doc, err = l.LoadDocument(remote.DocumentURL)
+		require.NoError(t, err)
+		require.Equal(t, remote, doc)
+
+		_, err = l.LoadDocument("https://example.com/other")
+		require.EqualError(t, err, "not found")
+	})
+}
+
+func TestEmbeddedContexts(t *testing.T) {
+	l := NewDocumentLoader(WithEmbeddedContexts())
+
+	// SHA-256 digests of the vendored contexts (JSON with the sorted keys), the embedded contexts are updated
+	// only by vendoring the new published versions
+	digests := map[string]string{
+		CredentialsV1Context:        "b01e671e873981f19a9102a9a57f666dbcaeb31b99e3e124378d143e71549247",
+		CredentialsV2Context:        "49f8cfd942c81c78c0806dd3ef6571f5c3003f3154d548034179a42e07a0af4b",
+		DataIntegrityV1Context:      "9505bf85338a4c2121ad03992ac90061bc85d43bcfa34d9d47b779381cf08b5f",
+		JSONWebSignature2020Context: "50cc09a9b71a64e94e30a1faa10a7ad77863581055a77b3be8246397f90064c9",
+		ExamplesV1Context:           "622dca7e3dfe3b52dd87dad042614cd41a70e193dcd536ae3ba90caa53d0dbcd",
+		DIDConfigurationV1Context:   "813c5f20d5de8eee24c6912d83c8396c670995ef4831a56bdc03c1e05cb24d8b",
+		RefreshServiceV1Context:     "a7fd5fe9c8eb4f914d9a9bb6cd37d7c72f76766b99b873681f14e747c8050a88",
+	}
+
+	require.Len(t, EmbeddedContexts(), len(digests))
+
+	for _, c := range EmbeddedContexts() {
+		docBytes, err := json.Marshal(c.Document)
+		require.NoError(t, err)
+
+		digest := sha256.Sum256(docBytes)
+		require.Equal(t, digests[c.DocumentURL], hex.EncodeToString(digest[:]), c.DocumentURL)
+	}
+
+	for u := range digests {
+		doc, err := l.LoadDocument(u)
+		require.NoError(t, err)
+		require.Equal(t, u, doc.DocumentURL)
+		require.Contains(t, doc.Document, "@context")
+	}
+
+	// embedded contexts define the terms of the domain linkage credential
+	vc := map[string]interface{}{
+		"@context": []interface{}{CredentialsV1Context, DIDConfigurationV1Context},
+		"type":     []interface{}{"VerifiableCredential", "DomainLinkageCredential"},
+		"credentialSubject": map[string]interface{}{
+			"id":     "did:example:123",
+			"origin": "https://example.com",
+		},
+	}
+
+	options := ld.NewJsonLdOptions("")
+	options.DocumentLoader = l
+	options.Format = "application/n-quads"
+	options.Algorithm = "URDNA2015"
+
+	view, err := ld.NewJsonLdProcessor().Normalize(vc, options)
+	require.NoError(t, err)
+	require.Contains(t, view,
+		"<https://identity.foundation/.well-known/resources/did-configuration/#DomainLinkageCredential>")
+	require.Contains(t, view, "<https://identity.foundation/.well-known/resources/did-configuration/#origin>")
+}
+
+func TestEmbeddedContexts_Offline(t *testing.T) {
+	// the loader without fallback serves the embedded contexts only, nothing is fetched
+	l := NewDocumentLoader(WithEmbeddedContexts())
+
+	tests := []struct {
+		name string
+		doc  string
+		iris []string
+	}{
+		{
+			name: "JsonWebSignature2020 credential with CSL status",
+			doc: `{
+  "@context": ["` + CredentialsV1Context + `", "` + ExamplesV1Context + `", "` + JSONWebSignature2020Context + `"],
+  "id": "http://example.com/credentials/1",
+  "type": ["VerifiableCredential", "UniversityDegreeCredential"],
+  "issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+  "issuanceDate": "2020-04-29T00:04:25Z",
+  "credentialStatus": {"id": "https://example.com/status/1", "type": "CredentialStatusList2017"},
+  "credentialSubject": {"id": "did:example:ebfeb1f712ebc6f1c276e12ec21", "degree": {"type": "BachelorDegree"}},
+  "proof": {
+    "type": "JsonWebSignature2020",
+    "created": "2020-04-29T00:04:29Z",
+    "jws": "eyJ..sig",
+    "proofPurpose": "assertionMethod",
+    "verificationMethod": "did:example:76e12ec712ebc6f1c221ebfeb1f#key-1"
+  }
+}`,
+			iris: []string{
+				"<https://example.org/examples#CredentialStatusList2017>",
+				"<https://example.org/examples#UniversityDegreeCredential>",
+				"<https://w3c-ccg.github.io/lds-jws2020/contexts/#JsonWebSignature2020>",
+				"<https://w3id.org/security#jws>",
+				"<https://w3id.org/security#proofPurpose> <https://w3id.org/security#assertionMethod>",
+				"<https://w3id.org/security#verificationMethod>",
+			},
+		},
+		{
+			name: "VC data model 2.0 credential with data integrity proof",
+			doc: `{
+  "@context": ["` + CredentialsV2Context + `"],
+  "id": "http://example.com/credentials/2",
+  "type": ["VerifiableCredential"],
+  "issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+  "validFrom": "2023-01-01T00:00:00Z",
+  "credentialSubject": {"id": "did:example:ebfeb1f712ebc6f1c276e12ec21"},
+  "proof": {
+    "type": "DataIntegrityProof",
+    "cryptosuite": "eddsa-2022",
+    "created": "2023-01-01T00:00:00Z",
+    "proofPurpose": "assertionMethod",
+    "proofValue": "z58DAdFfa9",
+    "verificationMethod": "did:example:76e12ec712ebc6f1c221ebfeb1f#key-1"
+  }
+}`,
+			iris: []string{
+				"<https://www.w3.org/2018/credentials#validFrom>",
+				"<https://w3id.org/security#DataIntegrityProof>",
+				"<https://w3id.org/security#cryptosuite>",
+				"<https://w3id.org/security#proofValue>",
+			},
+		},
+		{
+			name: "VC data model 1.1 credential with data integrity proof",
+			doc: `{
+  "@context": ["` + CredentialsV1Context + `", "` + DataIntegrityV1Context + `"],
+  "id": "http://example.com/credentials/3",
+  "type": ["VerifiableCredential"],
+  "issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+  "issuanceDate": "2023-01-01T00:00:00Z",
+  "credentialSubject": {"id": "did:example:ebfeb1f712ebc6f1c276e12ec21"},
+  "proof": {
+    "type": "DataIntegrityProof",
+    "cryptosuite": "eddsa-2022",
+    "created": "2023-01-01T00:00:00Z",
+    "proofPurpose": "assertionMethod",
+    "proofValue": "z58DAdFfa9",
+    "verificationMethod": "did:example:76e12ec712ebc6f1c221ebfeb1f#key-1"
+  }
+}`,
+			iris: []string{
+				"<https://w3id.org/security#DataIntegrityProof>",
+				"<https://w3id.org/security#cryptosuite>",
+				"<https://w3id.org/security#proofValue>",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(tc.doc), &doc), tc.name)
+
+		options := ld.NewJsonLdOptions("")
+		options.DocumentLoader = l
+		options.Format = "application/n-quads"
+		options.Algorithm = "URDNA2015"
+
+		view, err := ld.NewJsonLdProcessor().Normalize(doc, options)
+		require.NoError(t, err, tc.name)
+
+		for _, iri := range tc.iris {
+			require.Contains(t, view, iri, tc.name)
+		}
+	}
+}
+
+func TestReadContexts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "contexts")
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "examples.jsonld"),
+		[]byte(`{"documentURL": "https://example.com/context", "document": {"@context": {"@vocab": "ex:"}}}`), 0600))
+
+	contexts, err := ReadContexts(dir)
+	require.NoError(t, err)
+	require.Len(t, contexts, 1)
+	require.Equal(t, "https://example.com/context", contexts[0].DocumentURL)
+	require.Equal(t, map[string]interface{}{"@context": map[string]interface{}{"@vocab": "ex:"}},
+		contexts[0].Document)
+
+	for content, errMsg := range map[string]string{
+		"invalid":                              "invalid context",
+		`{"document": {}}`:                     "documentURL and document are required",
+		`{"documentURL": "https://a.com/ctx"}`: "documentURL and document are required",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "invalid.json"), []byte(content), 0600))
+
+		_, err = ReadContexts(dir)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errMsg)
+	}
+
+	_, err = ReadContext(filepath.Join(dir, "missing.json"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to read context")
+}
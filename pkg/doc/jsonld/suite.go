@@ -0,0 +1,218 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonld
+
+import (
+	"fmt"
+
+	ariesjsonld "github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/signer"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/piprate/json-gold/ld"
+)
+
+const (
+	contextKey = "@context"
+
+	// maxContextDepth limits the nesting of the remote contexts referenced by the contexts
+	maxContextDepth = 10
+)
+
+// SignerSuite returns the signature suite canonicalizing the documents with the contexts of the loader.
+// The JSON-LD processor of the linked data signature suites can't be given the document loader and fetches
+// the contexts with the default HTTP client, so the remote contexts are inlined before the canonicalization.
+func SignerSuite(s signer.SignatureSuite, loader ld.DocumentLoader) signer.SignatureSuite {
+	if loader == nil {
+		return s
+	}
+
+	return &signerSuite{SignatureSuite: s, loader: loader}
+}
+
+// VerifierSuite returns the signature suite canonicalizing the documents with the contexts of the loader
+// (see SignerSuite).
+func VerifierSuite(s verifier.SignatureSuite, loader ld.DocumentLoader) verifier.SignatureSuite {
+	if loader == nil {
+		return s
+	}
+
+	return &verifierSuite{SignatureSuite: s, loader: loader}
+}
+
+type signerSuite struct {
+	signer.SignatureSuite
+	loader ld.DocumentLoader
+}
+
+func (s *signerSuite) GetCanonicalDocument(doc map[string]interface{},
+	opts ...ariesjsonld.CanonicalizationOpts) ([]byte, error) {
+	inlined, err := InlineContexts(doc, s.loader)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SignatureSuite.GetCanonicalDocument(inlined, opts...)
+}
+
+type verifierSuite struct {
+	verifier.SignatureSuite
+	loader ld.DocumentLoader
+}
+
+func (s *verifierSuite) GetCanonicalDocument(doc map[string]interface{},
+	opts ...ariesjsonld.CanonicalizationOpts) ([]byte, error) {
+	inlined, err := InlineContexts(doc, s.loader)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SignatureSuite.GetCanonicalDocument(inlined, opts...)
+}
+
+// InlineContexts returns the copy of the document with the remote contexts replaced by the context definitions
+// loaded by the loader, including the remote contexts referenced by the loaded contexts.
+func InlineContexts(doc map[string]interface{}, loader ld.DocumentLoader) (map[string]interface{}, error) {
+	inlined, err := inlineNode(doc, loader)
+	if err != nil {
+		return nil, err
+	}
+
+	return inlined.(map[string]interface{}), nil
+}
+
+// inlineNode copies the node inlining the contexts of the node and its children
+func inlineNode(node interface{}, loader ld.DocumentLoader) (interface{}, error) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		inlined := make(map[string]interface{}, len(n))
+
+		for k, v := range n {
+			var err error
+
+			if k == contextKey {
+				inlined[k], err = inlineContext(v, loader, 0)
+			} else {
+				inlined[k], err = inlineNode(v, loader)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return inlined, nil
+	case []interface{}:
+		inlined := make([]interface{}, len(n))
+
+		for i, v := range n {
+			var err error
+
+			inlined[i], err = inlineNode(v, loader)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return inlined, nil
+	default:
+		return node, nil
+	}
+}
+
+// inlineContext replaces the remote contexts of the context value with their definitions
+func inlineContext(context interface{}, loader ld.DocumentLoader, depth int) (interface{}, error) {
+	if depth > maxContextDepth {
+		return nil, fmt.Errorf("contexts are nested deeper than %d levels", maxContextDepth)
+	}
+
+	switch c := context.(type) {
+	case string:
+		return loadContext(c, loader, depth)
+	case []interface{}:
+		inlined := make([]interface{}, 0, len(c))
+
+		for _, item := range c {
+			v, err := inlineContext(item, loader, depth)
+			if err != nil {
+				return nil, err
+			}
+
+			// the context array can't be nested
+			if items, ok := v.([]interface{}); ok {
+				inlined = append(inlined, items...)
+			} else {
+				inlined = append(inlined, v)
+			}
+		}
+
+		return inlined, nil
+	case map[string]interface{}:
+		// the term definitions could have scoped contexts
+		inlined := make(map[string]interface{}, len(c))
+
+		for k, v := range c {
+			definition, ok := v.(map[string]interface{})
+			if !ok {
+				inlined[k] = v
+
+				continue
+			}
+
+			var err error
+
+			inlined[k], err = inlineScopedContext(definition, loader, depth)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return inlined, nil
+	default:
+		return context, nil
+	}
+}
+
+func inlineScopedContext(definition map[string]interface{}, loader ld.DocumentLoader,
+	depth int) (map[string]interface{}, error) {
+	inlined := make(map[string]interface{}, len(definition))
+
+	for k, v := range definition {
+		if k != contextKey {
+			inlined[k] = v
+
+			continue
+		}
+
+		var err error
+
+		inlined[k], err = inlineContext(v, loader, depth+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return inlined, nil
+}
+
+func loadContext(u string, loader ld.DocumentLoader, depth int) (interface{}, error) {
+	doc, err := loader.LoadDocument(u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load context %s: %w", u, err)
+	}
+
+	contextDoc, ok := doc.Document.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid context %s", u)
+	}
+
+	context, ok := contextDoc[contextKey]
+	if !ok {
+		return nil, fmt.Errorf("invalid context %s: no %s", u, contextKey)
+	}
+
+	return inlineContext(context, loader, depth+1)
+}
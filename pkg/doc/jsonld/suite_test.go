@@ -0,0 +1,183 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonld
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+)
+
+const testCredential = `{
+  "@context": ["https://www.w3.org/2018/credentials/v1", "https://identity.foundation/.well-known/did-configuration/v1"],
+  "id": "http://example.edu/credentials/1872",
+  "type": ["VerifiableCredential", "DomainLinkageCredential"],
+  "issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+  "issuanceDate": "2010-01-01T19:23:24Z",
+  "credentialSubject": {
+    "id": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+    "origin": "https://example.com"
+  }
+}`
+
+// failingTransport fails any network call of the JSON-LD processors
+type failingTransport struct{}
+
+func (t *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, errors.New("unexpected network call " + req.URL.String())
+}
+
+type ed25519Signer struct {
+	privateKey ed25519.PrivateKey
+}
+
+func (s *ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.privateKey, data), nil
+}
+
+func TestSuites(t *testing.T) {
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = &failingTransport{}
+
+	defer func() { http.DefaultTransport = defaultTransport }()
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	loader := NewDocumentLoader(WithEmbeddedContexts())
+
+	vc, _, err := verifiable.NewCredential([]byte(testCredential), verifiable.WithJSONLDDocumentLoader(loader))
+	require.NoError(t, err)
+
+	err = vc.AddLinkedDataProof(&verifiable.LinkedDataProofContext{
+		SignatureType: "Ed25519Signature2018",
+		Suite: SignerSuite(ed25519signature2018.New(suite.WithSigner(&ed25519Signer{privateKey: privKey})),
+			loader),
+		SignatureRepresentation: verifiable.SignatureProofValue,
+		VerificationMethod:      "did:example:76e12ec712ebc6f1c221ebfeb1f#key-1",
+	})
+	require.NoError(t, err)
+
+	vcBytes, err := vc.MarshalJSON()
+	require.NoError(t, err)
+
+	t.Run("test proof is verified without network", func(t *testing.T) {
+		_, _, err := verifiable.NewCredential(vcBytes, verifiable.WithJSONLDDocumentLoader(loader),
+			verifiable.WithPublicKeyFetcher(verifiable.SingleKey(pubKey, "Ed25519Signature2018")),
+			verifiable.WithEmbeddedSignatureSuites(VerifierSuite(ed25519signature2018.New(
+				suite.WithVerifier(ed25519signature2018.NewPublicKeyVerifier())), loader)))
+		require.NoError(t, err)
+	})
+
+	t.Run("test proof is verified with network by the aries suites", func(t *testing.T) {
+		_, _, err := verifiable.NewCredential(vcBytes, verifiable.WithJSONLDDocumentLoader(loader),
+			verifiable.WithPublicKeyFetcher(verifiable.SingleKey(pubKey, "Ed25519Signature2018")))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "loading remote context failed")
+	})
+
+	t.Run("test unregistered context", func(t *testing.T) {
+		_, _, err := verifiable.NewCredential(vcBytes, verifiable.WithJSONLDDocumentLoader(loader),
+			verifiable.WithPublicKeyFetcher(verifiable.SingleKey(pubKey, "Ed25519Signature2018")),
+			verifiable.WithEmbeddedSignatureSuites(VerifierSuite(ed25519signature2018.New(
+				suite.WithVerifier(ed25519signature2018.NewPublicKeyVerifier())), NewDocumentLoader())))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "context https://www.w3.org/2018/credentials/v1 is not registered")
+	})
+
+	t.Run("test without loader", func(t *testing.T) {
+		s := ed25519signature2018.New()
+
+		require.Equal(t, s, SignerSuite(s, nil))
+		require.Equal(t, s, VerifierSuite(s, nil))
+	})
+}
+
+func TestInlineContexts(t *testing.T) {
+	loader := &mockLoader{docs: map[string]*ld.RemoteDocument{
+		"https://example.com/ctx1": remoteDocument(t, `{"@context": ["https://example.com/ctx2", {
+			"a": "https://example.com/a",
+			"b": {"@id": "https://example.com/b", "@context": "https://example.com/ctx2"}
+		}]}`),
+		"https://example.com/ctx2":   remoteDocument(t, `{"@context": {"c": "https://example.com/c"}}`),
+		"https://example.com/cyclic": remoteDocument(t, `{"@context": ["https://example.com/cyclic"]}`),
+		"https://example.com/empty":  remoteDocument(t, `{}`),
+		"https://example.com/array":  {DocumentURL: "https://example.com/array", Document: []interface{}{}},
+	}}
+
+	doc := map[string]interface{}{
+		"@context": []interface{}{"https://example.com/ctx1", map[string]interface{}{"d": "https://example.com/d"}},
+		"a":        "value",
+		"items":    []interface{}{map[string]interface{}{"@context": "https://example.com/ctx2", "c": "value"}},
+	}
+
+	inlined, err := InlineContexts(doc, loader)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"@context": []interface{}{
+			map[string]interface{}{"c": "https://example.com/c"},
+			map[string]interface{}{
+				"a": "https://example.com/a",
+				"b": map[string]interface{}{
+					"@id":      "https://example.com/b",
+					"@context": map[string]interface{}{"c": "https://example.com/c"},
+				},
+			},
+			map[string]interface{}{"d": "https://example.com/d"},
+		},
+		"a": "value",
+		"items": []interface{}{map[string]interface{}{
+			"@context": map[string]interface{}{"c": "https://example.com/c"},
+			"c":        "value",
+		}},
+	}, inlined)
+
+	// the document is not changed
+	require.Equal(t, "https://example.com/ctx2", doc["items"].([]interface{})[0].(map[string]interface{})["@context"])
+
+	tests := []struct {
+		context string
+		err     string
+	}{
+		{context: "https://example.com/unknown", err: "failed to load context https://example.com/unknown"},
+		{context: "https://example.com/cyclic", err: "contexts are nested deeper than 10 levels"},
+		{context: "https://example.com/empty", err: "invalid context https://example.com/empty: no @context"},
+		{context: "https://example.com/array", err: "invalid context https://example.com/array"},
+	}
+
+	for _, tc := range tests {
+		_, err := InlineContexts(map[string]interface{}{"items": []interface{}{
+			map[string]interface{}{"@context": []interface{}{tc.context}},
+		}}, loader)
+		require.Error(t, err, tc.context)
+		require.Contains(t, err.Error(), tc.err, tc.context)
+	}
+
+	_, err = InlineContexts(map[string]interface{}{"@context": map[string]interface{}{
+		"b": map[string]interface{}{"@context": "https://example.com/unknown"},
+	}}, loader)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to load context https://example.com/unknown")
+}
+
+func remoteDocument(t *testing.T, content string) *ld.RemoteDocument {
+	t.Helper()
+
+	doc, err := ld.DocumentFromReader(strings.NewReader(content))
+	require.NoError(t, err)
+
+	return &ld.RemoteDocument{Document: doc}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package challenge
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/edge-core/pkg/storage"
+)
+
+const (
+	keyPattern = "%s_%s"
+	keyPrefix  = "challenge"
+
+	challengeSize = 32
+)
+
+// Challenge is the nonce issued to the holder for the presentation proof.
+type Challenge struct {
+	Value    string    `json:"challenge"`
+	Verifier string    `json:"verifier,omitempty"`
+	Domain   string    `json:"domain,omitempty"`
+	Expires  time.Time `json:"expires"`
+	Used     bool      `json:"used,omitempty"`
+}
+
+// New returns new challenge manager instance, issued challenges are valid for the given period
+func New(store storage.Store, expiry time.Duration) *Manager {
+	return &Manager{store: store, expiry: expiry}
+}
+
+// Manager issues challenges and accepts each of them exactly once.
+//
+// The store has neither atomic compare-and-set nor delete operations: the check and the update of the challenge are
+// serialized by the manager, so the challenge is accepted exactly once only if the store isn't shared by several
+// service instances. Used and expired challenges are purged by replacing them with the tombstones, the challenges
+// issued before the restart of the service are not purged.
+type Manager struct {
+	store  storage.Store
+	expiry time.Duration
+	mutex  sync.Mutex
+	// challenges issued by the manager in the order of expiry, the expiry period is the same for all of them
+	issued []Challenge
+}
+
+// Issue issues new challenge scoped to the verifier and domain
+func (m *Manager) Issue(verifier, domain string) (*Challenge, error) {
+	nonce := make([]byte, challengeSize)
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+
+	c := &Challenge{
+		Value:    base64.RawURLEncoding.EncodeToString(nonce),
+		Verifier: verifier,
+		Domain:   domain,
+		Expires:  time.Now().UTC().Add(m.expiry),
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.purgeExpired(); err != nil {
+		return nil, err
+	}
+
+	if err := m.save(c); err != nil {
+		return nil, err
+	}
+
+	m.issued = append(m.issued, *c)
+
+	return c, nil
+}
+
+// Use accepts the challenge if it was issued for the verifier and domain, isn't expired and wasn't used before
+func (m *Manager) Use(value, verifier, domain string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	bytes, err := m.store.Get(getDBKey(value))
+	if err != nil {
+		if errors.Is(err, storage.ErrValueNotFound) {
+			return fmt.Errorf("unknown challenge %s", value)
+		}
+
+		return fmt.Errorf("failed to get challenge: %w", err)
+	}
+
+	c := &Challenge{}
+
+	if err := json.Unmarshal(bytes, c); err != nil {
+		return fmt.Errorf("failed to get challenge: %w", err)
+	}
+
+	switch {
+	case c.Used:
+		return fmt.Errorf("challenge %s is already used", value)
+	case time.Now().After(c.Expires):
+		return fmt.Errorf("challenge %s is expired", value)
+	case c.Verifier != verifier:
+		return fmt.Errorf("challenge %s is issued for another verifier", value)
+	case c.Domain != domain:
+		return fmt.Errorf("challenge %s is issued for another domain", value)
+	}
+
+	// the used challenge is kept only to reject its replay
+	return m.save(&Challenge{Value: c.Value, Used: true})
+}
+
+// purgeExpired replaces the expired challenges with the tombstones, the tombstone has no expiry and is rejected
+// as expired unless the challenge was used before
+func (m *Manager) purgeExpired() error {
+	now := time.Now()
+
+	for len(m.issued) > 0 && now.After(m.issued[0].Expires) {
+		bytes, err := m.store.Get(getDBKey(m.issued[0].Value))
+		if err != nil {
+			return fmt.Errorf("failed to purge challenge: %w", err)
+		}
+
+		c := &Challenge{}
+
+		if err := json.Unmarshal(bytes, c); err != nil {
+			return fmt.Errorf("failed to purge challenge: %w", err)
+		}
+
+		if !c.Used {
+			if err := m.save(&Challenge{Value: c.Value}); err != nil {
+				return fmt.Errorf("failed to purge challenge: %w", err)
+			}
+		}
+
+		m.issued = m.issued[1:]
+	}
+
+	return nil
+}
+
+func (m *Manager) save(c *Challenge) error {
+	bytes, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("save challenge marshalling error: %s", err.Error())
+	}
+
+	return m.store.Put(getDBKey(c.Value), bytes)
+}
+
+func getDBKey(value string) string {
+	return fmt.Sprintf(keyPattern, keyPrefix, value)
+}
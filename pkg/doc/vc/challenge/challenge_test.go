@@ -0,0 +1,131 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package challenge
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	mockstorage "github.com/trustbloc/edge-core/pkg/storage/mockstore"
+)
+
+func TestManager(t *testing.T) {
+	t.Run("test challenge is accepted once", func(t *testing.T) {
+		m := New(&mockstorage.MockStore{Store: make(map[string][]byte)}, time.Minute)
+
+		c, err := m.Issue("verifier", "example.com")
+		require.NoError(t, err)
+		require.NotEmpty(t, c.Value)
+		require.Equal(t, "verifier", c.Verifier)
+		require.Equal(t, "example.com", c.Domain)
+		require.True(t, c.Expires.After(time.Now()))
+
+		other, err := m.Issue("verifier", "example.com")
+		require.NoError(t, err)
+		require.NotEqual(t, c.Value, other.Value)
+
+		require.NoError(t, m.Use(c.Value, "verifier", "example.com"))
+
+		err = m.Use(c.Value, "verifier", "example.com")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is already used")
+	})
+
+	t.Run("test used and expired challenges are purged", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: make(map[string][]byte)}
+		m := New(store, time.Minute)
+
+		c, err := m.Issue("verifier", "example.com")
+		require.NoError(t, err)
+		require.NoError(t, m.Use(c.Value, "verifier", "example.com"))
+		require.JSONEq(t, `{"challenge": "`+c.Value+`", "expires": "0001-01-01T00:00:00Z", "used": true}`,
+			string(store.Store[getDBKey(c.Value)]))
+
+		m = New(store, -time.Second)
+
+		expired, err := m.Issue("verifier", "example.com")
+		require.NoError(t, err)
+
+		_, err = m.Issue("verifier", "example.com")
+		require.NoError(t, err)
+		require.JSONEq(t, `{"challenge": "`+expired.Value+`", "expires": "0001-01-01T00:00:00Z"}`,
+			string(store.Store[getDBKey(expired.Value)]))
+		require.Len(t, m.issued, 1)
+
+		err = m.Use(expired.Value, "verifier", "example.com")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is expired")
+
+		store.Store[getDBKey(m.issued[0].Value)] = []byte("invalid")
+
+		_, err = m.Issue("verifier", "example.com")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to purge challenge")
+
+		delete(store.Store, getDBKey(m.issued[0].Value))
+
+		_, err = m.Issue("verifier", "example.com")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to purge challenge")
+	})
+
+	t.Run("test challenge scope", func(t *testing.T) {
+		m := New(&mockstorage.MockStore{Store: make(map[string][]byte)}, time.Minute)
+
+		c, err := m.Issue("verifier", "example.com")
+		require.NoError(t, err)
+
+		err = m.Use(c.Value, "other", "example.com")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is issued for another verifier")
+
+		err = m.Use(c.Value, "verifier", "other.com")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is issued for another domain")
+
+		// challenge is not spent by the failed attempts
+		require.NoError(t, m.Use(c.Value, "verifier", "example.com"))
+	})
+
+	t.Run("test unknown and expired challenge", func(t *testing.T) {
+		m := New(&mockstorage.MockStore{Store: make(map[string][]byte)}, -time.Second)
+
+		err := m.Use("unknown", "", "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unknown challenge unknown")
+
+		c, err := m.Issue("", "")
+		require.NoError(t, err)
+
+		err = m.Use(c.Value, "", "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is expired")
+	})
+
+	t.Run("test store errors", func(t *testing.T) {
+		m := New(&mockstorage.MockStore{Store: make(map[string][]byte), ErrPut: errors.New("put error")}, time.Minute)
+
+		_, err := m.Issue("", "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "put error")
+
+		store := &mockstorage.MockStore{Store: make(map[string][]byte), ErrGet: errors.New("get error")}
+		store.Store[getDBKey("abc")] = []byte("{}")
+
+		err = New(store, time.Minute).Use("abc", "", "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get challenge: get error")
+
+		store = &mockstorage.MockStore{Store: make(map[string][]byte)}
+		store.Store[getDBKey("abc")] = []byte("invalid")
+
+		err = New(store, time.Minute).Use("abc", "", "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get challenge")
+	})
+}
@@ -0,0 +1,137 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
+)
+
+const (
+	// ApplicationField is the property of the presentation holding the credential application
+	ApplicationField = "credential_application"
+	// FulfillmentField is the property of the presentation holding the credential fulfillment
+	FulfillmentField = "credential_fulfillment"
+	// FulfillmentType is the type of the presentation wrapping the credentials of the fulfillment
+	FulfillmentType = "CredentialFulfillment"
+
+	// LDPVCFormat is the format of the credentials secured with the linked data proofs
+	LDPVCFormat = "ldp_vc"
+	// LDPVPFormat is the format of the presentations secured with the linked data proofs
+	LDPVPFormat = "ldp_vp"
+)
+
+// CredentialManifest describes the credentials the issuer issues and the inputs it requires
+// (DIF Credential Manifest).
+type CredentialManifest struct {
+	ID                     string                           `json:"id"`
+	Issuer                 *Issuer                          `json:"issuer"`
+	OutputDescriptors      []*OutputDescriptor              `json:"output_descriptors"`
+	Format                 map[string]*Format               `json:"format,omitempty"`
+	PresentationDefinition *presexch.PresentationDefinition `json:"presentation_definition,omitempty"`
+}
+
+// Issuer of the credentials of the manifest.
+type Issuer struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// OutputDescriptor describes the credential issued for the application.
+type OutputDescriptor struct {
+	ID          string `json:"id"`
+	Schema      string `json:"schema"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Format lists the proof types of the credential or presentation format.
+type Format struct {
+	ProofTypes []string `json:"proof_type"`
+}
+
+// CredentialApplication is the application of the holder for the credentials of the manifest, it is embedded
+// in the presentation submitting the inputs of the manifest.
+type CredentialApplication struct {
+	ID         string             `json:"id"`
+	ManifestID string             `json:"manifest_id"`
+	Format     map[string]*Format `json:"format,omitempty"`
+}
+
+// CredentialFulfillment maps the issued credentials of the presentation to the output descriptors
+// of the manifest.
+type CredentialFulfillment struct {
+	ID            string                             `json:"id"`
+	ManifestID    string                             `json:"manifest_id"`
+	ApplicationID string                             `json:"application_id,omitempty"`
+	DescriptorMap []*presexch.InputDescriptorMapping `json:"descriptor_map"`
+}
+
+// Validate checks the manifest is well formed.
+func (m *CredentialManifest) Validate() error {
+	if m.ID == "" {
+		return errors.New("credential manifest id is required")
+	}
+
+	if len(m.OutputDescriptors) == 0 {
+		return errors.New("credential manifest must have output descriptors")
+	}
+
+	ids := make(map[string]bool)
+
+	for _, d := range m.OutputDescriptors {
+		switch {
+		case d.ID == "":
+			return errors.New("output descriptor id is required")
+		case ids[d.ID]:
+			return fmt.Errorf("duplicate output descriptor id: %s", d.ID)
+		case d.Schema == "":
+			return fmt.Errorf("output descriptor %s: schema is required", d.ID)
+		}
+
+		ids[d.ID] = true
+	}
+
+	if m.PresentationDefinition == nil {
+		return nil
+	}
+
+	return m.PresentationDefinition.Validate()
+}
+
+// ParseApplication returns the credential application of the JSON-LD presentation.
+func ParseApplication(vpBytes []byte) (*CredentialApplication, error) {
+	var vp map[string]interface{}
+
+	if err := json.Unmarshal(vpBytes, &vp); err != nil {
+		return nil, fmt.Errorf("presentation must be a JSON object: %w", err)
+	}
+
+	raw, ok := vp[ApplicationField]
+	if !ok {
+		return nil, fmt.Errorf("%s is missing", ApplicationField)
+	}
+
+	applicationBytes, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ApplicationField, err)
+	}
+
+	application := &CredentialApplication{}
+
+	if err := json.Unmarshal(applicationBytes, application); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ApplicationField, err)
+	}
+
+	if application.ManifestID == "" {
+		return nil, fmt.Errorf("%s manifest_id is required", ApplicationField)
+	}
+
+	return application, nil
+}
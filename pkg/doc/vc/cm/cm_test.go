@@ -0,0 +1,79 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialManifest_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		err      string
+	}{
+		{name: "missing id", manifest: `{}`, err: "credential manifest id is required"},
+		{name: "missing output descriptors", manifest: `{"id": "1"}`, err: "must have output descriptors"},
+		{name: "missing descriptor id", manifest: `{"id": "1", "output_descriptors": [{}]}`,
+			err: "output descriptor id is required"},
+		{name: "duplicate descriptor id",
+			manifest: `{"id": "1", "output_descriptors": [{"id": "a", "schema": "s"}, {"id": "a", "schema": "s"}]}`,
+			err:      "duplicate output descriptor id: a"},
+		{name: "missing schema", manifest: `{"id": "1", "output_descriptors": [{"id": "a"}]}`,
+			err: "output descriptor a: schema is required"},
+		{name: "invalid presentation definition",
+			manifest: `{"id": "1", "output_descriptors": [{"id": "a", "schema": "s"}], "presentation_definition": {}}`,
+			err:      "presentation definition id is required"},
+	}
+
+	for _, tc := range tests {
+		m := &CredentialManifest{}
+		require.NoError(t, json.Unmarshal([]byte(tc.manifest), m))
+
+		err := m.Validate()
+		require.Error(t, err, tc.name)
+		require.Contains(t, err.Error(), tc.err, tc.name)
+	}
+
+	m := &CredentialManifest{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "UniversityDegree",
+		"issuer": {"id": "did:example:76e12ec712ebc6f1c221ebfeb1f"},
+		"output_descriptors": [{"id": "UniversityDegree", "schema": "UniversityDegreeCredential"}],
+		"presentation_definition": {"id": "1", "input_descriptors": [{"id": "a"}]}
+	}`), m))
+	require.NoError(t, m.Validate())
+}
+
+func TestParseApplication(t *testing.T) {
+	application, err := ParseApplication([]byte(`{
+		"type": "VerifiablePresentation",
+		"credential_application": {"id": "9b1deb4d", "manifest_id": "UniversityDegree"}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, &CredentialApplication{ID: "9b1deb4d", ManifestID: "UniversityDegree"}, application)
+
+	tests := []struct {
+		name string
+		vp   string
+		err  string
+	}{
+		{name: "not JSON object", vp: `eyJhbGciOiJub25lIn0.e30.`, err: "presentation must be a JSON object"},
+		{name: "missing application", vp: `{}`, err: "credential_application is missing"},
+		{name: "invalid application", vp: `{"credential_application": "abc"}`, err: "invalid credential_application"},
+		{name: "missing manifest", vp: `{"credential_application": {"id": "1"}}`,
+			err: "credential_application manifest_id is required"},
+	}
+
+	for _, tc := range tests {
+		_, err := ParseApplication([]byte(tc.vp))
+		require.Error(t, err, tc.name)
+		require.Contains(t, err.Error(), tc.err, tc.name)
+	}
+}
@@ -13,24 +13,34 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/btcsuite/btcutil/base58"
 	ariescrypto "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
 	ariessigner "github.com/hyperledger/aries-framework-go/pkg/doc/signature/signer"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/jsonwebsignature2020"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/piprate/json-gold/ld"
 
+	"github.com/trustbloc/edge-service/pkg/doc/jsonld"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/dataintegrity"
 	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
 )
 
 const (
 	creatorParts = 2
+
+	// JWT signing algorithms of the key types
+	edDSAAlg = "EdDSA"
+	es256Alg = "ES256"
 )
 
 const (
@@ -38,6 +48,8 @@ const (
 	Ed25519Signature2018 = "Ed25519Signature2018"
 	// JSONWebSignature2020 json web signature suite
 	JSONWebSignature2020 = "JsonWebSignature2020"
+	// DataIntegrityProof data integrity proof with eddsa-2022 cryptosuite
+	DataIntegrityProof = dataintegrity.ProofType
 
 	// Ed25519VerificationKey2018 ed25119 verification key
 	Ed25519VerificationKey2018 = "Ed25519VerificationKey2018"
@@ -117,9 +129,27 @@ func (s *privateKeySigner) Sign(data []byte) ([]byte, error) {
 	return nil, fmt.Errorf("invalid key type : %s", s.keyType)
 }
 
+// Option configures vc crypto
+type Option func(c *Crypto)
+
+// WithDocumentLoader sets JSON-LD document loader used to canonicalize the documents of the linked data proofs
+func WithDocumentLoader(loader ld.DocumentLoader) Option {
+	return func(c *Crypto) {
+		c.documentLoader = loader
+	}
+}
+
 // New return new instance of vc crypto
-func New(keyManager kms.KeyManager, c ariescrypto.Crypto) *Crypto {
-	return &Crypto{keyManager: keyManager, crypto: c}
+func New(keyManager kms.KeyManager, c ariescrypto.Crypto, opts ...Option) *Crypto {
+	vcCrypto := &Crypto{keyManager: keyManager, crypto: c}
+
+	for _, opt := range opts {
+		opt(vcCrypto)
+	}
+
+	vcCrypto.dataIntegrity = dataintegrity.New(dataintegrity.WithDocumentLoader(vcCrypto.documentLoader))
+
+	return vcCrypto
 }
 
 // signingOpts holds options for the signing credential
@@ -187,8 +217,10 @@ func WithDomain(domain string) SigningOpts {
 
 // Crypto to sign credential
 type Crypto struct {
-	keyManager kms.KeyManager
-	crypto     ariescrypto.Crypto
+	keyManager     kms.KeyManager
+	crypto         ariescrypto.Crypto
+	documentLoader ld.DocumentLoader
+	dataIntegrity  *dataintegrity.Suite
 }
 
 // SignCredential sign vc
@@ -204,6 +236,18 @@ func (c *Crypto) SignCredential(dataProfile *vcprofile.DataProfile, vc *verifiab
 		signatureType = signOpts.SignatureType
 	}
 
+	if signatureType == DataIntegrityProof {
+		proof, err := c.createDataIntegrityProof(vc, dataProfile.DID, dataProfile.DIDKeyType,
+			dataProfile.DIDPrivateKey, dataProfile.Creator, signOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign vc: %w", err)
+		}
+
+		vc.Proofs = append(vc.Proofs, proof)
+
+		return vc, nil
+	}
+
 	signingCtx, err := c.getLinkedDataProofContext(dataProfile.DID, dataProfile.DIDKeyType,
 		dataProfile.DIDPrivateKey, dataProfile.Creator, signatureType, dataProfile.SignatureRepresentation, signOpts)
 	if err != nil {
@@ -233,6 +277,18 @@ func (c *Crypto) SignPresentation(profile *vcprofile.HolderProfile, vp *verifiab
 		signatureType = signOpts.SignatureType
 	}
 
+	if signatureType == DataIntegrityProof {
+		proof, err := c.createDataIntegrityProof(vp, profile.DID, profile.DIDKeyType, profile.DIDPrivateKey,
+			profile.Creator, signOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign vp: %w", err)
+		}
+
+		vp.Proofs = append(vp.Proofs, proof)
+
+		return vp, nil
+	}
+
 	signingCtx, err := c.getLinkedDataProofContext(profile.DID, profile.DIDKeyType, profile.DIDPrivateKey,
 		profile.Creator, signatureType, profile.SignatureRepresentation, signOpts)
 	if err != nil {
@@ -247,6 +303,42 @@ func (c *Crypto) SignPresentation(profile *vcprofile.HolderProfile, vp *verifiab
 	return vp, nil
 }
 
+// SignJWT signs the JWT claims with the DID key of the verifier profile (EdDSA or ES256 for the P256 keys)
+func (c *Crypto) SignJWT(profile *vcprofile.VerifierProfile, claims interface{}, typ string) (string, error) {
+	s, kid, err := c.getSigner(profile.DID, profile.DIDKeyType, profile.DIDPrivateKey, profile.Creator,
+		&signingOpts{})
+	if err != nil {
+		return "", err
+	}
+
+	alg := edDSAAlg
+	if profile.DIDKeyType == P256KeyType {
+		alg = es256Alg
+	}
+
+	token, err := jwt.NewSigned(claims, jose.Headers{jose.HeaderKeyID: kid, jose.HeaderType: typ},
+		&jwtSigner{signer: s, alg: alg})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign jwt: %w", err)
+	}
+
+	return token.Serialize(false)
+}
+
+// jwtSigner signs the JWTs with the profile signer
+type jwtSigner struct {
+	signer signer
+	alg    string
+}
+
+func (s *jwtSigner) Sign(data []byte) ([]byte, error) {
+	return s.signer.Sign(data)
+}
+
+func (s *jwtSigner) Headers() jose.Headers {
+	return jose.Headers{jose.HeaderAlgorithm: s.alg}
+}
+
 func (c *Crypto) getLinkedDataProofContext(did, didKeyType, didPrivateKey, creator, signatureType string,
 	signRep verifiable.SignatureRepresentation, opts *signingOpts) (*verifiable.LinkedDataProofContext, error) {
 	s, method, err := c.getSigner(did, didKeyType, didPrivateKey, creator, opts)
@@ -265,6 +357,8 @@ func (c *Crypto) getLinkedDataProofContext(did, didKeyType, didPrivateKey, creat
 		return nil, fmt.Errorf("signature type unsupported %s", signatureType)
 	}
 
+	signatureSuite = jsonld.SignerSuite(signatureSuite, c.documentLoader)
+
 	if opts.Representation != "" {
 		signRep, err = getSignatureRepresentation(opts.Representation)
 		if err != nil {
@@ -287,6 +381,32 @@ func (c *Crypto) getLinkedDataProofContext(did, didKeyType, didPrivateKey, creat
 	return signingCtx, nil
 }
 
+// createDataIntegrityProof creates eddsa-2022 data integrity proof for the given credential or presentation
+func (c *Crypto) createDataIntegrityProof(doc json.Marshaler, did, didKeyType, didPrivateKey, creator string,
+	opts *signingOpts) (verifiable.Proof, error) {
+	if didPrivateKey != "" && didKeyType != Ed25519KeyType {
+		return nil, fmt.Errorf("%s requires %s key type", DataIntegrityProof, Ed25519KeyType)
+	}
+
+	s, method, err := c.getSigner(did, didKeyType, didPrivateKey, creator, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	docBytes, err := doc.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.dataIntegrity.CreateProof(docBytes, s, &dataintegrity.ProofContext{
+		VerificationMethod: method,
+		Purpose:            opts.Purpose,
+		Created:            opts.Created,
+		Challenge:          opts.Challenge,
+		Domain:             opts.Domain,
+	})
+}
+
 // getSigner returns signer and verification method based on profile and signing opts
 // verificationMethod from opts takes priority to create signer and verification method
 func (c *Crypto) getSigner(did, didKeyType, didPrivateKey, creator string, opts *signingOpts) (signer, string, error) {
@@ -11,17 +11,22 @@ import (
 	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
 	"fmt"
+	"math/big"
 	"testing"
 	"time"
 
 	"github.com/btcsuite/btcutil/base58"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
 	"github.com/stretchr/testify/require"
 
+	"github.com/trustbloc/edge-service/pkg/doc/vc/dataintegrity"
 	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/jsonld"
 	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
 )
 
@@ -325,6 +330,54 @@ func TestCrypto_SignCredential(t *testing.T) {
 		require.Contains(t, err.Error(), "failed to sign vc")
 		require.Nil(t, signedVC)
 	})
+
+	t.Run("test success - data integrity proof", func(t *testing.T) {
+		pubKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		c := New(nil, nil, WithDocumentLoader(jsonld.DocumentLoader()))
+
+		p := getTestIssuerProfile()
+		p.SignatureType = DataIntegrityProof
+		p.DIDPrivateKey = base58.Encode(privateKey)
+		p.DIDKeyType = Ed25519KeyType
+
+		signedVC, err := c.SignCredential(p, getTestV2Credential(), WithChallenge("challenge"))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(signedVC.Proofs))
+		require.Equal(t, DataIntegrityProof, signedVC.Proofs[0]["type"])
+		require.Equal(t, "eddsa-2022", signedVC.Proofs[0]["cryptosuite"])
+		require.Equal(t, "did:test:abc#key1", signedVC.Proofs[0]["verificationMethod"])
+		require.Equal(t, "challenge", signedVC.Proofs[0]["challenge"])
+
+		vcBytes, err := signedVC.MarshalJSON()
+		require.NoError(t, err)
+
+		err = dataintegrity.New(dataintegrity.WithDocumentLoader(jsonld.DocumentLoader())).Verify(vcBytes,
+			verifiable.SingleKey(pubKey, Ed25519VerificationKey2018))
+		require.NoError(t, err)
+	})
+
+	t.Run("test error - data integrity proof", func(t *testing.T) {
+		c := New(&kms.KeyManager{}, &cryptomock.Crypto{SignErr: fmt.Errorf("failed to sign")},
+			WithDocumentLoader(jsonld.DocumentLoader()))
+
+		p := getTestIssuerProfile()
+		p.SignatureType = DataIntegrityProof
+
+		signedVC, err := c.SignCredential(p, getTestV2Credential())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to sign vc")
+		require.Nil(t, signedVC)
+
+		p.DIDPrivateKey = "privateKey"
+		p.DIDKeyType = P256KeyType
+
+		signedVC, err = c.SignCredential(p, getTestV2Credential())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "DataIntegrityProof requires Ed25519 key type")
+		require.Nil(t, signedVC)
+	})
 }
 
 func TestSignPresentation(t *testing.T) {
@@ -349,6 +402,24 @@ func TestSignPresentation(t *testing.T) {
 		require.Equal(t, 1, len(signedVP.Proofs))
 	})
 
+	t.Run("sign presentation - data integrity proof", func(t *testing.T) {
+		c := New(&kms.KeyManager{}, &cryptomock.Crypto{}, WithDocumentLoader(jsonld.DocumentLoader()))
+
+		signedVP, err := c.SignPresentation(getTestHolderProfile(),
+			&verifiable.Presentation{
+				Context: []string{"https://www.w3.org/ns/credentials/v2"},
+				ID:      "http://example.edu/presentation/1872",
+				Type:    []string{"VerifiablePresentation"},
+				Holder:  "did:test:abc",
+			},
+			WithSignatureType(DataIntegrityProof), WithDomain("example.com"),
+		)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(signedVP.Proofs))
+		require.Equal(t, DataIntegrityProof, signedVP.Proofs[0]["type"])
+		require.Equal(t, "example.com", signedVP.Proofs[0]["domain"])
+	})
+
 	t.Run("sign presentation - fail", func(t *testing.T) {
 		c := New(&kms.KeyManager{}, &cryptomock.Crypto{})
 
@@ -362,6 +433,89 @@ func TestSignPresentation(t *testing.T) {
 	})
 }
 
+func TestSignJWT(t *testing.T) {
+	claims := map[string]interface{}{"iss": "did:test:abc", "nonce": "abc"}
+
+	t.Run("sign jwt - Ed25519 key", func(t *testing.T) {
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		profile := &vcprofile.VerifierProfile{Name: "test", DID: "did:test:abc", Creator: "did:test:abc#key1",
+			DIDKeyType: Ed25519KeyType, DIDPrivateKey: base58.Encode(privKey)}
+
+		token, err := New(&kms.KeyManager{}, &cryptomock.Crypto{}).SignJWT(profile, claims, "oauth-authz-req+jwt")
+		require.NoError(t, err)
+
+		jws, err := jose.ParseJWS(token, jose.SignatureVerifierFunc(
+			func(headers jose.Headers, _, signingInput, signature []byte) error {
+				kid, _ := headers.KeyID()
+				require.Equal(t, "did:test:abc#key1", kid)
+				require.Equal(t, "oauth-authz-req+jwt", headers[jose.HeaderType])
+
+				alg, _ := headers.Algorithm()
+				require.Equal(t, "EdDSA", alg)
+
+				require.True(t, ed25519.Verify(pubKey, signingInput, signature))
+
+				return nil
+			}))
+		require.NoError(t, err)
+		require.Contains(t, string(jws.Payload), `"nonce":"abc"`)
+	})
+
+	t.Run("sign jwt - P256 key", func(t *testing.T) {
+		privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		keyBytes, err := x509.MarshalECPrivateKey(privKey)
+		require.NoError(t, err)
+
+		profile := &vcprofile.VerifierProfile{Name: "test", DID: "did:test:abc", Creator: "did:test:abc#key1",
+			DIDKeyType: P256KeyType, DIDPrivateKey: base58.Encode(keyBytes)}
+
+		token, err := New(&kms.KeyManager{}, &cryptomock.Crypto{}).SignJWT(profile, claims, "JWT")
+		require.NoError(t, err)
+
+		_, err = jose.ParseJWS(token, jose.SignatureVerifierFunc(
+			func(headers jose.Headers, _, signingInput, signature []byte) error {
+				alg, _ := headers.Algorithm()
+				require.Equal(t, "ES256", alg)
+
+				hash := sha256.Sum256(signingInput)
+				r := new(big.Int).SetBytes(signature[:32])
+				s := new(big.Int).SetBytes(signature[32:])
+				require.True(t, ecdsa.Verify(&privKey.PublicKey, hash[:], r, s))
+
+				return nil
+			}))
+		require.NoError(t, err)
+	})
+
+	t.Run("sign jwt - kms key", func(t *testing.T) {
+		profile := &vcprofile.VerifierProfile{Name: "test", DID: "did:test:abc", Creator: "did:test:abc#key1"}
+
+		token, err := New(&kms.KeyManager{}, &cryptomock.Crypto{SignValue: []byte("signature")}).SignJWT(
+			profile, claims, "JWT")
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+	})
+
+	t.Run("sign jwt - error", func(t *testing.T) {
+		profile := &vcprofile.VerifierProfile{Name: "test", DID: "did:test:abc", Creator: "did:test:abc"}
+
+		_, err := New(&kms.KeyManager{}, &cryptomock.Crypto{}).SignJWT(profile, claims, "JWT")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "wrong id")
+
+		profile.Creator = "did:test:abc#key1"
+
+		_, err = New(&kms.KeyManager{}, &cryptomock.Crypto{SignErr: fmt.Errorf("sign error")}).SignJWT(
+			profile, claims, "JWT")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "sign error")
+	})
+}
+
 func getTestIssuerProfile() *vcprofile.DataProfile {
 	return &vcprofile.DataProfile{
 		Name:          "test",
@@ -372,6 +526,19 @@ func getTestIssuerProfile() *vcprofile.DataProfile {
 	}
 }
 
+func getTestV2Credential() *verifiable.Credential {
+	return &verifiable.Credential{
+		Context: []string{"https://www.w3.org/ns/credentials/v2"},
+		ID:      "http://example.edu/credentials/1872",
+		Types:   []string{"VerifiableCredential"},
+		Issuer:  verifiable.Issuer{ID: "did:test:abc"},
+		Subject: "did:example:ebfeb1f712ebc6f1c276e12ec21",
+		CustomFields: map[string]interface{}{
+			"validFrom": "2020-01-01T19:23:24Z",
+		},
+	}
+}
+
 func getTestHolderProfile() *vcprofile.HolderProfile {
 	return &vcprofile.HolderProfile{
 		Name:          "test",
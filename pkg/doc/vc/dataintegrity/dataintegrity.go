@@ -0,0 +1,416 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dataintegrity
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/piprate/json-gold/ld"
+)
+
+const (
+	// ProofType is the proof type of https://www.w3.org/TR/vc-data-integrity/
+	ProofType = "DataIntegrityProof"
+	// CryptosuiteEddsa2022 is the EdDSA cryptosuite using RDF Dataset Canonicalization
+	CryptosuiteEddsa2022 = "eddsa-2022"
+	// Context for Data Integrity proofs in VC Data Model 1.1 documents (already part of the 2.0 base context)
+	Context = "https://w3id.org/security/data-integrity/v1"
+
+	defaultPurpose     = "assertionMethod"
+	multibaseBase58BTC = "z"
+	canonicalAlgorithm = "URDNA2015"
+	canonicalFormat    = "application/n-quads"
+	vmParts            = 2
+
+	// proof json keys
+	jsonKeyContext            = "@context"
+	jsonKeyProof              = "proof"
+	jsonKeyType               = "type"
+	jsonKeyCryptosuite        = "cryptosuite"
+	jsonKeyCreated            = "created"
+	jsonKeyVerificationMethod = "verificationMethod"
+	jsonKeyProofPurpose       = "proofPurpose"
+	jsonKeyProofValue         = "proofValue"
+	jsonKeyChallenge          = "challenge"
+	jsonKeyDomain             = "domain"
+)
+
+type signer interface {
+	// Sign will sign document and return signature
+	Sign(data []byte) ([]byte, error)
+}
+
+// ProofContext holds options needed to build a Data Integrity proof.
+type ProofContext struct {
+	VerificationMethod string     // required
+	Purpose            string     // optional, defaults to assertionMethod
+	Created            *time.Time // optional, defaults to current time
+	Challenge          string     // optional
+	Domain             string     // optional
+}
+
+// Suite implements the eddsa-2022 cryptosuite.
+type Suite struct {
+	documentLoader ld.DocumentLoader
+}
+
+// Option configures the suite.
+type Option func(s *Suite)
+
+// WithDocumentLoader sets the JSON-LD document loader used for canonicalization.
+func WithDocumentLoader(loader ld.DocumentLoader) Option {
+	return func(s *Suite) {
+		s.documentLoader = loader
+	}
+}
+
+// VerifyOpt configures the proof verification.
+type VerifyOpt func(opts *verifyOpts)
+
+type verifyOpts struct {
+	controller  string
+	methodCheck func(verificationMethod, purpose string) error
+}
+
+// WithController requires the verification methods of the proofs to belong to the DID (issuer or holder).
+func WithController(controller string) VerifyOpt {
+	return func(opts *verifyOpts) {
+		opts.controller = controller
+	}
+}
+
+// WithVerificationMethodCheck sets the check of the verification method authorized for the proof purpose,
+// e.g. the verification relationship of the DID document.
+func WithVerificationMethodCheck(check func(verificationMethod, purpose string) error) VerifyOpt {
+	return func(opts *verifyOpts) {
+		opts.methodCheck = check
+	}
+}
+
+// New returns new eddsa-2022 suite.
+func New(opts ...Option) *Suite {
+	s := &Suite{}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.documentLoader == nil {
+		s.documentLoader = verifiable.CachingJSONLDLoader()
+	}
+
+	return s
+}
+
+// CreateProof signs the JSON-LD document and returns the new proof. Proofs already present in the document
+// are not covered by the signature, so the result can be appended to the document proof set.
+func (s *Suite) CreateProof(doc []byte, signer signer, ctx *ProofContext) (verifiable.Proof, error) {
+	if ctx.VerificationMethod == "" {
+		return nil, errors.New("verification method is required")
+	}
+
+	jsonldDoc, err := toMap(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	created := time.Now().UTC()
+	if ctx.Created != nil {
+		created = ctx.Created.UTC()
+	}
+
+	purpose := defaultPurpose
+	if ctx.Purpose != "" {
+		purpose = ctx.Purpose
+	}
+
+	proof := verifiable.Proof{
+		jsonKeyType:               ProofType,
+		jsonKeyCryptosuite:        CryptosuiteEddsa2022,
+		jsonKeyCreated:            created.Format(time.RFC3339),
+		jsonKeyVerificationMethod: ctx.VerificationMethod,
+		jsonKeyProofPurpose:       purpose,
+	}
+
+	if ctx.Challenge != "" {
+		proof[jsonKeyChallenge] = ctx.Challenge
+	}
+
+	if ctx.Domain != "" {
+		proof[jsonKeyDomain] = ctx.Domain
+	}
+
+	hashData, err := s.hashData(jsonldDoc, proof)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := signer.Sign(hashData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign data integrity proof: %w", err)
+	}
+
+	proof[jsonKeyProofValue] = multibaseBase58BTC + base58.Encode(signature)
+
+	return proof, nil
+}
+
+// Verify checks every Data Integrity proof of the document, proofs of other types are ignored
+// (see RemoveProofs). It fails if the document doesn't contain any Data Integrity proof.
+func (s *Suite) Verify(doc []byte, fetcher verifiable.PublicKeyFetcher, opts ...VerifyOpt) error {
+	jsonldDoc, err := toMap(doc)
+	if err != nil {
+		return err
+	}
+
+	options := &verifyOpts{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	verified := 0
+
+	for _, proof := range getProofs(jsonldDoc) {
+		if proof[jsonKeyType] != ProofType {
+			continue
+		}
+
+		if err := s.verifyProof(jsonldDoc, proof, fetcher, options); err != nil {
+			return fmt.Errorf("data integrity proof: %w", err)
+		}
+
+		verified++
+	}
+
+	if verified == 0 {
+		return errors.New("data integrity proof is missing")
+	}
+
+	return nil
+}
+
+// RemoveProofs returns the document without its Data Integrity proofs, so the proofs of other types can be
+// verified by their suites. The flag reports whether the document has any proof of other types.
+func RemoveProofs(doc []byte) ([]byte, bool, error) {
+	jsonldDoc, err := toMap(doc)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var others []interface{}
+
+	for _, proof := range getProofs(jsonldDoc) {
+		if proof[jsonKeyType] != ProofType {
+			others = append(others, proof)
+		}
+	}
+
+	switch len(others) {
+	case 0:
+		delete(jsonldDoc, jsonKeyProof)
+	case 1:
+		jsonldDoc[jsonKeyProof] = others[0]
+	default:
+		jsonldDoc[jsonKeyProof] = others
+	}
+
+	result, err := json.Marshal(jsonldDoc)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal document: %w", err)
+	}
+
+	return result, len(others) > 0, nil
+}
+
+// HasProof checks whether the document contains at least one Data Integrity proof.
+func HasProof(doc []byte) bool {
+	jsonldDoc, err := toMap(doc)
+	if err != nil {
+		return false
+	}
+
+	for _, proof := range getProofs(jsonldDoc) {
+		if proof[jsonKeyType] == ProofType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Suite) verifyProof(doc, proof map[string]interface{}, fetcher verifiable.PublicKeyFetcher,
+	opts *verifyOpts) error {
+	if cryptosuite := stringValue(proof, jsonKeyCryptosuite); cryptosuite != CryptosuiteEddsa2022 {
+		return fmt.Errorf("unsupported cryptosuite: %s", cryptosuite)
+	}
+
+	proofValue := stringValue(proof, jsonKeyProofValue)
+	if !strings.HasPrefix(proofValue, multibaseBase58BTC) {
+		return errors.New("proof value is not a base58-btc multibase value")
+	}
+
+	signature := base58.Decode(strings.TrimPrefix(proofValue, multibaseBase58BTC))
+
+	vm := stringValue(proof, jsonKeyVerificationMethod)
+
+	vmSplit := strings.Split(vm, "#")
+	if len(vmSplit) != vmParts {
+		return fmt.Errorf("wrong verification method %s to resolve", vm)
+	}
+
+	if opts.controller != "" && vmSplit[0] != opts.controller {
+		return fmt.Errorf("verification method %s is not controlled by %s", vm, opts.controller)
+	}
+
+	if opts.methodCheck != nil {
+		if err := opts.methodCheck(vm, stringValue(proof, jsonKeyProofPurpose)); err != nil {
+			return err
+		}
+	}
+
+	pubKey, err := fetcher(vmSplit[0], "#"+vmSplit[1])
+	if err != nil {
+		return fmt.Errorf("failed to fetch public key: %w", err)
+	}
+
+	keyBytes, err := ed25519PublicKey(pubKey)
+	if err != nil {
+		return err
+	}
+
+	proofConfig := make(map[string]interface{}, len(proof))
+
+	for k, v := range proof {
+		if k != jsonKeyProofValue {
+			proofConfig[k] = v
+		}
+	}
+
+	hashData, err := s.hashData(doc, proofConfig)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(keyBytes, hashData, signature) {
+		return errors.New("signature doesn't match")
+	}
+
+	return nil
+}
+
+// hashData returns SHA-256 of the canonical proof configuration followed by SHA-256 of the canonical document.
+func (s *Suite) hashData(doc, proofConfig map[string]interface{}) ([]byte, error) {
+	unsecuredDoc := make(map[string]interface{}, len(doc))
+
+	for k, v := range doc {
+		if k != jsonKeyProof {
+			unsecuredDoc[k] = v
+		}
+	}
+
+	canonicalDoc, err := s.canonicalize(unsecuredDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	config := make(map[string]interface{}, len(proofConfig)+1)
+
+	for k, v := range proofConfig {
+		config[k] = v
+	}
+
+	config[jsonKeyContext] = doc[jsonKeyContext]
+
+	canonicalConfig, err := s.canonicalize(config)
+	if err != nil {
+		return nil, err
+	}
+
+	configHash := sha256.Sum256(canonicalConfig)
+	docHash := sha256.Sum256(canonicalDoc)
+
+	return append(configHash[:], docHash[:]...), nil
+}
+
+func (s *Suite) canonicalize(doc map[string]interface{}) ([]byte, error) {
+	options := ld.NewJsonLdOptions("")
+	options.Algorithm = canonicalAlgorithm
+	options.Format = canonicalFormat
+	options.DocumentLoader = s.documentLoader
+
+	view, err := ld.NewJsonLdProcessor().Normalize(doc, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize JSON-LD document: %w", err)
+	}
+
+	result, ok := view.(string)
+	if !ok || result == "" {
+		// an empty dataset means none of the terms were defined by the document context
+		return nil, errors.New("JSON-LD document canonicalized to an empty dataset")
+	}
+
+	return []byte(result), nil
+}
+
+func ed25519PublicKey(pubKey *verifier.PublicKey) (ed25519.PublicKey, error) {
+	if pubKey.JWK != nil {
+		if key, ok := pubKey.JWK.Key.(ed25519.PublicKey); ok {
+			return key, nil
+		}
+	}
+
+	if len(pubKey.Value) == ed25519.PublicKeySize {
+		return pubKey.Value, nil
+	}
+
+	return nil, errors.New("public key is not an Ed25519 key")
+}
+
+func getProofs(doc map[string]interface{}) []map[string]interface{} {
+	switch p := doc[jsonKeyProof].(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{p}
+	case []interface{}:
+		var proofs []map[string]interface{}
+
+		for _, v := range p {
+			if proof, ok := v.(map[string]interface{}); ok {
+				proofs = append(proofs, proof)
+			}
+		}
+
+		return proofs
+	default:
+		return nil
+	}
+}
+
+func stringValue(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string) // nolint: errcheck
+
+	return s
+}
+
+func toMap(doc []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+
+	if err := json.Unmarshal(doc, &m); err != nil {
+		return nil, fmt.Errorf("document is not a JSON object: %w", err)
+	}
+
+	return m, nil
+}
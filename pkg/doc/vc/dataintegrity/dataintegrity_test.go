@@ -0,0 +1,342 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dataintegrity
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/edge-service/pkg/internal/mock/jsonld"
+)
+
+const (
+	testVerificationMethod = "did:test:abc#key1"
+
+	v2Credential = `{
+  "@context": ["https://www.w3.org/ns/credentials/v2"],
+  "id": "http://example.edu/credentials/1872",
+  "type": ["VerifiableCredential", "UniversityDegreeCredential"],
+  "issuer": "did:test:abc",
+  "validFrom": "2020-01-01T19:23:24Z",
+  "credentialSubject": {
+    "id": "did:example:ebfeb1f712ebc6f1c276e12ec21",
+    "degree": "Bachelor of Science and Arts"
+  }
+}`
+
+	v1Credential = `{
+  "@context": ["https://www.w3.org/2018/credentials/v1", "https://w3id.org/security/data-integrity/v1"],
+  "id": "http://example.edu/credentials/1872",
+  "type": "VerifiableCredential",
+  "issuer": "did:test:abc",
+  "issuanceDate": "2020-01-01T19:23:24Z",
+  "credentialSubject": {
+    "id": "did:example:ebfeb1f712ebc6f1c276e12ec21"
+  }
+}`
+)
+
+type testSigner struct {
+	privateKey ed25519.PrivateKey
+	err        error
+}
+
+func (s *testSigner) Sign(data []byte) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return ed25519.Sign(s.privateKey, data), nil
+}
+
+func TestSuite_CreateProof(t *testing.T) {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	s := New(WithDocumentLoader(jsonld.DocumentLoader()))
+
+	t.Run("test success - VC data model 2.0", func(t *testing.T) {
+		created := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+
+		proof, err := s.CreateProof([]byte(v2Credential), &testSigner{privateKey: privKey}, &ProofContext{
+			VerificationMethod: testVerificationMethod,
+			Created:            &created,
+			Challenge:          "challenge",
+			Domain:             "example.com",
+		})
+		require.NoError(t, err)
+		require.Equal(t, ProofType, proof["type"])
+		require.Equal(t, CryptosuiteEddsa2022, proof["cryptosuite"])
+		require.Equal(t, "assertionMethod", proof["proofPurpose"])
+		require.Equal(t, "2020-05-01T10:00:00Z", proof["created"])
+		require.Equal(t, "challenge", proof["challenge"])
+		require.Equal(t, "example.com", proof["domain"])
+		require.Contains(t, proof["proofValue"], "z")
+
+		signed := addProof(t, v2Credential, proof)
+		require.True(t, HasProof(signed))
+		require.NoError(t, s.Verify(signed, getPublicKeyFetcher(pubKey)))
+	})
+
+	t.Run("test success - VC data model 1.1", func(t *testing.T) {
+		proof, err := s.CreateProof([]byte(v1Credential), &testSigner{privateKey: privKey}, &ProofContext{
+			VerificationMethod: testVerificationMethod,
+			Purpose:            "authentication",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "authentication", proof["proofPurpose"])
+
+		require.NoError(t, s.Verify(addProof(t, v1Credential, proof), getPublicKeyFetcher(pubKey)))
+	})
+
+	t.Run("test error - missing verification method", func(t *testing.T) {
+		proof, err := s.CreateProof([]byte(v2Credential), &testSigner{privateKey: privKey}, &ProofContext{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "verification method is required")
+		require.Nil(t, proof)
+	})
+
+	t.Run("test error - invalid document", func(t *testing.T) {
+		proof, err := s.CreateProof([]byte("[]"), &testSigner{privateKey: privKey},
+			&ProofContext{VerificationMethod: testVerificationMethod})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "document is not a JSON object")
+		require.Nil(t, proof)
+	})
+
+	t.Run("test error - terms not defined by context", func(t *testing.T) {
+		proof, err := s.CreateProof([]byte(`{"name":"value"}`), &testSigner{privateKey: privKey},
+			&ProofContext{VerificationMethod: testVerificationMethod})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "canonicalized to an empty dataset")
+		require.Nil(t, proof)
+	})
+
+	t.Run("test error - sign failure", func(t *testing.T) {
+		proof, err := s.CreateProof([]byte(v2Credential), &testSigner{err: errors.New("sign error")},
+			&ProofContext{VerificationMethod: testVerificationMethod})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "sign error")
+		require.Nil(t, proof)
+	})
+}
+
+func TestSuite_Verify(t *testing.T) {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	s := New(WithDocumentLoader(jsonld.DocumentLoader()))
+
+	proof, err := s.CreateProof([]byte(v2Credential), &testSigner{privateKey: privKey},
+		&ProofContext{VerificationMethod: testVerificationMethod})
+	require.NoError(t, err)
+
+	t.Run("test success - proof set with other proof types", func(t *testing.T) {
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(v2Credential), &doc))
+
+		doc["proof"] = []interface{}{map[string]interface{}{"type": "Ed25519Signature2018"}, proof}
+
+		signed, err := json.Marshal(doc)
+		require.NoError(t, err)
+
+		require.NoError(t, s.Verify(signed, getPublicKeyFetcher(pubKey)))
+	})
+
+	t.Run("test success - public key as JWK", func(t *testing.T) {
+		fetcher := func(issuerID, keyID string) (*verifier.PublicKey, error) {
+			require.Equal(t, "did:test:abc", issuerID)
+			require.Equal(t, "#key1", keyID)
+
+			jwk := &jose.JWK{}
+
+			err := jwk.UnmarshalJSON([]byte(`{"kty":"OKP","crv":"Ed25519","x":"` +
+				base64.RawURLEncoding.EncodeToString(pubKey) + `"}`))
+			if err != nil {
+				return nil, err
+			}
+
+			return &verifier.PublicKey{JWK: jwk}, nil
+		}
+
+		require.NoError(t, s.Verify(addProof(t, v2Credential, proof), fetcher))
+	})
+
+	t.Run("test error - tampered document", func(t *testing.T) {
+		signed := addProof(t, v2Credential, proof)
+
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(signed, &doc))
+
+		doc["issuer"] = "did:test:xyz"
+
+		tampered, err := json.Marshal(doc)
+		require.NoError(t, err)
+
+		err = s.Verify(tampered, getPublicKeyFetcher(pubKey))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "signature doesn't match")
+	})
+
+	t.Run("test error - wrong public key", func(t *testing.T) {
+		otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		err = s.Verify(addProof(t, v2Credential, proof), getPublicKeyFetcher(otherKey))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "signature doesn't match")
+	})
+
+	t.Run("test error - missing proof", func(t *testing.T) {
+		require.False(t, HasProof([]byte(v2Credential)))
+
+		err := s.Verify([]byte(v2Credential), getPublicKeyFetcher(pubKey))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "data integrity proof is missing")
+	})
+
+	t.Run("test error - invalid proof data", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			key   string
+			value string
+			err   string
+		}{
+			{name: "unsupported cryptosuite", key: "cryptosuite", value: "ecdsa-2019",
+				err: "unsupported cryptosuite: ecdsa-2019"},
+			{name: "invalid proof value", key: "proofValue", value: "abc",
+				err: "not a base58-btc multibase value"},
+			{name: "invalid verification method", key: "verificationMethod", value: "did:test:abc",
+				err: "wrong verification method"},
+		}
+
+		for _, tc := range tests {
+			p := verifiable.Proof{}
+			for k, v := range proof {
+				p[k] = v
+			}
+
+			p[tc.key] = tc.value
+
+			err := s.Verify(addProof(t, v2Credential, p), getPublicKeyFetcher(pubKey))
+			require.Error(t, err, tc.name)
+			require.Contains(t, err.Error(), tc.err, tc.name)
+		}
+	})
+
+	t.Run("test error - public key", func(t *testing.T) {
+		err := s.Verify(addProof(t, v2Credential, proof), func(issuerID, keyID string) (*verifier.PublicKey, error) {
+			return nil, errors.New("resolve error")
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to fetch public key: resolve error")
+
+		err = s.Verify(addProof(t, v2Credential, proof), func(issuerID, keyID string) (*verifier.PublicKey, error) {
+			return &verifier.PublicKey{Value: []byte("key")}, nil
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "public key is not an Ed25519 key")
+	})
+
+	t.Run("test controller and verification method check", func(t *testing.T) {
+		signed := addProof(t, v2Credential, proof)
+
+		require.NoError(t, s.Verify(signed, getPublicKeyFetcher(pubKey), WithController("did:test:abc"),
+			WithVerificationMethodCheck(func(vm, purpose string) error {
+				require.Equal(t, testVerificationMethod, vm)
+				require.Equal(t, "assertionMethod", purpose)
+
+				return nil
+			})))
+
+		err := s.Verify(signed, getPublicKeyFetcher(pubKey), WithController("did:test:xyz"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "verification method did:test:abc#key1 is not controlled by did:test:xyz")
+
+		err = s.Verify(signed, getPublicKeyFetcher(pubKey), WithVerificationMethodCheck(
+			func(vm, purpose string) error {
+				return errors.New("not authorized")
+			}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not authorized")
+	})
+
+	t.Run("test error - invalid document", func(t *testing.T) {
+		require.False(t, HasProof([]byte("[]")))
+
+		err := s.Verify([]byte("[]"), getPublicKeyFetcher(pubKey))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "document is not a JSON object")
+	})
+}
+
+func TestRemoveProofs(t *testing.T) {
+	dataIntegrityProof := map[string]interface{}{"type": ProofType}
+	ldProof := map[string]interface{}{"type": "Ed25519Signature2018"}
+
+	tests := []struct {
+		name   string
+		proof  interface{}
+		result interface{}
+		others bool
+	}{
+		{name: "data integrity proof", proof: dataIntegrityProof},
+		{name: "proof set", proof: []interface{}{dataIntegrityProof, ldProof}, result: ldProof, others: true},
+		{name: "other proofs", proof: []interface{}{ldProof, ldProof}, result: []interface{}{ldProof, ldProof},
+			others: true},
+	}
+
+	for _, tc := range tests {
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(v1Credential), &doc))
+
+		doc["proof"] = tc.proof
+
+		docBytes, err := json.Marshal(doc)
+		require.NoError(t, err)
+
+		result, others, err := RemoveProofs(docBytes)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.others, others, tc.name)
+
+		var resultDoc map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &resultDoc), tc.name)
+		require.Equal(t, tc.result, resultDoc["proof"], tc.name)
+	}
+
+	_, _, err := RemoveProofs([]byte("[]"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "document is not a JSON object")
+}
+
+func addProof(t *testing.T, doc string, proof verifiable.Proof) []byte {
+	t.Helper()
+
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(doc), &m))
+
+	m["proof"] = proof
+
+	b, err := json.Marshal(m)
+	require.NoError(t, err)
+
+	return b
+}
+
+func getPublicKeyFetcher(pubKey ed25519.PublicKey) verifiable.PublicKeyFetcher {
+	return verifiable.SingleKey(pubKey, "Ed25519VerificationKey2018")
+}
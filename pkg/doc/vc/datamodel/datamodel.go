@@ -0,0 +1,205 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package datamodel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+)
+
+const (
+	// ContextV1 is the base context of VC Data Model 1.1
+	ContextV1 = "https://www.w3.org/2018/credentials/v1"
+	// ContextV2 is the base context of VC Data Model 2.0
+	ContextV2 = "https://www.w3.org/ns/credentials/v2"
+
+	// Version1 VC Data Model 1.1
+	Version1 = "1.1"
+	// Version2 VC Data Model 2.0
+	Version2 = "2.0"
+
+	vcType = "VerifiableCredential"
+
+	// VC Data Model 2.0 validity period fields
+	validFromField  = "validFrom"
+	validUntilField = "validUntil"
+)
+
+// ValidateVersion checks if the given data model version is supported, empty version means 1.1.
+func ValidateVersion(version string) error {
+	switch version {
+	case "", Version1, Version2:
+		return nil
+	default:
+		return fmt.Errorf("unsupported VC data model version: %s", version)
+	}
+}
+
+// IsV2 checks whether the credential follows VC Data Model 2.0.
+func IsV2(vc *verifiable.Credential) bool {
+	return len(vc.Context) > 0 && vc.Context[0] == ContextV2
+}
+
+// IsV2Document checks whether the JSON-LD document (credential or presentation) uses the 2.0 base context.
+func IsV2Document(docBytes []byte) bool {
+	var doc struct {
+		Context interface{} `json:"@context"`
+	}
+
+	if err := json.Unmarshal(docBytes, &doc); err != nil {
+		return false
+	}
+
+	switch ctx := doc.Context.(type) {
+	case string:
+		return ctx == ContextV2
+	case []interface{}:
+		return len(ctx) > 0 && ctx[0] == ContextV2
+	default:
+		return false
+	}
+}
+
+// ValidFrom returns the beginning of the credential validity period (validFrom or issuanceDate).
+func ValidFrom(vc *verifiable.Credential) (*time.Time, error) {
+	if !IsV2(vc) {
+		return vc.Issued, nil
+	}
+
+	return timeField(vc, validFromField)
+}
+
+// ValidUntil returns the end of the credential validity period (validUntil or expirationDate).
+func ValidUntil(vc *verifiable.Credential) (*time.Time, error) {
+	if !IsV2(vc) {
+		return vc.Expired, nil
+	}
+
+	return timeField(vc, validUntilField)
+}
+
+// ToV2 converts the credential built by the service into VC Data Model 2.0: the base context is replaced
+// and issuanceDate/expirationDate are moved to validFrom/validUntil.
+func ToV2(vc *verifiable.Credential) {
+	context := []string{ContextV2}
+
+	for _, c := range vc.Context {
+		if c != ContextV1 && c != ContextV2 {
+			context = append(context, c)
+		}
+	}
+
+	vc.Context = context
+
+	if vc.CustomFields == nil {
+		vc.CustomFields = make(verifiable.CustomFields)
+	}
+
+	if vc.Issued != nil {
+		vc.CustomFields[validFromField] = vc.Issued.UTC().Format(time.RFC3339)
+		vc.Issued = nil
+	}
+
+	if vc.Expired != nil {
+		vc.CustomFields[validUntilField] = vc.Expired.UTC().Format(time.RFC3339)
+		vc.Expired = nil
+	}
+}
+
+// SetValidity sets the credential validity period (issuanceDate/expirationDate or validFrom/validUntil),
+// nil validUntil removes the end of the validity period.
+func SetValidity(vc *verifiable.Credential, validFrom, validUntil *time.Time) {
+	if !IsV2(vc) {
+		vc.Issued = validFrom
+		vc.Expired = validUntil
+
+		return
+	}
+
+	if vc.CustomFields == nil {
+		vc.CustomFields = make(verifiable.CustomFields)
+	}
+
+	vc.CustomFields[validFromField] = validFrom.UTC().Format(time.RFC3339)
+	delete(vc.CustomFields, validUntilField)
+
+	if validUntil != nil {
+		vc.CustomFields[validUntilField] = validUntil.UTC().Format(time.RFC3339)
+	}
+}
+
+// Validate checks the mandatory properties of a VC Data Model 2.0 credential.
+func Validate(vc *verifiable.Credential) error {
+	if !IsV2(vc) {
+		return fmt.Errorf("first @context must be %s", ContextV2)
+	}
+
+	if !hasType(vc.Types, vcType) {
+		return fmt.Errorf("type must include %s", vcType)
+	}
+
+	if vc.Issuer.ID == "" {
+		return errors.New("issuer is required")
+	}
+
+	if vc.Subject == nil {
+		return errors.New("credentialSubject is required")
+	}
+
+	if vc.Issued != nil || vc.Expired != nil {
+		return errors.New("issuanceDate and expirationDate are replaced by validFrom and validUntil")
+	}
+
+	validFrom, err := ValidFrom(vc)
+	if err != nil {
+		return err
+	}
+
+	validUntil, err := ValidUntil(vc)
+	if err != nil {
+		return err
+	}
+
+	if validFrom != nil && validUntil != nil && validUntil.Before(*validFrom) {
+		return errors.New("validUntil is before validFrom")
+	}
+
+	return nil
+}
+
+func timeField(vc *verifiable.Credential, name string) (*time.Time, error) {
+	v, ok := vc.CustomFields[name]
+	if !ok {
+		return nil, nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a string", name)
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return &t, nil
+}
+
+func hasType(types []string, t string) bool {
+	for _, v := range types {
+		if v == t {
+			return true
+		}
+	}
+
+	return false
+}
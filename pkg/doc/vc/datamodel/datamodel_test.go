@@ -0,0 +1,163 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package datamodel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateVersion(t *testing.T) {
+	require.NoError(t, ValidateVersion(""))
+	require.NoError(t, ValidateVersion(Version1))
+	require.NoError(t, ValidateVersion(Version2))
+
+	err := ValidateVersion("3.0")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unsupported VC data model version: 3.0")
+}
+
+func TestIsV2Document(t *testing.T) {
+	require.True(t, IsV2Document([]byte(`{"@context":"https://www.w3.org/ns/credentials/v2"}`)))
+	require.True(t, IsV2Document([]byte(`{"@context":["https://www.w3.org/ns/credentials/v2","ctx"]}`)))
+	require.False(t, IsV2Document([]byte(`{"@context":["https://www.w3.org/2018/credentials/v1"]}`)))
+	require.False(t, IsV2Document([]byte(`{"@context":{}}`)))
+	require.False(t, IsV2Document([]byte(`eyJhbGciOiJub25lIn0.e30.`)))
+}
+
+func TestToV2(t *testing.T) {
+	issued := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	expired := issued.AddDate(1, 0, 0)
+
+	vc := &verifiable.Credential{
+		Context: []string{ContextV1, "https://example.com/context"},
+		Types:   []string{vcType},
+		Issuer:  verifiable.Issuer{ID: "did:example:123"},
+		Subject: "did:example:456",
+		Issued:  &issued,
+		Expired: &expired,
+	}
+
+	require.False(t, IsV2(vc))
+
+	validFrom, err := ValidFrom(vc)
+	require.NoError(t, err)
+	require.Equal(t, &issued, validFrom)
+
+	ToV2(vc)
+
+	require.True(t, IsV2(vc))
+	require.Equal(t, []string{ContextV2, "https://example.com/context"}, vc.Context)
+	require.Nil(t, vc.Issued)
+	require.Nil(t, vc.Expired)
+	require.Equal(t, "2020-01-01T10:00:00Z", vc.CustomFields["validFrom"])
+	require.Equal(t, "2021-01-01T10:00:00Z", vc.CustomFields["validUntil"])
+
+	validFrom, err = ValidFrom(vc)
+	require.NoError(t, err)
+	require.True(t, issued.Equal(*validFrom))
+
+	validUntil, err := ValidUntil(vc)
+	require.NoError(t, err)
+	require.True(t, expired.Equal(*validUntil))
+
+	require.NoError(t, Validate(vc))
+
+	vcBytes, err := vc.MarshalJSON()
+	require.NoError(t, err)
+	require.True(t, IsV2Document(vcBytes))
+}
+
+func TestSetValidity(t *testing.T) {
+	validFrom := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	validUntil := validFrom.AddDate(1, 0, 0)
+
+	vc := &verifiable.Credential{Context: []string{ContextV1}}
+
+	SetValidity(vc, &validFrom, &validUntil)
+	require.Equal(t, &validFrom, vc.Issued)
+	require.Equal(t, &validUntil, vc.Expired)
+
+	vc = &verifiable.Credential{Context: []string{ContextV2}}
+
+	SetValidity(vc, &validFrom, &validUntil)
+	require.Equal(t, "2020-01-01T10:00:00Z", vc.CustomFields["validFrom"])
+	require.Equal(t, "2021-01-01T10:00:00Z", vc.CustomFields["validUntil"])
+
+	SetValidity(vc, &validUntil, nil)
+	require.Equal(t, "2021-01-01T10:00:00Z", vc.CustomFields["validFrom"])
+	require.NotContains(t, vc.CustomFields, "validUntil")
+}
+
+func TestValidate(t *testing.T) {
+	issued := time.Now()
+
+	tests := []struct {
+		name string
+		vc   *verifiable.Credential
+		err  string
+	}{
+		{
+			name: "not v2 context",
+			vc:   &verifiable.Credential{Context: []string{ContextV1}},
+			err:  "first @context must be " + ContextV2,
+		},
+		{
+			name: "missing type",
+			vc:   &verifiable.Credential{Context: []string{ContextV2}, Types: []string{"Custom"}},
+			err:  "type must include VerifiableCredential",
+		},
+		{
+			name: "missing issuer",
+			vc:   &verifiable.Credential{Context: []string{ContextV2}, Types: []string{vcType}},
+			err:  "issuer is required",
+		},
+		{
+			name: "missing subject",
+			vc: &verifiable.Credential{Context: []string{ContextV2}, Types: []string{vcType},
+				Issuer: verifiable.Issuer{ID: "did:example:123"}},
+			err: "credentialSubject is required",
+		},
+		{
+			name: "1.1 validity period",
+			vc: &verifiable.Credential{Context: []string{ContextV2}, Types: []string{vcType},
+				Issuer: verifiable.Issuer{ID: "did:example:123"}, Subject: "did:example:456", Issued: &issued},
+			err: "issuanceDate and expirationDate are replaced by validFrom and validUntil",
+		},
+		{
+			name: "invalid validFrom type",
+			vc: &verifiable.Credential{Context: []string{ContextV2}, Types: []string{vcType},
+				Issuer: verifiable.Issuer{ID: "did:example:123"}, Subject: "did:example:456",
+				CustomFields: map[string]interface{}{"validFrom": 1}},
+			err: "validFrom must be a string",
+		},
+		{
+			name: "invalid validUntil format",
+			vc: &verifiable.Credential{Context: []string{ContextV2}, Types: []string{vcType},
+				Issuer: verifiable.Issuer{ID: "did:example:123"}, Subject: "did:example:456",
+				CustomFields: map[string]interface{}{"validUntil": "tomorrow"}},
+			err: "invalid validUntil",
+		},
+		{
+			name: "validUntil before validFrom",
+			vc: &verifiable.Credential{Context: []string{ContextV2}, Types: []string{vcType},
+				Issuer: verifiable.Issuer{ID: "did:example:123"}, Subject: "did:example:456",
+				CustomFields: map[string]interface{}{
+					"validFrom": "2020-01-01T10:00:00Z", "validUntil": "2019-01-01T10:00:00Z"}},
+			err: "validUntil is before validFrom",
+		},
+	}
+
+	for _, tc := range tests {
+		err := Validate(tc.vc)
+		require.Error(t, err, tc.name)
+		require.Contains(t, err.Error(), tc.err, tc.name)
+	}
+}
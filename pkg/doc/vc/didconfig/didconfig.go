@@ -0,0 +1,301 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didconfig
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/trustbloc/edge-core/pkg/storage"
+)
+
+const (
+	// WellKnownPath is the path of the DID configuration resource at the linked domain origin.
+	WellKnownPath = "/.well-known/did-configuration.json"
+	// Context of the DID configuration resource and the Domain Linkage credentials.
+	Context = "https://identity.foundation/.well-known/did-configuration/v1"
+	// DomainLinkageCredentialType is the type of the credential linking the DID to the origin.
+	DomainLinkageCredentialType = "DomainLinkageCredential"
+	// LinkedDomainsServiceType is the type of the DID document service listing the linked domain origins.
+	LinkedDomainsServiceType = "LinkedDomains"
+
+	credentialsContext = "https://www.w3.org/2018/credentials/v1"
+
+	jwtParts = 3
+
+	keyPattern  = "%s_%s"
+	keyPrefix   = "linkeddid"
+	profilesKey = "didconfig_profiles"
+)
+
+// Configuration is the DID configuration resource served at the well-known path of the origin.
+type Configuration struct {
+	Context    string            `json:"@context"`
+	LinkedDIDs []json.RawMessage `json:"linked_dids"`
+}
+
+// LinkedDID is the signed Domain Linkage credential of the profile.
+type LinkedDID struct {
+	Credential json.RawMessage `json:"credential"`
+	Expires    time.Time       `json:"expires"`
+}
+
+// Origin returns the origin (scheme and host) of the URI, only https origins are linked to the DIDs.
+func Origin(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid uri: %w", err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("uri %s has no origin", uri)
+	}
+
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("uri %s has no https origin", uri)
+	}
+
+	return u.Scheme + "://" + u.Host, nil
+}
+
+// CreateCredential returns the unsigned Domain Linkage credential linking the DID to the origin.
+func CreateCredential(did, origin string, issued, expires time.Time) *verifiable.Credential {
+	return &verifiable.Credential{
+		Context: []string{credentialsContext, Context},
+		Types:   []string{"VerifiableCredential", DomainLinkageCredentialType},
+		Issuer:  verifiable.Issuer{ID: did},
+		Issued:  &issued,
+		Expired: &expires,
+		Subject: map[string]interface{}{"id": did, "origin": origin},
+	}
+}
+
+// Verify checks the DID configuration has the valid Domain Linkage credential of the DID and the origin,
+// the credentials are parsed (and their proofs verified) with the given options. The credentials must be signed
+// by the DID, the credentials without a proof and the unsecured JWTs are rejected.
+func Verify(configBytes []byte, did, origin string, asOf time.Time, opts ...verifiable.CredentialOpt) error {
+	config := &Configuration{}
+
+	if err := json.Unmarshal(configBytes, config); err != nil {
+		return fmt.Errorf("invalid DID configuration: %w", err)
+	}
+
+	if config.Context != Context {
+		return fmt.Errorf("invalid DID configuration context %s", config.Context)
+	}
+
+	var errs []string
+
+	for _, linkedDID := range config.LinkedDIDs {
+		err := verifyCredential(linkedDID, did, origin, asOf, opts...)
+		if err == nil {
+			return nil
+		}
+
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) == 0 {
+		return fmt.Errorf("DID configuration of %s has no linked DIDs", origin)
+	}
+
+	return fmt.Errorf("DID configuration of %s doesn't link %s: %s", origin, did, strings.Join(errs, "; "))
+}
+
+func verifyCredential(vcBytes []byte, did, origin string, asOf time.Time, opts ...verifiable.CredentialOpt) error {
+	// JWT domain linkage credentials are JSON strings
+	var jwt string
+	if json.Unmarshal(vcBytes, &jwt) == nil {
+		if err := checkJWS(jwt); err != nil {
+			return err
+		}
+
+		vcBytes = []byte(jwt)
+	}
+
+	vc, _, err := verifiable.NewCredential(vcBytes, opts...)
+	if err != nil {
+		return err
+	}
+
+	if jwt == "" {
+		if err := checkProofs(vc, did); err != nil {
+			return err
+		}
+	}
+
+	if !contains(vc.Types, DomainLinkageCredentialType) {
+		return fmt.Errorf("credential isn't %s", DomainLinkageCredentialType)
+	}
+
+	subject, ok := vc.Subject.(map[string]interface{})
+	if !ok {
+		return errors.New("credential must have a single subject")
+	}
+
+	switch {
+	case vc.Issuer.ID != did || subject["id"] != did:
+		return fmt.Errorf("credential is issued to %v by %s", subject["id"], vc.Issuer.ID)
+	case subject["origin"] != origin:
+		return fmt.Errorf("credential origin %v doesn't match", subject["origin"])
+	case vc.Issued == nil || asOf.Before(*vc.Issued):
+		return errors.New("credential isn't valid yet")
+	case vc.Expired == nil || asOf.After(*vc.Expired):
+		return errors.New("credential has expired")
+	}
+
+	return nil
+}
+
+// New returns new DID configuration store instance.
+func New(store storage.Store) *Store {
+	return &Store{store: store}
+}
+
+// Store keeps the issuer profiles linked to their domains and the signed Domain Linkage credentials
+// of the profiles.
+type Store struct {
+	store storage.Store
+	mutex sync.Mutex
+}
+
+// AddProfile adds the profile to the profiles linked to their domains.
+func (s *Store) AddProfile(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	profiles, err := s.Profiles()
+	if err != nil {
+		return err
+	}
+
+	if contains(profiles, name) {
+		return nil
+	}
+
+	bytes, err := json.Marshal(append(profiles, name))
+	if err != nil {
+		return err
+	}
+
+	return s.store.Put(profilesKey, bytes)
+}
+
+// Profiles returns the profiles linked to their domains.
+func (s *Store) Profiles() ([]string, error) {
+	bytes, err := s.store.Get(profilesKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrValueNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get linked profiles: %w", err)
+	}
+
+	var profiles []string
+
+	if err := json.Unmarshal(bytes, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal linked profiles: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// SaveLinkedDID saves the signed Domain Linkage credential of the profile.
+func (s *Store) SaveLinkedDID(profile string, linkedDID *LinkedDID) error {
+	bytes, err := json.Marshal(linkedDID)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Put(getDBKey(profile), bytes)
+}
+
+// GetLinkedDID returns the signed Domain Linkage credential of the profile.
+func (s *Store) GetLinkedDID(profile string) (*LinkedDID, error) {
+	bytes, err := s.store.Get(getDBKey(profile))
+	if err != nil {
+		return nil, err
+	}
+
+	linkedDID := &LinkedDID{}
+
+	if err := json.Unmarshal(bytes, linkedDID); err != nil {
+		return nil, err
+	}
+
+	return linkedDID, nil
+}
+
+// checkJWS checks the JWT credential is signed, the signature is verified with the key of the issuer
+// when the credential is parsed
+func checkJWS(jwt string) error {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != jwtParts || parts[jwtParts-1] == "" {
+		return errors.New("credential JWT isn't signed")
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return fmt.Errorf("invalid credential JWT header: %w", err)
+	}
+
+	header := &struct {
+		Algorithm string `json:"alg"`
+	}{}
+
+	if err := json.Unmarshal(headerBytes, header); err != nil {
+		return fmt.Errorf("invalid credential JWT header: %w", err)
+	}
+
+	if header.Algorithm == "" || strings.EqualFold(header.Algorithm, "none") {
+		return errors.New("credential JWT isn't signed")
+	}
+
+	return nil
+}
+
+// checkProofs checks the linked data credential has the proofs and they are made with the keys of the DID
+func checkProofs(vc *verifiable.Credential, did string) error {
+	if len(vc.Proofs) == 0 {
+		return errors.New("credential has no proof")
+	}
+
+	for _, proof := range vc.Proofs {
+		method, ok := proof["verificationMethod"].(string)
+		if !ok {
+			method, ok = proof["creator"].(string)
+		}
+
+		if !ok || !strings.HasPrefix(method, did+"#") {
+			return fmt.Errorf("credential proof isn't made by %s", did)
+		}
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getDBKey(profile string) string {
+	return fmt.Sprintf(keyPattern, keyPrefix, profile)
+}
@@ -0,0 +1,339 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didconfig
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage"
+	mockstorage "github.com/trustbloc/edge-core/pkg/storage/mockstore"
+
+	"github.com/trustbloc/edge-service/pkg/doc/jsonld"
+)
+
+const (
+	testDID    = "did:example:76e12ec712ebc6f1c221ebfeb1f"
+	testOrigin = "https://example.com"
+)
+
+func TestOrigin(t *testing.T) {
+	origin, err := Origin("https://example.com:8080/issuer/profile?id=1")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com:8080", origin)
+
+	_, err = Origin("example.com/issuer")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "has no origin")
+
+	_, err = Origin("http://example.com/issuer")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "uri http://example.com/issuer has no https origin")
+
+	_, err = Origin(":invalid")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid uri")
+}
+
+func TestVerify(t *testing.T) {
+	issued := time.Now().UTC().Add(-time.Hour)
+	expires := issued.Add(2 * time.Hour)
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	loader := jsonld.NewDocumentLoader(jsonld.WithEmbeddedContexts())
+
+	// the proofs are verified with the key of the test DID
+	opts := []verifiable.CredentialOpt{
+		verifiable.WithJSONLDDocumentLoader(loader),
+		verifiable.WithPublicKeyFetcher(verifiable.SingleKey(pubKey, "Ed25519Signature2018")),
+		verifiable.WithEmbeddedSignatureSuites(jsonld.VerifierSuite(ed25519signature2018.New(
+			suite.WithVerifier(ed25519signature2018.NewPublicKeyVerifier())), loader)),
+		verifiable.WithBaseContextExtendedValidation([]string{Context}, []string{DomainLinkageCredentialType}),
+	}
+
+	sign := func(t *testing.T, vc *verifiable.Credential, verificationMethod string) *verifiable.Credential {
+		t.Helper()
+
+		require.NoError(t, vc.AddLinkedDataProof(&verifiable.LinkedDataProofContext{
+			SignatureType: "Ed25519Signature2018",
+			Suite: jsonld.SignerSuite(ed25519signature2018.New(suite.WithSigner(&ed25519Signer{privKey})),
+				loader),
+			SignatureRepresentation: verifiable.SignatureProofValue,
+			VerificationMethod:      verificationMethod,
+		}))
+
+		return vc
+	}
+
+	configuration := func(t *testing.T, credentials ...*verifiable.Credential) []byte {
+		t.Helper()
+
+		config := &Configuration{Context: Context, LinkedDIDs: []json.RawMessage{}}
+
+		for _, vc := range credentials {
+			if len(vc.Proofs) == 0 {
+				sign(t, vc, testDID+"#key-1")
+			}
+
+			vcBytes, err := vc.MarshalJSON()
+			require.NoError(t, err)
+
+			config.LinkedDIDs = append(config.LinkedDIDs, vcBytes)
+		}
+
+		configBytes, err := json.Marshal(config)
+		require.NoError(t, err)
+
+		return configBytes
+	}
+
+	t.Run("test verify success", func(t *testing.T) {
+		configBytes := configuration(t,
+			CreateCredential("did:example:other", testOrigin, issued, expires),
+			CreateCredential(testDID, testOrigin, issued, expires),
+		)
+
+		require.NoError(t, Verify(configBytes, testDID, testOrigin, time.Now(), opts...))
+	})
+
+	jwtConfiguration := func(t *testing.T, jwt string) []byte {
+		t.Helper()
+
+		jwtBytes, err := json.Marshal(jwt)
+		require.NoError(t, err)
+
+		configBytes, err := json.Marshal(&Configuration{Context: Context, LinkedDIDs: []json.RawMessage{jwtBytes}})
+		require.NoError(t, err)
+
+		return configBytes
+	}
+
+	t.Run("test verify JWT credential", func(t *testing.T) {
+		jwtClaims, err := CreateCredential(testDID, testOrigin, issued, expires).JWTClaims(false)
+		require.NoError(t, err)
+
+		jws, err := jwtClaims.MarshalJWS(verifiable.EdDSA, &ed25519Signer{privKey}, testDID+"#key-1")
+		require.NoError(t, err)
+
+		require.NoError(t, Verify(jwtConfiguration(t, jws), testDID, testOrigin, time.Now(), opts...))
+	})
+
+	t.Run("test verify credential signature", func(t *testing.T) {
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		vc := CreateCredential(testDID, testOrigin, issued, expires)
+		require.NoError(t, vc.AddLinkedDataProof(&verifiable.LinkedDataProofContext{
+			SignatureType: "Ed25519Signature2018",
+			Suite: jsonld.SignerSuite(ed25519signature2018.New(suite.WithSigner(&ed25519Signer{otherKey})),
+				loader),
+			SignatureRepresentation: verifiable.SignatureProofValue,
+			VerificationMethod:      testDID + "#key-1",
+		}))
+
+		err = Verify(configuration(t, vc), testDID, testOrigin, time.Now(), opts...)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "doesn't link")
+
+		// the claims of the signed credential are tampered with
+		vc = sign(t, CreateCredential(testDID, "https://other.com", issued, expires), testDID+"#key-1")
+		vc.Subject = map[string]interface{}{"id": testDID, "origin": testOrigin}
+
+		err = Verify(configuration(t, vc), testDID, testOrigin, time.Now(), opts...)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "doesn't link")
+
+		jwtClaims, err := CreateCredential(testDID, testOrigin, issued, expires).JWTClaims(false)
+		require.NoError(t, err)
+
+		jws, err := jwtClaims.MarshalJWS(verifiable.EdDSA, &ed25519Signer{otherKey}, testDID+"#key-1")
+		require.NoError(t, err)
+
+		err = Verify(jwtConfiguration(t, jws), testDID, testOrigin, time.Now(), opts...)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "doesn't link")
+	})
+
+	t.Run("test verify unsigned credential", func(t *testing.T) {
+		vcBytes, err := CreateCredential(testDID, testOrigin, issued, expires).MarshalJSON()
+		require.NoError(t, err)
+
+		configBytes, err := json.Marshal(&Configuration{Context: Context, LinkedDIDs: []json.RawMessage{vcBytes}})
+		require.NoError(t, err)
+
+		err = Verify(configBytes, testDID, testOrigin, time.Now(), opts...)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "credential has no proof")
+
+		// the proofs are required even if they aren't checked by the options
+		err = Verify(configBytes, testDID, testOrigin, time.Now(), verifiable.WithDisabledProofCheck(),
+			verifiable.WithJSONLDDocumentLoader(loader))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "credential has no proof")
+
+		otherKeyVC := sign(t, CreateCredential(testDID, testOrigin, issued, expires), "did:example:other#key-1")
+
+		err = Verify(configuration(t, otherKeyVC), testDID, testOrigin, time.Now(), opts...)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "credential proof isn't made by "+testDID)
+
+		jwtClaims, err := CreateCredential(testDID, testOrigin, issued, expires).JWTClaims(false)
+		require.NoError(t, err)
+
+		unsecured, err := jwtClaims.MarshalUnsecuredJWT()
+		require.NoError(t, err)
+
+		for _, jwt := range []string{unsecured, "eyJhbGciOiJub25lIn0.e30.c2ln", "header.e30.c2ln", "e30.e30.c2ln"} {
+			err = Verify(jwtConfiguration(t, jwt), testDID, testOrigin, time.Now(), opts...)
+			require.Error(t, err, jwt)
+			require.Contains(t, err.Error(), "credential JWT", jwt)
+		}
+	})
+
+	t.Run("test verify invalid configuration", func(t *testing.T) {
+		err := Verify([]byte("invalid"), testDID, testOrigin, time.Now(), opts...)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid DID configuration")
+
+		err = Verify([]byte(`{"@context":"https://example.com/context"}`), testDID, testOrigin, time.Now(), opts...)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid DID configuration context")
+
+		err = Verify(configuration(t), testDID, testOrigin, time.Now(), opts...)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "has no linked DIDs")
+
+		err = Verify([]byte(`{"@context":"`+Context+`","linked_dids":[{}]}`), testDID, testOrigin, time.Now(),
+			opts...)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "doesn't link")
+	})
+
+	t.Run("test verify credential mismatch", func(t *testing.T) {
+		otherType := CreateCredential(testDID, testOrigin, issued, expires)
+		otherType.Types = []string{"VerifiableCredential"}
+
+		multipleSubjects := CreateCredential(testDID, testOrigin, issued, expires)
+		multipleSubjects.Subject = []map[string]interface{}{{"id": testDID}, {"id": testDID}}
+
+		tests := []struct {
+			name       string
+			credential *verifiable.Credential
+			asOf       time.Time
+			err        string
+		}{
+			{"other type", otherType, time.Now(), "credential isn't " + DomainLinkageCredentialType},
+			{"multiple subjects", multipleSubjects, time.Now(), "credential must have a single subject"},
+			{
+				"other DID", CreateCredential("did:example:other", testOrigin, issued, expires), time.Now(),
+				"credential is issued to did:example:other",
+			},
+			{
+				"other origin", CreateCredential(testDID, "https://other.com", issued, expires), time.Now(),
+				"credential origin https://other.com doesn't match",
+			},
+			{
+				"not valid yet", CreateCredential(testDID, testOrigin, issued, expires), issued.Add(-time.Hour),
+				"credential isn't valid yet",
+			},
+			{
+				"expired", CreateCredential(testDID, testOrigin, issued, expires), expires.Add(time.Hour),
+				"credential has expired",
+			},
+		}
+
+		for _, tc := range tests {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				err := Verify(configuration(t, tc.credential), testDID, testOrigin, tc.asOf, opts...)
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.err)
+			})
+		}
+	})
+}
+
+type ed25519Signer struct {
+	privateKey ed25519.PrivateKey
+}
+
+func (s *ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.privateKey, data), nil
+}
+
+func TestStore(t *testing.T) {
+	t.Run("test profiles", func(t *testing.T) {
+		store := New(&mockstorage.MockStore{Store: make(map[string][]byte)})
+		require.NotNil(t, store)
+
+		profiles, err := store.Profiles()
+		require.NoError(t, err)
+		require.Empty(t, profiles)
+
+		require.NoError(t, store.AddProfile("issuer1"))
+		require.NoError(t, store.AddProfile("issuer2"))
+		require.NoError(t, store.AddProfile("issuer1"))
+
+		profiles, err = store.Profiles()
+		require.NoError(t, err)
+		require.Equal(t, []string{"issuer1", "issuer2"}, profiles)
+	})
+
+	t.Run("test profiles store errors", func(t *testing.T) {
+		mockStore := &mockstorage.MockStore{Store: make(map[string][]byte)}
+		mockStore.Store[profilesKey] = []byte("invalid")
+
+		store := New(mockStore)
+
+		_, err := store.Profiles()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal linked profiles")
+
+		require.Error(t, store.AddProfile("issuer"))
+
+		mockStore.ErrGet = errors.New("get error")
+
+		_, err = store.Profiles()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get linked profiles: get error")
+	})
+
+	t.Run("test linked DIDs", func(t *testing.T) {
+		mockStore := &mockstorage.MockStore{Store: make(map[string][]byte)}
+		store := New(mockStore)
+
+		_, err := store.GetLinkedDID("issuer")
+		require.True(t, errors.Is(err, storage.ErrValueNotFound))
+
+		expires := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+		linkedDID := &LinkedDID{Credential: json.RawMessage(`{"id":"vc"}`), Expires: expires}
+
+		require.NoError(t, store.SaveLinkedDID("issuer", linkedDID))
+
+		result, err := store.GetLinkedDID("issuer")
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":"vc"}`, string(result.Credential))
+		require.True(t, expires.Equal(result.Expires))
+
+		mockStore.Store[getDBKey("issuer")] = []byte("invalid")
+
+		_, err = store.GetLinkedDID("issuer")
+		require.Error(t, err)
+
+		mockStore.ErrPut = errors.New("put error")
+		require.EqualError(t, store.SaveLinkedDID("issuer", linkedDID), "put error")
+	})
+}
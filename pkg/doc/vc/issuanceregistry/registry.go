@@ -0,0 +1,370 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuanceregistry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/edge-core/pkg/storage"
+)
+
+const (
+	keyPattern      = "%s_%s_%s"
+	keyPrefix       = "issued"
+	indexKeyPattern = "%s_%s_%s"
+	countKeyPrefix  = "issuedcount"
+	countKeyPattern = "%s_%s"
+	entryKeyPattern = "%s_%s_%d"
+	entryKeyPrefix  = "issuedindex"
+	reservedPrefix  = "issuedreserved"
+
+	// profileKeyPattern prefixes the profile name with its length, so the keys of the profiles and the values
+	// which contain the separator (e.g. profile "a" with ID "b_c" and profile "a_b" with ID "c") don't collide
+	profileKeyPattern = "%d:%s"
+
+	// defaultReservationPeriod is the period the credential ID is reserved for its issuance
+	defaultReservationPeriod = 5 * time.Minute
+
+	allIndex     = "all"
+	subjectIndex = "subject"
+	typeIndex    = "type"
+
+	// DefaultLimit is the number of the index entries the query scans if the limit isn't set
+	DefaultLimit = 100
+	// MaxLimit is the maximal number of the index entries the query scans
+	MaxLimit = 1000
+)
+
+// ErrDuplicateID is returned when the credential ID has already been issued (or is being issued) by the profile.
+var ErrDuplicateID = errors.New("duplicate credential ID")
+
+// New returns new issuance registry instance
+func New(store storage.Store) *Registry {
+	return &Registry{store: store, reservationPeriod: defaultReservationPeriod}
+}
+
+// Registry keeps the records of the credentials issued by the profiles, the records are indexed
+// per profile by the credential subject and type. Each index keeps the count of its entries and an entry
+// per record, so indexing the record and reading the page of the index don't depend on the index size.
+// The records and the ID reservations are updated under the mutex of the registry, the store has no conditional
+// updates so the registry must not be shared by the instances of the service.
+type Registry struct {
+	store             storage.Store
+	mutex             sync.Mutex
+	reservationPeriod time.Duration
+}
+
+// Record struct for the issued credential entry
+type Record struct {
+	ID       string     `json:"id"`
+	Profile  string     `json:"profile"`
+	Issuer   string     `json:"issuer"`
+	Context  []string   `json:"@context"`
+	Types    []string   `json:"types"`
+	Subjects []string   `json:"subjects,omitempty"`
+	Status   *Status    `json:"status,omitempty"`
+	Issued   *time.Time `json:"issued"`
+	// Hash is the hex encoded SHA-256 hash of the issued credential
+	Hash string `json:"hash"`
+	// Replaces is the ID of the credential replaced by the reissued credential
+	Replaces string `json:"replaces,omitempty"`
+	// ReplacedBy is the ID of the credential reissued to replace the credential
+	ReplacedBy string `json:"replacedBy,omitempty"`
+}
+
+// Status of the issued credential in the status list
+type Status struct {
+	ListID string `json:"listID"`
+	Index  int    `json:"index"`
+}
+
+// Query filters the records of the profile, empty Subject and Type match any subject and type.
+// The query scans Limit index entries (DefaultLimit if not set) in the order of issuance starting from Offset.
+type Query struct {
+	Profile string
+	Subject string
+	Type    string
+	Offset  int
+	Limit   int
+}
+
+// SaveRecords saves (or replaces) the records and indexes them
+func (r *Registry) SaveRecords(records ...*Record) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	indices := make(map[string][]string)
+
+	for _, record := range records {
+		indexKeys, err := r.newIndexKeys(record)
+		if err != nil {
+			return err
+		}
+
+		bytes, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("save issued credential marshalling error: %s", err.Error())
+		}
+
+		if err := r.store.Put(getDBKey(record.Profile, record.ID), bytes); err != nil {
+			return err
+		}
+
+		for _, key := range indexKeys {
+			indices[key] = append(indices[key], record.ID)
+		}
+	}
+
+	for key, ids := range indices {
+		if err := r.addToIndex(key, ids); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReserveID reserves the ID of the credential the profile is about to issue, the ID must be neither recorded
+// nor reserved by the profile. The reservation lapses if the credential isn't recorded in the reservation period
+// (e.g. its signing failed) so the ID can be reserved again.
+func (r *Registry) ReserveID(profile, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, err := r.GetProfileRecord(profile, id)
+	if err == nil {
+		return fmt.Errorf("%w: %s has already been issued", ErrDuplicateID, id)
+	}
+
+	if !errors.Is(err, storage.ErrValueNotFound) {
+		return fmt.Errorf("failed to check credential ID: %w", err)
+	}
+
+	key := getReservationKey(profile, id)
+
+	reservedUntil, err := r.store.Get(key)
+	if err != nil && !errors.Is(err, storage.ErrValueNotFound) {
+		return fmt.Errorf("failed to check credential ID: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	if err == nil {
+		until, parseErr := time.Parse(time.RFC3339Nano, string(reservedUntil))
+		if parseErr == nil && now.Before(until) {
+			return fmt.Errorf("%w: %s is being issued", ErrDuplicateID, id)
+		}
+	}
+
+	if err := r.store.Put(key, []byte(now.Add(r.reservationPeriod).Format(time.RFC3339Nano))); err != nil {
+		return fmt.Errorf("failed to reserve credential ID: %w", err)
+	}
+
+	return nil
+}
+
+// GetProfileRecord returns the record of the credential issued by the profile from underlying store,
+// the record of the credential issued by another profile is not found
+func (r *Registry) GetProfileRecord(profile, id string) (*Record, error) {
+	bytes, err := r.store.Get(getDBKey(profile, id))
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{}
+
+	if err := json.Unmarshal(bytes, record); err != nil {
+		return nil, err
+	}
+
+	if record.Profile != profile || record.ID != id {
+		return nil, fmt.Errorf("%w: credential %s isn't issued by profile %s", storage.ErrValueNotFound, id, profile)
+	}
+
+	return record, nil
+}
+
+// QueryRecords returns the records of the profile matching the query and the offset of the next page of the query,
+// the next offset is 0 if there are no more records
+func (r *Registry) QueryRecords(query *Query) ([]*Record, int, error) {
+	key := getIndexKey(query.Profile, allIndex, "")
+
+	switch {
+	case query.Subject != "":
+		key = getIndexKey(query.Profile, subjectIndex, query.Subject)
+	case query.Type != "":
+		key = getIndexKey(query.Profile, typeIndex, query.Type)
+	}
+
+	count, err := r.getCount(key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	end := query.Offset + limit
+	if end > count {
+		end = count
+	}
+
+	var records []*Record
+
+	for i := query.Offset; i < end; i++ {
+		id, err := r.store.Get(getEntryKey(key, i))
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get issued credentials index entry: %w", err)
+		}
+
+		record, err := r.GetProfileRecord(query.Profile, string(id))
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get issued credential %s: %w", id, err)
+		}
+
+		if matches(record, query) {
+			records = append(records, record)
+		}
+	}
+
+	if end < count {
+		return records, end, nil
+	}
+
+	return records, 0, nil
+}
+
+func matches(record *Record, query *Query) bool {
+	if query.Subject != "" && !contains(record.Subjects, query.Subject) {
+		return false
+	}
+
+	return query.Type == "" || contains(record.Types, query.Type)
+}
+
+// newIndexKeys returns the keys of the indices the record isn't indexed by yet, the replaced record
+// has been indexed by its subjects and types already
+func (r *Registry) newIndexKeys(record *Record) ([]string, error) {
+	saved, err := r.GetProfileRecord(record.Profile, record.ID)
+	if err != nil {
+		if errors.Is(err, storage.ErrValueNotFound) {
+			return getIndexKeys(record), nil
+		}
+
+		return nil, fmt.Errorf("failed to get issued credential %s: %w", record.ID, err)
+	}
+
+	indexed := make(map[string]bool)
+	for _, key := range getIndexKeys(saved) {
+		indexed[key] = true
+	}
+
+	var keys []string
+
+	for _, key := range getIndexKeys(record) {
+		if !indexed[key] {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (r *Registry) addToIndex(key string, ids []string) error {
+	count, err := r.getCount(key)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := r.store.Put(getEntryKey(key, count), []byte(id)); err != nil {
+			return err
+		}
+
+		count++
+	}
+
+	return r.store.Put(getCountKey(key), []byte(strconv.Itoa(count)))
+}
+
+func (r *Registry) getCount(key string) (int, error) {
+	bytes, err := r.store.Get(getCountKey(key))
+	if err != nil {
+		if errors.Is(err, storage.ErrValueNotFound) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("failed to get issued credentials index: %w", err)
+	}
+
+	count, err := strconv.Atoi(string(bytes))
+	if err != nil {
+		return 0, fmt.Errorf("invalid issued credentials index count: %w", err)
+	}
+
+	return count, nil
+}
+
+func getIndexKeys(record *Record) []string {
+	keys := []string{getIndexKey(record.Profile, allIndex, "")}
+
+	for _, subject := range record.Subjects {
+		keys = append(keys, getIndexKey(record.Profile, subjectIndex, subject))
+	}
+
+	for _, t := range record.Types {
+		keys = append(keys, getIndexKey(record.Profile, typeIndex, t))
+	}
+
+	return keys
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getDBKey(profile, id string) string {
+	return fmt.Sprintf(keyPattern, keyPrefix, getProfileKey(profile), id)
+}
+
+// getIndexKey returns the key identifying the index, the count and the entries of the index are stored
+// under the keys derived from it
+func getIndexKey(profile, index, value string) string {
+	return fmt.Sprintf(indexKeyPattern, getProfileKey(profile), index, value)
+}
+
+func getReservationKey(profile, id string) string {
+	return fmt.Sprintf(keyPattern, reservedPrefix, getProfileKey(profile), id)
+}
+
+func getProfileKey(profile string) string {
+	return fmt.Sprintf(profileKeyPattern, len(profile), profile)
+}
+
+func getCountKey(key string) string {
+	return fmt.Sprintf(countKeyPattern, countKeyPrefix, key)
+}
+
+func getEntryKey(key string, i int) string {
+	return fmt.Sprintf(entryKeyPattern, entryKeyPrefix, key, i)
+}
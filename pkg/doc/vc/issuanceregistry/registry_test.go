@@ -0,0 +1,365 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuanceregistry
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage"
+	mockstorage "github.com/trustbloc/edge-core/pkg/storage/mockstore"
+)
+
+func newRecord(id, profile, subject string, types ...string) *Record {
+	issued := time.Now().UTC()
+
+	return &Record{
+		ID:       id,
+		Profile:  profile,
+		Issuer:   "did:example:76e12ec712ebc6f1c221ebfeb1f",
+		Context:  []string{"https://www.w3.org/2018/credentials/v1"},
+		Types:    append([]string{"VerifiableCredential"}, types...),
+		Subjects: []string{subject},
+		Status:   &Status{ListID: "https://example.com/status/1", Index: 1},
+		Issued:   &issued,
+		Hash:     "hash",
+	}
+}
+
+func TestRegistry_SaveRecords(t *testing.T) {
+	t.Run("test save and get record success", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: make(map[string][]byte)}
+		registry := New(store)
+		require.NotNil(t, registry)
+
+		value := newRecord("http://example.edu/credentials/1", "issuer", "did:example:1")
+
+		require.NoError(t, registry.SaveRecords(value))
+
+		record, err := registry.GetProfileRecord("issuer", value.ID)
+		require.NoError(t, err)
+		require.Equal(t, value.ID, record.ID)
+		require.Equal(t, value.Subjects, record.Subjects)
+		require.Equal(t, value.Status, record.Status)
+		require.Equal(t, value.Hash, record.Hash)
+
+		_, err = registry.GetProfileRecord("other", value.ID)
+		require.True(t, errors.Is(err, storage.ErrValueNotFound))
+	})
+
+	t.Run("test save replaced record", func(t *testing.T) {
+		registry := New(&mockstorage.MockStore{Store: make(map[string][]byte)})
+
+		replaced := newRecord("http://example.edu/credentials/1", "issuer", "did:example:1")
+		require.NoError(t, registry.SaveRecords(replaced))
+
+		replacement := newRecord("http://example.edu/credentials/2", "issuer", "did:example:1")
+		replacement.Replaces = replaced.ID
+		replaced.ReplacedBy = replacement.ID
+
+		require.NoError(t, registry.SaveRecords(replaced, replacement))
+
+		record, err := registry.GetProfileRecord("issuer", replaced.ID)
+		require.NoError(t, err)
+		require.Equal(t, replacement.ID, record.ReplacedBy)
+
+		record, err = registry.GetProfileRecord("issuer", replacement.ID)
+		require.NoError(t, err)
+		require.Equal(t, replaced.ID, record.Replaces)
+
+		records, next, err := registry.QueryRecords(&Query{Profile: "issuer"})
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Zero(t, next)
+	})
+
+	t.Run("test get record not found", func(t *testing.T) {
+		record, err := New(&mockstorage.MockStore{Store: make(map[string][]byte)}).GetProfileRecord("issuer", "id")
+		require.True(t, errors.Is(err, storage.ErrValueNotFound))
+		require.Nil(t, record)
+	})
+
+	t.Run("test save record error", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: make(map[string][]byte), ErrPut: errors.New("put error")}
+
+		err := New(store).SaveRecords(newRecord("id", "issuer", "did:example:1"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "put error")
+	})
+
+	t.Run("test get record unmarshal error", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: make(map[string][]byte)}
+		store.Store[getDBKey("issuer", "id")] = []byte("invalid")
+
+		record, err := New(store).GetProfileRecord("issuer", "id")
+		require.Error(t, err)
+		require.Nil(t, record)
+
+		err = New(store).SaveRecords(newRecord("id", "issuer", "did:example:1"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get issued credential id")
+	})
+}
+
+func TestRegistry_ProfileKeys(t *testing.T) {
+	t.Run("test profiles with colliding names and IDs", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: make(map[string][]byte)}
+		registry := New(store)
+
+		// profile "a" with ID "b_c" and profile "a_b" with ID "c" are joined to the same string
+		require.NoError(t, registry.ReserveID("a", "b_c"))
+		require.NoError(t, registry.ReserveID("a_b", "c"))
+
+		require.NoError(t, registry.SaveRecords(newRecord("b_c", "a", "did:example:1", "TypeA"),
+			newRecord("c", "a_b", "did:example:2", "TypeB")))
+
+		record, err := registry.GetProfileRecord("a", "b_c")
+		require.NoError(t, err)
+		require.Equal(t, "a", record.Profile)
+		require.Equal(t, "did:example:1", record.Subjects[0])
+
+		record, err = registry.GetProfileRecord("a_b", "c")
+		require.NoError(t, err)
+		require.Equal(t, "a_b", record.Profile)
+		require.Equal(t, "did:example:2", record.Subjects[0])
+
+		records, _, err := registry.QueryRecords(&Query{Profile: "a"})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		require.Equal(t, "b_c", records[0].ID)
+
+		records, _, err = registry.QueryRecords(&Query{Profile: "a", Type: "TypeB"})
+		require.NoError(t, err)
+		require.Empty(t, records)
+
+		_, err = registry.GetProfileRecord("a", "c")
+		require.True(t, errors.Is(err, storage.ErrValueNotFound))
+	})
+
+	t.Run("test record of another profile is not found", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: make(map[string][]byte)}
+		registry := New(store)
+
+		require.NoError(t, registry.SaveRecords(newRecord("id", "other", "did:example:1")))
+
+		// the record stored under the key of the profile belongs to another profile
+		store.Store[getDBKey("issuer", "id")] = store.Store[getDBKey("other", "id")]
+
+		record, err := registry.GetProfileRecord("issuer", "id")
+		require.True(t, errors.Is(err, storage.ErrValueNotFound))
+		require.Contains(t, err.Error(), "credential id isn't issued by profile issuer")
+		require.Nil(t, record)
+
+		require.NoError(t, registry.ReserveID("issuer", "id"))
+	})
+}
+
+func TestRegistry_QueryRecords(t *testing.T) {
+	t.Run("test query records by subject and type", func(t *testing.T) {
+		registry := New(&mockstorage.MockStore{Store: make(map[string][]byte)})
+
+		require.NoError(t, registry.SaveRecords(
+			newRecord("1", "issuer", "did:example:1", "UniversityDegreeCredential"),
+			newRecord("2", "issuer", "did:example:2", "UniversityDegreeCredential"),
+		))
+		require.NoError(t, registry.SaveRecords(
+			newRecord("3", "issuer", "did:example:1", "PermanentResidentCard"),
+			newRecord("4", "other", "did:example:1", "UniversityDegreeCredential"),
+		))
+
+		ids := func(query *Query) []string {
+			records, next, err := registry.QueryRecords(query)
+			require.NoError(t, err)
+			require.Zero(t, next)
+
+			var ids []string
+			for _, r := range records {
+				ids = append(ids, r.ID)
+			}
+
+			return ids
+		}
+
+		require.Equal(t, []string{"1", "2", "3"}, ids(&Query{Profile: "issuer"}))
+		require.Equal(t, []string{"1", "3"}, ids(&Query{Profile: "issuer", Subject: "did:example:1"}))
+		require.Equal(t, []string{"1", "2"}, ids(&Query{Profile: "issuer", Type: "UniversityDegreeCredential"}))
+		require.Equal(t, []string{"1"}, ids(&Query{Profile: "issuer", Subject: "did:example:1",
+			Type: "UniversityDegreeCredential"}))
+		require.Equal(t, []string{"4"}, ids(&Query{Profile: "other"}))
+		require.Empty(t, ids(&Query{Profile: "unknown"}))
+
+		// credential with the same ID issued by another profile is recorded for each profile
+		require.NoError(t, registry.SaveRecords(newRecord("1", "other", "did:example:1")))
+		require.Equal(t, []string{"1", "2", "3"}, ids(&Query{Profile: "issuer"}))
+		require.Equal(t, []string{"4", "1"}, ids(&Query{Profile: "other"}))
+
+		record, err := registry.GetProfileRecord("issuer", "1")
+		require.NoError(t, err)
+		require.Equal(t, "issuer", record.Profile)
+
+		// replaced record is indexed once, by its new types only
+		require.NoError(t, registry.SaveRecords(newRecord("1", "other", "did:example:1", "PermanentResidentCard")))
+		require.Equal(t, []string{"4", "1"}, ids(&Query{Profile: "other"}))
+		require.Equal(t, []string{"1"}, ids(&Query{Profile: "other", Type: "PermanentResidentCard"}))
+	})
+
+	t.Run("test query records pages", func(t *testing.T) {
+		registry := New(&mockstorage.MockStore{Store: make(map[string][]byte)})
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, registry.SaveRecords(newRecord(strconv.Itoa(i), "issuer",
+				"did:example:"+strconv.Itoa(i%2))))
+		}
+
+		records, next, err := registry.QueryRecords(&Query{Profile: "issuer", Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Equal(t, "0", records[0].ID)
+		require.Equal(t, 2, next)
+
+		records, next, err = registry.QueryRecords(&Query{Profile: "issuer", Offset: next, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Equal(t, "2", records[0].ID)
+		require.Equal(t, 4, next)
+
+		records, next, err = registry.QueryRecords(&Query{Profile: "issuer", Offset: next, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		require.Equal(t, "4", records[0].ID)
+		require.Zero(t, next)
+
+		records, next, err = registry.QueryRecords(&Query{Profile: "issuer", Subject: "did:example:1", Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		require.Equal(t, "1", records[0].ID)
+		require.Equal(t, 1, next)
+
+		records, next, err = registry.QueryRecords(&Query{Profile: "issuer", Offset: 10})
+		require.NoError(t, err)
+		require.Empty(t, records)
+		require.Zero(t, next)
+	})
+
+	t.Run("test query records store errors", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: make(map[string][]byte)}
+		registry := New(store)
+
+		countKey := getCountKey(getIndexKey("issuer", allIndex, ""))
+		store.Store[countKey] = []byte("invalid")
+
+		records, _, err := registry.QueryRecords(&Query{Profile: "issuer"})
+		require.Error(t, err)
+		require.Nil(t, records)
+		require.Contains(t, err.Error(), "invalid issued credentials index count")
+
+		err = registry.SaveRecords(newRecord("1", "issuer", "did:example:1"))
+		require.Error(t, err)
+
+		store.Store[countKey] = []byte("1")
+
+		records, _, err = registry.QueryRecords(&Query{Profile: "issuer"})
+		require.Error(t, err)
+		require.Nil(t, records)
+		require.Contains(t, err.Error(), "failed to get issued credentials index entry")
+
+		store.Store[getEntryKey(getIndexKey("issuer", allIndex, ""), 0)] = []byte("missing")
+
+		records, _, err = registry.QueryRecords(&Query{Profile: "issuer"})
+		require.Error(t, err)
+		require.Nil(t, records)
+		require.Contains(t, err.Error(), "failed to get issued credential missing")
+
+		store.ErrGet = errors.New("get error")
+
+		records, _, err = registry.QueryRecords(&Query{Profile: "issuer"})
+		require.Error(t, err)
+		require.Nil(t, records)
+		require.Contains(t, err.Error(), "failed to get issued credentials index: get error")
+	})
+}
+
+func TestRegistry_ReserveID(t *testing.T) {
+	t.Run("test concurrent reservations of the ID", func(t *testing.T) {
+		registry := New(&mockstorage.MockStore{Store: make(map[string][]byte)})
+
+		var (
+			wg       sync.WaitGroup
+			reserved int32
+		)
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				err := registry.ReserveID("issuer", "1")
+				if err == nil {
+					atomic.AddInt32(&reserved, 1)
+
+					return
+				}
+
+				require.True(t, errors.Is(err, ErrDuplicateID))
+				require.Contains(t, err.Error(), "1 is being issued")
+			}()
+		}
+
+		wg.Wait()
+		require.Equal(t, int32(1), reserved)
+
+		// the ID is reserved per profile
+		require.NoError(t, registry.ReserveID("other", "1"))
+	})
+
+	t.Run("test issued ID", func(t *testing.T) {
+		registry := New(&mockstorage.MockStore{Store: make(map[string][]byte)})
+
+		require.NoError(t, registry.ReserveID("issuer", "1"))
+		require.NoError(t, registry.SaveRecords(newRecord("1", "issuer", "did:example:1")))
+
+		registry.reservationPeriod = 0
+
+		err := registry.ReserveID("issuer", "1")
+		require.True(t, errors.Is(err, ErrDuplicateID))
+		require.Contains(t, err.Error(), "1 has already been issued")
+	})
+
+	t.Run("test reservation lapses", func(t *testing.T) {
+		registry := New(&mockstorage.MockStore{Store: make(map[string][]byte)})
+		registry.reservationPeriod = 0
+
+		require.NoError(t, registry.ReserveID("issuer", "1"))
+		require.NoError(t, registry.ReserveID("issuer", "1"))
+	})
+
+	t.Run("test store errors", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: make(map[string][]byte), ErrPut: errors.New("put error")}
+
+		err := New(store).ReserveID("issuer", "1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to reserve credential ID: put error")
+
+		store.ErrGet = errors.New("get error")
+		store.Store[getReservationKey("issuer", "1")] = []byte("reserved")
+
+		err = New(store).ReserveID("issuer", "1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to check credential ID: get error")
+
+		store.Store[getDBKey("issuer", "1")] = []byte("{}")
+
+		err = New(store).ReserveID("issuer", "1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to check credential ID: get error")
+	})
+}
@@ -0,0 +1,216 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuerregistry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/edge-core/pkg/storage"
+)
+
+const (
+	keyPattern = "%s_%s"
+	keyPrefix  = "trustedissuer"
+	// index of the DIDs of the trusted issuers, the registry is managed by the verifier admin so it stays small
+	indexKey = "trustedissuerindex"
+	// the store doesn't support deletion, the removed entry is overwritten with the empty one
+	removedEntry = "{}"
+)
+
+// New returns new trusted issuer registry instance
+func New(store storage.Store) *Registry {
+	return &Registry{store: store}
+}
+
+// Registry keeps the issuers trusted by the verifier
+type Registry struct {
+	store storage.Store
+	// serializes the index updates of this instance
+	mutex sync.Mutex
+}
+
+// TrustedIssuer struct for trusted issuer entry
+type TrustedIssuer struct {
+	DID             string     `json:"did"`
+	CredentialTypes []string   `json:"credentialTypes,omitempty"`
+	ValidFrom       *time.Time `json:"validFrom,omitempty"`
+	ValidUntil      *time.Time `json:"validUntil,omitempty"`
+	Created         *time.Time `json:"created"`
+}
+
+// SaveIssuer saves (or replaces) trusted issuer entry in the underlying store
+func (r *Registry) SaveIssuer(data *TrustedIssuer) error {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("save trusted issuer marshalling error: %s", err.Error())
+	}
+
+	if err := r.store.Put(getDBKey(data.DID), bytes); err != nil {
+		return err
+	}
+
+	return r.updateIndex(func(dids []string) []string {
+		for _, did := range dids {
+			if did == data.DID {
+				return dids
+			}
+		}
+
+		return append(dids, data.DID)
+	})
+}
+
+// DeleteIssuer removes trusted issuer entry, storage.ErrValueNotFound is returned if the issuer isn't registered
+func (r *Registry) DeleteIssuer(did string) error {
+	if _, err := r.GetIssuer(did); err != nil {
+		return err
+	}
+
+	if err := r.store.Put(getDBKey(did), []byte(removedEntry)); err != nil {
+		return err
+	}
+
+	return r.updateIndex(func(dids []string) []string {
+		var result []string
+
+		for _, d := range dids {
+			if d != did {
+				result = append(result, d)
+			}
+		}
+
+		return result
+	})
+}
+
+// ListIssuers returns trusted issuer entries ordered by DID
+func (r *Registry) ListIssuers() ([]*TrustedIssuer, error) {
+	dids, err := r.getIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	issuers := make([]*TrustedIssuer, 0, len(dids))
+
+	for _, did := range dids {
+		issuer, getErr := r.GetIssuer(did)
+		if getErr != nil {
+			if errors.Is(getErr, storage.ErrValueNotFound) {
+				continue
+			}
+
+			return nil, getErr
+		}
+
+		issuers = append(issuers, issuer)
+	}
+
+	return issuers, nil
+}
+
+// GetIssuer returns trusted issuer entry for the given DID from underlying store
+func (r *Registry) GetIssuer(did string) (*TrustedIssuer, error) {
+	bytes, err := r.store.Get(getDBKey(did))
+	if err != nil {
+		return nil, err
+	}
+
+	response := &TrustedIssuer{}
+
+	err = json.Unmarshal(bytes, response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.DID == "" {
+		return nil, storage.ErrValueNotFound
+	}
+
+	return response, nil
+}
+
+// CheckIssuer checks that the issuer is trusted at the given time for at least one of the credential types.
+// Issuer registered without credential types is trusted for any type.
+func (r *Registry) CheckIssuer(did string, credentialTypes []string, at time.Time) error {
+	issuer, err := r.GetIssuer(did)
+	if err != nil {
+		if errors.Is(err, storage.ErrValueNotFound) {
+			return fmt.Errorf("issuer %s is not trusted", did)
+		}
+
+		return fmt.Errorf("failed to get trusted issuer: %w", err)
+	}
+
+	if issuer.ValidFrom != nil && at.Before(*issuer.ValidFrom) {
+		return fmt.Errorf("issuer %s is not trusted before %s", did, issuer.ValidFrom.Format(time.RFC3339))
+	}
+
+	if issuer.ValidUntil != nil && at.After(*issuer.ValidUntil) {
+		return fmt.Errorf("issuer %s is not trusted after %s", did, issuer.ValidUntil.Format(time.RFC3339))
+	}
+
+	if len(issuer.CredentialTypes) == 0 {
+		return nil
+	}
+
+	for _, t := range credentialTypes {
+		for _, allowed := range issuer.CredentialTypes {
+			if t == allowed {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("issuer %s is not trusted for credential types %v", did, credentialTypes)
+}
+
+func (r *Registry) updateIndex(update func(dids []string) []string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	dids, err := r.getIndex()
+	if err != nil {
+		return err
+	}
+
+	dids = update(dids)
+	sort.Strings(dids)
+
+	bytes, err := json.Marshal(dids)
+	if err != nil {
+		return fmt.Errorf("trusted issuer index marshalling error: %w", err)
+	}
+
+	return r.store.Put(indexKey, bytes)
+}
+
+func (r *Registry) getIndex() ([]string, error) {
+	bytes, err := r.store.Get(indexKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrValueNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get trusted issuer index: %w", err)
+	}
+
+	var dids []string
+
+	if err = json.Unmarshal(bytes, &dids); err != nil {
+		return nil, fmt.Errorf("invalid trusted issuer index: %w", err)
+	}
+
+	return dids, nil
+}
+
+func getDBKey(did string) string {
+	return fmt.Sprintf(keyPattern, keyPrefix, did)
+}
@@ -0,0 +1,190 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package issuerregistry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage"
+	mockstorage "github.com/trustbloc/edge-core/pkg/storage/mockstore"
+)
+
+const issuerDID = "did:example:76e12ec712ebc6f1c221ebfeb1f"
+
+func TestRegistry_SaveIssuer(t *testing.T) {
+	t.Run("test save and get issuer success", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: make(map[string][]byte)}
+		registry := New(store)
+		require.NotNil(t, registry)
+
+		created := time.Now().UTC()
+
+		value := &TrustedIssuer{
+			DID:             issuerDID,
+			CredentialTypes: []string{"UniversityDegreeCredential"},
+			Created:         &created,
+		}
+
+		require.NoError(t, registry.SaveIssuer(value))
+
+		v, err := store.Get(getDBKey(issuerDID))
+		require.NoError(t, err)
+		require.NotEmpty(t, v)
+
+		issuer, err := registry.GetIssuer(issuerDID)
+		require.NoError(t, err)
+		require.Equal(t, value.CredentialTypes, issuer.CredentialTypes)
+		require.True(t, created.Equal(*issuer.Created))
+	})
+
+	t.Run("test get issuer - not found", func(t *testing.T) {
+		registry := New(&mockstorage.MockStore{Store: make(map[string][]byte)})
+
+		issuer, err := registry.GetIssuer(issuerDID)
+		require.Error(t, err)
+		require.Nil(t, issuer)
+	})
+
+	t.Run("test get issuer - invalid data", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: make(map[string][]byte)}
+		store.Store[getDBKey(issuerDID)] = []byte("invalid")
+
+		issuer, err := New(store).GetIssuer(issuerDID)
+		require.Error(t, err)
+		require.Nil(t, issuer)
+	})
+}
+
+func TestRegistry_CheckIssuer(t *testing.T) {
+	now := time.Now()
+	validFrom := now.Add(-time.Hour)
+	validUntil := now.Add(time.Hour)
+
+	store := &mockstorage.MockStore{Store: make(map[string][]byte)}
+	registry := New(store)
+
+	require.NoError(t, registry.SaveIssuer(&TrustedIssuer{
+		DID:             issuerDID,
+		CredentialTypes: []string{"UniversityDegreeCredential"},
+		ValidFrom:       &validFrom,
+		ValidUntil:      &validUntil,
+	}))
+
+	require.NoError(t, registry.SaveIssuer(&TrustedIssuer{DID: "did:example:any"}))
+
+	t.Run("test success", func(t *testing.T) {
+		require.NoError(t, registry.CheckIssuer(issuerDID,
+			[]string{"VerifiableCredential", "UniversityDegreeCredential"}, now))
+		require.NoError(t, registry.CheckIssuer("did:example:any", []string{"VerifiableCredential"}, now))
+	})
+
+	t.Run("test error - issuer not registered", func(t *testing.T) {
+		err := registry.CheckIssuer("did:example:unknown", []string{"VerifiableCredential"}, now)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "issuer did:example:unknown is not trusted")
+	})
+
+	t.Run("test error - credential type not allowed", func(t *testing.T) {
+		err := registry.CheckIssuer(issuerDID, []string{"VerifiableCredential", "PermanentResidentCard"}, now)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not trusted for credential types")
+	})
+
+	t.Run("test error - outside of validity window", func(t *testing.T) {
+		err := registry.CheckIssuer(issuerDID, []string{"UniversityDegreeCredential"}, now.Add(-2*time.Hour))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not trusted before")
+
+		err = registry.CheckIssuer(issuerDID, []string{"UniversityDegreeCredential"}, now.Add(2*time.Hour))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not trusted after")
+	})
+
+	t.Run("test error - store failure", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: make(map[string][]byte), ErrGet: errors.New("get error")}
+		store.Store[getDBKey(issuerDID)] = []byte("{}")
+
+		err := New(store).CheckIssuer(issuerDID, nil, now)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get trusted issuer: get error")
+	})
+}
+
+func TestRegistry_ListAndDeleteIssuers(t *testing.T) {
+	t.Run("test list and delete success", func(t *testing.T) {
+		registry := New(&mockstorage.MockStore{Store: make(map[string][]byte)})
+
+		issuers, err := registry.ListIssuers()
+		require.NoError(t, err)
+		require.Empty(t, issuers)
+
+		require.NoError(t, registry.SaveIssuer(&TrustedIssuer{DID: "did:example:b"}))
+		require.NoError(t, registry.SaveIssuer(&TrustedIssuer{DID: "did:example:a"}))
+		require.NoError(t, registry.SaveIssuer(&TrustedIssuer{DID: "did:example:b",
+			CredentialTypes: []string{"UniversityDegreeCredential"}}))
+
+		issuers, err = registry.ListIssuers()
+		require.NoError(t, err)
+		require.Len(t, issuers, 2)
+		require.Equal(t, "did:example:a", issuers[0].DID)
+		require.Equal(t, "did:example:b", issuers[1].DID)
+		require.Equal(t, []string{"UniversityDegreeCredential"}, issuers[1].CredentialTypes)
+
+		require.NoError(t, registry.DeleteIssuer("did:example:a"))
+
+		_, err = registry.GetIssuer("did:example:a")
+		require.True(t, errors.Is(err, storage.ErrValueNotFound))
+
+		err = registry.DeleteIssuer("did:example:a")
+		require.True(t, errors.Is(err, storage.ErrValueNotFound))
+
+		err = registry.CheckIssuer("did:example:a", nil, time.Now())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "issuer did:example:a is not trusted")
+
+		issuers, err = registry.ListIssuers()
+		require.NoError(t, err)
+		require.Len(t, issuers, 1)
+		require.Equal(t, "did:example:b", issuers[0].DID)
+
+		// the issuer can be registered again
+		require.NoError(t, registry.SaveIssuer(&TrustedIssuer{DID: "did:example:a"}))
+
+		issuers, err = registry.ListIssuers()
+		require.NoError(t, err)
+		require.Len(t, issuers, 2)
+	})
+
+	t.Run("test store errors", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: make(map[string][]byte)}
+		store.Store[indexKey] = []byte("invalid")
+
+		_, err := New(store).ListIssuers()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid trusted issuer index")
+
+		err = New(store).SaveIssuer(&TrustedIssuer{DID: issuerDID})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid trusted issuer index")
+
+		store = &mockstorage.MockStore{Store: make(map[string][]byte), ErrPut: errors.New("put error")}
+		store.Store[getDBKey(issuerDID)] = []byte(`{"did":"` + issuerDID + `"}`)
+
+		err = New(store).DeleteIssuer(issuerDID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "put error")
+
+		store = &mockstorage.MockStore{Store: make(map[string][]byte), ErrGet: errors.New("get error")}
+		store.Store[indexKey] = []byte(`["` + issuerDID + `"]`)
+
+		_, err = New(store).ListIssuers()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get error")
+	})
+}
@@ -0,0 +1,163 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oidc4vci
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+const (
+	// IssuerMetadataPath is the well-known path of the credential issuer metadata, the path of the credential
+	// issuer identifier is appended to it
+	IssuerMetadataPath = "/.well-known/openid-credential-issuer"
+	// AuthorizationServerMetadataPath is the well-known path of the authorization server metadata
+	AuthorizationServerMetadataPath = "/.well-known/oauth-authorization-server"
+	// OfferURIScheme is the scheme of the credential offers passed to the wallets
+	OfferURIScheme = "openid-credential-offer://"
+
+	// PreAuthorizedCodeGrantType is the grant type of the pre-authorized code flow
+	PreAuthorizedCodeGrantType = "urn:ietf:params:oauth:grant-type:pre-authorized_code"
+	// BearerTokenType is the type of the access tokens
+	BearerTokenType = "Bearer"
+
+	// LDPVCFormat is the format of the credentials secured with the linked data proofs
+	LDPVCFormat = "ldp_vc"
+	// JWTProofType is the type of the proof of possession of the key the credential is bound to
+	JWTProofType = "jwt"
+	// ProofJWTType is the typ header of the proof JWT
+	ProofJWTType = "openid4vci-proof+jwt"
+	// DIDBindingMethod binds the credentials to the DIDs of the holders
+	DIDBindingMethod = "did"
+)
+
+// OAuth and OpenID4VCI error codes.
+const (
+	ErrorInvalidRequest                 = "invalid_request"
+	ErrorInvalidGrant                   = "invalid_grant"
+	ErrorUnsupportedGrantType           = "unsupported_grant_type"
+	ErrorInvalidToken                   = "invalid_token"
+	ErrorInvalidProof                   = "invalid_proof"
+	ErrorInvalidNonce                   = "invalid_nonce"
+	ErrorUnsupportedCredentialFormat    = "unsupported_credential_format"
+	ErrorUnknownCredentialConfiguration = "unknown_credential_configuration"
+)
+
+var (
+	// ErrInvalidGrant is returned when the pre-authorized code or transaction code isn't valid
+	ErrInvalidGrant = errors.New(ErrorInvalidGrant)
+	// ErrInvalidToken is returned when the access token isn't valid
+	ErrInvalidToken = errors.New(ErrorInvalidToken)
+	// ErrInvalidProof is returned when the proof of possession isn't valid
+	ErrInvalidProof = errors.New(ErrorInvalidProof)
+	// ErrInvalidNonce is returned when the proof of possession doesn't have the current nonce
+	ErrInvalidNonce = errors.New(ErrorInvalidNonce)
+)
+
+// IssuerMetadata is the credential issuer metadata.
+type IssuerMetadata struct {
+	CredentialIssuer   string `json:"credential_issuer"`
+	CredentialEndpoint string `json:"credential_endpoint"`
+	// TokenEndpoint is kept for the wallets which don't read the authorization server metadata
+	TokenEndpoint            string                              `json:"token_endpoint,omitempty"`
+	Display                  []*Display                          `json:"display,omitempty"`
+	CredentialConfigurations map[string]*CredentialConfiguration `json:"credential_configurations_supported"`
+}
+
+// AuthorizationServerMetadata is the metadata of the authorization server issuing the access tokens
+// of the credential issuer.
+type AuthorizationServerMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	GrantTypes                        []string `json:"grant_types_supported"`
+	PreAuthorizedGrantAnonymousAccess bool     `json:"pre-authorized_grant_anonymous_access_supported"`
+}
+
+// Display is the display properties of the credential issuer or credential.
+type Display struct {
+	Name string `json:"name"`
+}
+
+// CredentialConfiguration describes the credential the issuer issues.
+type CredentialConfiguration struct {
+	Format                      string                `json:"format"`
+	CryptographicBindingMethods []string              `json:"cryptographic_binding_methods_supported,omitempty"`
+	CredentialSigningAlgs       []string              `json:"credential_signing_alg_values_supported,omitempty"`
+	ProofTypes                  map[string]*ProofType `json:"proof_types_supported,omitempty"`
+	CredentialDefinition        *CredentialDefinition `json:"credential_definition"`
+	Display                     []*Display            `json:"display,omitempty"`
+}
+
+// ProofType is the proof of possession type supported by the issuer.
+type ProofType struct {
+	SigningAlgs []string `json:"proof_signing_alg_values_supported"`
+}
+
+// CredentialDefinition is the contexts and types of the credential.
+type CredentialDefinition struct {
+	Context []string `json:"@context,omitempty"`
+	Types   []string `json:"type"`
+}
+
+// CredentialOffer is passed to the wallet to start the issuance.
+type CredentialOffer struct {
+	CredentialIssuer           string                             `json:"credential_issuer"`
+	CredentialConfigurationIDs []string                           `json:"credential_configuration_ids"`
+	Grants                     map[string]*PreAuthorizedCodeGrant `json:"grants"`
+}
+
+// PreAuthorizedCodeGrant is the grant of the credential offer redeemed at the token endpoint.
+type PreAuthorizedCodeGrant struct {
+	PreAuthorizedCode string  `json:"pre-authorized_code"`
+	TxCode            *TxCode `json:"tx_code,omitempty"`
+}
+
+// TxCode describes the transaction code the holder enters when redeeming the pre-authorized code,
+// the code is passed to the holder out of band.
+type TxCode struct {
+	InputMode   string `json:"input_mode,omitempty"`
+	Length      int    `json:"length,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// TokenResponse is the access token response of the token endpoint.
+type TokenResponse struct {
+	AccessToken     string `json:"access_token"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int    `json:"expires_in"`
+	CNonce          string `json:"c_nonce"`
+	CNonceExpiresIn int    `json:"c_nonce_expires_in"`
+}
+
+// CredentialRequest is the request of the credential endpoint.
+type CredentialRequest struct {
+	Format                    string                `json:"format,omitempty"`
+	CredentialConfigurationID string                `json:"credential_configuration_id,omitempty"`
+	CredentialDefinition      *CredentialDefinition `json:"credential_definition,omitempty"`
+	Proof                     *Proof                `json:"proof,omitempty"`
+}
+
+// Proof is the proof of possession of the key the credential is bound to.
+type Proof struct {
+	ProofType string `json:"proof_type"`
+	JWT       string `json:"jwt,omitempty"`
+}
+
+// CredentialResponse is the response of the credential endpoint.
+type CredentialResponse struct {
+	Format          string          `json:"format,omitempty"`
+	Credential      json.RawMessage `json:"credential"`
+	CNonce          string          `json:"c_nonce,omitempty"`
+	CNonceExpiresIn int             `json:"c_nonce_expires_in,omitempty"`
+}
+
+// ErrorResponse is the OAuth error response, the nonce is returned with the proof errors.
+type ErrorResponse struct {
+	Error           string `json:"error"`
+	Description     string `json:"error_description,omitempty"`
+	CNonce          string `json:"c_nonce,omitempty"`
+	CNonceExpiresIn int    `json:"c_nonce_expires_in,omitempty"`
+}
@@ -0,0 +1,170 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oidc4vci
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+)
+
+const (
+	algEdDSA = "EdDSA"
+	algES256 = "ES256"
+
+	es256SignatureSize = 64
+)
+
+// ProofSigningAlgs returns the signing algorithms of the proof JWTs verified by VerifyProof
+func ProofSigningAlgs() []string {
+	return []string{algEdDSA, algES256}
+}
+
+type proofClaims struct {
+	Audience interface{} `json:"aud"`
+	IssuedAt *int64      `json:"iat"`
+	Nonce    string      `json:"nonce"`
+}
+
+// VerifyProof verifies the proof JWT of the possession of the DID key (the kid header) issued for the credential
+// issuer within the clock skew of now and returns the DID the credential is bound to with the nonce of the proof.
+// The errors are ErrInvalidProof.
+func VerifyProof(proof *Proof, fetcher verifiable.PublicKeyFetcher, audience string, now time.Time,
+	skew time.Duration) (string, string, error) {
+	if proof == nil || proof.ProofType != JWTProofType || proof.JWT == "" {
+		return "", "", fmt.Errorf("%w: %s proof is required", ErrInvalidProof, JWTProofType)
+	}
+
+	// the JWT parser accepts the JWT typ header only
+	jws, err := jose.ParseJWS(proof.JWT, proofVerifier(fetcher))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %s", ErrInvalidProof, err.Error())
+	}
+
+	if typ, _ := jws.ProtectedHeaders[jose.HeaderType].(string); typ != ProofJWTType {
+		return "", "", fmt.Errorf("%w: proof JWT type must be %s", ErrInvalidProof, ProofJWTType)
+	}
+
+	claims := &proofClaims{}
+
+	if err := json.Unmarshal(jws.Payload, claims); err != nil {
+		return "", "", fmt.Errorf("%w: invalid proof claims: %s", ErrInvalidProof, err.Error())
+	}
+
+	if !hasAudience(claims.Audience, audience) {
+		return "", "", fmt.Errorf("%w: proof audience must be %s", ErrInvalidProof, audience)
+	}
+
+	if claims.IssuedAt == nil || time.Unix(*claims.IssuedAt, 0).After(now.Add(skew)) {
+		return "", "", fmt.Errorf("%w: proof must be issued before now", ErrInvalidProof)
+	}
+
+	kid, _ := jws.ProtectedHeaders.KeyID()
+
+	return strings.Split(kid, "#")[0], claims.Nonce, nil
+}
+
+// proofVerifier verifies the proof signature with the DID key of the kid header
+func proofVerifier(fetcher verifiable.PublicKeyFetcher) jose.SignatureVerifier {
+	return jose.SignatureVerifierFunc(func(headers jose.Headers, _, signingInput, signature []byte) error {
+		kid, _ := headers.KeyID()
+
+		parts := strings.Split(kid, "#")
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], "did:") {
+			return fmt.Errorf("proof kid %s must be the DID URL of the key", kid)
+		}
+
+		pubKey, err := fetcher(parts[0], "#"+parts[1])
+		if err != nil {
+			return fmt.Errorf("failed to resolve proof key %s: %w", kid, err)
+		}
+
+		alg, _ := headers.Algorithm()
+
+		switch alg {
+		case algEdDSA:
+			return verifyEdDSA(pubKey, signingInput, signature)
+		case algES256:
+			return verifyES256(pubKey, signingInput, signature)
+		default:
+			return fmt.Errorf("unsupported proof algorithm %s", alg)
+		}
+	})
+}
+
+func verifyEdDSA(pubKey *verifier.PublicKey, message, signature []byte) error {
+	var key ed25519.PublicKey
+
+	if pubKey.JWK != nil {
+		key, _ = pubKey.JWK.Key.(ed25519.PublicKey) // nolint: errcheck
+	}
+
+	if key == nil && len(pubKey.Value) == ed25519.PublicKeySize {
+		key = pubKey.Value
+	}
+
+	if key == nil {
+		return errors.New("proof key is not an Ed25519 key")
+	}
+
+	if !ed25519.Verify(key, message, signature) {
+		return errors.New("proof signature doesn't match")
+	}
+
+	return nil
+}
+
+func verifyES256(pubKey *verifier.PublicKey, message, signature []byte) error {
+	var key *ecdsa.PublicKey
+
+	if pubKey.JWK != nil {
+		key, _ = pubKey.JWK.Key.(*ecdsa.PublicKey) // nolint: errcheck
+	}
+
+	if key == nil || key.Curve != elliptic.P256() {
+		return errors.New("proof key is not a P-256 key")
+	}
+
+	if len(signature) != es256SignatureSize {
+		return errors.New("invalid ES256 signature size")
+	}
+
+	hash := sha256.Sum256(message)
+	r := new(big.Int).SetBytes(signature[:es256SignatureSize/2])
+	s := new(big.Int).SetBytes(signature[es256SignatureSize/2:])
+
+	if !ecdsa.Verify(key, hash[:], r, s) {
+		return errors.New("proof signature doesn't match")
+	}
+
+	return nil
+}
+
+func hasAudience(aud interface{}, audience string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == audience
+	case []interface{}:
+		for _, v := range a {
+			if v == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,191 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oidc4vci
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDID      = "did:example:ebfeb1f712ebc6f1c276e12ec21"
+	testKID      = testDID + "#key-1"
+	testAudience = "https://example.com/issuer"
+)
+
+func TestVerifyProof(t *testing.T) {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	fetcher := func(issuerID, keyID string) (*verifier.PublicKey, error) {
+		if issuerID != testDID || keyID != "#key-1" {
+			return nil, errors.New("key not found")
+		}
+
+		return &verifier.PublicKey{Type: "Ed25519VerificationKey2018", Value: pubKey}, nil
+	}
+
+	now := time.Now()
+
+	t.Run("test valid proof", func(t *testing.T) {
+		proof := createProof(t, &ed25519Signer{privKey: privKey}, testKID, ProofJWTType,
+			map[string]interface{}{"aud": testAudience, "iat": now.Unix(), "nonce": "abc"})
+
+		did, nonce, err := VerifyProof(proof, fetcher, testAudience, now, time.Minute)
+		require.NoError(t, err)
+		require.Equal(t, testDID, did)
+		require.Equal(t, "abc", nonce)
+
+		proof = createProof(t, &ed25519Signer{privKey: privKey}, testKID, ProofJWTType,
+			map[string]interface{}{"aud": []string{"other", testAudience}, "iat": now.Unix()})
+
+		_, _, err = VerifyProof(proof, fetcher, testAudience, now, time.Minute)
+		require.NoError(t, err)
+	})
+
+	t.Run("test ES256 proof", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		ecFetcher := func(string, string) (*verifier.PublicKey, error) {
+			return &verifier.PublicKey{Type: "JwsVerificationKey2020", JWK: &jose.JWK{}}, nil
+		}
+
+		proof := createProof(t, &es256Signer{privKey: key}, testKID, ProofJWTType,
+			map[string]interface{}{"aud": testAudience, "iat": now.Unix()})
+
+		_, _, err = VerifyProof(proof, ecFetcher, testAudience, now, time.Minute)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "proof key is not a P-256 key")
+
+		ecFetcher = func(string, string) (*verifier.PublicKey, error) {
+			jwk := &jose.JWK{}
+			jwk.Key = &key.PublicKey
+
+			return &verifier.PublicKey{Type: "JwsVerificationKey2020", JWK: jwk}, nil
+		}
+
+		did, _, err := VerifyProof(proof, ecFetcher, testAudience, now, time.Minute)
+		require.NoError(t, err)
+		require.Equal(t, testDID, did)
+	})
+
+	t.Run("test invalid proof", func(t *testing.T) {
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		claims := map[string]interface{}{"aud": testAudience, "iat": now.Unix()}
+
+		tests := []struct {
+			name  string
+			proof *Proof
+			err   string
+		}{
+			{"no proof", nil, "jwt proof is required"},
+			{"invalid proof type", &Proof{ProofType: "cwt", JWT: "abc"}, "jwt proof is required"},
+			{"invalid JWT", &Proof{ProofType: JWTProofType, JWT: "abc"}, "invalid_proof"},
+			{
+				"invalid type", createProof(t, &ed25519Signer{privKey: privKey}, testKID, "JWT", claims),
+				"proof JWT type must be " + ProofJWTType,
+			},
+			{
+				"kid is not DID URL", createProof(t, &ed25519Signer{privKey: privKey}, "key-1", ProofJWTType, claims),
+				"must be the DID URL of the key",
+			},
+			{
+				"unknown key", createProof(t, &ed25519Signer{privKey: privKey}, testDID+"#key-2", ProofJWTType, claims),
+				"failed to resolve proof key",
+			},
+			{
+				"invalid signature", createProof(t, &ed25519Signer{privKey: otherKey}, testKID, ProofJWTType, claims),
+				"proof signature doesn't match",
+			},
+			{
+				"invalid audience", createProof(t, &ed25519Signer{privKey: privKey}, testKID, ProofJWTType,
+					map[string]interface{}{"aud": "other", "iat": now.Unix()}),
+				"proof audience must be " + testAudience,
+			},
+			{
+				"no issuance time", createProof(t, &ed25519Signer{privKey: privKey}, testKID, ProofJWTType,
+					map[string]interface{}{"aud": testAudience}),
+				"proof must be issued before now",
+			},
+			{
+				"issued in future", createProof(t, &ed25519Signer{privKey: privKey}, testKID, ProofJWTType,
+					map[string]interface{}{"aud": testAudience, "iat": now.Add(time.Hour).Unix()}),
+				"proof must be issued before now",
+			},
+		}
+
+		for _, tc := range tests {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				_, _, err := VerifyProof(tc.proof, fetcher, testAudience, now, time.Minute)
+				require.True(t, errors.Is(err, ErrInvalidProof))
+				require.Contains(t, err.Error(), tc.err)
+			})
+		}
+	})
+}
+
+func createProof(t *testing.T, signer jose.Signer, kid, typ string, claims map[string]interface{}) *Proof {
+	t.Helper()
+
+	token, err := jwt.NewSigned(claims, jose.Headers{jose.HeaderKeyID: kid, jose.HeaderType: typ}, signer)
+	require.NoError(t, err)
+
+	s, err := token.Serialize(false)
+	require.NoError(t, err)
+
+	return &Proof{ProofType: JWTProofType, JWT: s}
+}
+
+type ed25519Signer struct {
+	privKey ed25519.PrivateKey
+}
+
+func (s *ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.privKey, data), nil
+}
+
+func (s *ed25519Signer) Headers() jose.Headers {
+	return jose.Headers{jose.HeaderAlgorithm: algEdDSA}
+}
+
+type es256Signer struct {
+	privKey *ecdsa.PrivateKey
+}
+
+func (s *es256Signer) Sign(data []byte) ([]byte, error) {
+	hash := sha256.Sum256(data)
+
+	r, sig, err := ecdsa.Sign(rand.Reader, s.privKey, hash[:])
+	if err != nil {
+		return nil, err
+	}
+
+	signature := make([]byte, es256SignatureSize)
+	rBytes, sBytes := r.Bytes(), sig.Bytes()
+	copy(signature[es256SignatureSize/2-len(rBytes):], rBytes)
+	copy(signature[es256SignatureSize-len(sBytes):], sBytes)
+
+	return signature, nil
+}
+
+func (s *es256Signer) Headers() jose.Headers {
+	return jose.Headers{jose.HeaderAlgorithm: algES256}
+}
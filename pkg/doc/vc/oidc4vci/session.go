@@ -0,0 +1,372 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oidc4vci
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/edge-core/pkg/storage"
+)
+
+const (
+	keyPattern     = "%s_%s_%s"
+	keyPrefix      = "oidc4vci"
+	sessionKeyType = "session"
+	codeKeyType    = "code"
+	tokenKeyType   = "token"
+
+	randomValueSize = 32
+
+	// maxTxCodeAttempts is the number of invalid transaction codes invalidating the offer
+	maxTxCodeAttempts = 5
+)
+
+// Session states.
+const (
+	StateOffered  = "offered"
+	StateRedeemed = "redeemed"
+	StateIssued   = "issued"
+	// StateInvalidated is the state of the offer invalidated by too many invalid transaction codes
+	StateInvalidated = "invalidated"
+	// StateExpired is the state of the offer with the pre-authorized code or access token expired
+	StateExpired = "expired"
+)
+
+// Session is the issuance of the offered credential, the pre-authorized code of the offer is redeemed once
+// for the access token and the access token is accepted for one credential. The claims, codes and token
+// of the completed, invalidated or expired session are purged.
+type Session struct {
+	ID                string          `json:"id"`
+	Profile           string          `json:"profile"`
+	ConfigurationID   string          `json:"configurationID"`
+	Claims            json.RawMessage `json:"claims,omitempty"`
+	ExpirationDate    *time.Time      `json:"expirationDate,omitempty"`
+	State             string          `json:"state"`
+	PreAuthorizedCode string          `json:"preAuthorizedCode"`
+	TxCode            string          `json:"txCode,omitempty"`
+	TxCodeAttempts    int             `json:"txCodeAttempts,omitempty"`
+	CodeExpires       time.Time       `json:"codeExpires"`
+	AccessToken       string          `json:"accessToken,omitempty"`
+	TokenExpires      time.Time       `json:"tokenExpires,omitempty"`
+	CNonce            string          `json:"cNonce,omitempty"`
+	CNonceExpires     time.Time       `json:"cNonceExpires,omitempty"`
+	CredentialID      string          `json:"credentialID,omitempty"`
+}
+
+// New returns new session manager instance, the pre-authorized codes are valid for the code expiry
+// and the access tokens and nonces for the token expiry
+func New(store storage.Store, codeExpiry, tokenExpiry time.Duration) *Manager {
+	return &Manager{store: store, codeExpiry: codeExpiry, tokenExpiry: tokenExpiry}
+}
+
+// Manager keeps the issuance sessions of the credential offers.
+type Manager struct {
+	store       storage.Store
+	codeExpiry  time.Duration
+	tokenExpiry time.Duration
+	mutex       sync.Mutex
+}
+
+// TokenExpiry returns the period the access tokens and nonces are valid for
+func (m *Manager) TokenExpiry() time.Duration {
+	return m.tokenExpiry
+}
+
+// CreateSession creates the session of the offer with new pre-authorized code
+func (m *Manager) CreateSession(s *Session) error {
+	id, err := randomValue()
+	if err != nil {
+		return err
+	}
+
+	code, err := randomValue()
+	if err != nil {
+		return err
+	}
+
+	s.ID = id
+	s.PreAuthorizedCode = code
+	s.CodeExpires = time.Now().UTC().Add(m.codeExpiry)
+	s.State = StateOffered
+
+	if err := m.store.Put(getDBKey(codeKeyType, code), []byte(id)); err != nil {
+		return err
+	}
+
+	return m.save(s)
+}
+
+// GetSession returns the session with the ID
+func (m *Manager) GetSession(id string) (*Session, error) {
+	bytes, err := m.store.Get(getDBKey(sessionKeyType, id))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Session{}
+
+	if err := json.Unmarshal(bytes, s); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal issuance session: %w", err)
+	}
+
+	return s, nil
+}
+
+// RedeemCode redeems the pre-authorized code (with the transaction code if the offer requires it) of the profile
+// for the access token and nonce, the offer is invalidated by too many invalid transaction codes
+func (m *Manager) RedeemCode(profile, code, txCode string) (*Session, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s, err := m.lookup(codeKeyType, code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown pre-authorized code", ErrInvalidGrant)
+	}
+
+	switch {
+	case s.Profile != profile:
+		return nil, fmt.Errorf("%w: pre-authorized code is issued by another issuer", ErrInvalidGrant)
+	case s.State != StateOffered:
+		return nil, fmt.Errorf("%w: pre-authorized code is already used", ErrInvalidGrant)
+	case time.Now().After(s.CodeExpires):
+		if err := m.purge(s, StateExpired); err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: pre-authorized code is expired", ErrInvalidGrant)
+	case subtle.ConstantTimeCompare([]byte(s.TxCode), []byte(txCode)) != 1:
+		return nil, m.rejectTxCode(s)
+	}
+
+	token, err := randomValue()
+	if err != nil {
+		return nil, err
+	}
+
+	s.State = StateRedeemed
+	s.AccessToken = token
+	s.TokenExpires = time.Now().UTC().Add(m.tokenExpiry)
+
+	if err := m.store.Put(getDBKey(tokenKeyType, token), []byte(s.ID)); err != nil {
+		return nil, err
+	}
+
+	if err := m.renewNonce(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// rejectTxCode counts the invalid transaction code of the session, the offer is invalidated
+// by too many invalid codes
+func (m *Manager) rejectTxCode(s *Session) error {
+	s.TxCodeAttempts++
+
+	if s.TxCodeAttempts < maxTxCodeAttempts {
+		if err := m.save(s); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("%w: invalid transaction code", ErrInvalidGrant)
+	}
+
+	if err := m.purge(s, StateInvalidated); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: invalid transaction code, the offer is invalidated", ErrInvalidGrant)
+}
+
+// Authorize returns the session of the access token of the profile, the token must not be expired or used already
+func (m *Manager) Authorize(profile, token string) (*Session, error) {
+	s, err := m.lookup(tokenKeyType, token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown access token", ErrInvalidToken)
+	}
+
+	switch {
+	case s.Profile != profile:
+		return nil, fmt.Errorf("%w: access token is issued by another issuer", ErrInvalidToken)
+	case s.State != StateRedeemed:
+		return nil, fmt.Errorf("%w: access token is already used", ErrInvalidToken)
+	case time.Now().After(s.TokenExpires):
+		if err := m.expire(s.ID); err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: access token is expired", ErrInvalidToken)
+	}
+
+	return s, nil
+}
+
+// expire purges the redeemed session with the access token expired
+func (m *Manager) expire(id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s, err := m.GetSession(id)
+	if err != nil {
+		return err
+	}
+
+	if s.State != StateRedeemed {
+		return nil
+	}
+
+	return m.purge(s, StateExpired)
+}
+
+// CheckNonce checks the nonce is the current nonce of the session
+func (s *Session) CheckNonce(nonce string) error {
+	if s.CNonce == "" || subtle.ConstantTimeCompare([]byte(s.CNonce), []byte(nonce)) != 1 {
+		return fmt.Errorf("%w: proof doesn't have the current c_nonce", ErrInvalidNonce)
+	}
+
+	if time.Now().After(s.CNonceExpires) {
+		return fmt.Errorf("%w: c_nonce is expired", ErrInvalidNonce)
+	}
+
+	return nil
+}
+
+// RenewNonce issues new nonce of the session, the session is updated with the saved session as the access
+// token could be used by the concurrent request
+func (m *Manager) RenewNonce(s *Session) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	current, err := m.GetSession(s.ID)
+	if err != nil {
+		return err
+	}
+
+	if current.State != StateRedeemed {
+		return fmt.Errorf("%w: access token is already used", ErrInvalidToken)
+	}
+
+	if err := m.renewNonce(current); err != nil {
+		return err
+	}
+
+	*s = *current
+
+	return nil
+}
+
+func (m *Manager) renewNonce(s *Session) error {
+	nonce, err := randomValue()
+	if err != nil {
+		return err
+	}
+
+	s.CNonce = nonce
+	s.CNonceExpires = time.Now().UTC().Add(m.tokenExpiry)
+
+	return m.save(s)
+}
+
+// Complete completes the session with the credential issued, the access token isn't accepted anymore and
+// the session is purged
+func (m *Manager) Complete(s *Session, credentialID string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// the token could be used by the concurrent request
+	current, err := m.GetSession(s.ID)
+	if err != nil {
+		return err
+	}
+
+	if current.State != StateRedeemed {
+		return fmt.Errorf("%w: access token is already used", ErrInvalidToken)
+	}
+
+	// the nonce of the credential response
+	nonce, err := randomValue()
+	if err != nil {
+		return err
+	}
+
+	current.CredentialID = credentialID
+
+	if err := m.purge(current, StateIssued); err != nil {
+		return err
+	}
+
+	s.State = StateIssued
+	s.CredentialID = credentialID
+	s.CNonce = nonce
+	s.CNonceExpires = time.Now().UTC().Add(m.tokenExpiry)
+
+	return nil
+}
+
+// purge saves the session in the final state without its claims, codes and token, the storage has no delete
+// so the entries of the code and token are overwritten with empty values
+func (m *Manager) purge(s *Session, state string) error {
+	for keyType, value := range map[string]string{codeKeyType: s.PreAuthorizedCode, tokenKeyType: s.AccessToken} {
+		if value == "" {
+			continue
+		}
+
+		if err := m.store.Put(getDBKey(keyType, value), []byte{}); err != nil {
+			return err
+		}
+	}
+
+	s.State = state
+	s.Claims = nil
+	s.PreAuthorizedCode = ""
+	s.TxCode = ""
+	s.AccessToken = ""
+	s.CNonce = ""
+
+	return m.save(s)
+}
+
+func (m *Manager) lookup(keyType, value string) (*Session, error) {
+	id, err := m.store.Get(getDBKey(keyType, value))
+	if err != nil {
+		return nil, err
+	}
+
+	// the code or token of the purged session
+	if len(id) == 0 {
+		return nil, storage.ErrValueNotFound
+	}
+
+	return m.GetSession(string(id))
+}
+
+func (m *Manager) save(s *Session) error {
+	bytes, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("save issuance session marshalling error: %s", err.Error())
+	}
+
+	return m.store.Put(getDBKey(sessionKeyType, s.ID), bytes)
+}
+
+func randomValue() (string, error) {
+	value := make([]byte, randomValueSize)
+	if _, err := rand.Read(value); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(value), nil
+}
+
+func getDBKey(keyType, value string) string {
+	return fmt.Sprintf(keyPattern, keyPrefix, keyType, value)
+}
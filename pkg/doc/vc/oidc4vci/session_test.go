@@ -0,0 +1,215 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oidc4vci
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	mockstorage "github.com/trustbloc/edge-core/pkg/storage/mockstore"
+)
+
+func TestManager(t *testing.T) {
+	newSession := func(t *testing.T, m *Manager, txCode string) *Session {
+		t.Helper()
+
+		s := &Session{Profile: "issuer", ConfigurationID: "UniversityDegree", TxCode: txCode}
+		require.NoError(t, m.CreateSession(s))
+
+		return s
+	}
+
+	t.Run("test pre-authorized code is redeemed once", func(t *testing.T) {
+		m := New(&mockstorage.MockStore{Store: make(map[string][]byte)}, time.Minute, time.Minute)
+
+		s := newSession(t, m, "")
+		require.NotEmpty(t, s.ID)
+		require.NotEmpty(t, s.PreAuthorizedCode)
+		require.Equal(t, StateOffered, s.State)
+
+		saved, err := m.GetSession(s.ID)
+		require.NoError(t, err)
+		require.Equal(t, s.PreAuthorizedCode, saved.PreAuthorizedCode)
+
+		redeemed, err := m.RedeemCode("issuer", s.PreAuthorizedCode, "")
+		require.NoError(t, err)
+		require.Equal(t, StateRedeemed, redeemed.State)
+		require.NotEmpty(t, redeemed.AccessToken)
+		require.NotEmpty(t, redeemed.CNonce)
+		require.NoError(t, redeemed.CheckNonce(redeemed.CNonce))
+
+		_, err = m.RedeemCode("issuer", s.PreAuthorizedCode, "")
+		require.True(t, errors.Is(err, ErrInvalidGrant))
+		require.Contains(t, err.Error(), "pre-authorized code is already used")
+
+		authorized, err := m.Authorize("issuer", redeemed.AccessToken)
+		require.NoError(t, err)
+		require.Equal(t, s.ID, authorized.ID)
+
+		require.NoError(t, m.Complete(authorized, "http://example.edu/credentials/1872"))
+		require.Equal(t, StateIssued, authorized.State)
+		require.NotEqual(t, redeemed.CNonce, authorized.CNonce)
+
+		_, err = m.Authorize("issuer", redeemed.AccessToken)
+		require.True(t, errors.Is(err, ErrInvalidToken))
+		require.Contains(t, err.Error(), "unknown access token")
+
+		// the concurrent request with the same token
+		err = m.Complete(redeemed, "http://example.edu/credentials/1873")
+		require.True(t, errors.Is(err, ErrInvalidToken))
+
+		err = m.RenewNonce(redeemed)
+		require.True(t, errors.Is(err, ErrInvalidToken))
+		require.Contains(t, err.Error(), "access token is already used")
+
+		// the completed session is purged
+		saved, err = m.GetSession(s.ID)
+		require.NoError(t, err)
+		require.Equal(t, StateIssued, saved.State)
+		require.Equal(t, "http://example.edu/credentials/1872", saved.CredentialID)
+		require.Empty(t, saved.Claims)
+		require.Empty(t, saved.PreAuthorizedCode)
+		require.Empty(t, saved.AccessToken)
+
+		_, err = m.RedeemCode("issuer", s.PreAuthorizedCode, "")
+		require.True(t, errors.Is(err, ErrInvalidGrant))
+		require.Contains(t, err.Error(), "unknown pre-authorized code")
+	})
+
+	t.Run("test nonce is renewed on the saved session", func(t *testing.T) {
+		m := New(&mockstorage.MockStore{Store: make(map[string][]byte)}, time.Minute, time.Minute)
+
+		s := newSession(t, m, "")
+
+		redeemed, err := m.RedeemCode("issuer", s.PreAuthorizedCode, "")
+		require.NoError(t, err)
+
+		stale := *redeemed
+		stale.State = StateOffered
+
+		require.NoError(t, m.RenewNonce(&stale))
+		require.Equal(t, StateRedeemed, stale.State)
+		require.NotEqual(t, redeemed.CNonce, stale.CNonce)
+
+		saved, err := m.GetSession(s.ID)
+		require.NoError(t, err)
+		require.Equal(t, stale.CNonce, saved.CNonce)
+	})
+
+	t.Run("test transaction code", func(t *testing.T) {
+		m := New(&mockstorage.MockStore{Store: make(map[string][]byte)}, time.Minute, time.Minute)
+
+		s := newSession(t, m, "4321")
+
+		_, err := m.RedeemCode("issuer", s.PreAuthorizedCode, "1234")
+		require.True(t, errors.Is(err, ErrInvalidGrant))
+		require.Contains(t, err.Error(), "invalid transaction code")
+
+		_, err = m.RedeemCode("issuer", s.PreAuthorizedCode, "4321")
+		require.NoError(t, err)
+
+		// the offer is invalidated by too many invalid transaction codes
+		s = newSession(t, m, "4321")
+
+		for i := 1; i < maxTxCodeAttempts; i++ {
+			_, err = m.RedeemCode("issuer", s.PreAuthorizedCode, "1234")
+			require.Contains(t, err.Error(), "invalid transaction code")
+		}
+
+		_, err = m.RedeemCode("issuer", s.PreAuthorizedCode, "1234")
+		require.True(t, errors.Is(err, ErrInvalidGrant))
+		require.Contains(t, err.Error(), "the offer is invalidated")
+
+		_, err = m.RedeemCode("issuer", s.PreAuthorizedCode, "4321")
+		require.True(t, errors.Is(err, ErrInvalidGrant))
+		require.Contains(t, err.Error(), "unknown pre-authorized code")
+
+		saved, err := m.GetSession(s.ID)
+		require.NoError(t, err)
+		require.Equal(t, StateInvalidated, saved.State)
+		require.Empty(t, saved.TxCode)
+	})
+
+	t.Run("test code and token scope", func(t *testing.T) {
+		m := New(&mockstorage.MockStore{Store: make(map[string][]byte)}, time.Minute, time.Minute)
+
+		s := newSession(t, m, "")
+
+		_, err := m.RedeemCode("other", s.PreAuthorizedCode, "")
+		require.True(t, errors.Is(err, ErrInvalidGrant))
+		require.Contains(t, err.Error(), "issued by another issuer")
+
+		_, err = m.RedeemCode("issuer", "unknown", "")
+		require.True(t, errors.Is(err, ErrInvalidGrant))
+		require.Contains(t, err.Error(), "unknown pre-authorized code")
+
+		redeemed, err := m.RedeemCode("issuer", s.PreAuthorizedCode, "")
+		require.NoError(t, err)
+
+		_, err = m.Authorize("other", redeemed.AccessToken)
+		require.True(t, errors.Is(err, ErrInvalidToken))
+		require.Contains(t, err.Error(), "issued by another issuer")
+
+		_, err = m.Authorize("issuer", "unknown")
+		require.True(t, errors.Is(err, ErrInvalidToken))
+		require.Contains(t, err.Error(), "unknown access token")
+	})
+
+	t.Run("test expired code, token and nonce", func(t *testing.T) {
+		m := New(&mockstorage.MockStore{Store: make(map[string][]byte)}, -time.Second, time.Minute)
+
+		s := newSession(t, m, "")
+
+		_, err := m.RedeemCode("issuer", s.PreAuthorizedCode, "")
+		require.True(t, errors.Is(err, ErrInvalidGrant))
+		require.Contains(t, err.Error(), "pre-authorized code is expired")
+
+		saved, err := m.GetSession(s.ID)
+		require.NoError(t, err)
+		require.Equal(t, StateExpired, saved.State)
+		require.Empty(t, saved.PreAuthorizedCode)
+
+		m = New(&mockstorage.MockStore{Store: make(map[string][]byte)}, time.Minute, -time.Second)
+
+		s = newSession(t, m, "")
+
+		redeemed, err := m.RedeemCode("issuer", s.PreAuthorizedCode, "")
+		require.NoError(t, err)
+
+		_, err = m.Authorize("issuer", redeemed.AccessToken)
+		require.True(t, errors.Is(err, ErrInvalidToken))
+		require.Contains(t, err.Error(), "access token is expired")
+
+		_, err = m.Authorize("issuer", redeemed.AccessToken)
+		require.Contains(t, err.Error(), "unknown access token")
+
+		err = redeemed.CheckNonce(redeemed.CNonce)
+		require.True(t, errors.Is(err, ErrInvalidNonce))
+		require.Contains(t, err.Error(), "c_nonce is expired")
+
+		err = redeemed.CheckNonce("invalid")
+		require.True(t, errors.Is(err, ErrInvalidNonce))
+		require.Contains(t, err.Error(), "doesn't have the current c_nonce")
+	})
+
+	t.Run("test store errors", func(t *testing.T) {
+		m := New(&mockstorage.MockStore{Store: make(map[string][]byte), ErrPut: errors.New("put error")},
+			time.Minute, time.Minute)
+
+		err := m.CreateSession(&Session{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "put error")
+
+		store := &mockstorage.MockStore{Store: make(map[string][]byte)}
+		store.Store[getDBKey(sessionKeyType, "abc")] = []byte("invalid")
+
+		_, err = New(store, time.Minute, time.Minute).GetSession("abc")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal issuance session")
+	})
+}
@@ -0,0 +1,68 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oidc4vp
+
+import (
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
+)
+
+const (
+	// AuthorizationRequestScheme is the scheme of the authorization requests passed to the wallets
+	AuthorizationRequestScheme = "openid4vp://"
+	// RequestObjectType is the typ header of the signed request objects
+	RequestObjectType = "oauth-authz-req+jwt"
+	// RequestObjectContentType is the content type of the request objects passed by reference
+	RequestObjectContentType = "application/oauth-authz-req+jwt"
+	// SelfIssuedAudience is the audience of the request objects for the wallets without the issuer identifier
+	SelfIssuedAudience = "https://self-issued.me/v2"
+
+	// ClientIDSchemeDID identifies the verifier by its DID, the request object is signed with the DID key
+	ClientIDSchemeDID = "did"
+	// ResponseTypeVPToken is the response type of the presentation requests
+	ResponseTypeVPToken = "vp_token"
+	// ResponseModeDirectPost is the response mode of the wallets posting the response to the verifier
+	ResponseModeDirectPost = "direct_post"
+	// LDPVPFormat is the format of the presentations secured with the linked data proofs
+	LDPVPFormat = "ldp_vp"
+)
+
+// OAuth error codes of the response endpoint.
+const (
+	ErrorInvalidRequest = "invalid_request"
+)
+
+// RequestObject is the claims of the signed authorization request.
+type RequestObject struct {
+	Issuer                 string                           `json:"iss"`
+	Audience               string                           `json:"aud"`
+	IssuedAt               int64                            `json:"iat"`
+	Expiry                 int64                            `json:"exp"`
+	ClientID               string                           `json:"client_id"`
+	ClientIDScheme         string                           `json:"client_id_scheme"`
+	ResponseType           string                           `json:"response_type"`
+	ResponseMode           string                           `json:"response_mode"`
+	ResponseURI            string                           `json:"response_uri"`
+	Nonce                  string                           `json:"nonce"`
+	State                  string                           `json:"state"`
+	PresentationDefinition *presexch.PresentationDefinition `json:"presentation_definition"`
+	ClientMetadata         *ClientMetadata                  `json:"client_metadata,omitempty"`
+}
+
+// ClientMetadata is the metadata of the verifier passed in the request object.
+type ClientMetadata struct {
+	VPFormats map[string]*VPFormat `json:"vp_formats"`
+}
+
+// VPFormat lists the proof types of the presentation format the verifier accepts.
+type VPFormat struct {
+	ProofTypes []string `json:"proof_type"`
+}
+
+// ErrorResponse is the OAuth error response of the response endpoint.
+type ErrorResponse struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
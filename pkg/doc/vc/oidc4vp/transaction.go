@@ -0,0 +1,194 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oidc4vp
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/edge-core/pkg/storage"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
+)
+
+const (
+	keyPattern        = "%s_%s"
+	requestKeyPattern = "%s_request:%s"
+	keyPrefix         = "oidc4vp"
+
+	transactionIDSize = 32
+)
+
+// Transaction states.
+const (
+	StatePending  = "pending"
+	StateReceived = "received"
+	StateVerified = "verified"
+	StateFailed   = "failed"
+)
+
+// ErrInvalidTransaction is returned when the transaction of the response is unknown, expired
+// or already responded
+var ErrInvalidTransaction = errors.New("invalid transaction")
+
+// Transaction is the presentation request of the verifier, the transaction ID is known to the verifier only and
+// the request ID is the state of the authorization request passed to the wallet, the wallet responds to it once.
+type Transaction struct {
+	ID                     string                           `json:"transactionID"`
+	RequestID              string                           `json:"requestID"`
+	Profile                string                           `json:"profile"`
+	ClientID               string                           `json:"clientID"`
+	Nonce                  string                           `json:"nonce,omitempty"`
+	PresentationDefinition *presexch.PresentationDefinition `json:"presentationDefinition"`
+	Checks                 []string                         `json:"checks,omitempty"`
+	State                  string                           `json:"state"`
+	Expires                time.Time                        `json:"expires"`
+	Presentation           json.RawMessage                  `json:"presentation,omitempty"`
+	Result                 json.RawMessage                  `json:"result,omitempty"`
+	Error                  string                           `json:"error,omitempty"`
+	Completed              *time.Time                       `json:"completed,omitempty"`
+}
+
+// New returns new transaction manager instance
+func New(store storage.Store) *Manager {
+	return &Manager{store: store}
+}
+
+// Manager keeps the transactions of the presentation requests.
+type Manager struct {
+	store storage.Store
+	mutex sync.Mutex
+}
+
+// Create creates the pending transaction with new transaction and request IDs
+func (m *Manager) Create(tx *Transaction) error {
+	id, err := randomID()
+	if err != nil {
+		return fmt.Errorf("failed to generate transaction ID: %w", err)
+	}
+
+	requestID, err := randomID()
+	if err != nil {
+		return fmt.Errorf("failed to generate request ID: %w", err)
+	}
+
+	tx.ID = id
+	tx.RequestID = requestID
+	tx.State = StatePending
+
+	if err := m.store.Put(getRequestDBKey(requestID), []byte(id)); err != nil {
+		return err
+	}
+
+	return m.save(tx)
+}
+
+// Get returns the transaction with the ID
+func (m *Manager) Get(id string) (*Transaction, error) {
+	bytes, err := m.store.Get(getDBKey(id))
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &Transaction{}
+
+	if err := json.Unmarshal(bytes, tx); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction: %w", err)
+	}
+
+	return tx, nil
+}
+
+// GetRequest returns the transaction with the request ID
+func (m *Manager) GetRequest(requestID string) (*Transaction, error) {
+	id, err := m.store.Get(getRequestDBKey(requestID))
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Get(string(id))
+}
+
+// Receive accepts the response to the pending transaction of the profile with the request ID (the state
+// of the response), the next responses are rejected
+func (m *Manager) Receive(profile, id string) (*Transaction, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	tx, err := m.GetRequest(id)
+	if errors.Is(err, storage.ErrValueNotFound) {
+		return nil, fmt.Errorf("%w: unknown state %s", ErrInvalidTransaction, id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case tx.Profile != profile:
+		return nil, fmt.Errorf("%w: state %s is issued by another verifier", ErrInvalidTransaction, id)
+	case tx.State != StatePending:
+		return nil, fmt.Errorf("%w: state %s is already responded", ErrInvalidTransaction, id)
+	case time.Now().After(tx.Expires):
+		return nil, fmt.Errorf("%w: state %s is expired", ErrInvalidTransaction, id)
+	}
+
+	tx.State = StateReceived
+
+	if err := m.save(tx); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// Complete records the verification result of the received response
+func (m *Manager) Complete(tx *Transaction, verified bool) error {
+	completed := time.Now().UTC()
+
+	tx.State = StateFailed
+	if verified {
+		tx.State = StateVerified
+	}
+
+	tx.Completed = &completed
+
+	return m.save(tx)
+}
+
+func (m *Manager) save(tx *Transaction) error {
+	bytes, err := json.Marshal(tx)
+	if err != nil {
+		return fmt.Errorf("save transaction marshalling error: %s", err.Error())
+	}
+
+	return m.store.Put(getDBKey(tx.ID), bytes)
+}
+
+func randomID() (string, error) {
+	id := make([]byte, transactionIDSize)
+
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(id), nil
+}
+
+func getDBKey(id string) string {
+	return fmt.Sprintf(keyPattern, keyPrefix, id)
+}
+
+// getRequestDBKey returns the key of the transaction ID of the request, the separator isn't used
+// by the transaction IDs
+func getRequestDBKey(requestID string) string {
+	return fmt.Sprintf(requestKeyPattern, keyPrefix, requestID)
+}
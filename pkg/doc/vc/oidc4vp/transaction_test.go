@@ -0,0 +1,116 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oidc4vp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage"
+	mockstorage "github.com/trustbloc/edge-core/pkg/storage/mockstore"
+)
+
+func TestManager(t *testing.T) {
+	t.Run("test transaction is responded once", func(t *testing.T) {
+		m := New(&mockstorage.MockStore{Store: make(map[string][]byte)})
+
+		tx := &Transaction{Profile: "verifier", Nonce: "abc", Expires: time.Now().Add(time.Minute)}
+		require.NoError(t, m.Create(tx))
+		require.NotEmpty(t, tx.ID)
+		require.Equal(t, StatePending, tx.State)
+
+		other := &Transaction{Profile: "verifier", Expires: time.Now().Add(time.Minute)}
+		require.NoError(t, m.Create(other))
+		require.NotEqual(t, tx.ID, other.ID)
+		require.NotEmpty(t, tx.RequestID)
+		require.NotEqual(t, tx.ID, tx.RequestID)
+
+		// the transaction ID isn't accepted as the state
+		_, err := m.Receive("verifier", tx.ID)
+		require.True(t, errors.Is(err, ErrInvalidTransaction))
+
+		requested, err := m.GetRequest(tx.RequestID)
+		require.NoError(t, err)
+		require.Equal(t, tx.ID, requested.ID)
+
+		received, err := m.Receive("verifier", tx.RequestID)
+		require.NoError(t, err)
+		require.Equal(t, StateReceived, received.State)
+		require.Equal(t, "abc", received.Nonce)
+
+		_, err = m.Receive("verifier", tx.RequestID)
+		require.True(t, errors.Is(err, ErrInvalidTransaction))
+		require.Contains(t, err.Error(), "is already responded")
+
+		require.NoError(t, m.Complete(received, true))
+
+		stored, err := m.Get(tx.ID)
+		require.NoError(t, err)
+		require.Equal(t, StateVerified, stored.State)
+		require.NotNil(t, stored.Completed)
+
+		received, err = m.Receive("verifier", other.RequestID)
+		require.NoError(t, err)
+		require.NoError(t, m.Complete(received, false))
+
+		stored, err = m.Get(other.ID)
+		require.NoError(t, err)
+		require.Equal(t, StateFailed, stored.State)
+	})
+
+	t.Run("test invalid transaction", func(t *testing.T) {
+		m := New(&mockstorage.MockStore{Store: make(map[string][]byte)})
+
+		_, err := m.Receive("verifier", "unknown")
+		require.True(t, errors.Is(err, ErrInvalidTransaction))
+		require.Contains(t, err.Error(), "unknown state unknown")
+
+		tx := &Transaction{Profile: "verifier", Expires: time.Now().Add(time.Minute)}
+		require.NoError(t, m.Create(tx))
+
+		_, err = m.Receive("other", tx.RequestID)
+		require.True(t, errors.Is(err, ErrInvalidTransaction))
+		require.Contains(t, err.Error(), "is issued by another verifier")
+
+		expired := &Transaction{Profile: "verifier", Expires: time.Now().Add(-time.Second)}
+		require.NoError(t, m.Create(expired))
+
+		_, err = m.Receive("verifier", expired.RequestID)
+		require.True(t, errors.Is(err, ErrInvalidTransaction))
+		require.Contains(t, err.Error(), "is expired")
+
+		// the transaction is not spent by the failed attempts
+		_, err = m.Receive("verifier", tx.RequestID)
+		require.NoError(t, err)
+	})
+
+	t.Run("test store errors", func(t *testing.T) {
+		m := New(&mockstorage.MockStore{Store: make(map[string][]byte), ErrPut: errors.New("put error")})
+
+		err := m.Create(&Transaction{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "put error")
+
+		_, err = New(&mockstorage.MockStore{Store: make(map[string][]byte)}).Get("abc")
+		require.True(t, errors.Is(err, storage.ErrValueNotFound))
+
+		store := &mockstorage.MockStore{Store: make(map[string][]byte), ErrGet: errors.New("get error")}
+		store.Store[getRequestDBKey("abc")] = []byte("abc")
+
+		_, err = New(store).Receive("", "abc")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get error")
+
+		store = &mockstorage.MockStore{Store: make(map[string][]byte)}
+		store.Store[getDBKey("abc")] = []byte("invalid")
+
+		_, err = New(store).Get("abc")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal transaction")
+	})
+}
@@ -0,0 +1,163 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presexch
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pathSegment is a single step of JSONPath expression: object member, array index or wildcard.
+type pathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parsePath parses the JSONPath subset used by presentation definitions: $, .name, ['name'], [n], [*] and .*
+func parsePath(path string) ([]pathSegment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid JSONPath %s: must start with $", path)
+	}
+
+	var segments []pathSegment
+
+	p := path[1:]
+
+	for len(p) > 0 {
+		var (
+			seg pathSegment
+			err error
+		)
+
+		switch {
+		case strings.HasPrefix(p, ".."):
+			return nil, fmt.Errorf("invalid JSONPath %s: recursive descent is not supported", path)
+		case p[0] == '.':
+			seg, p, err = parseDotSegment(p[1:])
+		case p[0] == '[':
+			seg, p, err = parseBracketSegment(p)
+		default:
+			err = fmt.Errorf("unexpected character %q", p[0])
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSONPath %s: %w", path, err)
+		}
+
+		segments = append(segments, seg)
+	}
+
+	return segments, nil
+}
+
+func parseDotSegment(p string) (pathSegment, string, error) {
+	end := strings.IndexAny(p, ".[")
+	if end == -1 {
+		end = len(p)
+	}
+
+	name := p[:end]
+
+	switch name {
+	case "":
+		return pathSegment{}, "", fmt.Errorf("empty member name")
+	case "*":
+		return pathSegment{wildcard: true}, p[end:], nil
+	default:
+		return pathSegment{key: name}, p[end:], nil
+	}
+}
+
+func parseBracketSegment(p string) (pathSegment, string, error) {
+	end := strings.Index(p, "]")
+	if end == -1 {
+		return pathSegment{}, "", fmt.Errorf("missing ]")
+	}
+
+	inner := strings.TrimSpace(p[1:end])
+	rest := p[end+1:]
+
+	if inner == "*" {
+		return pathSegment{wildcard: true}, rest, nil
+	}
+
+	if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') {
+		if inner[len(inner)-1] != inner[0] {
+			return pathSegment{}, "", fmt.Errorf("unterminated member name %s", inner)
+		}
+
+		return pathSegment{key: inner[1 : len(inner)-1]}, rest, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil || index < 0 {
+		return pathSegment{}, "", fmt.Errorf("invalid array index %s", inner)
+	}
+
+	return pathSegment{index: index, isIndex: true}, rest, nil
+}
+
+// evaluatePath returns all the values selected by JSONPath in the decoded JSON document.
+func evaluatePath(doc interface{}, path string) ([]interface{}, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []interface{}{doc}
+
+	for _, seg := range segments {
+		var next []interface{}
+
+		for _, node := range nodes {
+			next = append(next, selectChildren(node, seg)...)
+		}
+
+		nodes = next
+	}
+
+	return nodes, nil
+}
+
+func selectChildren(node interface{}, seg pathSegment) []interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if seg.wildcard {
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+
+			sort.Strings(keys)
+
+			children := make([]interface{}, 0, len(keys))
+			for _, k := range keys {
+				children = append(children, v[k])
+			}
+
+			return children
+		}
+
+		if child, ok := v[seg.key]; ok && !seg.isIndex {
+			return []interface{}{child}
+		}
+	case []interface{}:
+		if seg.wildcard {
+			return v
+		}
+
+		if seg.isIndex && seg.index < len(v) {
+			return []interface{}{v[seg.index]}
+		}
+	}
+
+	return nil
+}
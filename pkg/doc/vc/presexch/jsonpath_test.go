@@ -0,0 +1,71 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presexch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluatePath(t *testing.T) {
+	var doc interface{}
+
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": ["VerifiableCredential", "UniversityDegreeCredential"],
+		"credentialSubject": {"degree": {"type": "BachelorDegree", "name": "Bachelor of Science"}},
+		"verifiableCredential": [{"id": "vc1"}, {"id": "vc2"}],
+		"with.dot": 1
+	}`), &doc))
+
+	tests := []struct {
+		path   string
+		result []interface{}
+	}{
+		{path: "$", result: []interface{}{doc}},
+		{path: "$.credentialSubject.degree.type", result: []interface{}{"BachelorDegree"}},
+		{path: "$['credentialSubject'][\"degree\"].name", result: []interface{}{"Bachelor of Science"}},
+		{path: "$.type[1]", result: []interface{}{"UniversityDegreeCredential"}},
+		{path: "$.type[*]", result: []interface{}{"VerifiableCredential", "UniversityDegreeCredential"}},
+		{path: "$.verifiableCredential[*].id", result: []interface{}{"vc1", "vc2"}},
+		{path: "$.credentialSubject.degree.*", result: []interface{}{"Bachelor of Science", "BachelorDegree"}},
+		{path: "$['with.dot']", result: []interface{}{float64(1)}},
+		{path: "$.type[5]"},
+		{path: "$.missing.field"},
+		{path: "$.type.name"},
+		{path: "$.credentialSubject[0]"},
+	}
+
+	for _, tc := range tests {
+		result, err := evaluatePath(doc, tc.path)
+		require.NoError(t, err, tc.path)
+		require.Equal(t, tc.result, result, tc.path)
+	}
+}
+
+func TestParsePath_Errors(t *testing.T) {
+	tests := []struct {
+		path string
+		err  string
+	}{
+		{path: "credentialSubject", err: "must start with $"},
+		{path: "$..id", err: "recursive descent is not supported"},
+		{path: "$.a.", err: "empty member name"},
+		{path: "$[0", err: "missing ]"},
+		{path: "$['id\"]", err: "unterminated member name"},
+		{path: "$[-1]", err: "invalid array index"},
+		{path: "$[abc]", err: "invalid array index"},
+		{path: "$id", err: "unexpected character"},
+	}
+
+	for _, tc := range tests {
+		_, err := parsePath(tc.path)
+		require.Error(t, err, tc.path)
+		require.Contains(t, err.Error(), tc.err, tc.path)
+	}
+}
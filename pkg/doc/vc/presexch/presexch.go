@@ -0,0 +1,457 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presexch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const submissionField = "presentation_submission"
+
+// PresentationDefinition describes the proofs the verifier requires (DIF Presentation Exchange).
+type PresentationDefinition struct {
+	ID               string             `json:"id"`
+	Name             string             `json:"name,omitempty"`
+	Purpose          string             `json:"purpose,omitempty"`
+	InputDescriptors []*InputDescriptor `json:"input_descriptors"`
+}
+
+// InputDescriptor describes the credential the verifier requires.
+type InputDescriptor struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name,omitempty"`
+	Purpose     string       `json:"purpose,omitempty"`
+	Schema      []*Schema    `json:"schema,omitempty"`
+	Constraints *Constraints `json:"constraints,omitempty"`
+}
+
+// Schema is the credential type (context, type or credentialSchema URI) accepted by the input descriptor.
+type Schema struct {
+	URI      string `json:"uri"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// Constraints of the input descriptor.
+type Constraints struct {
+	Fields []*Field `json:"fields,omitempty"`
+}
+
+// Field is the credential property selected by JSONPath which optionally must match JSON Schema filter,
+// the value of the field with ID is returned in the descriptor result.
+type Field struct {
+	ID      string          `json:"id,omitempty"`
+	Path    []string        `json:"path"`
+	Purpose string          `json:"purpose,omitempty"`
+	Filter  json.RawMessage `json:"filter,omitempty"`
+}
+
+// PresentationSubmission maps the credentials of the presentation to the input descriptors.
+type PresentationSubmission struct {
+	ID            string                    `json:"id,omitempty"`
+	DefinitionID  string                    `json:"definition_id"`
+	DescriptorMap []*InputDescriptorMapping `json:"descriptor_map"`
+}
+
+// InputDescriptorMapping points to the credential submitted for the input descriptor, the nested path
+// is evaluated against the object selected by the path (e.g. the presentation of the OpenID4VP vp_token).
+type InputDescriptorMapping struct {
+	ID         string                  `json:"id"`
+	Format     string                  `json:"format,omitempty"`
+	Path       string                  `json:"path"`
+	PathNested *InputDescriptorMapping `json:"path_nested,omitempty"`
+}
+
+// DescriptorResult is the evaluation result of the input descriptor, the values of the fields with IDs
+// are selected from the credential satisfying the descriptor.
+type DescriptorResult struct {
+	ID        string                 `json:"id"`
+	Satisfied bool                   `json:"satisfied"`
+	Error     string                 `json:"error,omitempty"`
+	Values    map[string]interface{} `json:"values,omitempty"`
+}
+
+// Validate checks the presentation definition is well formed.
+func (pd *PresentationDefinition) Validate() error {
+	if pd.ID == "" {
+		return errors.New("presentation definition id is required")
+	}
+
+	if len(pd.InputDescriptors) == 0 {
+		return errors.New("presentation definition must have input descriptors")
+	}
+
+	ids := make(map[string]bool)
+	fieldIDs := make(map[string]bool)
+
+	for _, d := range pd.InputDescriptors {
+		if d.ID == "" {
+			return errors.New("input descriptor id is required")
+		}
+
+		if ids[d.ID] {
+			return fmt.Errorf("duplicate input descriptor id: %s", d.ID)
+		}
+
+		ids[d.ID] = true
+
+		if err := d.validateFields(fieldIDs); err != nil {
+			return fmt.Errorf("input descriptor %s: %w", d.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// Evaluate evaluates the presentation_submission of the JSON-LD presentation against the input descriptors.
+// Every input descriptor is evaluated, the error is returned only if the presentation couldn't be evaluated.
+func (pd *PresentationDefinition) Evaluate(vpBytes []byte) ([]*DescriptorResult, error) {
+	var vp map[string]interface{}
+
+	if err := json.Unmarshal(vpBytes, &vp); err != nil {
+		return nil, fmt.Errorf("presentation must be a JSON object: %w", err)
+	}
+
+	submission, err := getSubmission(vp)
+	if err != nil {
+		return nil, err
+	}
+
+	return pd.evaluate(vp, submission)
+}
+
+// EvaluateSubmission evaluates the presentation submission passed outside of the JSON-LD presentation
+// (e.g. the OpenID4VP presentation_submission parameter) against the input descriptors.
+func (pd *PresentationDefinition) EvaluateSubmission(vpBytes []byte,
+	submission *PresentationSubmission) ([]*DescriptorResult, error) {
+	var vp map[string]interface{}
+
+	if err := json.Unmarshal(vpBytes, &vp); err != nil {
+		return nil, fmt.Errorf("presentation must be a JSON object: %w", err)
+	}
+
+	return pd.evaluate(vp, submission)
+}
+
+func (pd *PresentationDefinition) evaluate(vp map[string]interface{},
+	submission *PresentationSubmission) ([]*DescriptorResult, error) {
+	if submission.DefinitionID != pd.ID {
+		return nil, fmt.Errorf("presentation submission is made for definition %s, expected %s",
+			submission.DefinitionID, pd.ID)
+	}
+
+	results := make([]*DescriptorResult, 0, len(pd.InputDescriptors))
+
+	for _, d := range pd.InputDescriptors {
+		result := &DescriptorResult{ID: d.ID}
+
+		values, err := d.evaluate(vp, submission)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Satisfied = true
+			result.Values = values
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func getSubmission(vp map[string]interface{}) (*PresentationSubmission, error) {
+	raw, ok := vp[submissionField]
+	if !ok {
+		return nil, fmt.Errorf("%s is missing", submissionField)
+	}
+
+	submissionBytes, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", submissionField, err)
+	}
+
+	submission := &PresentationSubmission{}
+
+	if err := json.Unmarshal(submissionBytes, submission); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", submissionField, err)
+	}
+
+	return submission, nil
+}
+
+func (d *InputDescriptor) validateFields(ids map[string]bool) error {
+	if d.Constraints == nil {
+		return nil
+	}
+
+	for _, f := range d.Constraints.Fields {
+		if len(f.Path) == 0 {
+			return errors.New("field path is required")
+		}
+
+		if f.ID != "" {
+			if ids[f.ID] {
+				return fmt.Errorf("duplicate field id: %s", f.ID)
+			}
+
+			ids[f.ID] = true
+		}
+
+		for _, p := range f.Path {
+			if _, err := parsePath(p); err != nil {
+				return err
+			}
+		}
+
+		if len(f.Filter) == 0 {
+			continue
+		}
+
+		if _, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(f.Filter)); err != nil {
+			return fmt.Errorf("invalid field filter: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// evaluate checks that at least one of the credentials submitted for the descriptor satisfies it and returns
+// the field values of the first one
+func (d *InputDescriptor) evaluate(vp map[string]interface{},
+	submission *PresentationSubmission) (map[string]interface{}, error) {
+	var errs []string
+
+	for _, m := range submission.DescriptorMap {
+		if m.ID != d.ID {
+			continue
+		}
+
+		values, err := d.evaluateMapping(vp, m)
+		if err == nil {
+			return values, nil
+		}
+
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) == 0 {
+		return nil, errors.New("no credential submitted")
+	}
+
+	return nil, errors.New(strings.Join(errs, "; "))
+}
+
+// evaluateMapping evaluates the credential the mapping points to, following the nested paths
+func (d *InputDescriptor) evaluateMapping(root map[string]interface{},
+	m *InputDescriptorMapping) (map[string]interface{}, error) {
+	if m.PathNested == nil {
+		return d.evaluateCredential(root, m.Path)
+	}
+
+	nodes, err := evaluatePath(root, m.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nodes) != 1 {
+		return nil, fmt.Errorf("path %s must select exactly one object", m.Path)
+	}
+
+	nested, ok := nodes[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value at %s is not a JSON object", m.Path)
+	}
+
+	return d.evaluateMapping(nested, m.PathNested)
+}
+
+func (d *InputDescriptor) evaluateCredential(vp map[string]interface{}, path string) (map[string]interface{}, error) {
+	nodes, err := evaluatePath(vp, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nodes) != 1 {
+		return nil, fmt.Errorf("path %s must select exactly one credential", path)
+	}
+
+	vc, ok := nodes[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("credential at %s is not a JSON-LD credential", path)
+	}
+
+	return d.match(vc)
+}
+
+// Match checks the JSON-LD credential satisfies the input descriptor, e.g. when the holder selects
+// the credentials submitted for the presentation definition.
+func (d *InputDescriptor) Match(vcBytes []byte) error {
+	var vc map[string]interface{}
+
+	if err := json.Unmarshal(vcBytes, &vc); err != nil {
+		return fmt.Errorf("credential must be a JSON object: %w", err)
+	}
+
+	_, err := d.match(vc)
+
+	return err
+}
+
+// match checks the credential satisfies the descriptor and returns the values of the fields with IDs
+func (d *InputDescriptor) match(vc map[string]interface{}) (map[string]interface{}, error) {
+	if err := d.matchSchema(vc); err != nil {
+		return nil, err
+	}
+
+	if d.Constraints == nil {
+		return nil, nil
+	}
+
+	var values map[string]interface{}
+
+	for _, f := range d.Constraints.Fields {
+		value, err := f.evaluate(vc)
+		if err != nil {
+			return nil, err
+		}
+
+		if f.ID == "" {
+			continue
+		}
+
+		if values == nil {
+			values = make(map[string]interface{})
+		}
+
+		values[f.ID] = value
+	}
+
+	return values, nil
+}
+
+// matchSchema checks that the credential matches any of the descriptor schemas and all the required ones
+func (d *InputDescriptor) matchSchema(vc map[string]interface{}) error {
+	if len(d.Schema) == 0 {
+		return nil
+	}
+
+	uris := credentialURIs(vc)
+	matched := false
+
+	for _, s := range d.Schema {
+		if matchURI(uris, s.URI) {
+			matched = true
+
+			continue
+		}
+
+		if s.Required {
+			return fmt.Errorf("credential doesn't match required schema %s", s.URI)
+		}
+	}
+
+	if !matched {
+		return errors.New("credential doesn't match any schema")
+	}
+
+	return nil
+}
+
+// evaluate returns the first value of the field paths matching the filter
+func (f *Field) evaluate(vc map[string]interface{}) (interface{}, error) {
+	for _, p := range f.Path {
+		values, err := evaluatePath(vc, p)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		if len(f.Filter) == 0 {
+			return values[0], nil
+		}
+
+		for _, v := range values {
+			if matchFilter(f.Filter, v) {
+				return v, nil
+			}
+		}
+
+		return nil, fmt.Errorf("field %s doesn't match the filter", p)
+	}
+
+	return nil, fmt.Errorf("field %s is missing", strings.Join(f.Path, ", "))
+}
+
+func matchFilter(filter json.RawMessage, value interface{}) bool {
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(filter), gojsonschema.NewGoLoader(value))
+
+	return err == nil && result.Valid()
+}
+
+// credentialURIs returns the contexts, types and credentialSchema IDs of the credential
+func credentialURIs(vc map[string]interface{}) []string {
+	var uris []string
+
+	uris = append(uris, stringValues(vc["@context"])...)
+	uris = append(uris, stringValues(vc["type"])...)
+
+	switch s := vc["credentialSchema"].(type) {
+	case map[string]interface{}:
+		uris = append(uris, stringValues(s["id"])...)
+	case []interface{}:
+		for _, e := range s {
+			if m, ok := e.(map[string]interface{}); ok {
+				uris = append(uris, stringValues(m["id"])...)
+			}
+		}
+	}
+
+	return uris
+}
+
+// matchURI matches the schema URI with the credential URIs, type is matched by URI fragment
+// (e.g. https://www.w3.org/2018/credentials#VerifiableCredential)
+func matchURI(uris []string, uri string) bool {
+	fragment := ""
+	if i := strings.LastIndex(uri, "#"); i != -1 {
+		fragment = uri[i+1:]
+	}
+
+	for _, u := range uris {
+		if u == uri || (fragment != "" && u == fragment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func stringValues(v interface{}) []string {
+	switch value := v.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var values []string
+
+		for _, e := range value {
+			if s, ok := e.(string); ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
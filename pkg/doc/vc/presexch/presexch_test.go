@@ -0,0 +1,262 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presexch
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	definition = `{
+  "id": "32f54163-7166-48f1-93d8-ff217bdb0653",
+  "input_descriptors": [
+    {
+      "id": "degree",
+      "schema": [{"uri": "https://www.w3.org/2018/credentials/examples/v1"}],
+      "constraints": {
+        "fields": [
+          {
+            "path": ["$.credentialSubject.degree.type", "$.credentialSubject.degreeType"],
+            "filter": {"type": "string", "pattern": "Degree$"}
+          }
+        ]
+      }
+    },
+    {
+      "id": "residence",
+      "schema": [{"uri": "https://w3id.org/citizenship#PermanentResidentCard", "required": true}]
+    }
+  ]
+}`
+
+	presentation = `{
+  "@context": ["https://www.w3.org/2018/credentials/v1"],
+  "type": "VerifiablePresentation",
+  "presentation_submission": {
+    "id": "a30e3b91-fb77-4d22-95fa-871689c322e2",
+    "definition_id": "32f54163-7166-48f1-93d8-ff217bdb0653",
+    "descriptor_map": [
+      {"id": "degree", "format": "ldp_vc", "path": "$.verifiableCredential[0]"},
+      {"id": "residence", "format": "ldp_vc", "path": "$.verifiableCredential[1]"}
+    ]
+  },
+  "verifiableCredential": [
+    {
+      "@context": ["https://www.w3.org/2018/credentials/v1", "https://www.w3.org/2018/credentials/examples/v1"],
+      "type": ["VerifiableCredential", "UniversityDegreeCredential"],
+      "credentialSubject": {"degree": {"type": "BachelorDegree"}}
+    },
+    {
+      "@context": ["https://www.w3.org/2018/credentials/v1", "https://w3id.org/citizenship/v1"],
+      "type": ["VerifiableCredential", "PermanentResidentCard"],
+      "credentialSubject": {"givenName": "John"}
+    }
+  ]
+}`
+)
+
+func TestPresentationDefinition_Evaluate(t *testing.T) {
+	pd := parseDefinition(t, definition)
+	require.NoError(t, pd.Validate())
+
+	t.Run("test success - all descriptors are satisfied", func(t *testing.T) {
+		results, err := pd.Evaluate([]byte(presentation))
+		require.NoError(t, err)
+		require.Equal(t, []*DescriptorResult{
+			{ID: "degree", Satisfied: true},
+			{ID: "residence", Satisfied: true},
+		}, results)
+	})
+
+	t.Run("test success - descriptors are not satisfied", func(t *testing.T) {
+		vp := strings.Replace(presentation, `"BachelorDegree"`, `"Bachelor"`, 1)
+		vp = strings.Replace(vp, `"PermanentResidentCard"]`, `"DriversLicense"]`, 1)
+
+		results, err := pd.Evaluate([]byte(vp))
+		require.NoError(t, err)
+		require.Equal(t, []*DescriptorResult{
+			{ID: "degree", Error: "field $.credentialSubject.degree.type doesn't match the filter"},
+			{ID: "residence", Error: "credential doesn't match required schema " +
+				"https://w3id.org/citizenship#PermanentResidentCard"},
+		}, results)
+	})
+
+	t.Run("test success - credentials are not submitted or invalid", func(t *testing.T) {
+		vp := strings.Replace(presentation, `"id": "residence"`, `"id": "other"`, 1)
+		vp = strings.Replace(vp, `"$.verifiableCredential[0]"`, `"$.verifiableCredential[5]"`, 1)
+
+		results, err := pd.Evaluate([]byte(vp))
+		require.NoError(t, err)
+		require.Equal(t, []*DescriptorResult{
+			{ID: "degree", Error: "path $.verifiableCredential[5] must select exactly one credential"},
+			{ID: "residence", Error: "no credential submitted"},
+		}, results)
+	})
+
+	t.Run("test success - field is missing", func(t *testing.T) {
+		vp := strings.Replace(presentation, `"degree": {"type": "BachelorDegree"}`, `"name": "Jayden"`, 1)
+
+		results, err := pd.Evaluate([]byte(vp))
+		require.NoError(t, err)
+		require.Equal(t, "field $.credentialSubject.degree.type, $.credentialSubject.degreeType is missing",
+			results[0].Error)
+	})
+
+	t.Run("test success - values of the fields with IDs", func(t *testing.T) {
+		withIDs := parseDefinition(t, strings.Replace(definition, `"path": ["$.credentialSubject.degree.type"`,
+			`"id": "degreeType", "path": ["$.credentialSubject.degree.type"`, 1))
+		require.NoError(t, withIDs.Validate())
+
+		results, err := withIDs.Evaluate([]byte(presentation))
+		require.NoError(t, err)
+		require.Equal(t, []*DescriptorResult{
+			{ID: "degree", Satisfied: true, Values: map[string]interface{}{"degreeType": "BachelorDegree"}},
+			{ID: "residence", Satisfied: true},
+		}, results)
+	})
+
+	t.Run("test error - invalid presentation", func(t *testing.T) {
+		_, err := pd.Evaluate([]byte("eyJhbGciOiJub25lIn0.e30."))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "presentation must be a JSON object")
+
+		_, err = pd.Evaluate([]byte(`{"type": "VerifiablePresentation"}`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "presentation_submission is missing")
+
+		_, err = pd.Evaluate([]byte(`{"presentation_submission": "submission"}`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid presentation_submission")
+
+		_, err = pd.Evaluate([]byte(strings.Replace(presentation,
+			`"definition_id": "32f54163-7166-48f1-93d8-ff217bdb0653"`, `"definition_id": "other"`, 1)))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "presentation submission is made for definition other")
+	})
+}
+
+func TestPresentationDefinition_EvaluateSubmission(t *testing.T) {
+	pd := parseDefinition(t, definition)
+
+	submission := &PresentationSubmission{
+		DefinitionID: pd.ID,
+		DescriptorMap: []*InputDescriptorMapping{
+			{
+				ID: "degree", Format: "ldp_vp", Path: "$",
+				PathNested: &InputDescriptorMapping{ID: "degree", Format: "ldp_vc", Path: "$.verifiableCredential[0]"},
+			},
+			{
+				ID: "residence", Format: "ldp_vp", Path: "$.verifiableCredential",
+				PathNested: &InputDescriptorMapping{ID: "residence", Format: "ldp_vc", Path: "$[1]"},
+			},
+		},
+	}
+
+	t.Run("test success - nested paths", func(t *testing.T) {
+		results, err := pd.EvaluateSubmission([]byte(presentation), submission)
+		require.NoError(t, err)
+		require.Equal(t, []*DescriptorResult{
+			{ID: "degree", Satisfied: true},
+			{ID: "residence", Error: "value at $.verifiableCredential is not a JSON object"},
+		}, results)
+	})
+
+	t.Run("test success - nested path doesn't select object", func(t *testing.T) {
+		results, err := pd.EvaluateSubmission([]byte(presentation), &PresentationSubmission{
+			DefinitionID: pd.ID,
+			DescriptorMap: []*InputDescriptorMapping{{
+				ID: "degree", Path: "$.verifiableCredential[*]",
+				PathNested: &InputDescriptorMapping{ID: "degree", Path: "$"},
+			}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "path $.verifiableCredential[*] must select exactly one object", results[0].Error)
+	})
+
+	t.Run("test error - invalid presentation", func(t *testing.T) {
+		_, err := pd.EvaluateSubmission([]byte("eyJhbGciOiJub25lIn0.e30."), submission)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "presentation must be a JSON object")
+
+		_, err = pd.EvaluateSubmission([]byte(presentation), &PresentationSubmission{DefinitionID: "other"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "presentation submission is made for definition other")
+	})
+}
+
+func TestInputDescriptor_Match(t *testing.T) {
+	pd := parseDefinition(t, definition)
+
+	var vp struct {
+		Credentials []json.RawMessage `json:"verifiableCredential"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(presentation), &vp))
+
+	require.NoError(t, pd.InputDescriptors[0].Match(vp.Credentials[0]))
+	require.NoError(t, pd.InputDescriptors[1].Match(vp.Credentials[1]))
+
+	err := pd.InputDescriptors[0].Match(vp.Credentials[1])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "credential doesn't match any schema")
+
+	err = pd.InputDescriptors[1].Match(vp.Credentials[0])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "credential doesn't match required schema")
+
+	err = pd.InputDescriptors[0].Match([]byte("eyJhbGciOiJub25lIn0.e30."))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "credential must be a JSON object")
+}
+
+func TestPresentationDefinition_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		err        string
+	}{
+		{name: "missing id", definition: `{}`, err: "presentation definition id is required"},
+		{name: "missing descriptors", definition: `{"id": "1"}`, err: "must have input descriptors"},
+		{name: "missing descriptor id", definition: `{"id": "1", "input_descriptors": [{}]}`,
+			err: "input descriptor id is required"},
+		{name: "duplicate descriptor id", definition: `{"id": "1", "input_descriptors": [{"id": "a"}, {"id": "a"}]}`,
+			err: "duplicate input descriptor id: a"},
+		{name: "missing field path",
+			definition: `{"id": "1", "input_descriptors": [{"id": "a", "constraints": {"fields": [{}]}}]}`,
+			err:        "input descriptor a: field path is required"},
+		{name: "invalid field path",
+			definition: `{"id": "1", "input_descriptors": [{"id": "a", "constraints": {"fields": [{"path": ["a"]}]}}]}`,
+			err:        "must start with $"},
+		{name: "duplicate field id",
+			definition: `{"id": "1", "input_descriptors": [{"id": "a", "constraints": {"fields": ` +
+				`[{"id": "f", "path": ["$.a"]}]}}, {"id": "b", "constraints": {"fields": [{"id": "f", "path": ["$.b"]}]}}]}`,
+			err: "input descriptor b: duplicate field id: f"},
+		{name: "invalid filter",
+			definition: `{"id": "1", "input_descriptors": [{"id": "a", "constraints": {"fields": ` +
+				`[{"path": ["$.a"], "filter": {"type": 1}}]}}]}`,
+			err: "invalid field filter"},
+	}
+
+	for _, tc := range tests {
+		err := parseDefinition(t, tc.definition).Validate()
+		require.Error(t, err, tc.name)
+		require.Contains(t, err.Error(), tc.err, tc.name)
+	}
+}
+
+func parseDefinition(t *testing.T, definition string) *PresentationDefinition {
+	t.Helper()
+
+	pd := &PresentationDefinition{}
+	require.NoError(t, json.Unmarshal([]byte(definition), pd))
+
+	return pd
+}
@@ -13,14 +13,26 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 
 	"github.com/trustbloc/edge-core/pkg/storage"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
 )
 
 const (
 	keyPattern       = "%s_%s_%s"
 	profileKeyPrefix = "profile"
 
-	issuerMode = "issuer"
-	holderMode = "holder"
+	issuerMode   = "issuer"
+	holderMode   = "holder"
+	verifierMode = "verifier"
+)
+
+const (
+	// CredentialIDUUID policy assigns urn:uuid:<uuid> IDs to the credentials issued without an ID
+	CredentialIDUUID = "uuid"
+	// CredentialIDURI policy assigns <profile URI>/<uuid> IDs to the credentials issued without an ID
+	CredentialIDURI = "uri"
+	// CredentialIDClient policy requires the clients to supply the credential IDs
+	CredentialIDClient = "client"
 )
 
 // New returns new credential recorder instance
@@ -46,6 +58,35 @@ type DataProfile struct {
 	DIDKeyType              string                             `json:"didKeyType"`
 	DisableVCStatus         bool                               `json:"disableVCStatus"`
 	OverwriteIssuer         bool                               `json:"overwriteIssuer"`
+	VCDataModel             string                             `json:"vcDataModel,omitempty"`
+	Webhook                 *Webhook                           `json:"webhook,omitempty"`
+	// CredentialIDPolicy of the credentials issued without an ID, the IDs are left as is if empty
+	CredentialIDPolicy string `json:"credentialIDPolicy,omitempty"`
+	// RefreshService adds the refresh service of the profile to the issued credentials
+	RefreshService bool `json:"refreshService,omitempty"`
+	// CredentialTemplates of the credentials offered to the wallets
+	CredentialTemplates []*CredentialTemplate `json:"credentialTemplates,omitempty"`
+}
+
+// CredentialTemplate describes the credentials offered by the profile, the offered credentials are composed
+// of the template types and contexts with the claims of the offer. The template is published as the credential
+// manifest, the presentation definition describes the inputs of the credential applications.
+type CredentialTemplate struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Types       []string `json:"types"`
+	Context     []string `json:"@context,omitempty"`
+	// Schema is the JSON schema of the credential subject added to the credentialSchema of the credentials
+	Schema                 string                           `json:"schema,omitempty"`
+	PresentationDefinition *presexch.PresentationDefinition `json:"presentationDefinition,omitempty"`
+}
+
+// Webhook is notified when the asynchronous jobs of the profile are completed, the notification is signed
+// with the secret.
+type Webhook struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"`
 }
 
 // HolderProfile struct for holder profile
@@ -60,6 +101,17 @@ type HolderProfile struct {
 	Created                 *time.Time                         `json:"created"`
 }
 
+// VerifierProfile struct for verifier profile
+type VerifierProfile struct {
+	Name                   string                           `json:"name"`
+	PresentationDefinition *presexch.PresentationDefinition `json:"presentationDefinition,omitempty"`
+	DID                    string                           `json:"did,omitempty"`
+	Creator                string                           `json:"creator,omitempty"`
+	DIDKeyType             string                           `json:"didKeyType,omitempty"`
+	DIDPrivateKey          string                           `json:"didPrivateKey,omitempty"`
+	Created                *time.Time                       `json:"created"`
+}
+
 // SaveProfile saves issuer profile to underlying store
 func (c *Profile) SaveProfile(data *DataProfile) error {
 	bytes, err := json.Marshal(data)
@@ -114,6 +166,33 @@ func (c *Profile) GetHolderProfile(name string) (*HolderProfile, error) {
 	return response, nil
 }
 
+// SaveVerifierProfile saves verifier profile to the underlying store.
+func (c *Profile) SaveVerifierProfile(data *VerifierProfile) error {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("save verifier profile : %s", err.Error())
+	}
+
+	return c.store.Put(getDBKey(verifierMode, data.Name), bytes)
+}
+
+// GetVerifierProfile retrieves the verifier profile based on name.
+func (c *Profile) GetVerifierProfile(name string) (*VerifierProfile, error) {
+	bytes, err := c.store.Get(getDBKey(verifierMode, name))
+	if err != nil {
+		return nil, err
+	}
+
+	response := &VerifierProfile{}
+
+	err = json.Unmarshal(bytes, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
 func getDBKey(mode, name string) string {
 	return fmt.Sprintf(keyPattern, profileKeyPrefix, mode, name)
 }
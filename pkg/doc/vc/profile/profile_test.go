@@ -14,6 +14,8 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/stretchr/testify/require"
 	mockstorage "github.com/trustbloc/edge-core/pkg/storage/mockstore"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
 )
 
 func TestCredentialRecord_SaveProfile(t *testing.T) {
@@ -171,3 +173,58 @@ func TestGetHolder(t *testing.T) {
 		require.Nil(t, resp)
 	})
 }
+
+func TestVerifierProfile(t *testing.T) {
+	t.Run("test save and get verifier - success", func(t *testing.T) {
+		s := make(map[string][]byte)
+
+		profileStore := New(&mockstorage.MockStore{Store: s})
+		require.NotNil(t, profileStore)
+
+		created := time.Now().UTC()
+
+		verifierProfile := &VerifierProfile{
+			Name: "verifier-1",
+			PresentationDefinition: &presexch.PresentationDefinition{
+				ID:               "definition-1",
+				InputDescriptors: []*presexch.InputDescriptor{{ID: "degree"}},
+			},
+			Created: &created,
+		}
+
+		err := profileStore.SaveVerifierProfile(verifierProfile)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(s))
+
+		resp, err := profileStore.GetVerifierProfile(verifierProfile.Name)
+		require.NoError(t, err)
+		require.Equal(t, verifierProfile.PresentationDefinition, resp.PresentationDefinition)
+		require.True(t, created.Equal(*resp.Created))
+	})
+
+	t.Run("test save verifier - fail", func(t *testing.T) {
+		profileStore := New(&mockstorage.MockStore{Store: make(map[string][]byte), ErrPut: errors.New("put error")})
+
+		err := profileStore.SaveVerifierProfile(&VerifierProfile{Name: "verifier-1"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "put error")
+	})
+
+	t.Run("test get verifier - fail", func(t *testing.T) {
+		s := make(map[string][]byte)
+
+		profileStore := New(&mockstorage.MockStore{Store: s})
+
+		resp, err := profileStore.GetVerifierProfile("verifier-1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "store does not have a value associated with this key")
+		require.Nil(t, resp)
+
+		s[getDBKey(verifierMode, "verifier-1")] = []byte("invalid-data")
+
+		resp, err = profileStore.GetVerifierProfile("verifier-1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid character")
+		require.Nil(t, resp)
+	})
+}
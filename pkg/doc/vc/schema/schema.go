@@ -0,0 +1,393 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package schema
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	log "github.com/sirupsen/logrus"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/trustbloc/edge-service/pkg/internal/common/fetch"
+)
+
+const (
+	// JSONSchemaValidator2018 credentialSchema type validating credentialSubject against JSON Schema
+	JSONSchemaValidator2018 = "JsonSchemaValidator2018"
+
+	subjectField = "credentialSubject"
+
+	defaultMaxCacheSize = 100
+	// max size of the fetched schema
+	maxSchemaSize = 1 << 20
+)
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// ValidationError is returned when credentialSubject doesn't match the schema.
+type ValidationError struct {
+	SchemaID   string
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("credentialSubject doesn't match schema %s: %s", e.SchemaID,
+		strings.Join(e.Violations, "; "))
+}
+
+// Option configures the validator
+type Option func(v *Validator)
+
+// WithHTTPClient sets the client used to fetch remote schemas instead of the restricted client of the validator,
+// the client is responsible for checking the redirects and the addresses it connects to
+func WithHTTPClient(client httpClient) Option {
+	return func(v *Validator) {
+		v.httpClient = client
+	}
+}
+
+// WithTLSConfig sets the TLS config of the restricted client fetching remote schemas
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(v *Validator) {
+		v.tlsConfig = tlsConfig
+	}
+}
+
+// WithSchema pins the local copy of the schema, it is used instead of fetching the schema by its ID
+func WithSchema(id string, schema []byte) Option {
+	return func(v *Validator) {
+		v.pinned[id] = schema
+	}
+}
+
+// WithAllowedHosts restricts the hosts the schemas are fetched from, e.g. "schemas.example.com" or
+// "schemas.example.com:8443". The allowed hosts may be internal hosts otherwise refused by the validator.
+func WithAllowedHosts(hosts ...string) Option {
+	return func(v *Validator) {
+		v.allowedHosts = append(v.allowedHosts, hosts...)
+	}
+}
+
+// WithMaxCacheSize sets the max number of the cached schemas, the least recently used schemas are evicted.
+func WithMaxCacheSize(size int) Option {
+	return func(v *Validator) {
+		v.maxCacheSize = size
+	}
+}
+
+// Validator validates credentials against their credentialSchema, fetched schemas are cached.
+// Schema IDs are credential data, so only https schemas of the allowed hosts (or of the public hosts if no hosts
+// are allowed explicitly) are fetched, the same applies to the schemas referenced with $ref.
+type Validator struct {
+	httpClient   httpClient
+	tlsConfig    *tls.Config
+	pinned       map[string][]byte
+	allowedHosts []string
+	maxCacheSize int
+
+	mutex sync.Mutex
+	cache map[string]*list.Element
+	lru   *list.List
+}
+
+type cacheEntry struct {
+	id     string
+	schema *gojsonschema.Schema
+}
+
+// New returns new credential schema validator
+func New(opts ...Option) *Validator {
+	v := &Validator{
+		pinned:       make(map[string][]byte),
+		maxCacheSize: defaultMaxCacheSize,
+		cache:        make(map[string]*list.Element),
+		lru:          list.New(),
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	if v.httpClient == nil {
+		v.httpClient = fetch.New(fetch.WithTLSConfig(v.tlsConfig), fetch.WithAllowedHosts(v.allowedHosts...))
+	}
+
+	return v
+}
+
+// Validate validates credentialSubject against every JsonSchemaValidator2018 schema of the credential,
+// schemas of other types are ignored.
+func (v *Validator) Validate(vc *verifiable.Credential) error {
+	if len(vc.Schemas) == 0 {
+		return nil
+	}
+
+	vcBytes, err := vc.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal credential: %w", err)
+	}
+
+	var doc map[string]json.RawMessage
+
+	if err = json.Unmarshal(vcBytes, &doc); err != nil {
+		return fmt.Errorf("failed to unmarshal credential: %w", err)
+	}
+
+	subjects, err := credentialSubjects(doc[subjectField])
+	if err != nil {
+		return err
+	}
+
+	for _, s := range vc.Schemas {
+		if s.Type != JSONSchemaValidator2018 {
+			continue
+		}
+
+		schema, err := v.getSchema(s.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, subject := range subjects {
+			if err := validateSubject(schema, s.ID, subject); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) getSchema(id string) (*gojsonschema.Schema, error) {
+	if schema, ok := v.getCached(id); ok {
+		return schema, nil
+	}
+
+	schemaBytes, err := v.loadSchema(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// the referenced schemas are loaded by the validator, the default gojsonschema loaders fetch any URL
+	schema, err := gojsonschema.NewSchema(&bytesLoader{
+		JSONLoader: gojsonschema.NewBytesLoader(schemaBytes),
+		v:          v,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential schema %s: %w", id, err)
+	}
+
+	v.putCached(id, schema)
+
+	return schema, nil
+}
+
+// loadSchema returns the pinned schema or fetches the schema by its ID
+func (v *Validator) loadSchema(id string) ([]byte, error) {
+	if schemaBytes, ok := v.pinned[id]; ok {
+		return schemaBytes, nil
+	}
+
+	return v.fetchSchema(id)
+}
+
+// schemaLoaderFactory creates the loaders of the schemas referenced with $ref
+type schemaLoaderFactory struct {
+	v *Validator
+}
+
+func (f *schemaLoaderFactory) New(source string) gojsonschema.JSONLoader {
+	return &refLoader{JSONLoader: gojsonschema.NewReferenceLoader(source), v: f.v}
+}
+
+// bytesLoader loads the schema from bytes, the referenced schemas are loaded by the validator
+type bytesLoader struct {
+	gojsonschema.JSONLoader
+	v *Validator
+}
+
+func (l *bytesLoader) LoaderFactory() gojsonschema.JSONLoaderFactory {
+	return &schemaLoaderFactory{v: l.v}
+}
+
+// refLoader loads the schema referenced with $ref, the schema is pinned or fetched by the validator
+type refLoader struct {
+	gojsonschema.JSONLoader
+	v *Validator
+}
+
+func (l *refLoader) LoaderFactory() gojsonschema.JSONLoaderFactory {
+	return &schemaLoaderFactory{v: l.v}
+}
+
+func (l *refLoader) LoadJSON() (interface{}, error) {
+	source, ok := l.JsonSource().(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid schema reference %v", l.JsonSource())
+	}
+
+	schemaBytes, err := l.v.loadSchema(strings.SplitN(source, "#", 2)[0])
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(schemaBytes))
+	decoder.UseNumber()
+
+	var document interface{}
+
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid credential schema %s: %w", source, err)
+	}
+
+	return document, nil
+}
+
+func (v *Validator) getCached(id string) (*gojsonschema.Schema, bool) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	elem, ok := v.cache[id]
+	if !ok {
+		return nil, false
+	}
+
+	v.lru.MoveToFront(elem)
+
+	return elem.Value.(*cacheEntry).schema, true
+}
+
+func (v *Validator) putCached(id string, schema *gojsonschema.Schema) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if v.maxCacheSize <= 0 {
+		return
+	}
+
+	if elem, ok := v.cache[id]; ok {
+		elem.Value.(*cacheEntry).schema = schema
+		v.lru.MoveToFront(elem)
+
+		return
+	}
+
+	v.cache[id] = v.lru.PushFront(&cacheEntry{id: id, schema: schema})
+
+	for v.lru.Len() > v.maxCacheSize {
+		oldest := v.lru.Back()
+		v.lru.Remove(oldest)
+		delete(v.cache, oldest.Value.(*cacheEntry).id)
+	}
+}
+
+// checkSchemaURL checks the schema could be fetched, i.e. it's https URL of the allowed host or of the public host
+func (v *Validator) checkSchemaURL(id string) error {
+	u, err := url.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid credential schema ID %s: %w", id, err)
+	}
+
+	if err := fetch.CheckURL(u, v.allowedHosts); err != nil {
+		return fmt.Errorf("credential schema %s is not fetched: %w", id, err)
+	}
+
+	return nil
+}
+
+func (v *Validator) fetchSchema(id string) ([]byte, error) {
+	if err := v.checkSchemaURL(id); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, id, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch credential schema %s: %w", id, err)
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch credential schema %s: %w", id, err)
+	}
+
+	defer func() {
+		err = resp.Body.Close()
+		if err != nil {
+			log.Warn("failed to close response body")
+		}
+	}()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSchemaSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential schema %s: %w", id, err)
+	}
+
+	if len(body) > maxSchemaSize {
+		return nil, fmt.Errorf("credential schema %s exceeds %d bytes", id, maxSchemaSize)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch credential schema %s: status %d", id, resp.StatusCode)
+	}
+
+	return body, nil
+}
+
+func validateSubject(schema *gojsonschema.Schema, schemaID string, subject json.RawMessage) error {
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(subject))
+	if err != nil {
+		return fmt.Errorf("failed to validate credentialSubject against schema %s: %w", schemaID, err)
+	}
+
+	if result.Valid() {
+		return nil
+	}
+
+	violations := make([]string, 0, len(result.Errors()))
+
+	for _, e := range result.Errors() {
+		field := subjectField
+		if e.Field() != gojsonschema.STRING_CONTEXT_ROOT {
+			field += "." + e.Field()
+		}
+
+		violations = append(violations, fmt.Sprintf("%s: %s", field, e.Description()))
+	}
+
+	return &ValidationError{SchemaID: schemaID, Violations: violations}
+}
+
+func credentialSubjects(raw json.RawMessage) ([]json.RawMessage, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%s is missing", subjectField)
+	}
+
+	if raw[0] != '[' {
+		return []json.RawMessage{raw}, nil
+	}
+
+	var subjects []json.RawMessage
+
+	if err := json.Unmarshal(raw, &subjects); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", subjectField, err)
+	}
+
+	return subjects, nil
+}
@@ -0,0 +1,325 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/trustbloc/edge-service/pkg/internal/common/fetch"
+)
+
+const (
+	degreeSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {
+    "id": {"type": "string"},
+    "name": {"type": "string"},
+    "degree": {
+      "type": "object",
+      "properties": {"type": {"type": "string"}},
+      "required": ["type"]
+    }
+  },
+  "required": ["name", "degree"]
+}`
+
+	vcTemplate = `{
+  "@context": ["https://www.w3.org/2018/credentials/v1"],
+  "id": "http://example.edu/credentials/1872",
+  "type": "VerifiableCredential",
+  "issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+  "issuanceDate": "2010-01-01T19:23:24Z",
+  "credentialSchema": {"id": "%s", "type": "%s"},
+  "credentialSubject": %s
+}`
+
+	validSubject   = `{"id": "did:example:ebfeb1f712ebc6f1c276e12ec21", "name": "Jayden Doe", "degree": {"type": "BS"}}`
+	invalidSubject = `{"id": "did:example:ebfeb1f712ebc6f1c276e12ec21", "degree": {"type": 1}}`
+)
+
+type mockHTTPClient struct {
+	err error
+}
+
+func (c *mockHTTPClient) Do(*http.Request) (*http.Response, error) {
+	return nil, c.err
+}
+
+func TestValidator_Validate(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		switch r.URL.Path {
+		case "/degree.json":
+			_, err := w.Write([]byte(degreeSchema))
+			require.NoError(t, err)
+		case "/large.json":
+			_, err := w.Write([]byte(`{"description": "` + strings.Repeat("a", maxSchemaSize) + `"}`))
+			require.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	schemaURL := server.URL + "/degree.json"
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	// the test server is the internal host allowed explicitly
+	newValidator := func(opts ...Option) *Validator {
+		return New(append([]Option{WithHTTPClient(server.Client()), WithAllowedHosts(serverURL.Host)}, opts...)...)
+	}
+
+	t.Run("test success - remote schema is fetched once", func(t *testing.T) {
+		v := newValidator()
+
+		require.NoError(t, v.Validate(parseVC(t, schemaURL, JSONSchemaValidator2018, validSubject)))
+		require.NoError(t, v.Validate(parseVC(t, schemaURL, JSONSchemaValidator2018,
+			"["+validSubject+","+validSubject+"]")))
+		require.Equal(t, 1, requests)
+	})
+
+	t.Run("test success - pinned schema", func(t *testing.T) {
+		v := New(WithSchema("https://example.com/schemas/degree.json", []byte(degreeSchema)),
+			WithHTTPClient(&mockHTTPClient{err: errors.New("no network")}))
+
+		require.NoError(t, v.Validate(parseVC(t, "https://example.com/schemas/degree.json",
+			JSONSchemaValidator2018, validSubject)))
+	})
+
+	t.Run("test success - no or unsupported schema", func(t *testing.T) {
+		v := New(WithHTTPClient(&mockHTTPClient{err: errors.New("no network")}))
+
+		require.NoError(t, v.Validate(&verifiable.Credential{}))
+		require.NoError(t, v.Validate(parseVC(t, schemaURL, "ZkpExampleSchema2018", invalidSubject)))
+	})
+
+	t.Run("test error - schema violations", func(t *testing.T) {
+		err := newValidator().Validate(parseVC(t, schemaURL, JSONSchemaValidator2018, invalidSubject))
+		require.Error(t, err)
+
+		validationErr := &ValidationError{}
+		require.True(t, errors.As(err, &validationErr))
+		require.Equal(t, schemaURL, validationErr.SchemaID)
+		require.ElementsMatch(t, []string{
+			"credentialSubject: name is required",
+			"credentialSubject.degree.type: Invalid type. Expected: string, given: integer",
+		}, validationErr.Violations)
+		require.Contains(t, err.Error(), "credentialSubject doesn't match schema "+schemaURL)
+	})
+
+	t.Run("test error - fetch schema", func(t *testing.T) {
+		err := newValidator().Validate(parseVC(t, server.URL+"/missing.json", JSONSchemaValidator2018, validSubject))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "status 404")
+
+		err = newValidator(WithHTTPClient(&mockHTTPClient{err: errors.New("no network")})).
+			Validate(parseVC(t, schemaURL, JSONSchemaValidator2018, validSubject))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no network")
+
+		err = newValidator().Validate(parseVC(t, server.URL+"/large.json", JSONSchemaValidator2018, validSubject))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "exceeds 1048576 bytes")
+	})
+
+	t.Run("test error - schema host is not allowed", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			schemaID string
+			opts     []Option
+			err      string
+		}{
+			{name: "http schema", schemaID: "http://example.com/degree.json", err: "only https URLs are supported"},
+			{name: "file schema", schemaID: "file:///etc/passwd", err: "only https URLs are supported"},
+			{name: "loopback", schemaID: schemaURL, err: "host " + serverURL.Host + " is internal"},
+			{name: "localhost", schemaID: "https://localhost/degree.json", err: "host localhost is internal"},
+			{name: "private network", schemaID: "https://10.0.0.1/degree.json", err: "host 10.0.0.1 is internal"},
+			{name: "link local", schemaID: "https://169.254.169.254/latest", err: "host 169.254.169.254 is internal"},
+			{name: "not allowed", schemaID: "https://example.com/degree.json",
+				opts: []Option{WithAllowedHosts("schemas.example.com")},
+				err:  "host example.com is not allowed"},
+		}
+
+		for _, tc := range tests {
+			v := New(append([]Option{WithHTTPClient(&mockHTTPClient{err: errors.New("no network")})}, tc.opts...)...)
+
+			err := v.Validate(parseVC(t, tc.schemaID, JSONSchemaValidator2018, validSubject))
+			require.Error(t, err, tc.name)
+			require.Contains(t, err.Error(), tc.err, tc.name)
+		}
+
+		// public host
+		err := New(WithHTTPClient(&mockHTTPClient{err: errors.New("no network")})).
+			Validate(parseVC(t, "https://example.com/degree.json", JSONSchemaValidator2018, validSubject))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no network")
+	})
+
+	t.Run("test success - least recently used schemas are evicted", func(t *testing.T) {
+		v := New(WithMaxCacheSize(2))
+
+		v.putCached("https://example.com/1", &gojsonschema.Schema{})
+		v.putCached("https://example.com/2", &gojsonschema.Schema{})
+
+		_, ok := v.getCached("https://example.com/1")
+		require.True(t, ok)
+
+		v.putCached("https://example.com/3", &gojsonschema.Schema{})
+
+		require.Equal(t, 2, v.lru.Len())
+
+		_, ok = v.getCached("https://example.com/2")
+		require.False(t, ok)
+
+		_, ok = v.getCached("https://example.com/1")
+		require.True(t, ok)
+
+		v = New(WithMaxCacheSize(0))
+		v.putCached("https://example.com/1", &gojsonschema.Schema{})
+		require.Equal(t, 0, v.lru.Len())
+	})
+
+	t.Run("test error - invalid schema", func(t *testing.T) {
+		err := newValidator(WithSchema(schemaURL, []byte(`{"type": 1}`))).
+			Validate(parseVC(t, schemaURL, JSONSchemaValidator2018, validSubject))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid credential schema")
+	})
+}
+
+func TestValidator_RestrictedFetch(t *testing.T) {
+	internalRequests := 0
+
+	// the internal server isn't allowed
+	internal := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internalRequests++
+
+		_, err := w.Write([]byte(degreeSchema))
+		require.NoError(t, err)
+	}))
+	defer internal.Close()
+
+	var serverURL string
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var schema string
+
+		switch r.URL.Path {
+		case "/degree.json":
+			schema = degreeSchema
+		case "/ref.json":
+			schema = `{"$ref": "` + serverURL + `/degree.json"}`
+		case "/internal-ref.json":
+			schema = `{"properties": {"degree": {"$ref": "` + internal.URL + `/degree.json#/properties/degree"}}}`
+		case "/http-ref.json":
+			schema = `{"properties": {"degree": {"$ref": "http://example.com/degree.json"}}}`
+		case "/redirect.json":
+			http.Redirect(w, r, internal.URL+"/degree.json", http.StatusFound)
+
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, err := w.Write([]byte(schema))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	serverURL = server.URL
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	tlsConfig := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	newValidator := func() *Validator {
+		return New(WithTLSConfig(tlsConfig), WithAllowedHosts(u.Host))
+	}
+
+	t.Run("test success - referenced schema of the allowed host", func(t *testing.T) {
+		v := newValidator()
+
+		require.NoError(t, v.Validate(parseVC(t, server.URL+"/ref.json", JSONSchemaValidator2018, validSubject)))
+
+		err := v.Validate(parseVC(t, server.URL+"/ref.json", JSONSchemaValidator2018, invalidSubject))
+		require.Error(t, err)
+		require.True(t, errors.As(err, new(*ValidationError)))
+	})
+
+	t.Run("test success - referenced schema is pinned", func(t *testing.T) {
+		v := New(WithTLSConfig(tlsConfig), WithAllowedHosts(u.Host),
+			WithSchema(internal.URL+"/degree.json", []byte(degreeSchema)))
+
+		require.NoError(t, v.Validate(parseVC(t, server.URL+"/internal-ref.json", JSONSchemaValidator2018,
+			validSubject)))
+		require.Equal(t, 0, internalRequests)
+	})
+
+	t.Run("test error - referenced schema is not fetched", func(t *testing.T) {
+		err := newValidator().Validate(parseVC(t, server.URL+"/internal-ref.json", JSONSchemaValidator2018,
+			validSubject))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "host "+strings.TrimPrefix(internal.URL, "https://")+" is not allowed")
+
+		err = newValidator().Validate(parseVC(t, server.URL+"/http-ref.json", JSONSchemaValidator2018,
+			validSubject))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "only https URLs are supported")
+
+		require.Equal(t, 0, internalRequests)
+	})
+
+	t.Run("test error - redirect to the internal host", func(t *testing.T) {
+		err := newValidator().Validate(parseVC(t, server.URL+"/redirect.json", JSONSchemaValidator2018,
+			validSubject))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "redirect to "+internal.URL+"/degree.json is refused")
+		require.Equal(t, 0, internalRequests)
+	})
+
+	t.Run("test error - host name resolves to the loopback address", func(t *testing.T) {
+		v := New(WithHTTPClient(fetch.New(fetch.WithTLSConfig(tlsConfig),
+			fetch.WithLookupIP(func(context.Context, string) ([]net.IP, error) {
+				return []net.IP{net.ParseIP("127.0.0.1")}, nil
+			}))))
+
+		err := v.Validate(parseVC(t, "https://schemas.example.com:"+u.Port()+"/degree.json",
+			JSONSchemaValidator2018, validSubject))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "host schemas.example.com resolves to the internal address 127.0.0.1")
+	})
+}
+
+func parseVC(t *testing.T, schemaID, schemaType, subject string) *verifiable.Credential {
+	t.Helper()
+
+	vc, err := verifiable.NewUnverifiedCredential([]byte(fmt.Sprintf(vcTemplate, schemaID, schemaType, subject)))
+	require.NoError(t, err)
+
+	return vc
+}
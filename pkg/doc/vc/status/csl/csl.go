@@ -11,13 +11,14 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
-	"strings"
+	"sync"
 
 	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/trustbloc/edge-core/pkg/storage"
 
+	"github.com/trustbloc/edge-service/pkg/doc/vc/datamodel"
 	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
 )
 
@@ -44,6 +45,7 @@ type crypto interface {
 
 // CredentialStatusManager implement spec https://w3c-ccg.github.io/vc-csl2017/
 type CredentialStatusManager struct {
+	mutex    sync.Mutex
 	store    storage.Store
 	url      string
 	listSize int
@@ -57,6 +59,34 @@ type CSL struct {
 	VC          []string `json:"verifiableCredential"`
 }
 
+// HasEntry returns true if the list has the status entry of the credential with the ID.
+func (c *CSL) HasEntry(vcID string) bool {
+	if vcID == "" {
+		return false
+	}
+
+	for _, vc := range c.VC {
+		if entryID(vc) == vcID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// entryID returns the ID of the credential the status list entry is created for
+func entryID(entry string) string {
+	vc := &struct {
+		ID string `json:"id"`
+	}{}
+
+	if err := json.Unmarshal([]byte(entry), vc); err != nil {
+		return ""
+	}
+
+	return vc.ID
+}
+
 // cslWrapper contain csl and metadata
 type cslWrapper struct {
 	CSL  *CSL   `json:"csl"`
@@ -89,31 +119,78 @@ func New(provider storage.Provider, url string, listSize int, c crypto) (*Creden
 
 // CreateStatusID create status id
 func (c *CredentialStatusManager) CreateStatusID() (*verifiable.TypedID, error) {
-	cslWrapper, err := c.getLatestCSL()
+	statusIDs, err := c.CreateStatusIDs(1)
 	if err != nil {
 		return nil, err
 	}
 
-	cslWrapper.Size++
+	return statusIDs[0].Status, nil
+}
+
+// StatusID is the credential status allocated in the status list.
+type StatusID struct {
+	Status *verifiable.TypedID
+	// Index of the credential in the status list
+	Index int
+}
+
+// CreateStatusIDs creates status ids for n credentials, the ids are allocated in a block
+// so every list is updated once.
+func (c *CredentialStatusManager) CreateStatusIDs(n int) ([]*StatusID, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
-	if err := c.storeCSL(cslWrapper); err != nil {
+	w, err := c.getLatestCSL()
+	if err != nil {
 		return nil, err
 	}
 
-	if cslWrapper.Size == c.listSize {
-		id, err := strconv.Atoi(cslWrapper.ID)
-		if err != nil {
+	statusIDs := make([]*StatusID, 0, n)
+
+	for len(statusIDs) < n {
+		block := c.listSize - w.Size
+		if remaining := n - len(statusIDs); block > remaining {
+			block = remaining
+		}
+
+		first := w.Size
+		w.Size += block
+
+		if err := c.storeCSL(w); err != nil {
 			return nil, err
 		}
 
-		id++
+		for i := first; i < w.Size; i++ {
+			statusIDs = append(statusIDs, &StatusID{
+				Status: &verifiable.TypedID{ID: w.CSL.ID, Type: CredentialStatusType},
+				Index:  i,
+			})
+		}
 
-		if err := c.store.Put(latestListID, []byte(strconv.FormatInt(int64(id), 10))); err != nil {
-			return nil, fmt.Errorf("failed to store latest list ID in store: %w", err)
+		if w.Size == c.listSize {
+			if w, err = c.nextCSL(w); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	return &verifiable.TypedID{ID: cslWrapper.CSL.ID, Type: CredentialStatusType}, nil
+	return statusIDs, nil
+}
+
+// nextCSL makes the list following the full one the latest list
+func (c *CredentialStatusManager) nextCSL(full *cslWrapper) (*cslWrapper, error) {
+	id, err := strconv.Atoi(full.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	listID := strconv.Itoa(id + 1)
+
+	if err := c.store.Put(latestListID, []byte(listID)); err != nil {
+		return nil, fmt.Errorf("failed to store latest list ID in store: %w", err)
+	}
+
+	return &cslWrapper{&CSL{ID: c.url + "/" + listID}, 0, listID}, nil
 }
 
 // UpdateVCStatus update vc status
@@ -140,7 +217,7 @@ func (c *CredentialStatusManager) UpdateVCStatus(v *verifiable.Credential, profi
 	}
 
 	for i, vc := range cslWrapper.CSL.VC {
-		if strings.Contains(vc, v.ID) {
+		if entryID(vc) == v.ID {
 			cslWrapper.CSL.VC = append(cslWrapper.CSL.VC[:i], cslWrapper.CSL.VC[i+1:]...)
 			break
 		}
@@ -190,6 +267,20 @@ func (c *CredentialStatusManager) createStatusCredential(v *verifiable.Credentia
 		return nil, fmt.Errorf("create credential marshalling failed: %s", err.Error())
 	}
 
+	if datamodel.IsV2(v) {
+		// VC Data Model 2.0 isn't covered by the JSON schema of the 1.1 base context
+		statusCred, err := verifiable.NewUnverifiedCredential(cred)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create new credential: %s", err.Error())
+		}
+
+		if err := datamodel.Validate(statusCred); err != nil {
+			return nil, fmt.Errorf("failed to create new credential: %s", err.Error())
+		}
+
+		return statusCred, nil
+	}
+
 	validatedStatusCred, _, err := verifiable.NewCredential(cred)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new credential: %s", err.Error())
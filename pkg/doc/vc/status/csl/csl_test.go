@@ -19,6 +19,7 @@ import (
 
 	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
 	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/jsonld"
 	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
 )
 
@@ -48,6 +49,23 @@ const (
     "spouse": "did:example:c276e12ec21ebfeb1f712ebc6f1"
   }
 }`
+
+	universityDegreeCredV2 = `{
+  "@context": [
+    "https://www.w3.org/ns/credentials/v2"
+  ],
+  "type": [
+    "VerifiableCredential",
+    "UniversityDegreeCredential"
+  ],
+  "id": "http://example.gov/credentials/3732",
+  "validFrom": "2020-03-16T22:37:26Z",
+  "issuer": "did:example:oakek12as93mas91220dapop092",
+  "credentialSubject": {
+    "id": "did:example:ebfeb1f712ebc6f1c276e12ec21",
+    "name": "Jayden Doe"
+  }
+}`
 )
 
 func TestCredentialStatusList_New(t *testing.T) {
@@ -162,6 +180,58 @@ func TestCredentialStatusList_CreateStatusID(t *testing.T) {
 	})
 }
 
+func TestCredentialStatusList_CreateStatusIDs(t *testing.T) {
+	t.Run("test success", func(t *testing.T) {
+		s, err := New(mockstore.NewMockStoreProvider(), "localhost:8080/status", 2,
+			vccrypto.New(&kms.KeyManager{}, &cryptomock.Crypto{}))
+		require.NoError(t, err)
+
+		_, err = s.CreateStatusID()
+		require.NoError(t, err)
+
+		statusIDs, err := s.CreateStatusIDs(4)
+		require.NoError(t, err)
+		require.Len(t, statusIDs, 4)
+
+		for i, expected := range []struct {
+			id    string
+			index int
+		}{{"1", 1}, {"2", 0}, {"2", 1}, {"3", 0}} {
+			require.Equal(t, CredentialStatusType, statusIDs[i].Status.Type)
+			require.Equal(t, "localhost:8080/status/"+expected.id, statusIDs[i].Status.ID)
+			require.Equal(t, expected.index, statusIDs[i].Index)
+		}
+
+		status, err := s.CreateStatusID()
+		require.NoError(t, err)
+		require.Equal(t, "localhost:8080/status/3", status.ID)
+
+		status, err = s.CreateStatusID()
+		require.NoError(t, err)
+		require.Equal(t, "localhost:8080/status/4", status.ID)
+	})
+
+	t.Run("test error from store csl list in store", func(t *testing.T) {
+		s, err := New(&storeProvider{store: &mockStore{getFunc: func(k string) (bytes []byte, err error) {
+			return nil, storage.ErrValueNotFound
+		},
+			putFunc: func(k string, v []byte) error {
+				if k == "localhost:8080/status/2" {
+					return fmt.Errorf("put error")
+				}
+				return nil
+			},
+		}}, "localhost:8080/status", 2,
+			vccrypto.New(&kms.KeyManager{}, &cryptomock.Crypto{}))
+		require.NoError(t, err)
+
+		statusIDs, err := s.CreateStatusIDs(3)
+		require.Error(t, err)
+		require.Nil(t, statusIDs)
+		require.Contains(t, err.Error(), "failed to store csl in store")
+	})
+}
+
 func TestCredentialStatusList_GetCSL(t *testing.T) {
 	t.Run("test error getting csl from store", func(t *testing.T) {
 		s, err := New(&storeProvider{store: &mockStore{getFunc: func(k string) (bytes []byte, err error) {
@@ -176,6 +246,18 @@ func TestCredentialStatusList_GetCSL(t *testing.T) {
 	})
 }
 
+func TestCSL_HasEntry(t *testing.T) {
+	csl := &CSL{VC: []string{
+		`{"id":"http://example.edu/credentials/10","credentialSubject":{"currentStatus":"revoked"}}`,
+		`invalid`,
+	}}
+
+	require.True(t, csl.HasEntry("http://example.edu/credentials/10"))
+	require.False(t, csl.HasEntry("http://example.edu/credentials/1"))
+	require.False(t, csl.HasEntry("credentials/10"))
+	require.False(t, csl.HasEntry(""))
+}
+
 func TestCredentialStatusList_UpdateVCStatus(t *testing.T) {
 	t.Run("test success", func(t *testing.T) {
 		s, err := New(mockstore.NewMockStoreProvider(), "localhost:8080/status", 2,
@@ -204,6 +286,40 @@ func TestCredentialStatusList_UpdateVCStatus(t *testing.T) {
 		}
 	})
 
+	t.Run("test success - VC data model 2.0", func(t *testing.T) {
+		s, err := New(mockstore.NewMockStoreProvider(), "localhost:8080/status", 2,
+			vccrypto.New(&kms.KeyManager{}, &cryptomock.Crypto{}, vccrypto.WithDocumentLoader(jsonld.DocumentLoader())))
+		require.NoError(t, err)
+
+		status, err := s.CreateStatusID()
+		require.NoError(t, err)
+
+		cred, err := verifiable.NewUnverifiedCredential([]byte(universityDegreeCredV2))
+		require.NoError(t, err)
+
+		cred.Status = status
+		cred.Proofs = []verifiable.Proof{{"type": vccrypto.DataIntegrityProof, "proofPurpose": "assertionMethod",
+			"verificationMethod": "did:test:abc#key1", "proofValue": "z123"}}
+
+		profile := getTestProfile()
+		profile.SignatureType = vccrypto.DataIntegrityProof
+
+		require.NoError(t, s.UpdateVCStatus(cred, profile, "Revoked", "Disciplinary action"))
+
+		csl, err := s.GetCSL(status.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(csl.VC))
+		require.Contains(t, csl.VC[0], "validFrom")
+		require.Contains(t, csl.VC[0], "Revoked")
+		require.Contains(t, csl.VC[0], vccrypto.DataIntegrityProof)
+
+		cred.CustomFields["validFrom"] = "invalid"
+
+		err = s.UpdateVCStatus(cred, profile, "Revoked", "Disciplinary action")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to create new credential: invalid validFrom")
+	})
+
 	t.Run("test error get csl from store", func(t *testing.T) {
 		s, err := New(&storeProvider{store: &mockStore{getFunc: func(k string) (bytes []byte, err error) {
 			return nil, fmt.Errorf("get error")
@@ -0,0 +1,228 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vpr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+const (
+	// QueryByExampleType is the query of the credentials matching the example
+	QueryByExampleType = "QueryByExample"
+	// DIDAuthType is the query of the presentation authenticating the holder DID
+	DIDAuthType = "DIDAuth"
+)
+
+// Request is the presentation request of the verifier (W3C CCG Verifiable Presentation Request).
+type Request struct {
+	Query     []*Query `json:"query"`
+	Challenge string   `json:"challenge,omitempty"`
+	Domain    string   `json:"domain,omitempty"`
+}
+
+// Query of the presentation request.
+type Query struct {
+	Type            string            `json:"type"`
+	CredentialQuery CredentialQueries `json:"credentialQuery,omitempty"`
+}
+
+// CredentialQueries are the credential queries of QueryByExample, passed as an object or an array.
+type CredentialQueries []*CredentialQuery
+
+// CredentialQuery describes the requested credential by example, the credential is optional unless Required.
+type CredentialQuery struct {
+	Reason        string           `json:"reason,omitempty"`
+	Required      bool             `json:"required,omitempty"`
+	Example       *Example         `json:"example"`
+	TrustedIssuer []*TrustedIssuer `json:"trustedIssuer,omitempty"`
+}
+
+// Example of the requested credential, the credential must have the contexts and types of the example,
+// and one of the credential subjects must have the properties of the example subject.
+type Example struct {
+	Context           interface{}            `json:"@context,omitempty"`
+	Type              interface{}            `json:"type,omitempty"`
+	CredentialSubject map[string]interface{} `json:"credentialSubject,omitempty"`
+}
+
+// TrustedIssuer is the issuer the requested credential must be issued by.
+type TrustedIssuer struct {
+	Issuer string `json:"issuer"`
+}
+
+// UnmarshalJSON unmarshals the credential query object or array.
+func (q *CredentialQueries) UnmarshalJSON(data []byte) error {
+	var queries []*CredentialQuery
+
+	if err := json.Unmarshal(data, &queries); err == nil {
+		*q = queries
+
+		return nil
+	}
+
+	query := &CredentialQuery{}
+
+	if err := json.Unmarshal(data, query); err != nil {
+		return fmt.Errorf("invalid credential query: %w", err)
+	}
+
+	*q = CredentialQueries{query}
+
+	return nil
+}
+
+// Validate checks the presentation request is well formed.
+func (r *Request) Validate() error {
+	if len(r.Query) == 0 {
+		return errors.New("presentation request query is required")
+	}
+
+	for _, q := range r.Query {
+		switch q.Type {
+		case DIDAuthType:
+		case QueryByExampleType:
+			if len(q.CredentialQuery) == 0 {
+				return fmt.Errorf("%s must have credential queries", QueryByExampleType)
+			}
+
+			for _, cq := range q.CredentialQuery {
+				if cq.Example == nil {
+					return errors.New("credential query example is required")
+				}
+			}
+		default:
+			return fmt.Errorf("unsupported query type: %s", q.Type)
+		}
+	}
+
+	return nil
+}
+
+// CredentialQueries returns the credential queries of the QueryByExample queries.
+func (r *Request) CredentialQueries() []*CredentialQuery {
+	var queries []*CredentialQuery
+
+	for _, q := range r.Query {
+		if q.Type == QueryByExampleType {
+			queries = append(queries, q.CredentialQuery...)
+		}
+	}
+
+	return queries
+}
+
+// Match checks the JSON-LD credential matches the example and is issued by one of the trusted issuers.
+func (q *CredentialQuery) Match(vcBytes []byte) bool {
+	var vc map[string]interface{}
+
+	if err := json.Unmarshal(vcBytes, &vc); err != nil {
+		return false
+	}
+
+	if !containsAll(stringValues(vc["@context"]), stringValues(q.Example.Context)) ||
+		!containsAll(stringValues(vc["type"]), stringValues(q.Example.Type)) {
+		return false
+	}
+
+	if len(q.TrustedIssuer) > 0 && !q.trustedIssuer(vc["issuer"]) {
+		return false
+	}
+
+	if len(q.Example.CredentialSubject) == 0 {
+		return true
+	}
+
+	subjects, ok := vc["credentialSubject"].([]interface{})
+	if !ok {
+		subjects = []interface{}{vc["credentialSubject"]}
+	}
+
+	for _, s := range subjects {
+		if matchExample(q.Example.CredentialSubject, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (q *CredentialQuery) trustedIssuer(issuer interface{}) bool {
+	if obj, ok := issuer.(map[string]interface{}); ok {
+		issuer = obj["id"]
+	}
+
+	for _, ti := range q.TrustedIssuer {
+		if ti.Issuer == issuer {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchExample checks the value has the properties of the example object (recursively) or equals the example
+func matchExample(example, value interface{}) bool {
+	exampleObj, ok := example.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(example, value)
+	}
+
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	for k, v := range exampleObj {
+		if !matchExample(v, obj[k]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsAll(values, required []string) bool {
+	for _, r := range required {
+		found := false
+
+		for _, v := range values {
+			if v == r {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stringValues returns the string or the strings of the array
+func stringValues(v interface{}) []string {
+	switch value := v.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var values []string
+
+		for _, e := range value {
+			if s, ok := e.(string); ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vpr
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const credential = `{
+  "@context": ["https://www.w3.org/2018/credentials/v1", "https://www.w3.org/2018/credentials/examples/v1"],
+  "type": ["VerifiableCredential", "UniversityDegreeCredential"],
+  "issuer": {"id": "did:example:76e12ec712ebc6f1c221ebfeb1f", "name": "Example University"},
+  "credentialSubject": [
+    {"id": "did:example:ebfeb1f712ebc6f1c276e12ec21", "degree": {"type": "BachelorDegree", "name": "Bachelor"}},
+    {"id": "did:example:c276e12ec21ebfeb1f712ebc6f1"}
+  ]
+}`
+
+func TestRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request string
+		err     string
+	}{
+		{name: "missing query", request: `{}`, err: "presentation request query is required"},
+		{name: "unsupported query", request: `{"query": [{"type": "QueryByFrame"}]}`,
+			err: "unsupported query type: QueryByFrame"},
+		{name: "missing credential query", request: `{"query": [{"type": "QueryByExample"}]}`,
+			err: "QueryByExample must have credential queries"},
+		{name: "missing example", request: `{"query": [{"type": "QueryByExample", "credentialQuery": {}}]}`,
+			err: "credential query example is required"},
+	}
+
+	for _, tc := range tests {
+		r := &Request{}
+		require.NoError(t, json.Unmarshal([]byte(tc.request), r), tc.name)
+
+		err := r.Validate()
+		require.Error(t, err, tc.name)
+		require.Contains(t, err.Error(), tc.err, tc.name)
+	}
+
+	r := &Request{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"query": [
+			{"type": "DIDAuth"},
+			{"type": "QueryByExample", "credentialQuery": {"example": {"type": "UniversityDegreeCredential"}}},
+			{"type": "QueryByExample", "credentialQuery": [
+				{"example": {"type": "AlumniCredential"}, "required": true},
+				{"example": {"type": "PermanentResidentCard"}}
+			]}
+		],
+		"challenge": "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+		"domain": "example.com"
+	}`), r))
+	require.NoError(t, r.Validate())
+	require.Len(t, r.CredentialQueries(), 3)
+	require.True(t, r.CredentialQueries()[1].Required)
+
+	err := json.Unmarshal([]byte(`{"query": [{"type": "QueryByExample", "credentialQuery": "abc"}]}`), r)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid credential query")
+}
+
+func TestCredentialQuery_Match(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		match bool
+	}{
+		{name: "type", query: `{"example": {"type": "UniversityDegreeCredential"}}`, match: true},
+		{name: "other type", query: `{"example": {"type": ["VerifiableCredential", "AlumniCredential"]}}`},
+		{name: "context", query: `{"example": {"@context": "https://www.w3.org/2018/credentials/examples/v1"}}`,
+			match: true},
+		{name: "other context", query: `{"example": {"@context": ["https://w3id.org/citizenship/v1"]}}`},
+		{name: "subject", query: `{"example": {"credentialSubject": {"id": "did:example:c276e12ec21ebfeb1f712ebc6f1"}}}`,
+			match: true},
+		{name: "nested subject", query: `{"example": {"credentialSubject": {"degree": {"type": "BachelorDegree"}}}}`,
+			match: true},
+		{name: "other subject", query: `{"example": {"credentialSubject": {"degree": {"type": "MasterDegree"}}}}`},
+		{name: "subject not object",
+			query: `{"example": {"credentialSubject": {"degree": {"type": {"name": "BachelorDegree"}}}}}`},
+		{name: "trusted issuer", match: true, query: `{"example": {},
+			"trustedIssuer": [{"issuer": "did:example:other"}, {"issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f"}]}`},
+		{name: "untrusted issuer", query: `{"example": {}, "trustedIssuer": [{"issuer": "did:example:other"}]}`},
+	}
+
+	for _, tc := range tests {
+		q := &CredentialQuery{}
+		require.NoError(t, json.Unmarshal([]byte(tc.query), q), tc.name)
+		require.Equal(t, tc.match, q.Match([]byte(credential)), tc.name)
+	}
+
+	q := &CredentialQuery{Example: &Example{}}
+	require.False(t, q.Match([]byte("eyJhbGciOiJub25lIn0.e30.")))
+	require.True(t, q.Match([]byte(`{"credentialSubject": {"id": "did:example:1"}}`)))
+}
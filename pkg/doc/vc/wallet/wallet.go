@@ -0,0 +1,52 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/trustbloc/edge-core/pkg/storage"
+)
+
+const (
+	keyPattern = "%s_%s_%s"
+	keyPrefix  = "walletremoved"
+)
+
+// New returns new wallet registry instance
+func New(store storage.Store) *Registry {
+	return &Registry{store: store}
+}
+
+// Registry keeps the credentials removed from the holder wallets. The wallet credentials are kept
+// in the EDV documents which can't be deleted, the removed documents are skipped when the wallet is read.
+type Registry struct {
+	store storage.Store
+}
+
+// Remove records the wallet document of the profile as removed
+func (r *Registry) Remove(profile, docID string) error {
+	return r.store.Put(getDBKey(profile, docID), []byte(docID))
+}
+
+// IsRemoved tells whether the wallet document of the profile was removed
+func (r *Registry) IsRemoved(profile, docID string) (bool, error) {
+	_, err := r.store.Get(getDBKey(profile, docID))
+	if err == nil {
+		return true, nil
+	}
+
+	if errors.Is(err, storage.ErrValueNotFound) {
+		return false, nil
+	}
+
+	return false, fmt.Errorf("failed to get removed wallet document: %w", err)
+}
+
+func getDBKey(profile, docID string) string {
+	return fmt.Sprintf(keyPattern, keyPrefix, profile, docID)
+}
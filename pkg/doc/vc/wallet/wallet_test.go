@@ -0,0 +1,47 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package wallet
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	mockstorage "github.com/trustbloc/edge-core/pkg/storage/mockstore"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Run("test remove success", func(t *testing.T) {
+		registry := New(&mockstorage.MockStore{Store: make(map[string][]byte)})
+
+		removed, err := registry.IsRemoved("holder", "doc1")
+		require.NoError(t, err)
+		require.False(t, removed)
+
+		require.NoError(t, registry.Remove("holder", "doc1"))
+
+		removed, err = registry.IsRemoved("holder", "doc1")
+		require.NoError(t, err)
+		require.True(t, removed)
+
+		removed, err = registry.IsRemoved("other", "doc1")
+		require.NoError(t, err)
+		require.False(t, removed)
+	})
+
+	t.Run("test store errors", func(t *testing.T) {
+		registry := New(&mockstorage.MockStore{Store: make(map[string][]byte),
+			ErrPut: errors.New("put error"), ErrGet: errors.New("get error")})
+
+		err := registry.Remove("holder", "doc1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "put error")
+
+		_, err = registry.IsRemoved("holder", "doc1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get error")
+	})
+}
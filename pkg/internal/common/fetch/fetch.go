@@ -0,0 +1,222 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fetch
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// max number of the redirects followed by the client
+	maxRedirects = 10
+
+	dialTimeout = 30 * time.Second
+)
+
+// private and shared IPv4 and IPv6 address ranges (RFC 1918, RFC 6598, RFC 4193)
+var privateNetworks = []string{ // nolint: gochecknoglobals
+	"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7",
+}
+
+// LookupIPFunc resolves the host name to its IP addresses
+type LookupIPFunc func(ctx context.Context, host string) ([]net.IP, error)
+
+// Option configures the client
+type Option func(c *Client)
+
+// WithTLSConfig sets the TLS config of the client
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = tlsConfig
+	}
+}
+
+// WithAllowedHosts restricts the hosts the client sends the requests to, e.g. "example.com" or "example.com:8443".
+// The allowed hosts may be internal hosts otherwise refused by the client.
+func WithAllowedHosts(hosts ...string) Option {
+	return func(c *Client) {
+		c.allowedHosts = append(c.allowedHosts, hosts...)
+	}
+}
+
+// WithLookupIP sets the resolver of the host names, the system resolver is used by default
+func WithLookupIP(lookupIP LookupIPFunc) Option {
+	return func(c *Client) {
+		c.lookupIP = lookupIP
+	}
+}
+
+// Client is the HTTP client of the URLs taken from the request data (e.g. credential schemas, DID configurations,
+// webhooks). Only https URLs of the allowed hosts, or of the public hosts if no hosts are allowed explicitly,
+// are requested. The URLs are checked again on redirects and the resolved addresses of the hosts which
+// aren't allowed explicitly are checked to be public when connecting.
+type Client struct {
+	tlsConfig    *tls.Config
+	allowedHosts []string
+	lookupIP     LookupIPFunc
+
+	client *http.Client
+}
+
+// New returns new restricted HTTP client
+func New(opts ...Option) *Client {
+	c := &Client{lookupIP: lookupIP}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.client = &http.Client{
+		Transport:     &http.Transport{TLSClientConfig: c.tlsConfig, DialContext: c.dialContext},
+		CheckRedirect: c.checkRedirect,
+	}
+
+	return c
+}
+
+// Do checks the request URL and sends the request
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if err := CheckURL(req.URL, c.allowedHosts); err != nil {
+		return nil, err
+	}
+
+	return c.client.Do(req)
+}
+
+func (c *Client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	if err := CheckURL(req.URL, c.allowedHosts); err != nil {
+		return fmt.Errorf("redirect to %s is refused: %w", req.URL, err)
+	}
+
+	return nil
+}
+
+// dialContext connects to the checked address of the host, so the host can't be resolved to another address
+// after the check
+func (c *Client) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if isAllowedHost(c.allowedHosts, addr, host) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	ips, err := c.lookupIP(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("host %s has no address", host)
+	}
+
+	for _, ip := range ips {
+		if IsInternalIP(ip) {
+			return nil, fmt.Errorf("host %s resolves to the internal address %s", host, ip)
+		}
+	}
+
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].String(), port))
+}
+
+// CheckURL checks the URL could be requested, i.e. it's https URL of the allowed host, or of the public host
+// if no hosts are allowed explicitly
+func CheckURL(u *url.URL, allowedHosts []string) error {
+	if u.Scheme != "https" {
+		return errors.New("only https URLs are supported")
+	}
+
+	if len(allowedHosts) > 0 {
+		if isAllowedHost(allowedHosts, u.Host, u.Hostname()) {
+			return nil
+		}
+
+		return fmt.Errorf("host %s is not allowed", u.Host)
+	}
+
+	if IsInternalHost(u.Hostname()) {
+		return fmt.Errorf("host %s is internal", u.Host)
+	}
+
+	return nil
+}
+
+func isAllowedHost(allowedHosts []string, hostPort, host string) bool {
+	for _, allowed := range allowedHosts {
+		if strings.EqualFold(allowed, hostPort) || strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsInternalHost checks the host is the local host name or the IP address not routable in public networks,
+// the host names are not resolved
+func IsInternalHost(host string) bool {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	return IsInternalIP(ip)
+}
+
+// IsInternalIP checks the IP address is not routable in public networks
+func IsInternalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return true
+	}
+
+	for _, cidr := range privateNetworks {
+		_, network, err := net.ParseCIDR(cidr)
+		if err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+
+	return ips, nil
+}
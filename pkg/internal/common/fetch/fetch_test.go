@@ -0,0 +1,137 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		allowedHosts []string
+		err          string
+	}{
+		{name: "public host", url: "https://example.com/doc.json"},
+		{name: "allowed host", url: "https://127.0.0.1:8443/doc.json", allowedHosts: []string{"127.0.0.1:8443"}},
+		{name: "allowed host name", url: "https://10.0.0.1:8443/doc.json", allowedHosts: []string{"10.0.0.1"}},
+		{name: "http", url: "http://example.com/doc.json", err: "only https URLs are supported"},
+		{name: "file", url: "file:///etc/passwd", err: "only https URLs are supported"},
+		{name: "localhost", url: "https://localhost/doc.json", err: "host localhost is internal"},
+		{name: "loopback", url: "https://127.0.0.1:8443/doc.json", err: "host 127.0.0.1:8443 is internal"},
+		{name: "IPv6 loopback", url: "https://[::1]/doc.json", err: "host [::1] is internal"},
+		{name: "private network", url: "https://192.168.1.1/doc.json", err: "host 192.168.1.1 is internal"},
+		{name: "link local", url: "https://169.254.169.254/latest", err: "host 169.254.169.254 is internal"},
+		{name: "not allowed", url: "https://example.com/doc.json", allowedHosts: []string{"docs.example.com"},
+			err: "host example.com is not allowed"},
+	}
+
+	for _, tc := range tests {
+		u, err := url.Parse(tc.url)
+		require.NoError(t, err, tc.name)
+
+		err = CheckURL(u, tc.allowedHosts)
+		if tc.err == "" {
+			require.NoError(t, err, tc.name)
+
+			continue
+		}
+
+		require.Error(t, err, tc.name)
+		require.Contains(t, err.Error(), tc.err, tc.name)
+	}
+}
+
+func TestClient_Do(t *testing.T) {
+	requests := 0
+
+	internal := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer internal.Close()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, internal.URL, http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	tlsConfig := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	loopback := func(context.Context, string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("127.0.0.1")}, nil
+	}
+
+	t.Run("test success - allowed host", func(t *testing.T) {
+		resp, err := New(WithTLSConfig(tlsConfig), WithAllowedHosts(u.Host)).Do(newRequest(t, server.URL))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("test error - URL is checked", func(t *testing.T) {
+		_, err := New().Do(newRequest(t, server.URL))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is internal")
+	})
+
+	t.Run("test error - redirect to the host which isn't allowed", func(t *testing.T) {
+		_, err := New(WithTLSConfig(tlsConfig), WithAllowedHosts(u.Host)).Do(newRequest(t, server.URL+"/redirect"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "redirect to "+internal.URL+" is refused")
+		require.Equal(t, 0, requests)
+	})
+
+	t.Run("test error - host name resolves to the internal address", func(t *testing.T) {
+		_, err := New(WithTLSConfig(tlsConfig), WithLookupIP(loopback)).
+			Do(newRequest(t, "https://docs.example.com:"+u.Port()))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "host docs.example.com resolves to the internal address 127.0.0.1")
+
+		_, err = New(WithLookupIP(func(context.Context, string) ([]net.IP, error) {
+			return []net.IP{net.ParseIP("8.8.8.8"), net.ParseIP("10.1.1.1")}, nil
+		})).Do(newRequest(t, "https://docs.example.com"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "resolves to the internal address 10.1.1.1")
+	})
+
+	t.Run("test error - host name lookup", func(t *testing.T) {
+		_, err := New(WithLookupIP(func(context.Context, string) ([]net.IP, error) {
+			return nil, errors.New("lookup error")
+		})).Do(newRequest(t, "https://docs.example.com"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "lookup error")
+
+		_, err = New(WithLookupIP(func(context.Context, string) ([]net.IP, error) {
+			return nil, nil
+		})).Do(newRequest(t, "https://docs.example.com"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "host docs.example.com has no address")
+	})
+}
+
+func newRequest(t *testing.T, u string) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	require.NoError(t, err)
+
+	return req
+}
@@ -0,0 +1,63 @@
+/*
+ Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonld
+
+import (
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/piprate/json-gold/ld"
+)
+
+// Minimal contexts used in tests instead of the remote ones, every undefined term is mapped by @vocab.
+// nolint: gochecknoglobals
+var contexts = map[string]string{
+	"https://www.w3.org/ns/credentials/v2": `{
+  "@context": {
+    "@version": 1.1,
+    "id": "@id",
+    "type": "@type",
+    "@vocab": "https://www.w3.org/ns/credentials/issuer-dependent#",
+    "proof": {"@id": "https://w3id.org/security#proof", "@type": "@id", "@container": "@graph"},
+    "verifiableCredential": {
+      "@id": "https://www.w3.org/2018/credentials#verifiableCredential", "@type": "@id", "@container": "@graph"
+    }
+  }
+}`,
+	"https://w3id.org/security/data-integrity/v1": `{
+  "@context": {
+    "@vocab": "https://w3id.org/security#"
+  }
+}`,
+	"https://trustbloc.github.io/context/vc/examples-v1.jsonld": `{
+  "@context": {
+    "@vocab": "https://trustbloc.github.io/context/vc/examples#"
+  }
+}`,
+	"https://trustbloc.github.io/context/vc/credentials-v1.jsonld": `{
+  "@context": {
+    "@vocab": "https://trustbloc.github.io/context/vc/credentials#"
+  }
+}`,
+}
+
+// DocumentLoader returns JSON-LD document loader which serves the base VC context and test contexts
+// without network access.
+func DocumentLoader() ld.DocumentLoader {
+	loader := verifiable.CachingJSONLDLoader()
+
+	for url, content := range contexts {
+		doc, err := ld.DocumentFromReader(strings.NewReader(content))
+		if err != nil {
+			panic(err)
+		}
+
+		loader.AddDocument(url, doc)
+	}
+
+	return loader
+}
@@ -0,0 +1,522 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package job
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/trustbloc/edge-core/pkg/storage"
+)
+
+const (
+	keyPattern = "%s_%s"
+	keyPrefix  = "job"
+	pendingKey = "job_pending"
+
+	defaultMaxAttempts    = 5
+	defaultBackoff        = time.Second
+	defaultConcurrency    = 10
+	defaultWebhookTimeout = 10 * time.Second
+	maxBackoffShift       = 16
+
+	// SignatureHeader is the header of the webhook request holding the HMAC-SHA256 signature of the body
+	// computed with the webhook secret, e.g. sha256=<hex encoded signature>.
+	SignatureHeader = "X-Webhook-Signature"
+	signaturePrefix = "sha256="
+)
+
+// ErrJobNotFound is returned when the job isn't found.
+var ErrJobNotFound = errors.New("job not found")
+
+// Status of the job.
+type Status string
+
+const (
+	// StatusPending job is waiting to be run (or retried).
+	StatusPending Status = "pending"
+	// StatusRunning job is running.
+	StatusRunning Status = "running"
+	// StatusCompleted job has completed.
+	StatusCompleted Status = "completed"
+	// StatusFailed job has failed after all the attempts.
+	StatusFailed Status = "failed"
+)
+
+// Job is the asynchronous job persisted in the store.
+type Job struct {
+	ID               string          `json:"id"`
+	Type             string          `json:"type"`
+	Request          json.RawMessage `json:"request"`
+	Status           Status          `json:"status"`
+	Attempts         int             `json:"attempts"`
+	Result           json.RawMessage `json:"result,omitempty"`
+	Error            string          `json:"error,omitempty"`
+	WebhookURL       string          `json:"webhookURL,omitempty"`
+	WebhookSecret    string          `json:"webhookSecret,omitempty"`
+	WebhookAttempts  int             `json:"webhookAttempts,omitempty"`
+	WebhookDelivered bool            `json:"webhookDelivered,omitempty"`
+	Created          time.Time       `json:"created"`
+	Updated          time.Time       `json:"updated"`
+}
+
+// Notification is the body of the webhook request sent when the job is completed or failed.
+type Notification struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	Status Status          `json:"status"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Handler runs the job request and returns the job result, the job is retried when an error is returned
+// (unless the handler is registered WithoutRetry).
+type Handler func(request json.RawMessage) (interface{}, error)
+
+// HandlerOption configures the handler of the job type.
+type HandlerOption func(h *handler)
+
+// WithoutRetry is the option of the handlers which aren't idempotent, the job fails on the first error and
+// the job interrupted by the restart fails instead of being run again.
+func WithoutRetry() HandlerOption {
+	return func(h *handler) {
+		h.noRetry = true
+	}
+}
+
+type handler struct {
+	handle  Handler
+	noRetry bool
+}
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Option configures the job manager.
+type Option func(m *Manager)
+
+// WithMaxAttempts sets the number of attempts to run the job and to deliver its webhook.
+func WithMaxAttempts(maxAttempts int) Option {
+	return func(m *Manager) {
+		m.maxAttempts = maxAttempts
+	}
+}
+
+// WithBackoff sets the delay before the first retry, the delay is doubled with every retry.
+func WithBackoff(backoff time.Duration) Option {
+	return func(m *Manager) {
+		m.backoff = backoff
+	}
+}
+
+// WithConcurrency sets the number of jobs run concurrently.
+func WithConcurrency(concurrency int) Option {
+	return func(m *Manager) {
+		m.concurrency = concurrency
+	}
+}
+
+// WithHTTPClient sets the HTTP client delivering the webhooks.
+func WithHTTPClient(client httpClient) Option {
+	return func(m *Manager) {
+		m.httpClient = client
+	}
+}
+
+// WithWebhookTimeout sets the timeout of the webhook request.
+func WithWebhookTimeout(timeout time.Duration) Option {
+	return func(m *Manager) {
+		m.webhookTimeout = timeout
+	}
+}
+
+// WithInstanceID sets the ID of the instance, the instances sharing the store must have distinct IDs
+// so that each of them keeps (and resumes after restart) its own pending jobs.
+func WithInstanceID(id string) Option {
+	return func(m *Manager) {
+		if id != "" {
+			m.pendingKey = fmt.Sprintf(keyPattern, pendingKey, id)
+		}
+	}
+}
+
+// Manager runs the jobs in background, retries the failed jobs with backoff and notifies the job webhooks.
+// Jobs are persisted in the store so the pending ones are resumed by Start after restart.
+// The pending jobs are tracked by the instance, so the instances sharing the store must be given distinct
+// instance IDs (see WithInstanceID), otherwise they overwrite the pending jobs of each other.
+type Manager struct {
+	store          storage.Store
+	handlers       map[string]*handler
+	maxAttempts    int
+	backoff        time.Duration
+	concurrency    int
+	httpClient     httpClient
+	webhookTimeout time.Duration
+	pendingKey     string
+	sem            chan struct{}
+	mutex          sync.Mutex
+}
+
+// New returns new job manager instance.
+func New(store storage.Store, opts ...Option) *Manager {
+	m := &Manager{
+		store:          store,
+		handlers:       make(map[string]*handler),
+		maxAttempts:    defaultMaxAttempts,
+		backoff:        defaultBackoff,
+		concurrency:    defaultConcurrency,
+		httpClient:     &http.Client{},
+		webhookTimeout: defaultWebhookTimeout,
+		pendingKey:     pendingKey,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.sem = make(chan struct{}, m.concurrency)
+
+	return m
+}
+
+// Register registers the handler of the job type, handlers must be registered before Start.
+func (m *Manager) Register(jobType string, h Handler, opts ...HandlerOption) {
+	jobHandler := &handler{handle: h}
+
+	for _, opt := range opts {
+		opt(jobHandler)
+	}
+
+	m.handlers[jobType] = jobHandler
+}
+
+// Start resumes the jobs which were pending (or interrupted) before restart.
+func (m *Manager) Start() error {
+	ids, err := m.pendingIDs()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		go m.run(id)
+	}
+
+	return nil
+}
+
+// Submit persists new job and runs it in background, the webhook is notified when the job is completed or failed.
+func (m *Manager) Submit(jobType string, request interface{}, webhookURL, webhookSecret string) (*Job, error) {
+	if _, ok := m.handlers[jobType]; !ok {
+		return nil, fmt.Errorf("unsupported job type %s", jobType)
+	}
+
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job request: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	j := &Job{
+		ID:            uuid.New().String(),
+		Type:          jobType,
+		Request:       requestBytes,
+		Status:        StatusPending,
+		WebhookURL:    webhookURL,
+		WebhookSecret: webhookSecret,
+		Created:       now,
+		Updated:       now,
+	}
+
+	if err := m.save(j); err != nil {
+		return nil, err
+	}
+
+	if err := m.updatePending(func(ids []string) []string { return append(ids, j.ID) }); err != nil {
+		return nil, err
+	}
+
+	go m.run(j.ID)
+
+	return j, nil
+}
+
+// Get returns the job.
+func (m *Manager) Get(id string) (*Job, error) {
+	jobBytes, err := m.store.Get(getDBKey(id))
+	if err != nil {
+		if errors.Is(err, storage.ErrValueNotFound) {
+			return nil, ErrJobNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	j := &Job{}
+
+	if err := json.Unmarshal(jobBytes, j); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+
+	return j, nil
+}
+
+// run executes the job and notifies its webhook, only the execution is limited by the concurrency
+// so the slow webhooks don't hold up the jobs
+func (m *Manager) run(id string) {
+	j, err := m.Get(id)
+	if err != nil {
+		log.Errorf("failed to run job %s: %s", id, err)
+
+		return
+	}
+
+	if j.Status == StatusPending || j.Status == StatusRunning {
+		m.sem <- struct{}{}
+		executed := m.execute(j)
+		<-m.sem
+
+		if !executed {
+			return
+		}
+	}
+
+	m.notify(j)
+}
+
+// execute runs the job handler, false is returned if the job is scheduled to be retried
+func (m *Manager) execute(j *Job) bool {
+	h := m.handlers[j.Type]
+
+	if h != nil && h.noRetry && j.Status == StatusRunning {
+		// the job was interrupted by the restart and it isn't safe to run it again
+		j.Status = StatusFailed
+		j.Error = "job was interrupted"
+
+		if err := m.save(j); err != nil {
+			log.Errorf("failed to save job %s: %s", j.ID, err)
+		}
+
+		return true
+	}
+
+	j.Attempts++
+	j.Status = StatusRunning
+
+	if err := m.save(j); err != nil {
+		log.Errorf("failed to save job %s: %s", j.ID, err)
+	}
+
+	result, err := m.handle(h, j)
+	if err != nil {
+		log.Warnf("job %s attempt %d failed: %s", j.ID, j.Attempts, err)
+
+		j.Error = err.Error()
+
+		if j.Attempts < m.maxAttempts && h != nil && !h.noRetry {
+			j.Status = StatusPending
+
+			if err := m.save(j); err != nil {
+				log.Errorf("failed to save job %s: %s", j.ID, err)
+			}
+
+			m.retry(j.ID, j.Attempts)
+
+			return false
+		}
+
+		j.Status = StatusFailed
+	} else {
+		j.Status = StatusCompleted
+		j.Result = result
+		j.Error = ""
+	}
+
+	if err := m.save(j); err != nil {
+		log.Errorf("failed to save job %s: %s", j.ID, err)
+	}
+
+	return true
+}
+
+func (m *Manager) handle(h *handler, j *Job) (json.RawMessage, error) {
+	if h == nil {
+		return nil, fmt.Errorf("unsupported job type %s", j.Type)
+	}
+
+	result, err := h.handle(j.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(result)
+}
+
+// notify delivers the webhook of the completed or failed job, the delivery is retried with backoff
+func (m *Manager) notify(j *Job) {
+	if j.WebhookURL != "" && !j.WebhookDelivered {
+		j.WebhookAttempts++
+
+		if err := m.sendWebhook(j); err != nil {
+			log.Warnf("job %s webhook attempt %d failed: %s", j.ID, j.WebhookAttempts, err)
+
+			if j.WebhookAttempts < m.maxAttempts {
+				if err := m.save(j); err != nil {
+					log.Errorf("failed to save job %s: %s", j.ID, err)
+				}
+
+				m.retry(j.ID, j.WebhookAttempts)
+
+				return
+			}
+		} else {
+			j.WebhookDelivered = true
+		}
+
+		if err := m.save(j); err != nil {
+			log.Errorf("failed to save job %s: %s", j.ID, err)
+		}
+	}
+
+	err := m.updatePending(func(ids []string) []string {
+		for i, id := range ids {
+			if id == j.ID {
+				return append(ids[:i], ids[i+1:]...)
+			}
+		}
+
+		return ids
+	})
+	if err != nil {
+		log.Errorf("failed to remove job %s from the pending jobs: %s", j.ID, err)
+	}
+}
+
+func (m *Manager) sendWebhook(j *Job) error {
+	body, err := json.Marshal(&Notification{ID: j.ID, Type: j.Type, Status: j.Status, Result: j.Result,
+		Error: j.Error})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if j.WebhookSecret != "" {
+		req.Header.Set(SignatureHeader, Sign(body, j.WebhookSecret))
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if err := resp.Body.Close(); err != nil {
+		log.Warn("failed to close response body")
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign returns the value of the webhook signature header for the body.
+func Sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body) // nolint: errcheck
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (m *Manager) retry(id string, attempt int) {
+	shift := attempt - 1
+	if shift > maxBackoffShift {
+		shift = maxBackoffShift
+	}
+
+	time.AfterFunc(m.backoff<<uint(shift), func() { m.run(id) })
+}
+
+func (m *Manager) save(j *Job) error {
+	j.Updated = time.Now().UTC()
+
+	jobBytes, err := json.Marshal(j)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	if err := m.store.Put(getDBKey(j.ID), jobBytes); err != nil {
+		return fmt.Errorf("failed to store job: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Manager) pendingIDs() ([]string, error) {
+	idsBytes, err := m.store.Get(m.pendingKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrValueNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get pending jobs: %w", err)
+	}
+
+	var ids []string
+
+	if err := json.Unmarshal(idsBytes, &ids); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pending jobs: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (m *Manager) updatePending(update func(ids []string) []string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	ids, err := m.pendingIDs()
+	if err != nil {
+		return err
+	}
+
+	idsBytes, err := json.Marshal(update(ids))
+	if err != nil {
+		return fmt.Errorf("failed to marshal pending jobs: %w", err)
+	}
+
+	if err := m.store.Put(m.pendingKey, idsBytes); err != nil {
+		return fmt.Errorf("failed to store pending jobs: %w", err)
+	}
+
+	return nil
+}
+
+func getDBKey(id string) string {
+	return fmt.Sprintf(keyPattern, keyPrefix, id)
+}
@@ -0,0 +1,353 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package job
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage"
+	"github.com/trustbloc/edge-core/pkg/storage/mockstore"
+)
+
+const (
+	testJobType = "test"
+	waitFor     = 5 * time.Second
+	tick        = 5 * time.Millisecond
+)
+
+func TestManager(t *testing.T) {
+	newStore := func() *mockstore.MockStore {
+		return &mockstore.MockStore{Store: make(map[string][]byte)}
+	}
+
+	waitForJob := func(t *testing.T, m *Manager, id string, done func(j *Job) bool) *Job {
+		t.Helper()
+
+		var j *Job
+
+		require.Eventually(t, func() bool {
+			var err error
+
+			j, err = m.Get(id)
+			require.NoError(t, err)
+
+			return done(j)
+		}, waitFor, tick)
+
+		return j
+	}
+
+	t.Run("test job completed and signed webhook delivered", func(t *testing.T) {
+		notifications := make(chan *Notification, 1)
+
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			body, err := ioutil.ReadAll(req.Body)
+			require.NoError(t, err)
+			require.Equal(t, Sign(body, "secret"), req.Header.Get(SignatureHeader))
+
+			n := &Notification{}
+			require.NoError(t, json.Unmarshal(body, n))
+
+			notifications <- n
+		}))
+		defer server.Close()
+
+		store := newStore()
+
+		m := New(store)
+		m.Register(testJobType, func(request json.RawMessage) (interface{}, error) {
+			return map[string]json.RawMessage{"request": request}, nil
+		})
+
+		j, err := m.Submit(testJobType, "value", server.URL, "secret")
+		require.NoError(t, err)
+		require.Equal(t, StatusPending, j.Status)
+
+		select {
+		case n := <-notifications:
+			require.Equal(t, j.ID, n.ID)
+			require.Equal(t, testJobType, n.Type)
+			require.Equal(t, StatusCompleted, n.Status)
+			require.JSONEq(t, `{"request": "value"}`, string(n.Result))
+		case <-time.After(waitFor):
+			require.Fail(t, "webhook wasn't delivered")
+		}
+
+		j = waitForJob(t, m, j.ID, func(j *Job) bool { return j.WebhookDelivered })
+		require.Equal(t, 1, j.Attempts)
+		require.Equal(t, 1, j.WebhookAttempts)
+
+		require.Eventually(t, func() bool {
+			ids, err := m.pendingIDs()
+			require.NoError(t, err)
+
+			return len(ids) == 0
+		}, waitFor, tick)
+	})
+
+	t.Run("test job retried with backoff", func(t *testing.T) {
+		var attempts int32
+
+		m := New(newStore(), WithBackoff(time.Millisecond), WithConcurrency(1))
+		m.Register(testJobType, func(request json.RawMessage) (interface{}, error) {
+			if atomic.AddInt32(&attempts, 1) < 3 {
+				return nil, errors.New("temporary error")
+			}
+
+			return "result", nil
+		})
+
+		j, err := m.Submit(testJobType, nil, "", "")
+		require.NoError(t, err)
+
+		j = waitForJob(t, m, j.ID, func(j *Job) bool { return j.Status == StatusCompleted })
+		require.Equal(t, 3, j.Attempts)
+		require.Empty(t, j.Error)
+		require.JSONEq(t, `"result"`, string(j.Result))
+	})
+
+	t.Run("test job failed after max attempts", func(t *testing.T) {
+		var webhookAttempts int32
+
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if atomic.AddInt32(&webhookAttempts, 1) == 1 {
+				rw.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+		defer server.Close()
+
+		m := New(newStore(), WithBackoff(time.Millisecond), WithMaxAttempts(2))
+		m.Register(testJobType, func(request json.RawMessage) (interface{}, error) {
+			return nil, errors.New("permanent error")
+		})
+
+		j, err := m.Submit(testJobType, nil, server.URL, "")
+		require.NoError(t, err)
+
+		j = waitForJob(t, m, j.ID, func(j *Job) bool { return j.WebhookDelivered })
+		require.Equal(t, StatusFailed, j.Status)
+		require.Equal(t, 2, j.Attempts)
+		require.Equal(t, 2, j.WebhookAttempts)
+		require.Equal(t, "permanent error", j.Error)
+	})
+
+	t.Run("test webhook delivery given up", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		m := New(newStore(), WithBackoff(time.Millisecond), WithMaxAttempts(3))
+		m.Register(testJobType, func(request json.RawMessage) (interface{}, error) {
+			return nil, nil
+		})
+
+		j, err := m.Submit(testJobType, nil, server.URL, "")
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			ids, err := m.pendingIDs()
+			require.NoError(t, err)
+
+			return len(ids) == 0
+		}, waitFor, tick)
+
+		j, err = m.Get(j.ID)
+		require.NoError(t, err)
+		require.Equal(t, StatusCompleted, j.Status)
+		require.Equal(t, 3, j.WebhookAttempts)
+		require.False(t, j.WebhookDelivered)
+	})
+
+	t.Run("test pending jobs resumed after restart", func(t *testing.T) {
+		store := newStore()
+
+		m := New(store)
+		require.NoError(t, m.save(&Job{ID: "1", Type: testJobType, Status: StatusRunning, Attempts: 1}))
+		require.NoError(t, m.save(&Job{ID: "2", Type: testJobType, Status: StatusCompleted,
+			WebhookURL: "http://localhost:1", WebhookAttempts: 1}))
+		require.NoError(t, m.updatePending(func([]string) []string { return []string{"1", "2"} }))
+
+		var delivered int32
+
+		m = New(store, WithHTTPClient(&mockHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&delivered, 1)
+
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(nil)}, nil
+		}}))
+		m.Register(testJobType, func(request json.RawMessage) (interface{}, error) {
+			return "resumed", nil
+		})
+		require.NoError(t, m.Start())
+
+		j := waitForJob(t, m, "1", func(j *Job) bool { return j.Status == StatusCompleted })
+		require.Equal(t, 2, j.Attempts)
+		require.JSONEq(t, `"resumed"`, string(j.Result))
+
+		j = waitForJob(t, m, "2", func(j *Job) bool { return j.WebhookDelivered })
+		require.Equal(t, 2, j.WebhookAttempts)
+		require.Equal(t, int32(1), atomic.LoadInt32(&delivered))
+	})
+
+	t.Run("test job without retry", func(t *testing.T) {
+		var attempts int32
+
+		m := New(newStore(), WithBackoff(time.Millisecond))
+		m.Register(testJobType, func(request json.RawMessage) (interface{}, error) {
+			atomic.AddInt32(&attempts, 1)
+
+			return nil, errors.New("not idempotent")
+		}, WithoutRetry())
+
+		j, err := m.Submit(testJobType, nil, "", "")
+		require.NoError(t, err)
+
+		j = waitForJob(t, m, j.ID, func(j *Job) bool { return j.Status == StatusFailed })
+		require.Equal(t, 1, j.Attempts)
+		require.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+		require.Equal(t, "not idempotent", j.Error)
+
+		// the job interrupted by the restart isn't run again
+		require.NoError(t, m.save(&Job{ID: "1", Type: testJobType, Status: StatusRunning, Attempts: 1}))
+		require.NoError(t, m.updatePending(func(ids []string) []string { return append(ids, "1") }))
+		require.NoError(t, m.Start())
+
+		j = waitForJob(t, m, "1", func(j *Job) bool { return j.Status == StatusFailed })
+		require.Equal(t, 1, j.Attempts)
+		require.Equal(t, "job was interrupted", j.Error)
+		require.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("test webhook timeout", func(t *testing.T) {
+		done := make(chan struct{})
+
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			<-done
+		}))
+
+		defer func() {
+			close(done)
+			server.Close()
+		}()
+
+		m := New(newStore(), WithBackoff(time.Millisecond), WithMaxAttempts(2),
+			WithWebhookTimeout(10*time.Millisecond))
+		m.Register(testJobType, func(request json.RawMessage) (interface{}, error) {
+			return nil, nil
+		})
+
+		j, err := m.Submit(testJobType, nil, server.URL, "")
+		require.NoError(t, err)
+
+		j = waitForJob(t, m, j.ID, func(j *Job) bool { return j.WebhookAttempts == 2 })
+		require.False(t, j.WebhookDelivered)
+	})
+
+	t.Run("test webhooks don't hold up the jobs", func(t *testing.T) {
+		done := make(chan struct{})
+		defer close(done)
+
+		m := New(newStore(), WithConcurrency(1),
+			WithHTTPClient(&mockHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+				<-done
+
+				return nil, errors.New("closed")
+			}}))
+		m.Register(testJobType, func(request json.RawMessage) (interface{}, error) {
+			return nil, nil
+		})
+
+		first, err := m.Submit(testJobType, nil, "http://localhost/webhook", "")
+		require.NoError(t, err)
+
+		second, err := m.Submit(testJobType, nil, "", "")
+		require.NoError(t, err)
+
+		waitForJob(t, m, first.ID, func(j *Job) bool { return j.Status == StatusCompleted })
+		waitForJob(t, m, second.ID, func(j *Job) bool { return j.Status == StatusCompleted })
+	})
+
+	t.Run("test pending jobs of the instances", func(t *testing.T) {
+		store := newStore()
+
+		m1 := New(store, WithInstanceID("1"))
+		require.NoError(t, m1.updatePending(func(ids []string) []string { return append(ids, "a") }))
+
+		m2 := New(store, WithInstanceID("2"))
+		require.NoError(t, m2.updatePending(func(ids []string) []string { return append(ids, "b") }))
+
+		ids, err := m1.pendingIDs()
+		require.NoError(t, err)
+		require.Equal(t, []string{"a"}, ids)
+
+		ids, err = m2.pendingIDs()
+		require.NoError(t, err)
+		require.Equal(t, []string{"b"}, ids)
+
+		ids, err = New(store).pendingIDs()
+		require.NoError(t, err)
+		require.Empty(t, ids)
+	})
+
+	t.Run("test unsupported job type", func(t *testing.T) {
+		j, err := New(newStore()).Submit("unsupported", nil, "", "")
+		require.Error(t, err)
+		require.Nil(t, j)
+		require.Contains(t, err.Error(), "unsupported job type unsupported")
+	})
+
+	t.Run("test job not found", func(t *testing.T) {
+		j, err := New(newStore()).Get("1")
+		require.True(t, errors.Is(err, ErrJobNotFound))
+		require.Nil(t, j)
+	})
+
+	t.Run("test store errors", func(t *testing.T) {
+		store := newStore()
+		store.ErrPut = errors.New("put error")
+
+		m := New(store)
+		m.Register(testJobType, func(request json.RawMessage) (interface{}, error) {
+			return nil, nil
+		})
+
+		j, err := m.Submit(testJobType, nil, "", "")
+		require.Error(t, err)
+		require.Nil(t, j)
+		require.Contains(t, err.Error(), "failed to store job: put error")
+
+		store = newStore()
+		store.Store[pendingKey] = []byte("invalid")
+
+		m = New(store)
+		require.Error(t, m.Start())
+
+		store.ErrGet = errors.New("get error")
+		store.Store[getDBKey("1")] = []byte("{}")
+
+		_, err = m.Get("1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get job: get error")
+		require.False(t, errors.Is(err, storage.ErrValueNotFound))
+	})
+}
+
+type mockHTTPClient struct {
+	doFunc func(req *http.Request) (*http.Response, error)
+}
+
+func (c *mockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return c.doFunc(req)
+}
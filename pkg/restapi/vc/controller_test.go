@@ -102,7 +102,7 @@ func TestIssuerController_GetOperations(t *testing.T) {
 
 	ops := controller.GetOperations()
 
-	require.Equal(t, 9, len(ops))
+	require.Equal(t, 27, len(ops))
 }
 
 func TestVerifierController_GetOperations(t *testing.T) {
@@ -121,5 +121,5 @@ func TestVerifierController_GetOperations(t *testing.T) {
 
 	ops := controller.GetOperations()
 
-	require.Equal(t, 3, len(ops))
+	require.Equal(t, 18, len(ops))
 }
@@ -0,0 +1,241 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultBatchConcurrency = 10
+	maxBatchSize            = 1000
+)
+
+// VerifyBatch swagger:route POST /verifier/batch verifier batchVerificationReq
+//
+// Verifies credentials and presentations in batch.
+//
+// Responses:
+//    default: genericError
+//        200: batchVerificationRes
+func (o *Operation) verifyBatchHandler(rw http.ResponseWriter, req *http.Request) {
+	request := &BatchVerificationRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	if len(request.Items) == 0 || len(request.Items) > maxBatchSize {
+		o.writeErrorResponse(rw, http.StatusBadRequest,
+			fmt.Sprintf(invalidRequestErrMsg+": batch must have 1 to %d items", maxBatchSize))
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, &BatchVerificationResponse{Results: o.verifyBatch(request.Items)})
+}
+
+// verifyBatch verifies the items concurrently, DID resolutions and status list lookups are shared
+// within the batch. Results are returned in the order of the items.
+func (o *Operation) verifyBatch(items []*BatchVerificationItem) []*BatchVerificationResult {
+	batchOp := *o
+	batchOp.vdri = &cachingVDRI{Registry: o.vdri, cache: newLookupCache()}
+	batchOp.httpClient = &cachingHTTPClient{client: o.httpClient, cache: newLookupCache()}
+
+	concurrency := o.batchConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	results := make([]*BatchVerificationResult, len(items))
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		wg.Add(1)
+
+		sem <- struct{}{}
+
+		go func(i int, item *BatchVerificationItem) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			results[i] = batchOp.verifyBatchItem(item)
+		}(i, item)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func (o *Operation) verifyBatchItem(item *BatchVerificationItem) *BatchVerificationResult {
+	var (
+		status int
+		resp   interface{}
+		err    error
+	)
+
+	switch {
+	case len(item.Credential) != 0 && len(item.Presentation) != 0:
+		err = errors.New("item must have either credential or presentation")
+	case len(item.Credential) != 0:
+		verificationReq := &CredentialsVerificationRequest{Credential: item.Credential}
+
+		if err = unmarshalOptions(item.Opts, &verificationReq.Opts); err == nil {
+			status, resp = o.verifyCredential(verificationReq)
+		}
+	case len(item.Presentation) != 0:
+		verificationReq := &VerifyPresentationRequest{Presentation: item.Presentation}
+
+		if err = unmarshalOptions(item.Opts, &verificationReq.Opts); err == nil {
+			status, resp = o.verifyPresentation(verificationReq)
+		}
+	default:
+		err = errors.New("item must have either credential or presentation")
+	}
+
+	if err != nil {
+		return &BatchVerificationResult{Result: &ErrorResponse{Message: fmt.Sprintf(invalidRequestErrMsg+": %s", err)}}
+	}
+
+	return &BatchVerificationResult{Verified: status == http.StatusOK, Result: resp}
+}
+
+func unmarshalOptions(optsBytes json.RawMessage, opts interface{}) error {
+	if len(optsBytes) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(optsBytes, opts); err != nil {
+		return fmt.Errorf("invalid options: %w", err)
+	}
+
+	return nil
+}
+
+// lookupCache runs each lookup once and shares the result with the concurrent and subsequent callers
+type lookupCache struct {
+	mutex   sync.Mutex
+	entries map[string]*lookupEntry
+}
+
+type lookupEntry struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+func newLookupCache() *lookupCache {
+	return &lookupCache{entries: make(map[string]*lookupEntry)}
+}
+
+func (c *lookupCache) get(key string, lookup func() (interface{}, error)) (interface{}, error) {
+	c.mutex.Lock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &lookupEntry{}
+		c.entries[key] = entry
+	}
+
+	c.mutex.Unlock()
+
+	entry.once.Do(func() {
+		entry.value, entry.err = lookup()
+	})
+
+	return entry.value, entry.err
+}
+
+// cachingVDRI shares the DID resolutions
+type cachingVDRI struct {
+	vdriapi.Registry
+	cache *lookupCache
+}
+
+func (v *cachingVDRI) Resolve(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
+	if len(opts) != 0 {
+		return v.Registry.Resolve(didID, opts...)
+	}
+
+	value, err := v.cache.get(didID, func() (interface{}, error) {
+		return v.Registry.Resolve(didID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	doc, _ := value.(*did.Doc) // nolint: errcheck
+
+	return doc, nil
+}
+
+// cachingHTTPClient shares the responses of GET requests (e.g. status lists)
+type cachingHTTPClient struct {
+	client httpClient
+	cache  *lookupCache
+}
+
+type cachedResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+func (c *cachingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return c.client.Do(req)
+	}
+
+	value, err := c.cache.get(req.URL.String(), func() (interface{}, error) {
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				log.Warn("failed to close response body")
+			}
+		}()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		return &cachedResponse{statusCode: resp.StatusCode, header: resp.Header, body: body}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cached, _ := value.(*cachedResponse) // nolint: errcheck
+
+	return &http.Response{
+		StatusCode: cached.statusCode,
+		Header:     cached.header,
+		Body:       ioutil.NopCloser(bytes.NewReader(cached.body)),
+	}, nil
+}
@@ -0,0 +1,248 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/composite/ecdhes"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
+	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage/memstore"
+
+	cslstatus "github.com/trustbloc/edge-service/pkg/doc/vc/status/csl"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
+)
+
+func TestVerifyBatch(t *testing.T) {
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		Crypto:             &cryptomock.Crypto{},
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI:               &vdrimock.MockVDRIRegistry{},
+		BatchConcurrency:   2,
+	})
+	require.NoError(t, err)
+
+	cslBytes, err := json.Marshal(&cslstatus.CSL{ID: "https://example.gov/status/24", VC: []string{
+		strings.ReplaceAll(validVCStatus, "#ID", "http://example.edu/credentials/1873")}})
+	require.NoError(t, err)
+
+	var statusRequests int32
+
+	op.httpClient = &mockHTTPClient{doFunc: func(*http.Request) (*http.Response, error) {
+		atomic.AddInt32(&statusRequests, 1)
+
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(cslBytes))}, nil
+	}}
+
+	handler := getHandler(t, op, batchVerificationEndpoint, verifierMode)
+
+	statusOpts := json.RawMessage(`{"checks": ["status"]}`)
+
+	t.Run("test success - results are returned in the order of the items", func(t *testing.T) {
+		atomic.StoreInt32(&statusRequests, 0)
+
+		items := []*BatchVerificationItem{
+			{Credential: []byte(validVC), Opts: statusOpts},
+			{Credential: []byte(strings.Replace(validVC, "/1872", "/1873", 1)), Opts: statusOpts},
+			{Presentation: []byte(vpWithoutProof), Opts: json.RawMessage(`{"checks": ["holderBinding"]}`)},
+			{Credential: []byte(validVC), Presentation: []byte(vpWithoutProof)},
+			{Credential: []byte(validVC), Opts: json.RawMessage(`{"checks": 1}`)},
+			{Credential: []byte(`"invalid"`)},
+			{},
+		}
+
+		for i := 0; i < 5; i++ {
+			items = append(items, &BatchVerificationItem{
+				Credential: []byte(strings.Replace(validVC, "/1872", fmt.Sprintf("/2%d", i), 1)),
+				Opts:       statusOpts,
+			})
+		}
+
+		reqBytes, err := json.Marshal(&BatchVerificationRequest{Items: items})
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, handler.Handle(), http.MethodPost, batchVerificationEndpoint, reqBytes)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		resp := &struct {
+			Results []*struct {
+				Verified bool            `json:"verified"`
+				Result   json.RawMessage `json:"result"`
+			} `json:"results"`
+		}{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, len(items), len(resp.Results))
+
+		require.True(t, resp.Results[0].Verified)
+		require.JSONEq(t, `{"checks": ["status"]}`, string(resp.Results[0].Result))
+
+		require.False(t, resp.Results[1].Verified)
+		require.Contains(t, string(resp.Results[1].Result), "Revoked")
+
+		require.True(t, resp.Results[2].Verified)
+		require.JSONEq(t, `{"checks": ["holderBinding"]}`, string(resp.Results[2].Result))
+
+		require.False(t, resp.Results[3].Verified)
+		require.Contains(t, string(resp.Results[3].Result), "item must have either credential or presentation")
+
+		require.False(t, resp.Results[4].Verified)
+		require.Contains(t, string(resp.Results[4].Result), "invalid options")
+
+		require.False(t, resp.Results[5].Verified)
+		require.Contains(t, string(resp.Results[5].Result), invalidRequestErrMsg)
+
+		require.False(t, resp.Results[6].Verified)
+		require.Contains(t, string(resp.Results[6].Result), "item must have either credential or presentation")
+
+		for _, r := range resp.Results[7:] {
+			require.True(t, r.Verified)
+		}
+
+		// status list is fetched once for the batch
+		require.Equal(t, int32(1), atomic.LoadInt32(&statusRequests))
+	})
+
+	t.Run("test failure - invalid request", func(t *testing.T) {
+		rr := serveHTTP(t, handler.Handle(), http.MethodPost, batchVerificationEndpoint, []byte("invalid json"))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), invalidRequestErrMsg)
+
+		rr = serveHTTP(t, handler.Handle(), http.MethodPost, batchVerificationEndpoint, []byte(`{"items": []}`))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "batch must have 1 to 1000 items")
+
+		reqBytes, err := json.Marshal(&BatchVerificationRequest{
+			Items: make([]*BatchVerificationItem, maxBatchSize+1)})
+		require.NoError(t, err)
+
+		rr = serveHTTP(t, handler.Handle(), http.MethodPost, batchVerificationEndpoint, reqBytes)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestCachingVDRI(t *testing.T) {
+	var resolutions int32
+
+	registry := &cachingVDRI{
+		Registry: &vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+			atomic.AddInt32(&resolutions, 1)
+
+			if didID == "did:example:unknown" {
+				return nil, errors.New("DID not found")
+			}
+
+			return &did.Doc{ID: didID}, nil
+		}},
+		cache: newLookupCache(),
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			doc, err := registry.Resolve("did:example:123")
+			require.NoError(t, err)
+			require.Equal(t, "did:example:123", doc.ID)
+		}()
+	}
+
+	wg.Wait()
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&resolutions))
+
+	_, err := registry.Resolve("did:example:unknown")
+	require.EqualError(t, err, "DID not found")
+
+	_, err = registry.Resolve("did:example:unknown")
+	require.EqualError(t, err, "DID not found")
+	require.Equal(t, int32(2), atomic.LoadInt32(&resolutions))
+
+	// resolution with options isn't cached
+	_, err = registry.Resolve("did:example:123", vdri.WithNoCache(true))
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&resolutions))
+}
+
+func TestCachingHTTPClient(t *testing.T) {
+	var requests int32
+
+	client := &cachingHTTPClient{
+		client: &mockHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&requests, 1)
+
+			if strings.HasSuffix(req.URL.Path, "/error") {
+				return nil, errors.New("connection refused")
+			}
+
+			return &http.Response{StatusCode: http.StatusOK,
+				Body: ioutil.NopCloser(strings.NewReader(req.URL.Path))}, nil
+		}},
+		cache: newLookupCache(),
+	}
+
+	get := func(t *testing.T, url string) (string, error) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		return string(body), nil
+	}
+
+	for i := 0; i < 3; i++ {
+		body, err := get(t, "https://example.gov/status/24")
+		require.NoError(t, err)
+		require.Equal(t, "/status/24", body)
+	}
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	_, err := get(t, "https://example.gov/status/error")
+	require.EqualError(t, err, "connection refused")
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// non GET requests aren't cached
+	req, err := http.NewRequest(http.MethodPost, "https://example.gov/status/24", nil)
+	require.NoError(t, err)
+
+	_, err = client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
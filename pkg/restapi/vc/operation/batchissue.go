@@ -0,0 +1,221 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/datamodel"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	cslstatus "github.com/trustbloc/edge-service/pkg/doc/vc/status/csl"
+)
+
+// batchCredential is the credential of the batch item prepared for signing
+type batchCredential struct {
+	index       int
+	credential  *verifiable.Credential
+	signingOpts []crypto.SigningOpts
+	status      *cslstatus.StatusID
+}
+
+// IssueCredentials swagger:route POST /{id}/credentials/issueCredentials issuer batchIssueCredentialReq
+//
+// Issues credentials in batch, the items which can't be issued don't abort the batch.
+//
+// Responses:
+//    default: genericError
+//        200: batchIssueCredentialRes
+func (o *Operation) issueCredentialsHandler(rw http.ResponseWriter, req *http.Request) {
+	profileID := mux.Vars(req)[profileIDPathParam]
+
+	profile, err := o.profileStore.GetProfile(profileID)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid issuer profile - id=%s: err=%s",
+			profileID, err.Error()))
+
+		return
+	}
+
+	request := &BatchIssueCredentialRequest{}
+
+	if err = json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	if len(request.Items) == 0 || len(request.Items) > maxBatchSize {
+		o.writeErrorResponse(rw, http.StatusBadRequest,
+			fmt.Sprintf(invalidRequestErrMsg+": batch must have 1 to %d items", maxBatchSize))
+
+		return
+	}
+
+	results, err := o.issueBatch(profile, request.Items)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, &BatchIssueCredentialResponse{Results: results})
+}
+
+// issueBatch prepares the credentials, allocates their status IDs in a block and signs them concurrently.
+// Results are returned in the order of the items.
+func (o *Operation) issueBatch(profile *vcprofile.DataProfile,
+	items []*BatchIssueCredentialItem) ([]*BatchIssueCredentialResult, error) {
+	results := make([]*BatchIssueCredentialResult, len(items))
+	credentials := make([]*batchCredential, 0, len(items))
+
+	ids := make(map[string]bool)
+
+	for i, item := range items {
+		credential, signingOpts, err := o.prepareBatchCredential(profile, item)
+
+		if err == nil && credential.ID != "" && ids[credential.ID] {
+			err = fmt.Errorf("%w: %s is duplicated in the batch", errDuplicateCredentialID, credential.ID)
+		}
+
+		if err == nil {
+			err = o.assignCredentialID(profile, credential)
+		}
+
+		if err != nil {
+			results[i] = &BatchIssueCredentialResult{Error: err.Error()}
+
+			continue
+		}
+
+		if credential.ID != "" {
+			ids[credential.ID] = true
+		}
+
+		credentials = append(credentials, &batchCredential{index: i, credential: credential, signingOpts: signingOpts})
+	}
+
+	if !profile.DisableVCStatus && len(credentials) != 0 {
+		vcs := make([]*verifiable.Credential, len(credentials))
+		for i, c := range credentials {
+			vcs[i] = c.credential
+		}
+
+		statuses, err := o.addCredentialStatus(vcs...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add credential status: %w", err)
+		}
+
+		for i, c := range credentials {
+			c.status = statuses[i]
+		}
+	}
+
+	for _, c := range credentials {
+		updateContext(c.credential, profile)
+		updateIssuer(c.credential, profile)
+		o.addRefreshService(c.credential, profile)
+	}
+
+	o.signBatch(profile, credentials, results)
+
+	issued := make([]*issuedCredential, 0, len(credentials))
+
+	for _, c := range credentials {
+		if signedVC := results[c.index].Credential; signedVC != nil {
+			issued = append(issued, &issuedCredential{credential: signedVC, status: c.status})
+		}
+	}
+
+	if err := o.recordIssued(profile, issued...); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (o *Operation) signBatch(profile *vcprofile.DataProfile, credentials []*batchCredential,
+	results []*BatchIssueCredentialResult) {
+	concurrency := o.batchConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+
+	for _, c := range credentials {
+		wg.Add(1)
+
+		sem <- struct{}{}
+
+		go func(c *batchCredential) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			signedVC, err := o.crypto.SignCredential(profile, c.credential, c.signingOpts...)
+			if err != nil {
+				results[c.index] = &BatchIssueCredentialResult{Error: fmt.Sprintf("failed to sign credential: %s", err)}
+
+				return
+			}
+
+			results[c.index] = &BatchIssueCredentialResult{Credential: signedVC}
+		}(c)
+	}
+
+	wg.Wait()
+}
+
+// prepareBatchCredential validates the credential or composes it from the compose request of the item
+func (o *Operation) prepareBatchCredential(profile *vcprofile.DataProfile,
+	item *BatchIssueCredentialItem) (*verifiable.Credential, []crypto.SigningOpts, error) {
+	switch {
+	case len(item.Credential) != 0 && item.Compose != nil:
+		return nil, nil, errors.New(invalidRequestErrMsg + ": item must have either credential or compose request")
+	case len(item.Credential) != 0:
+		if err := validateIssueCredOptions(item.Opts); err != nil {
+			return nil, nil, err
+		}
+
+		credential, err := parseCredentialToIssue(item.Credential, profile, o.withDocumentLoader()...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to validate credential: %w", err)
+		}
+
+		return credential, getIssuerSigningOpts(item.Opts), nil
+	case item.Compose != nil:
+		credential, err := buildCredential(item.Compose)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to build credential: %w", err)
+		}
+
+		if profile.VCDataModel == datamodel.Version2 {
+			datamodel.ToV2(credential)
+		}
+
+		signingOpts, err := getComposeSigningOpts(item.Compose)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to prepare signing options: %w", err)
+		}
+
+		return credential, signingOpts, nil
+	default:
+		return nil, nil, errors.New(invalidRequestErrMsg + ": item must have either credential or compose request")
+	}
+}
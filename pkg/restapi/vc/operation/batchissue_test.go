@@ -0,0 +1,266 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/composite/ecdhes"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
+	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage/memstore"
+
+	"github.com/trustbloc/edge-service/pkg/doc/jsonld"
+	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	cslstatus "github.com/trustbloc/edge-service/pkg/doc/vc/status/csl"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
+)
+
+const batchVC = `{
+  "@context": ["https://www.w3.org/2018/credentials/v1"],
+  "id": "http://example.edu/credentials/1872",
+  "type": "VerifiableCredential",
+  "credentialSubject": {"id": "did:example:ebfeb1f712ebc6f1c276e12ec21"},
+  "issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+  "issuanceDate": "2010-01-01T19:23:24Z"
+}`
+
+// the security terms of the proof, the context isn't shipped with the service
+const securityV2Context = `{
+  "@context": {
+    "id": "@id",
+    "type": "@type",
+    "sec": "https://w3id.org/security#",
+    "xsd": "http://www.w3.org/2001/XMLSchema#",
+    "JsonWebSignature2020": "https://w3c-ccg.github.io/lds-jws2020/contexts/#JsonWebSignature2020",
+    "created": {"@id": "http://purl.org/dc/terms/created", "@type": "xsd:dateTime"},
+    "jws": "sec:jws",
+    "proofPurpose": {"@id": "sec:proofPurpose", "@type": "@vocab"},
+    "assertionMethod": {"@id": "sec:assertionMethod", "@type": "@id", "@container": "@set"},
+    "verificationMethod": {"@id": "sec:verificationMethod", "@type": "@id"}
+  }
+}`
+
+const examplesV1Context = "https://trustbloc.github.io/context/vc/examples-v1.jsonld"
+
+func TestIssueCredentials(t *testing.T) {
+	defer setTestContexts(t)()
+
+	endpoint := "/test/credentials/issueCredentials"
+
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	newOperation := func(t *testing.T, c *cryptomock.Crypto) *Operation {
+		t.Helper()
+
+		op, err := New(&Config{
+			StoreProvider:      memstore.NewProvider(),
+			KMSSecretsProvider: mem.NewProvider(),
+			KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+			VDRI: &vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+				return createDIDDoc(didID, pubKey), nil
+			}},
+			Crypto:           c,
+			BatchConcurrency: 2,
+		})
+		require.NoError(t, err)
+
+		profile := getTestProfile()
+		profile.SignatureRepresentation = verifiable.SignatureJWS
+		profile.SignatureType = vccrypto.JSONWebSignature2020
+
+		require.NoError(t, op.profileStore.SaveProfile(profile))
+
+		return op
+	}
+
+	urlVars := map[string]string{profileIDPathParam: getTestProfile().Name}
+
+	type batchResult struct {
+		Credential *verifiable.Credential
+		Error      string
+	}
+
+	issue := func(t *testing.T, op *Operation, request interface{}) []*batchResult {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		rr := serveHTTPMux(t, getHandler(t, op, issueCredentialsPath, issuerMode), endpoint, reqBytes, urlVars)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		resp := &struct {
+			Results []struct {
+				Credential json.RawMessage `json:"credential"`
+				Error      string          `json:"error"`
+			} `json:"results"`
+		}{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+
+		results := make([]*batchResult, len(resp.Results))
+
+		for i, r := range resp.Results {
+			results[i] = &batchResult{Error: r.Error}
+
+			if len(r.Credential) != 0 {
+				results[i].Credential, err = verifiable.NewUnverifiedCredential(r.Credential)
+				require.NoError(t, err)
+			}
+		}
+
+		return results
+	}
+
+	t.Run("issue credentials - success with partial failures", func(t *testing.T) {
+		op := newOperation(t, &cryptomock.Crypto{})
+
+		results := issue(t, op, &BatchIssueCredentialRequest{Items: []*BatchIssueCredentialItem{
+			{Credential: []byte(batchVC)},
+			{Compose: &ComposeCredentialRequest{Issuer: "did:example:123", Subject: "did:example:456"}},
+			{Credential: []byte(`{"@context": 1}`)},
+			{Credential: []byte(batchVC), Compose: &ComposeCredentialRequest{}},
+			{},
+			{Compose: &ComposeCredentialRequest{Claims: []byte(`"claims"`)}},
+		}})
+		require.Len(t, results, 6)
+
+		for _, result := range results[:2] {
+			require.Empty(t, result.Error)
+			require.NotNil(t, result.Credential)
+			require.NotEmpty(t, result.Credential.Proofs)
+			require.NotNil(t, result.Credential.Status)
+			require.Equal(t, cslstatus.CredentialStatusType, result.Credential.Status.Type)
+			require.Contains(t, result.Credential.Context, cslstatus.Context)
+		}
+
+		require.Equal(t, "did:example:76e12ec712ebc6f1c221ebfeb1f", results[0].Credential.Issuer.ID)
+		require.Equal(t, "did:example:123", results[1].Credential.Issuer.ID)
+
+		require.Contains(t, results[2].Error, "failed to validate credential")
+		require.Contains(t, results[3].Error, "item must have either credential or compose request")
+		require.Contains(t, results[4].Error, "item must have either credential or compose request")
+		require.Contains(t, results[5].Error, "failed to build credential")
+
+		for _, result := range results[2:] {
+			require.Nil(t, result.Credential)
+		}
+	})
+
+	t.Run("issue credentials - sign error", func(t *testing.T) {
+		op := newOperation(t, &cryptomock.Crypto{SignErr: errors.New("sign error")})
+
+		results := issue(t, op, &BatchIssueCredentialRequest{Items: []*BatchIssueCredentialItem{
+			{Credential: []byte(batchVC)},
+		}})
+		require.Len(t, results, 1)
+		require.Contains(t, results[0].Error, "failed to sign credential")
+	})
+
+	t.Run("issue credentials - invalid requests", func(t *testing.T) {
+		op := newOperation(t, &cryptomock.Crypto{})
+		handler := getHandler(t, op, issueCredentialsPath, issuerMode)
+
+		rr := serveHTTPMux(t, handler, endpoint, []byte(`{"items": [{}]}`),
+			map[string]string{profileIDPathParam: "invalid"})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid issuer profile")
+
+		rr = serveHTTPMux(t, handler, endpoint, []byte("invalid"), urlVars)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), invalidRequestErrMsg)
+
+		for _, items := range [][]*BatchIssueCredentialItem{nil, make([]*BatchIssueCredentialItem, maxBatchSize+1)} {
+			reqBytes, err := json.Marshal(&BatchIssueCredentialRequest{Items: items})
+			require.NoError(t, err)
+
+			rr = serveHTTPMux(t, handler, endpoint, reqBytes, urlVars)
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+			require.Contains(t, rr.Body.String(), fmt.Sprintf("batch must have 1 to %d items", maxBatchSize))
+		}
+	})
+
+	t.Run("issue credentials - status error", func(t *testing.T) {
+		op := newOperation(t, &cryptomock.Crypto{})
+		op.vcStatusManager = &mockVCStatusManager{createStatusIDErr: errors.New("status error")}
+
+		reqBytes, err := json.Marshal(&BatchIssueCredentialRequest{Items: []*BatchIssueCredentialItem{
+			{Credential: []byte(batchVC)},
+		}})
+		require.NoError(t, err)
+
+		rr := serveHTTPMux(t, getHandler(t, op, issueCredentialsPath, issuerMode), endpoint, reqBytes, urlVars)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "failed to add credential status: status error")
+	})
+}
+
+// setTestContexts serves the contexts to the signature suites which fetch them with the default HTTP client,
+// the returned function restores the default HTTP client.
+func setTestContexts(t *testing.T) func() {
+	t.Helper()
+
+	doc, err := ld.DocumentFromReader(strings.NewReader(securityV2Context))
+	require.NoError(t, err)
+
+	loader := jsonld.NewDocumentLoader(jsonld.WithEmbeddedContexts(),
+		jsonld.WithContexts(&ld.RemoteDocument{DocumentURL: "https://w3id.org/security/v2", Document: doc}))
+
+	// the JSON-LD processors fetch the contexts with the clients using the default transport
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = &contextsTransport{loader: loader}
+
+	return func() { http.DefaultTransport = defaultTransport }
+}
+
+// contextsTransport serves the contexts of the loader, other requests are rejected
+type contextsTransport struct {
+	loader ld.DocumentLoader
+}
+
+func (t *contextsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return nil, fmt.Errorf("unexpected request %s %s", req.Method, req.URL)
+	}
+
+	doc, err := t.loader.LoadDocument(req.URL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	docBytes, err := json.Marshal(doc.Document)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/ld+json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(docBytes)),
+		Request:    req,
+	}, nil
+}
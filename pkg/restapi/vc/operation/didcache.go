@@ -0,0 +1,75 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+const didCacheNotConfiguredErrMsg = "DID cache is not configured"
+
+// DIDCacheMetrics swagger:route GET /admin/didcache admin didCacheMetricsReq
+//
+// Returns the DID cache metrics.
+//
+// Responses:
+//    default: genericError
+//        200: didCacheMetricsRes
+func (o *Operation) didCacheMetricsHandler(rw http.ResponseWriter, req *http.Request) {
+	if o.didCache == nil {
+		o.writeErrorResponse(rw, http.StatusNotFound, didCacheNotConfiguredErrMsg)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, o.didCache.Metrics())
+}
+
+// PurgeDIDCache swagger:route DELETE /admin/didcache admin purgeDIDCacheReq
+//
+// Purges all the cached DID documents.
+//
+// Responses:
+//    default: genericError
+//        200: purgeDIDCacheRes
+func (o *Operation) purgeDIDCacheHandler(rw http.ResponseWriter, req *http.Request) {
+	if o.didCache == nil {
+		o.writeErrorResponse(rw, http.StatusNotFound, didCacheNotConfiguredErrMsg)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, &DIDCachePurgeResponse{Purged: o.didCache.PurgeAll()})
+}
+
+// PurgeDIDCacheEntry swagger:route DELETE /admin/didcache/{id} admin purgeDIDCacheEntryReq
+//
+// Purges the cached DID document (e.g. after the issuer has rotated the keys).
+//
+// Responses:
+//    default: genericError
+//        200: purgeDIDCacheRes
+func (o *Operation) purgeDIDCacheEntryHandler(rw http.ResponseWriter, req *http.Request) {
+	if o.didCache == nil {
+		o.writeErrorResponse(rw, http.StatusNotFound, didCacheNotConfiguredErrMsg)
+
+		return
+	}
+
+	resp := &DIDCachePurgeResponse{}
+
+	if o.didCache.Purge(mux.Vars(req)["id"]) {
+		resp.Purged = 1
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, resp)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/composite/ecdhes"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
+	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage/memstore"
+
+	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
+	"github.com/trustbloc/edge-service/pkg/vdri/cache"
+)
+
+func TestDIDCache(t *testing.T) {
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	didCache := cache.New(&vdrimock.MockVDRIRegistry{
+		ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+			return &did.Doc{ID: didID}, nil
+		}})
+
+	newOperation := func(t *testing.T, didCache *cache.Registry) *Operation {
+		t.Helper()
+
+		op, err := New(&Config{
+			Crypto:             &cryptomock.Crypto{},
+			StoreProvider:      memstore.NewProvider(),
+			KMSSecretsProvider: mem.NewProvider(),
+			KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+			VDRI:               &vdrimock.MockVDRIRegistry{},
+			DIDCache:           didCache,
+		})
+		require.NoError(t, err)
+
+		return op
+	}
+
+	t.Run("test metrics and purge", func(t *testing.T) {
+		op := newOperation(t, didCache)
+
+		for _, didID := range []string{"did:example:1", "did:example:2", "did:example:1"} {
+			_, err := didCache.Resolve(didID)
+			require.NoError(t, err)
+		}
+
+		rr := serveHTTPMux(t, getMethodHandler(t, op, didCacheEndpoint, http.MethodGet, verifierMode),
+			didCacheEndpoint, nil, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		metrics := &cache.Metrics{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), metrics))
+		require.Equal(t, &cache.Metrics{Hits: 1, Misses: 2, Size: 2}, metrics)
+
+		purgeEntry := getMethodHandler(t, op, didCacheEntryEndpoint, http.MethodDelete, verifierMode)
+
+		rr = serveHTTPMux(t, purgeEntry, didCacheEndpoint+"/did:example:1", nil,
+			map[string]string{"id": "did:example:1"})
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"purged": 1}`, rr.Body.String())
+
+		rr = serveHTTPMux(t, purgeEntry, didCacheEndpoint+"/did:example:1", nil,
+			map[string]string{"id": "did:example:1"})
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"purged": 0}`, rr.Body.String())
+
+		rr = serveHTTPMux(t, getMethodHandler(t, op, didCacheEndpoint, http.MethodDelete, verifierMode),
+			didCacheEndpoint, nil, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"purged": 1}`, rr.Body.String())
+		require.Equal(t, 0, didCache.Metrics().Size)
+	})
+
+	t.Run("test DID cache is not configured", func(t *testing.T) {
+		op := newOperation(t, nil)
+
+		for _, h := range []Handler{
+			getMethodHandler(t, op, didCacheEndpoint, http.MethodGet, verifierMode),
+			getMethodHandler(t, op, didCacheEndpoint, http.MethodDelete, verifierMode),
+			getMethodHandler(t, op, didCacheEntryEndpoint, http.MethodDelete, verifierMode),
+		} {
+			rr := serveHTTPMux(t, h, didCacheEndpoint, nil, map[string]string{"id": "did:example:1"})
+			require.Equal(t, http.StatusNotFound, rr.Code)
+			require.Contains(t, rr.Body.String(), didCacheNotConfiguredErrMsg)
+		}
+	})
+}
+
+func getMethodHandler(t *testing.T, op *Operation, lookup, method, mode string) Handler {
+	t.Helper()
+
+	handlers, err := op.GetRESTHandlers(mode)
+	require.NoError(t, err)
+
+	for _, h := range handlers {
+		if h.Path() == lookup && h.Method() == method {
+			return h
+		}
+	}
+
+	require.Fail(t, "unable to find handler")
+
+	return nil
+}
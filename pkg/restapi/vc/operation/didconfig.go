@@ -0,0 +1,167 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	log "github.com/sirupsen/logrus"
+	"github.com/trustbloc/edge-core/pkg/storage"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/didconfig"
+)
+
+// domainLinkageValidity is the validity period of the Domain Linkage credentials, the credentials are
+// signed again once expired
+const domainLinkageValidity = 365 * 24 * time.Hour
+
+// DIDConfiguration swagger:route GET /.well-known/did-configuration.json issuer didConfigurationReq
+//
+// Retrieves the DID configuration with the Domain Linkage credentials linking the DIDs of the issuer profiles
+// to the origins of the profile URIs.
+//
+// Responses:
+//    default: genericError
+//        200: didConfigurationRes
+func (o *Operation) didConfigurationHandler(rw http.ResponseWriter, req *http.Request) {
+	profiles, err := o.didConfig.Profiles()
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	config := &didconfig.Configuration{Context: didconfig.Context, LinkedDIDs: []json.RawMessage{}}
+
+	for _, name := range profiles {
+		linkedDID, err := o.linkedDID(name)
+		if err != nil {
+			o.writeErrorResponse(rw, http.StatusInternalServerError,
+				fmt.Sprintf("failed to create domain linkage credential of profile %s: %s", name, err.Error()))
+
+			return
+		}
+
+		config.LinkedDIDs = append(config.LinkedDIDs, linkedDID.Credential)
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, config)
+}
+
+// linkedDID returns the Domain Linkage credential of the profile, the credential is signed when it's
+// requested first or has expired
+func (o *Operation) linkedDID(name string) (*didconfig.LinkedDID, error) {
+	now := time.Now().UTC()
+
+	linkedDID, err := o.didConfig.GetLinkedDID(name)
+	if err == nil && now.Before(linkedDID.Expires) {
+		return linkedDID, nil
+	}
+
+	if err != nil && !errors.Is(err, storage.ErrValueNotFound) {
+		return nil, err
+	}
+
+	profile, err := o.profileStore.GetProfile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	origin, err := didconfig.Origin(profile.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	expires := now.Add(domainLinkageValidity)
+
+	credential := didconfig.CreateCredential(profile.DID, origin, now, expires)
+
+	updateContext(credential, profile)
+
+	signedVC, err := o.crypto.SignCredential(profile, credential)
+	if err != nil {
+		return nil, err
+	}
+
+	vcBytes, err := signedVC.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	linkedDID = &didconfig.LinkedDID{Credential: vcBytes, Expires: expires}
+
+	if err := o.didConfig.SaveLinkedDID(name, linkedDID); err != nil {
+		return nil, err
+	}
+
+	return linkedDID, nil
+}
+
+// validateLinkedDomain checks the DID configuration of the domains linked to the issuer DID (the LinkedDomains
+// services of the DID document) has the valid Domain Linkage credential of the issuer DID
+func (o *Operation) validateLinkedDomain(vc *verifiable.Credential, asOf *time.Time) error {
+	verificationTime := time.Now()
+	if asOf != nil {
+		verificationTime = *asOf
+	}
+
+	doc, err := o.vdri.Resolve(vc.Issuer.ID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve issuer DID %s: %w", vc.Issuer.ID, err)
+	}
+
+	var errs []string
+
+	for _, service := range doc.Service {
+		if service.Type != didconfig.LinkedDomainsServiceType {
+			continue
+		}
+
+		err := o.validateDIDConfiguration(vc.Issuer.ID, service.ServiceEndpoint, verificationTime)
+		if err == nil {
+			return nil
+		}
+
+		log.Debugf("linked domain %s of %s isn't valid: %s", service.ServiceEndpoint, vc.Issuer.ID, err)
+
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) == 0 {
+		return fmt.Errorf("issuer DID %s has no linked domains", vc.Issuer.ID)
+	}
+
+	return errors.New(strings.Join(errs, "; "))
+}
+
+func (o *Operation) validateDIDConfiguration(did, endpoint string, asOf time.Time) error {
+	origin, err := didconfig.Origin(endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, origin+didconfig.WellKnownPath, nil)
+	if err != nil {
+		return err
+	}
+
+	config, err := sendHTTPRequest(o.linkedDomainClient, req, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("failed to fetch DID configuration of %s: %w", origin, err)
+	}
+
+	return didconfig.Verify(config, did, origin, asOf, o.withDocumentLoader(
+		verifiable.WithPublicKeyFetcher(verifiable.NewDIDKeyResolver(o.vdri).PublicKeyFetcher()),
+	)...)
+}
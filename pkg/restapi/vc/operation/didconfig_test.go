@@ -0,0 +1,320 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/composite/ecdhes"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
+	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage/memstore"
+
+	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/didconfig"
+	"github.com/trustbloc/edge-service/pkg/internal/common/fetch"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/didbloc"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/edv"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
+)
+
+const linkedDomainVC = `{
+  "@context": "https://www.w3.org/2018/credentials/v1",
+  "id": "http://example.edu/credentials/1872",
+  "type": "VerifiableCredential",
+  "credentialSubject": {
+    "id": "did:example:ebfeb1f712ebc6f1c276e12ec21"
+  },
+  "issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+  "issuanceDate": "2010-01-01T19:23:24Z"
+}`
+
+func TestDIDConfiguration(t *testing.T) {
+	defer setTestContexts(t)()
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI:               &vdrimock.MockVDRIRegistry{},
+		Crypto:             &cryptomock.Crypto{},
+	})
+	require.NoError(t, err)
+
+	handler := getHandler(t, op, didConfigurationEndpoint, issuerMode)
+
+	getConfiguration := func(t *testing.T) *didconfig.Configuration {
+		t.Helper()
+
+		rr := serveHTTPMux(t, handler, didConfigurationEndpoint, nil, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		config := &didconfig.Configuration{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), config))
+		require.Equal(t, didconfig.Context, config.Context)
+
+		return config
+	}
+
+	t.Run("no linked profiles", func(t *testing.T) {
+		require.Empty(t, getConfiguration(t).LinkedDIDs)
+	})
+
+	profile := getTestProfile()
+	profile.SignatureRepresentation = verifiable.SignatureJWS
+	profile.SignatureType = vccrypto.JSONWebSignature2020
+
+	require.NoError(t, op.profileStore.SaveProfile(profile))
+	require.NoError(t, op.didConfig.AddProfile(profile.Name))
+
+	t.Run("profile domain linkage credential", func(t *testing.T) {
+		config := getConfiguration(t)
+		require.Len(t, config.LinkedDIDs, 1)
+
+		vc, err := verifiable.NewUnverifiedCredential(config.LinkedDIDs[0])
+		require.NoError(t, err)
+		require.Contains(t, vc.Types, didconfig.DomainLinkageCredentialType)
+		require.Equal(t, profile.DID, vc.Issuer.ID)
+		require.Len(t, vc.Proofs, 1)
+		require.NotNil(t, vc.Expired)
+
+		subject, ok := vc.Subject.(map[string]interface{})
+		require.True(t, ok)
+		require.Equal(t, profile.DID, subject["id"])
+		require.Equal(t, "https://test.com", subject["origin"])
+
+		// the signed credential is served until it expires
+		require.Equal(t, config.LinkedDIDs, getConfiguration(t).LinkedDIDs)
+
+		linkedDID, err := op.didConfig.GetLinkedDID(profile.Name)
+		require.NoError(t, err)
+
+		linkedDID.Expires = time.Now().Add(-time.Hour)
+		require.NoError(t, op.didConfig.SaveLinkedDID(profile.Name, linkedDID))
+
+		config = getConfiguration(t)
+		require.Len(t, config.LinkedDIDs, 1)
+		require.NotEqual(t, linkedDID.Credential, config.LinkedDIDs[0])
+	})
+
+	t.Run("profile without origin", func(t *testing.T) {
+		invalid := getTestProfile()
+		invalid.Name = "invalid"
+		invalid.URI = "invalid"
+
+		require.NoError(t, op.profileStore.SaveProfile(invalid))
+		require.NoError(t, op.didConfig.AddProfile(invalid.Name))
+
+		rr := serveHTTPMux(t, handler, didConfigurationEndpoint, nil, nil)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "failed to create domain linkage credential of profile invalid")
+	})
+
+	t.Run("profile without https origin", func(t *testing.T) {
+		invalid := getTestProfile()
+		invalid.Name = "invalid"
+		invalid.URI = "http://example.com/credentials"
+
+		require.NoError(t, op.profileStore.SaveProfile(invalid))
+
+		rr := serveHTTPMux(t, handler, didConfigurationEndpoint, nil, nil)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "uri http://example.com/credentials has no https origin")
+	})
+}
+
+func TestDIDConfiguration_LinkedProfiles(t *testing.T) {
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		EDVClient:          edv.NewMockEDVClient("test", nil, nil, []string{"testID"}),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI:               &vdrimock.MockVDRIRegistry{},
+		Crypto:             &cryptomock.Crypto{},
+	})
+	require.NoError(t, err)
+
+	op.didBlocClient = &didbloc.Client{CreateDIDValue: createDefaultDID()}
+
+	for name, uri := range map[string]string{"https": "https://example.com/credentials",
+		"http": "http://example.com/credentials"} {
+		_, err := op.createAndSaveIssuerProfile(&ProfileRequest{Name: name, URI: uri,
+			SignatureType: vccrypto.Ed25519Signature2018, DIDKeyType: vccrypto.Ed25519KeyType})
+		require.NoError(t, err)
+	}
+
+	// the profile without https origin is created but its DID isn't linked
+	profiles, err := op.didConfig.Profiles()
+	require.NoError(t, err)
+	require.Equal(t, []string{"https"}, profiles)
+}
+
+func TestLinkedDomainCheck(t *testing.T) {
+	defer setTestContexts(t)()
+
+	const issuerDID = "did:example:76e12ec712ebc6f1c221ebfeb1f"
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	var configuration []byte
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, didconfig.WellKnownPath, req.URL.Path)
+
+		_, err := rw.Write(configuration)
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	linkedDomains := []did.Service{{
+		ID:              issuerDID + "#linked-domain",
+		Type:            didconfig.LinkedDomainsServiceType,
+		ServiceEndpoint: server.URL + "/issuer",
+	}}
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI: &vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+			doc := createDIDDoc(didID, pubKey)
+			doc.Service = append(doc.Service, linkedDomains...)
+
+			return doc, nil
+		}},
+		Crypto: &cryptomock.Crypto{},
+	})
+	require.NoError(t, err)
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	// the test server is internal, it's allowed explicitly
+	op.linkedDomainClient = fetch.New(fetch.WithTLSConfig(server.Client().Transport.(*http.Transport).TLSClientConfig),
+		fetch.WithAllowedHosts(serverURL.Host))
+
+	setConfiguration := func(t *testing.T, linkedDID, origin string) {
+		t.Helper()
+
+		issued := time.Now().UTC().Add(-time.Hour)
+
+		jwtClaims, err := didconfig.CreateCredential(linkedDID, origin, issued, issued.Add(domainLinkageValidity)).
+			JWTClaims(false)
+		require.NoError(t, err)
+
+		jws, err := jwtClaims.MarshalJWS(verifiable.EdDSA, getEd25519TestSigner(privKey), linkedDID+"#key-1")
+		require.NoError(t, err)
+
+		jwsBytes, err := json.Marshal(jws)
+		require.NoError(t, err)
+
+		configuration, err = json.Marshal(&didconfig.Configuration{
+			Context:    didconfig.Context,
+			LinkedDIDs: []json.RawMessage{jwsBytes},
+		})
+		require.NoError(t, err)
+	}
+
+	verify := func(t *testing.T) *httptest.ResponseRecorder {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(&CredentialsVerificationRequest{
+			Credential: []byte(linkedDomainVC),
+			Opts:       &CredentialsVerificationOptions{Checks: []string{linkedDomainCheck}},
+		})
+		require.NoError(t, err)
+
+		return serveHTTPMux(t, getHandler(t, op, credentialsVerificationEndpoint, verifierMode),
+			credentialsVerificationEndpoint, reqBytes, nil)
+	}
+
+	t.Run("linked domain", func(t *testing.T) {
+		setConfiguration(t, issuerDID, server.URL)
+
+		rr := verify(t)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Contains(t, rr.Body.String(), linkedDomainCheck)
+	})
+
+	t.Run("domain doesn't link the issuer", func(t *testing.T) {
+		setConfiguration(t, "did:example:other", server.URL)
+
+		rr := verify(t)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "doesn't link "+issuerDID)
+	})
+
+	t.Run("linked domain unavailable", func(t *testing.T) {
+		defer func(services []did.Service) { linkedDomains = services }(linkedDomains)
+
+		linkedDomains = []did.Service{{Type: didconfig.LinkedDomainsServiceType, ServiceEndpoint: "invalid"}}
+
+		rr := verify(t)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "has no origin")
+	})
+
+	t.Run("linked domain without https origin", func(t *testing.T) {
+		defer func(services []did.Service) { linkedDomains = services }(linkedDomains)
+
+		httpURL := "http://" + serverURL.Host
+		linkedDomains = []did.Service{{Type: didconfig.LinkedDomainsServiceType, ServiceEndpoint: httpURL}}
+
+		setConfiguration(t, issuerDID, httpURL)
+
+		rr := verify(t)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "uri "+httpURL+" has no https origin")
+	})
+
+	t.Run("internal linked domain", func(t *testing.T) {
+		defer func(services []did.Service) { linkedDomains = services }(linkedDomains)
+		defer func(client httpClient) { op.linkedDomainClient = client }(op.linkedDomainClient)
+
+		op.linkedDomainClient = fetch.New()
+
+		setConfiguration(t, issuerDID, server.URL)
+
+		rr := verify(t)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "failed to fetch DID configuration of "+server.URL)
+		require.Contains(t, rr.Body.String(), "host "+serverURL.Host+" is internal")
+	})
+
+	t.Run("no linked domains", func(t *testing.T) {
+		defer func(services []did.Service) { linkedDomains = services }(linkedDomains)
+
+		linkedDomains = nil
+
+		rr := verify(t)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "issuer DID "+issuerDID+" has no linked domains")
+	})
+}
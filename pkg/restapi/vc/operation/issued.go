@@ -0,0 +1,239 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/issuanceregistry"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	cslstatus "github.com/trustbloc/edge-service/pkg/doc/vc/status/csl"
+)
+
+const (
+	subjectQueryParam = "subject"
+	typeQueryParam    = "type"
+	offsetQueryParam  = "offset"
+	limitQueryParam   = "limit"
+	uuidURNPrefix     = "urn:uuid:"
+)
+
+var (
+	errCredentialIDRequired  = errors.New("credential ID is required by the profile")
+	errDuplicateCredentialID = issuanceregistry.ErrDuplicateID
+)
+
+// issuedCredential is the signed credential with the status allocated for it (if any)
+type issuedCredential struct {
+	credential *verifiable.Credential
+	status     *cslstatus.StatusID
+}
+
+// IssuedCredentials swagger:route GET /{id}/credentials/issued issuer issuedCredentialsReq
+//
+// Retrieves the records of the credentials issued by the profile, optionally filtered by the subject and type.
+//
+// Responses:
+//    default: genericError
+//        200: issuedCredentialsRes
+func (o *Operation) issuedCredentialsHandler(rw http.ResponseWriter, req *http.Request) {
+	profileID := mux.Vars(req)[profileIDPathParam]
+
+	profile, err := o.profileStore.GetProfile(profileID)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid issuer profile - id=%s: err=%s",
+			profileID, err.Error()))
+
+		return
+	}
+
+	offset, err := intQueryParam(req, offsetQueryParam)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	limit, err := intQueryParam(req, limitQueryParam)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	records, next, err := o.issuanceRegistry.QueryRecords(&issuanceregistry.Query{
+		Profile: profile.Name,
+		Subject: req.URL.Query().Get(subjectQueryParam),
+		Type:    req.URL.Query().Get(typeQueryParam),
+		Offset:  offset,
+		Limit:   limit,
+	})
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	if records == nil {
+		records = []*issuanceregistry.Record{}
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, &IssuedCredentialsResponse{Records: records, Next: next})
+}
+
+// intQueryParam returns the non-negative integer query parameter, 0 if it isn't set
+func intQueryParam(req *http.Request, name string) (int, error) {
+	value := req.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: %s", name, value)
+	}
+
+	return n, nil
+}
+
+// assignCredentialID assigns the ID to the credential issued without one according to the credential ID policy
+// of the profile, the IDs supplied by the clients are reserved for the issuance so they must neither have been
+// issued by the profile already nor be issued concurrently
+func (o *Operation) assignCredentialID(profile *vcprofile.DataProfile, credential *verifiable.Credential) error {
+	if credential.ID == "" {
+		switch profile.CredentialIDPolicy {
+		case vcprofile.CredentialIDUUID:
+			credential.ID = uuidURNPrefix + uuid.New().String()
+		case vcprofile.CredentialIDURI:
+			credential.ID = strings.TrimSuffix(profile.URI, "/") + "/" + uuid.New().String()
+		case vcprofile.CredentialIDClient:
+			return errCredentialIDRequired
+		}
+
+		return nil
+	}
+
+	return o.issuanceRegistry.ReserveID(profile.Name, credential.ID)
+}
+
+// credentialIDErrorStatus returns the HTTP status of the credential ID assignment error
+func credentialIDErrorStatus(err error) int {
+	if errors.Is(err, errCredentialIDRequired) || errors.Is(err, errDuplicateCredentialID) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+// addCredentialStatus allocates the status IDs of the credentials in a block and sets them to the credentials
+func (o *Operation) addCredentialStatus(credentials ...*verifiable.Credential) ([]*cslstatus.StatusID, error) {
+	statuses, err := o.vcStatusManager.CreateStatusIDs(len(credentials))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, credential := range credentials {
+		credential.Status = statuses[i].Status
+		credential.Context = appendContext(credential.Context, cslstatus.Context)
+	}
+
+	return statuses, nil
+}
+
+// recordIssued saves the records of the issued credentials in the issuance registry, credentials without
+// an ID can't be looked up later and aren't recorded
+func (o *Operation) recordIssued(profile *vcprofile.DataProfile, issued ...*issuedCredential) error {
+	records := make([]*issuanceregistry.Record, 0, len(issued))
+
+	for _, c := range issued {
+		if c.credential.ID == "" {
+			continue
+		}
+
+		record, err := newIssuedRecord(profile, c)
+		if err != nil {
+			return fmt.Errorf("failed to record issued credential: %w", err)
+		}
+
+		records = append(records, record)
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	if err := o.issuanceRegistry.SaveRecords(records...); err != nil {
+		return fmt.Errorf("failed to record issued credential: %w", err)
+	}
+
+	return nil
+}
+
+func newIssuedRecord(profile *vcprofile.DataProfile, c *issuedCredential) (*issuanceregistry.Record, error) {
+	vcBytes, err := c.credential.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(vcBytes)
+
+	record := &issuanceregistry.Record{
+		ID:       c.credential.ID,
+		Profile:  profile.Name,
+		Issuer:   c.credential.Issuer.ID,
+		Context:  c.credential.Context,
+		Types:    c.credential.Types,
+		Subjects: subjectIDs(c.credential.Subject),
+		Issued:   c.credential.Issued,
+		Hash:     hex.EncodeToString(hash[:]),
+		Replaces: replacesID(c.credential),
+	}
+
+	if c.status != nil && c.status.Status != nil {
+		record.Status = &issuanceregistry.Status{ListID: c.status.Status.ID, Index: c.status.Index}
+	}
+
+	return record, nil
+}
+
+// issuedCredentialByID rebuilds the credential fields the status list entry is created from
+// using the issuance record of the credential issued by the profile, the credential ID alone is ambiguous
+// as the profiles assign the IDs independently
+func (o *Operation) issuedCredentialByID(profile, id string) (*verifiable.Credential, error) {
+	if profile == "" {
+		return nil, errors.New("profile is required with the credential ID")
+	}
+
+	record, err := o.issuanceRegistry.GetProfileRecord(profile, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get issued credential %s: %w", id, err)
+	}
+
+	if record.Status == nil {
+		return nil, fmt.Errorf("issued credential %s has no status", id)
+	}
+
+	return &verifiable.Credential{
+		Context: record.Context,
+		ID:      record.ID,
+		Types:   record.Types,
+		Issuer:  verifiable.Issuer{ID: record.Issuer, Name: record.Profile},
+		Issued:  record.Issued,
+		Status:  &verifiable.TypedID{ID: record.Status.ListID, Type: cslstatus.CredentialStatusType},
+	}, nil
+}
@@ -0,0 +1,349 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/composite/ecdhes"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
+	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage/memstore"
+	"github.com/trustbloc/edge-core/pkg/storage/mockstore"
+
+	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/issuanceregistry"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
+)
+
+func TestIssuedCredentials(t *testing.T) {
+	defer setTestContexts(t)()
+
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI: &vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+			return createDIDDoc(didID, pubKey), nil
+		}},
+		Crypto:  &cryptomock.Crypto{},
+		HostURL: "http://example.com",
+	})
+	require.NoError(t, err)
+
+	profile := getTestProfile()
+	profile.SignatureRepresentation = verifiable.SignatureJWS
+	profile.SignatureType = vccrypto.JSONWebSignature2020
+
+	require.NoError(t, op.profileStore.SaveProfile(profile))
+
+	urlVars := map[string]string{profileIDPathParam: profile.Name}
+	issuedEndpoint := "/test/credentials/issued"
+
+	query := func(t *testing.T, rawQuery string) []*issuanceregistry.Record {
+		t.Helper()
+
+		rr := serveHTTPMux(t, getHandler(t, op, issuedCredentialsPath, issuerMode), issuedEndpoint+rawQuery,
+			nil, urlVars)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		resp := &IssuedCredentialsResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+
+		return resp.Records
+	}
+
+	t.Run("issued credentials are recorded", func(t *testing.T) {
+		reqBytes, err := json.Marshal(&BatchIssueCredentialRequest{Items: []*BatchIssueCredentialItem{
+			{Credential: []byte(batchVC)},
+			{Compose: &ComposeCredentialRequest{Issuer: "did:example:123", Subject: "did:example:456"}},
+		}})
+		require.NoError(t, err)
+
+		rr := serveHTTPMux(t, getHandler(t, op, issueCredentialsPath, issuerMode),
+			"/test/credentials/issueCredentials", reqBytes, urlVars)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		records := query(t, "")
+		require.Len(t, records, 1)
+
+		record := records[0]
+		require.Equal(t, "http://example.edu/credentials/1872", record.ID)
+		require.Equal(t, profile.Name, record.Profile)
+		require.Equal(t, "did:example:76e12ec712ebc6f1c221ebfeb1f", record.Issuer)
+		require.Equal(t, []string{"did:example:ebfeb1f712ebc6f1c276e12ec21"}, record.Subjects)
+		require.Equal(t, []string{"VerifiableCredential"}, record.Types)
+		require.NotNil(t, record.Issued)
+		require.NotNil(t, record.Status)
+		require.True(t, strings.HasPrefix(record.Status.ListID, "http://example.com"+credentialStatus))
+		require.Len(t, record.Hash, 64)
+
+		require.Len(t, query(t, "?subject=did:example:ebfeb1f712ebc6f1c276e12ec21"), 1)
+		require.Len(t, query(t, "?type=VerifiableCredential"), 1)
+		require.Empty(t, query(t, "?subject=did:example:456"))
+		require.Empty(t, query(t, "?type=UniversityDegreeCredential"))
+	})
+
+	t.Run("issued credentials pages", func(t *testing.T) {
+		rr := serveHTTPMux(t, getHandler(t, op, issuedCredentialsPath, issuerMode), issuedEndpoint+"?limit=1",
+			nil, urlVars)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		resp := &IssuedCredentialsResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Len(t, resp.Records, 1)
+		require.Zero(t, resp.Next)
+
+		require.Empty(t, query(t, "?offset=1"))
+
+		for _, rawQuery := range []string{"?offset=-1", "?limit=many"} {
+			rr := serveHTTPMux(t, getHandler(t, op, issuedCredentialsPath, issuerMode), issuedEndpoint+rawQuery,
+				nil, urlVars)
+			require.Equal(t, http.StatusBadRequest, rr.Code, rawQuery)
+			require.Contains(t, rr.Body.String(), "invalid")
+		}
+	})
+
+	t.Run("update status by credential ID", func(t *testing.T) {
+		handler := getHandler(t, op, updateCredentialStatusEndpoint, issuerMode)
+
+		reqBytes, err := json.Marshal(&UpdateCredentialStatusRequest{
+			CredentialID: "http://example.edu/credentials/1872",
+			Profile:      profile.Name,
+			Status:       "revoked",
+			StatusReason: "disciplinary action",
+		})
+		require.NoError(t, err)
+
+		rr := serveHTTPMux(t, handler, updateCredentialStatusEndpoint, reqBytes, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		record, err := op.issuanceRegistry.GetProfileRecord(profile.Name, "http://example.edu/credentials/1872")
+		require.NoError(t, err)
+
+		csl, err := op.vcStatusManager.GetCSL(record.Status.ListID)
+		require.NoError(t, err)
+		require.Len(t, csl.VC, 1)
+		require.Contains(t, csl.VC[0], "revoked")
+
+		reqBytes, err = json.Marshal(&UpdateCredentialStatusRequest{CredentialID: "invalid", Profile: profile.Name,
+			Status: "revoked"})
+		require.NoError(t, err)
+
+		rr = serveHTTPMux(t, handler, updateCredentialStatusEndpoint, reqBytes, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "failed to get issued credential invalid")
+
+		// the credential ID is looked up only in the records of the profile
+		reqBytes, err = json.Marshal(&UpdateCredentialStatusRequest{CredentialID: "http://example.edu/credentials/1872",
+			Profile: "other", Status: "revoked"})
+		require.NoError(t, err)
+
+		rr = serveHTTPMux(t, handler, updateCredentialStatusEndpoint, reqBytes, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "failed to get issued credential")
+
+		reqBytes, err = json.Marshal(&UpdateCredentialStatusRequest{CredentialID: "http://example.edu/credentials/1872",
+			Status: "revoked"})
+		require.NoError(t, err)
+
+		rr = serveHTTPMux(t, handler, updateCredentialStatusEndpoint, reqBytes, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "profile is required with the credential ID")
+	})
+
+	t.Run("invalid profile", func(t *testing.T) {
+		rr := serveHTTPMux(t, getHandler(t, op, issuedCredentialsPath, issuerMode), issuedEndpoint, nil,
+			map[string]string{profileIDPathParam: "invalid"})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid issuer profile")
+	})
+
+	t.Run("registry errors", func(t *testing.T) {
+		store := &mockstore.MockStore{Store: make(map[string][]byte), ErrPut: errors.New("put error")}
+		op.issuanceRegistry = issuanceregistry.New(store)
+
+		reqBytes, err := json.Marshal(&IssueCredentialRequest{Credential: []byte(batchVC)})
+		require.NoError(t, err)
+
+		rr := serveHTTPMux(t, getHandler(t, op, issueCredentialPath, issuerMode),
+			"/test/credentials/issueCredential", reqBytes, urlVars)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "failed to reserve credential ID: put error")
+
+		store.Store["issuedcount_4:test_all_"] = []byte("invalid")
+
+		rr = serveHTTPMux(t, getHandler(t, op, issuedCredentialsPath, issuerMode), issuedEndpoint, nil, urlVars)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid issued credentials index count")
+	})
+}
+
+func TestCredentialIDPolicy(t *testing.T) {
+	defer setTestContexts(t)()
+
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI: &vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+			return createDIDDoc(didID, pubKey), nil
+		}},
+		Crypto: &cryptomock.Crypto{},
+	})
+	require.NoError(t, err)
+
+	saveProfile := func(t *testing.T, name, policy string) {
+		t.Helper()
+
+		profile := getTestProfile()
+		profile.Name = name
+		profile.URI = "https://example.com/credentials/"
+		profile.SignatureRepresentation = verifiable.SignatureJWS
+		profile.SignatureType = vccrypto.JSONWebSignature2020
+		profile.CredentialIDPolicy = policy
+
+		require.NoError(t, op.profileStore.SaveProfile(profile))
+	}
+
+	issue := func(t *testing.T, profile string, request interface{}, status int) *verifiable.Credential {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		path, endpoint := issueCredentialPath, "/"+profile+"/credentials/issueCredential"
+		if _, ok := request.(*ComposeCredentialRequest); ok {
+			path, endpoint = composeAndIssueCredentialPath, "/"+profile+"/credentials/composeAndIssueCredential"
+		}
+
+		rr := serveHTTPMux(t, getHandler(t, op, path, issuerMode), endpoint, reqBytes,
+			map[string]string{profileIDPathParam: profile})
+		require.Equal(t, status, rr.Code, rr.Body.String())
+
+		if status != http.StatusCreated {
+			return nil
+		}
+
+		vc, err := verifiable.NewUnverifiedCredential(rr.Body.Bytes())
+		require.NoError(t, err)
+
+		return vc
+	}
+
+	noIDVC := strings.Replace(batchVC, `"id": "http://example.edu/credentials/1872",`, "", 1)
+
+	t.Run("uuid policy", func(t *testing.T) {
+		saveProfile(t, "uuid", vcprofile.CredentialIDUUID)
+
+		vc := issue(t, "uuid", &IssueCredentialRequest{Credential: []byte(noIDVC)}, http.StatusCreated)
+		require.True(t, strings.HasPrefix(vc.ID, "urn:uuid:"))
+
+		vc = issue(t, "uuid", &ComposeCredentialRequest{Subject: "did:example:456"}, http.StatusCreated)
+		require.True(t, strings.HasPrefix(vc.ID, "urn:uuid:"))
+
+		_, err := op.issuanceRegistry.GetProfileRecord("uuid", vc.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("uri policy", func(t *testing.T) {
+		saveProfile(t, "uri", vcprofile.CredentialIDURI)
+
+		vc := issue(t, "uri", &IssueCredentialRequest{Credential: []byte(noIDVC)}, http.StatusCreated)
+		require.True(t, strings.HasPrefix(vc.ID, "https://example.com/credentials/"))
+		require.False(t, strings.HasPrefix(vc.ID, "https://example.com/credentials//"))
+	})
+
+	t.Run("client policy", func(t *testing.T) {
+		saveProfile(t, "client", vcprofile.CredentialIDClient)
+
+		issue(t, "client", &IssueCredentialRequest{Credential: []byte(noIDVC)}, http.StatusBadRequest)
+		issue(t, "client", &ComposeCredentialRequest{Subject: "did:example:456"}, http.StatusBadRequest)
+
+		vc := issue(t, "client", &ComposeCredentialRequest{CredentialID: "http://example.edu/credentials/1",
+			Subject: "did:example:456"}, http.StatusCreated)
+		require.Equal(t, "http://example.edu/credentials/1", vc.ID)
+	})
+
+	t.Run("no policy", func(t *testing.T) {
+		saveProfile(t, "none", "")
+
+		vc := issue(t, "none", &IssueCredentialRequest{Credential: []byte(noIDVC)}, http.StatusCreated)
+		require.Empty(t, vc.ID)
+	})
+
+	t.Run("duplicate IDs are rejected within the profile", func(t *testing.T) {
+		saveProfile(t, "duplicate", "")
+		saveProfile(t, "other", "")
+
+		issue(t, "duplicate", &IssueCredentialRequest{Credential: []byte(batchVC)}, http.StatusCreated)
+		issue(t, "duplicate", &IssueCredentialRequest{Credential: []byte(batchVC)}, http.StatusBadRequest)
+		issue(t, "other", &IssueCredentialRequest{Credential: []byte(batchVC)}, http.StatusCreated)
+
+		reqBytes, err := json.Marshal(&BatchIssueCredentialRequest{Items: []*BatchIssueCredentialItem{
+			{Compose: &ComposeCredentialRequest{CredentialID: "http://example.edu/credentials/2"}},
+			{Compose: &ComposeCredentialRequest{CredentialID: "http://example.edu/credentials/2"}},
+			{Credential: []byte(batchVC)},
+		}})
+		require.NoError(t, err)
+
+		rr := serveHTTPMux(t, getHandler(t, op, issueCredentialsPath, issuerMode),
+			"/duplicate/credentials/issueCredentials", reqBytes, map[string]string{profileIDPathParam: "duplicate"})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		resp := &struct {
+			Results []struct {
+				Credential json.RawMessage `json:"credential"`
+				Error      string          `json:"error"`
+			} `json:"results"`
+		}{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Len(t, resp.Results, 3)
+		require.NotEmpty(t, resp.Results[0].Credential)
+		require.Contains(t, resp.Results[1].Error, "duplicate credential ID: http://example.edu/credentials/2 is "+
+			"duplicated in the batch")
+		require.Contains(t, resp.Results[2].Error, "has already been issued")
+	})
+
+	t.Run("registry error", func(t *testing.T) {
+		saveProfile(t, "error", "")
+
+		store := &mockstore.MockStore{Store: make(map[string][]byte), ErrGet: errors.New("get error")}
+		store.Store["issued_5:error_http://example.edu/credentials/1872"] = []byte("{}")
+		store.Store["issued_5:error_http://example.edu/credentials/3"] = []byte("{}")
+		op.issuanceRegistry = issuanceregistry.New(store)
+
+		issue(t, "error", &IssueCredentialRequest{Credential: []byte(batchVC)}, http.StatusInternalServerError)
+		issue(t, "error", &ComposeCredentialRequest{CredentialID: "http://example.edu/credentials/3"},
+			http.StatusInternalServerError)
+	})
+}
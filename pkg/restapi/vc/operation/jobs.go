@@ -0,0 +1,222 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	"github.com/trustbloc/edge-service/pkg/internal/common/fetch"
+	"github.com/trustbloc/edge-service/pkg/job"
+)
+
+const (
+	issueCredentialsJobType = "issueCredentials"
+	createProfileJobType    = "createProfile"
+)
+
+// issueCredentialsJobRequest is the persisted request of the batch issuance job
+type issueCredentialsJobRequest struct {
+	Profile string                      `json:"profile"`
+	Items   []*BatchIssueCredentialItem `json:"items"`
+}
+
+func (o *Operation) registerJobs() {
+	// the batch issuance isn't idempotent (the credential IDs are reserved and the statuses are allocated before
+	// the credentials are recorded) so it isn't retried either
+	o.jobs.Register(issueCredentialsJobType, o.issueCredentialsJob, job.WithoutRetry())
+	// the profile creation isn't idempotent (the profile DID is created with the profile) so it isn't retried
+	o.jobs.Register(createProfileJobType, o.createProfileJob, job.WithoutRetry())
+}
+
+// SubmitJob swagger:route POST /jobs issuer submitJobReq
+//
+// Submits the asynchronous job (e.g. batch issuance or profile creation), the job status is polled with the job ID
+// and the webhook of the profile is notified when the job is completed.
+//
+// Responses:
+//    default: genericError
+//        202: jobRes
+func (o *Operation) submitJobHandler(rw http.ResponseWriter, req *http.Request) {
+	request := &JobRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	var (
+		jobRequest interface{}
+		webhook    *vcprofile.Webhook
+		err        error
+	)
+
+	switch request.Type {
+	case issueCredentialsJobType:
+		jobRequest, webhook, err = o.prepareIssueCredentialsJob(request)
+	case createProfileJobType:
+		jobRequest, webhook, err = prepareCreateProfileJob(request)
+	default:
+		err = fmt.Errorf("unsupported job type %s", request.Type)
+	}
+
+	if err == nil {
+		err = o.checkWebhook(webhook)
+	}
+
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	if webhook == nil {
+		webhook = &vcprofile.Webhook{}
+	}
+
+	j, err := o.jobs.Submit(request.Type, jobRequest, webhook.URL, webhook.Secret)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to submit job: %s", err.Error()))
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusAccepted)
+	o.writeResponse(rw, newJobResponse(j))
+}
+
+// GetJob swagger:route GET /jobs/{id} issuer getJobReq
+//
+// Retrieves the asynchronous job status and its result once completed.
+//
+// Responses:
+//    default: genericError
+//        200: jobRes
+func (o *Operation) getJobHandler(rw http.ResponseWriter, req *http.Request) {
+	j, err := o.jobs.Get(mux.Vars(req)["id"])
+	if err != nil {
+		if errors.Is(err, job.ErrJobNotFound) {
+			o.writeErrorResponse(rw, http.StatusNotFound, err.Error())
+
+			return
+		}
+
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, newJobResponse(j))
+}
+
+func (o *Operation) prepareIssueCredentialsJob(request *JobRequest) (interface{}, *vcprofile.Webhook, error) {
+	profile, err := o.profileStore.GetProfile(request.Profile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid issuer profile - id=%s: err=%w", request.Profile, err)
+	}
+
+	batch := &BatchIssueCredentialRequest{}
+
+	if err := json.Unmarshal(request.Request, batch); err != nil {
+		return nil, nil, err
+	}
+
+	if len(batch.Items) == 0 || len(batch.Items) > maxBatchSize {
+		return nil, nil, fmt.Errorf("batch must have 1 to %d items", maxBatchSize)
+	}
+
+	return &issueCredentialsJobRequest{Profile: profile.Name, Items: batch.Items}, profile.Webhook, nil
+}
+
+func prepareCreateProfileJob(request *JobRequest) (interface{}, *vcprofile.Webhook, error) {
+	pr := &ProfileRequest{}
+
+	if err := json.Unmarshal(request.Request, pr); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateProfileRequest(pr); err != nil {
+		return nil, nil, err
+	}
+
+	return pr, pr.Webhook, nil
+}
+
+func (o *Operation) issueCredentialsJob(request json.RawMessage) (interface{}, error) {
+	jobRequest := &issueCredentialsJobRequest{}
+
+	if err := json.Unmarshal(request, jobRequest); err != nil {
+		return nil, err
+	}
+
+	profile, err := o.profileStore.GetProfile(jobRequest.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issuer profile - id=%s: err=%w", jobRequest.Profile, err)
+	}
+
+	results, err := o.issueBatch(profile, jobRequest.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BatchIssueCredentialResponse{Results: results}, nil
+}
+
+func (o *Operation) createProfileJob(request json.RawMessage) (interface{}, error) {
+	pr := &ProfileRequest{}
+
+	if err := json.Unmarshal(request, pr); err != nil {
+		return nil, err
+	}
+
+	profile, err := o.createAndSaveIssuerProfile(pr)
+	if err != nil {
+		return nil, err
+	}
+
+	return withoutWebhookSecret(profile), nil
+}
+
+// checkWebhook checks the webhook could be notified, i.e. its URL is https URL of the allowed webhook host, or of
+// the public host if no webhook hosts are allowed explicitly
+func (o *Operation) checkWebhook(webhook *vcprofile.Webhook) error {
+	if webhook == nil || webhook.URL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(webhook.URL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	if err := fetch.CheckURL(u, o.webhookAllowedHosts); err != nil {
+		return fmt.Errorf("invalid webhook URL %s: %w", webhook.URL, err)
+	}
+
+	return nil
+}
+
+func newJobResponse(j *job.Job) *JobResponse {
+	return &JobResponse{
+		ID:       j.ID,
+		Type:     j.Type,
+		Status:   string(j.Status),
+		Attempts: j.Attempts,
+		Result:   j.Result,
+		Error:    j.Error,
+		Created:  j.Created,
+		Updated:  j.Updated,
+	}
+}
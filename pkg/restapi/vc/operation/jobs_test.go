@@ -0,0 +1,328 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/composite/ecdhes"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
+	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage/memstore"
+	"github.com/trustbloc/edge-core/pkg/storage/mockstore"
+
+	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	cslstatus "github.com/trustbloc/edge-service/pkg/doc/vc/status/csl"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/didbloc"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/edv"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
+	"github.com/trustbloc/edge-service/pkg/job"
+)
+
+func TestJobs(t *testing.T) {
+	defer setTestContexts(t)()
+
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	notifications := make(chan *job.Notification, 1)
+
+	webhook := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.Equal(t, job.Sign(body, "secret"), req.Header.Get(job.SignatureHeader))
+
+		n := &job.Notification{}
+		require.NoError(t, json.Unmarshal(body, n))
+
+		notifications <- n
+	}))
+	defer webhook.Close()
+
+	webhookURL, err := url.Parse(webhook.URL)
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		EDVClient:          edv.NewMockEDVClient("test", nil, nil, []string{"testID"}),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI: &vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+			return createDIDDoc(didID, pubKey), nil
+		}},
+		Crypto:              &cryptomock.Crypto{},
+		TLSConfig:           webhook.Client().Transport.(*http.Transport).TLSClientConfig,
+		WebhookAllowedHosts: []string{webhookURL.Host},
+		JobOptions:          []job.Option{job.WithBackoff(time.Millisecond), job.WithMaxAttempts(2)},
+	})
+	require.NoError(t, err)
+
+	op.didBlocClient = &didbloc.Client{CreateDIDValue: createDefaultDID()}
+
+	profile := getTestProfile()
+	profile.SignatureRepresentation = verifiable.SignatureJWS
+	profile.SignatureType = vccrypto.JSONWebSignature2020
+	profile.Webhook = &vcprofile.Webhook{URL: webhook.URL, Secret: "secret"}
+
+	require.NoError(t, op.profileStore.SaveProfile(profile))
+
+	submitJob := getHandler(t, op, jobsEndpoint, issuerMode)
+	getJob := getHandler(t, op, jobEndpoint, issuerMode)
+
+	submit := func(t *testing.T, request *JobRequest) *JobResponse {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		rr := serveHTTPMux(t, submitJob, jobsEndpoint, reqBytes, nil)
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+
+		resp := &JobResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.NotEmpty(t, resp.ID)
+		require.Equal(t, request.Type, resp.Type)
+
+		return resp
+	}
+
+	waitForNotification := func(t *testing.T, id string) *job.Notification {
+		t.Helper()
+
+		select {
+		case n := <-notifications:
+			require.Equal(t, id, n.ID)
+
+			return n
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "webhook wasn't notified")
+		}
+
+		return nil
+	}
+
+	t.Run("issue credentials job", func(t *testing.T) {
+		items, err := json.Marshal(&BatchIssueCredentialRequest{Items: []*BatchIssueCredentialItem{
+			{Credential: []byte(batchVC)},
+			{Credential: []byte(`{"@context": 1}`)},
+		}})
+		require.NoError(t, err)
+
+		resp := submit(t, &JobRequest{Type: issueCredentialsJobType, Profile: profile.Name, Request: items})
+
+		n := waitForNotification(t, resp.ID)
+		require.Equal(t, job.StatusCompleted, n.Status)
+
+		rr := serveHTTPMux(t, getJob, jobsEndpoint+"/"+resp.ID, nil, map[string]string{"id": resp.ID})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp = &JobResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, string(job.StatusCompleted), resp.Status)
+		require.Equal(t, 1, resp.Attempts)
+
+		result := &struct {
+			Results []struct {
+				Credential json.RawMessage `json:"credential"`
+				Error      string          `json:"error"`
+			} `json:"results"`
+		}{}
+		require.NoError(t, json.Unmarshal(resp.Result, result))
+		require.Len(t, result.Results, 2)
+		require.NotEmpty(t, result.Results[0].Credential)
+		require.Contains(t, result.Results[1].Error, "failed to validate credential")
+	})
+
+	t.Run("create profile job", func(t *testing.T) {
+		pr, err := json.Marshal(&ProfileRequest{
+			Name:          "async",
+			URI:           "https://example.com/credentials",
+			SignatureType: vccrypto.Ed25519Signature2018,
+			DIDKeyType:    vccrypto.Ed25519KeyType,
+			Webhook:       &vcprofile.Webhook{URL: webhook.URL, Secret: "secret"},
+		})
+		require.NoError(t, err)
+
+		resp := submit(t, &JobRequest{Type: createProfileJobType, Request: pr})
+
+		n := waitForNotification(t, resp.ID)
+		require.Equal(t, job.StatusCompleted, n.Status)
+
+		created := &vcprofile.DataProfile{}
+		require.NoError(t, json.Unmarshal(n.Result, created))
+		require.Equal(t, "async", created.Name)
+		require.Equal(t, webhook.URL, created.Webhook.URL)
+		require.Empty(t, created.Webhook.Secret)
+
+		saved, err := op.profileStore.GetProfile("async")
+		require.NoError(t, err)
+		require.Equal(t, created.DID, saved.DID)
+		require.Equal(t, "secret", saved.Webhook.Secret)
+	})
+
+	t.Run("failed job", func(t *testing.T) {
+		op.didBlocClient = &didbloc.Client{CreateDIDErr: errors.New("create DID error")}
+
+		pr, err := json.Marshal(&ProfileRequest{
+			Name:          "failed",
+			URI:           "https://example.com/credentials",
+			SignatureType: vccrypto.Ed25519Signature2018,
+			DIDKeyType:    vccrypto.Ed25519KeyType,
+			Webhook:       &vcprofile.Webhook{URL: webhook.URL, Secret: "secret"},
+		})
+		require.NoError(t, err)
+
+		resp := submit(t, &JobRequest{Type: createProfileJobType, Request: pr})
+
+		n := waitForNotification(t, resp.ID)
+		require.Equal(t, job.StatusFailed, n.Status)
+		require.Contains(t, n.Error, "create DID error")
+
+		// the profile creation isn't retried
+		j, err := op.jobs.Get(resp.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, j.Attempts)
+	})
+
+	t.Run("issue credentials job isn't retried", func(t *testing.T) {
+		statusManager := op.vcStatusManager
+		op.vcStatusManager = &onceFailingStatusManager{vcStatusManager: statusManager}
+
+		defer func() { op.vcStatusManager = statusManager }()
+
+		items, err := json.Marshal(&BatchIssueCredentialRequest{Items: []*BatchIssueCredentialItem{
+			{Credential: []byte(strings.Replace(batchVC, "credentials/1872", "credentials/1890", 1))},
+		}})
+		require.NoError(t, err)
+
+		// the status allocation fails once, the job fails on the first attempt although two attempts are configured
+		resp := submit(t, &JobRequest{Type: issueCredentialsJobType, Profile: profile.Name, Request: items})
+
+		n := waitForNotification(t, resp.ID)
+		require.Equal(t, job.StatusFailed, n.Status)
+		require.Contains(t, n.Error, "failed to add credential status: status error")
+
+		j, err := op.jobs.Get(resp.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, j.Attempts)
+		require.Empty(t, j.Result)
+
+		// the ID stays reserved by the failed attempt, so the retry would only report the credential as duplicated
+		resp = submit(t, &JobRequest{Type: issueCredentialsJobType, Profile: profile.Name, Request: items})
+
+		n = waitForNotification(t, resp.ID)
+		require.Equal(t, job.StatusCompleted, n.Status)
+
+		result := &BatchIssueCredentialResponse{}
+		require.NoError(t, json.Unmarshal(n.Result, result))
+		require.Len(t, result.Results, 1)
+		require.Empty(t, result.Results[0].Credential)
+		require.Contains(t, result.Results[0].Error, "http://example.edu/credentials/1890 is being issued")
+	})
+
+	t.Run("invalid webhook", func(t *testing.T) {
+		invalid := getTestProfile()
+		invalid.Name = "invalid-webhook"
+		invalid.Webhook = &vcprofile.Webhook{URL: "https://webhooks.example.com"}
+
+		require.NoError(t, op.profileStore.SaveProfile(invalid))
+
+		for _, tc := range []struct {
+			request string
+			err     string
+		}{
+			{`{"type": "issueCredentials", "profile": "invalid-webhook", "request": {"items": [{}]}}`,
+				"invalid webhook URL https://webhooks.example.com: host webhooks.example.com is not allowed"},
+			{`{"type": "createProfile", "request": {"name": "webhook", "uri": "https://example.com",
+				"signatureType": "Ed25519Signature2018", "webhook": {"url": "http://` + webhookURL.Host + `"}}}`,
+				"only https URLs are supported"},
+		} {
+			rr := serveHTTPMux(t, submitJob, jobsEndpoint, []byte(tc.request), nil)
+			require.Equal(t, http.StatusBadRequest, rr.Code, tc.request)
+			require.Contains(t, rr.Body.String(), tc.err)
+		}
+	})
+
+	t.Run("invalid job requests", func(t *testing.T) {
+		for _, tc := range []struct {
+			request string
+			err     string
+		}{
+			{`invalid`, invalidRequestErrMsg},
+			{`{"type": "unsupported"}`, "unsupported job type unsupported"},
+			{`{"type": "issueCredentials", "profile": "invalid"}`, "invalid issuer profile"},
+			{`{"type": "issueCredentials", "profile": "test", "request": {"items": []}}`, "batch must have 1 to"},
+			{`{"type": "issueCredentials", "profile": "test", "request": []}`, invalidRequestErrMsg},
+			{`{"type": "createProfile", "request": {}}`, "missing profile name"},
+			{`{"type": "createProfile", "request": []}`, invalidRequestErrMsg},
+		} {
+			rr := serveHTTPMux(t, submitJob, jobsEndpoint, []byte(tc.request), nil)
+			require.Equal(t, http.StatusBadRequest, rr.Code, tc.request)
+			require.Contains(t, rr.Body.String(), tc.err)
+		}
+	})
+
+	t.Run("job not found", func(t *testing.T) {
+		rr := serveHTTPMux(t, getJob, jobsEndpoint+"/invalid", nil, map[string]string{"id": "invalid"})
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), job.ErrJobNotFound.Error())
+	})
+}
+
+// onceFailingStatusManager fails to allocate the credential statuses once
+type onceFailingStatusManager struct {
+	vcStatusManager
+	failed bool
+}
+
+func (m *onceFailingStatusManager) CreateStatusIDs(n int) ([]*cslstatus.StatusID, error) {
+	if !m.failed {
+		m.failed = true
+
+		return nil, errors.New("status error")
+	}
+
+	return m.vcStatusManager.CreateStatusIDs(n)
+}
+
+func TestNewResumeJobsError(t *testing.T) {
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	storeProvider := mockstore.NewMockStoreProvider()
+	storeProvider.Store.Store["job_pending"] = []byte("invalid")
+
+	op, err := New(&Config{
+		StoreProvider:      storeProvider,
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		Crypto:             &cryptomock.Crypto{},
+	})
+	require.Error(t, err)
+	require.Nil(t, op)
+	require.Contains(t, err.Error(), "failed to resume jobs")
+}
@@ -0,0 +1,352 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/cm"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+)
+
+const (
+	manifestIDPathParam = "manifestID"
+
+	verifiablePresentationType = "VerifiablePresentation"
+)
+
+// CredentialManifests swagger:route GET /{id}/credentials/manifests issuer credentialManifestsReq
+//
+// Retrieves the DIF credential manifests of the profile credential templates.
+//
+// Responses:
+//    default: genericError
+//        200: credentialManifestsRes
+func (o *Operation) credentialManifestsHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.oidc4vciProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	manifests := make([]*cm.CredentialManifest, 0, len(profile.CredentialTemplates))
+
+	for _, template := range profile.CredentialTemplates {
+		manifests = append(manifests, credentialManifest(profile, template))
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, &CredentialManifestsResponse{Manifests: manifests})
+}
+
+// CredentialManifest swagger:route GET /{id}/credentials/manifests/{manifestID} issuer credentialManifestReq
+//
+// Retrieves the DIF credential manifest of the profile credential template.
+//
+// Responses:
+//    default: genericError
+//        200: credentialManifestRes
+func (o *Operation) credentialManifestHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.oidc4vciProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	manifestID := mux.Vars(req)[manifestIDPathParam]
+
+	template := credentialTemplate(profile, manifestID)
+	if template == nil {
+		o.writeErrorResponse(rw, http.StatusNotFound, fmt.Sprintf("profile %s has no credential manifest %s",
+			profile.Name, manifestID))
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, credentialManifest(profile, template))
+}
+
+// CredentialApplicationRequest swagger:route GET /{id}/credentials/applications issuer credentialApplicationRequestReq
+//
+// Issues the DID authentication request answered by the credential application, the challenge of the request
+// is accepted once.
+//
+// Responses:
+//    default: genericError
+//        200: didAuthRes
+func (o *Operation) credentialApplicationRequestHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.oidc4vciProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	// the challenge is scoped to the applications endpoint so it isn't accepted by the other DID authentication flows
+	c, err := o.challenges.Issue(profile.Name, o.oidc4vciURL(credentialApplicationsPath, profile))
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, &DIDAuthRequest{
+		Query:     []*DIDAuthQuery{{Type: didAuthQueryType}},
+		Challenge: c.Value,
+		Domain:    c.Domain,
+	})
+}
+
+// CredentialApplication swagger:route POST /{id}/credentials/applications issuer credentialApplicationReq
+//
+// Issues the credential of the manifest for the credential application. The application presentation is signed
+// by the applicant with the challenge of the application request and satisfies the presentation definition
+// of the manifest, the input credentials are signed and issued to the applicant. The credential is issued to the
+// applicant with the values of the presentation definition fields with IDs.
+//
+// Responses:
+//    default: genericError
+//        201: credentialFulfillmentRes
+func (o *Operation) credentialApplicationHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.oidc4vciProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	request := &CredentialApplicationRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	application, err := cm.ParseApplication(request.Presentation)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid credential application: %s",
+			err.Error()))
+
+		return
+	}
+
+	template := credentialTemplate(profile, application.ManifestID)
+	if template == nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("profile %s has no credential manifest %s",
+			profile.Name, application.ManifestID))
+
+		return
+	}
+
+	// the applicants don't supply the credential IDs
+	if profile.CredentialIDPolicy == vcprofile.CredentialIDClient {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("profile %s requires the client credential IDs",
+			profile.Name))
+
+		return
+	}
+
+	applicant, claims, err := o.verifyCredentialApplication(profile, template, request.Presentation)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid credential application: %s",
+			err.Error()))
+
+		return
+	}
+
+	credential, err := o.composeTemplateCredential(profile, template, applicant, claims, nil)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to compose credential: %s",
+			err.Error()))
+
+		return
+	}
+
+	signedVC, status, err := o.signTemplateCredential(profile, credential)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	if err := o.recordIssued(profile, &issuedCredential{credential: signedVC, status: status}); err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	o.writeResponse(rw, &CredentialFulfillmentResponse{
+		Context: []string{baseContext(profile)},
+		Type:    []string{verifiablePresentationType, cm.FulfillmentType},
+		Fulfillment: &cm.CredentialFulfillment{
+			ID:            uuidURNPrefix + uuid.New().String(),
+			ManifestID:    template.ID,
+			ApplicationID: application.ID,
+			DescriptorMap: []*presexch.InputDescriptorMapping{{
+				ID: template.ID, Format: cm.LDPVCFormat, Path: "$.verifiableCredential[0]",
+			}},
+		},
+		Credentials: []*verifiable.Credential{signedVC},
+	})
+}
+
+// useDIDAuthChallenge accepts the challenge of the DID authentication presentation issued by the profile for the
+// endpoint, the presentation must be signed for the endpoint domain so the challenges of the other endpoints of the
+// profile are rejected
+func (o *Operation) useDIDAuthChallenge(vp *verifiable.Presentation, profile *vcprofile.DataProfile,
+	endpoint string) error {
+	challenge, _ := vp.Proofs[0]["challenge"].(string) // nolint: errcheck
+	domain, _ := vp.Proofs[0]["domain"].(string)       // nolint: errcheck
+
+	if domain != endpoint {
+		return fmt.Errorf("invalid challenge: presentation is signed for domain %s instead of %s", domain, endpoint)
+	}
+
+	if err := o.challenges.Use(challenge, profile.Name, domain); err != nil {
+		return fmt.Errorf("invalid challenge: %w", err)
+	}
+
+	return nil
+}
+
+// verifyCredentialApplication verifies the application presentation and returns the applicant DID with the claims
+// of the credential selected by the presentation definition of the template
+func (o *Operation) verifyCredentialApplication(profile *vcprofile.DataProfile, template *vcprofile.CredentialTemplate,
+	vpBytes []byte) (string, json.RawMessage, error) {
+	vp, err := o.parseAndVerifyVP(vpBytes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(vp.Proofs) == 0 {
+		return "", nil, errors.New("presentation must be signed by the applicant")
+	}
+
+	// the challenge is issued by the application request and is accepted only once to prevent application replay
+	if err := o.useDIDAuthChallenge(vp, profile, o.oidc4vciURL(credentialApplicationsPath, profile)); err != nil {
+		return "", nil, err
+	}
+
+	applicant, err := presenterDID(vp)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := checkInputCredentials(vpBytes, vp, applicant); err != nil {
+		return "", nil, err
+	}
+
+	if template.PresentationDefinition == nil {
+		return applicant, nil, nil
+	}
+
+	results, err := template.PresentationDefinition.Evaluate(vpBytes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	claims := make(map[string]interface{})
+
+	for _, result := range results {
+		if !result.Satisfied {
+			return "", nil, fmt.Errorf("input descriptor %s isn't satisfied: %s", result.ID, result.Error)
+		}
+
+		for k, v := range result.Values {
+			claims[k] = v
+		}
+	}
+
+	if len(claims) == 0 {
+		return applicant, nil, nil
+	}
+
+	claimsBytes, err := json.Marshal(claims)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return applicant, claimsBytes, nil
+}
+
+// checkInputCredentials checks the input credentials of the application carry the proofs (verified with the
+// presentation) and are issued to the applicant
+func checkInputCredentials(vpBytes []byte, vp *verifiable.Presentation, applicant string) error {
+	raw := &struct {
+		Credentials interface{} `json:"verifiableCredential"`
+	}{}
+
+	if err := json.Unmarshal(vpBytes, raw); err != nil {
+		return err
+	}
+
+	rawCredentials, ok := raw.Credentials.([]interface{})
+	if !ok {
+		rawCredentials = []interface{}{raw.Credentials}
+	}
+
+	for i, cred := range vp.Credentials() {
+		vcBytes, err := credentialBytes(cred)
+		if err != nil {
+			return err
+		}
+
+		vc, err := verifiable.NewUnverifiedCredential(vcBytes)
+		if err != nil {
+			return err
+		}
+
+		// the proof of the credential in JWT format is its signature
+		var jws string
+
+		isJWT := false
+		if i < len(rawCredentials) {
+			jws, isJWT = rawCredentials[i].(string)
+		}
+
+		if isJWT && !jwt.IsJWS(jws) || !isJWT && len(vc.Proofs) == 0 {
+			return fmt.Errorf("input credential %s isn't signed", vc.ID)
+		}
+
+		if !containsString(subjectIDs(vc.Subject), applicant) {
+			return fmt.Errorf("applicant %s is not the subject of input credential %s", applicant, vc.ID)
+		}
+	}
+
+	return nil
+}
+
+// credentialManifest returns the credential manifest of the profile template, the output descriptor schema
+// is the credential subject schema of the template or its most specific credential type
+func credentialManifest(profile *vcprofile.DataProfile, template *vcprofile.CredentialTemplate) *cm.CredentialManifest {
+	schemaID := template.Schema
+	if schemaID == "" {
+		schemaID = template.Types[len(template.Types)-1]
+	}
+
+	return &cm.CredentialManifest{
+		ID:     template.ID,
+		Issuer: &cm.Issuer{ID: profile.DID, Name: profile.Name},
+		OutputDescriptors: []*cm.OutputDescriptor{{
+			ID:          template.ID,
+			Schema:      schemaID,
+			Name:        template.Name,
+			Description: template.Description,
+		}},
+		Format: map[string]*cm.Format{
+			cm.LDPVCFormat: {ProofTypes: []string{profile.SignatureType}},
+		},
+		PresentationDefinition: template.PresentationDefinition,
+	}
+}
@@ -0,0 +1,394 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/composite/ecdhes"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
+	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage/memstore"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/cm"
+	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/datamodel"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/schema"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
+)
+
+func TestCredentialManifest(t *testing.T) {
+	defer setTestContexts(t)()
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI: &vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+			return createJWKDIDDoc(t, didID, pubKey), nil
+		}},
+		Crypto:  &cryptomock.Crypto{},
+		HostURL: "http://example.com",
+	})
+	require.NoError(t, err)
+
+	definition := &presexch.PresentationDefinition{
+		ID: "degree-application",
+		InputDescriptors: []*presexch.InputDescriptor{{
+			ID:     "degree",
+			Schema: []*presexch.Schema{{URI: examplesV1Context + "#UniversityDegreeCredential"}},
+			Constraints: &presexch.Constraints{Fields: []*presexch.Field{{
+				ID:   "degree",
+				Path: []string{"$.credentialSubject.degree"},
+			}}},
+		}},
+	}
+
+	profile := getTestProfile()
+	profile.SignatureRepresentation = verifiable.SignatureJWS
+	profile.SignatureType = vccrypto.JSONWebSignature2020
+	profile.CredentialIDPolicy = vcprofile.CredentialIDUUID
+	profile.CredentialTemplates = []*vcprofile.CredentialTemplate{
+		{
+			ID:                     degreeTemplateID,
+			Name:                   "University Degree",
+			Description:            "Degree of the university graduates",
+			Types:                  []string{"VerifiableCredential", "UniversityDegreeCredential"},
+			Context:                []string{examplesV1Context},
+			Schema:                 "https://example.com/schemas/degree.json",
+			PresentationDefinition: definition,
+		},
+		{
+			ID:    "Membership",
+			Types: []string{"VerifiableCredential", "MembershipCredential"},
+		},
+	}
+
+	require.NoError(t, op.profileStore.SaveProfile(profile))
+
+	urlVars := map[string]string{profileIDPathParam: profile.Name}
+
+	t.Run("test get manifests", func(t *testing.T) {
+		rr := serveHTTPMux(t, getHandler(t, op, credentialManifestsPath, issuerMode), "/test/credentials/manifests",
+			nil, urlVars)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		resp := &CredentialManifestsResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Len(t, resp.Manifests, 2)
+
+		degree := resp.Manifests[0]
+		require.NoError(t, degree.Validate())
+		require.Equal(t, degreeTemplateID, degree.ID)
+		require.Equal(t, &cm.Issuer{ID: profile.DID, Name: profile.Name}, degree.Issuer)
+		require.Equal(t, []*cm.OutputDescriptor{{
+			ID:          degreeTemplateID,
+			Schema:      "https://example.com/schemas/degree.json",
+			Name:        "University Degree",
+			Description: "Degree of the university graduates",
+		}}, degree.OutputDescriptors)
+		require.Equal(t, []string{vccrypto.JSONWebSignature2020}, degree.Format[cm.LDPVCFormat].ProofTypes)
+		require.Equal(t, definition.ID, degree.PresentationDefinition.ID)
+
+		membership := resp.Manifests[1]
+		require.NoError(t, membership.Validate())
+		require.Equal(t, "MembershipCredential", membership.OutputDescriptors[0].Schema)
+		require.Nil(t, membership.PresentationDefinition)
+
+		rr = serveHTTPMux(t, getHandler(t, op, credentialManifestPath, issuerMode), "", nil,
+			map[string]string{profileIDPathParam: profile.Name, manifestIDPathParam: "Membership"})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		manifest := &cm.CredentialManifest{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), manifest))
+		require.Equal(t, membership, manifest)
+
+		rr = serveHTTPMux(t, getHandler(t, op, credentialManifestPath, issuerMode), "", nil,
+			map[string]string{profileIDPathParam: profile.Name, manifestIDPathParam: "unknown"})
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), "profile test has no credential manifest unknown")
+
+		rr = serveHTTPMux(t, getHandler(t, op, credentialManifestsPath, issuerMode), "", nil,
+			map[string]string{profileIDPathParam: "unknown"})
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	issued := time.Now().UTC().Truncate(time.Second)
+
+	degreeVC := &verifiable.Credential{
+		Context: []string{datamodel.ContextV1, examplesV1Context, jsonWebSignature2020Context},
+		ID:      "http://example.edu/credentials/1872",
+		Types:   []string{"VerifiableCredential", "UniversityDegreeCredential"},
+		Issuer:  verifiable.Issuer{ID: "did:example:university"},
+		Issued:  &issued,
+		Subject: map[string]interface{}{
+			"id":     holderDID,
+			"degree": map[string]interface{}{"type": "BachelorDegree", "name": "Bachelor of Science and Arts"},
+		},
+	}
+
+	signLDP(t, degreeVC, privKey, "did:example:university#key-1", "", "")
+
+	applicationRequest := func(t *testing.T) *DIDAuthRequest {
+		t.Helper()
+
+		rr := serveHTTPMux(t, getMethodHandler(t, op, credentialApplicationsPath, http.MethodGet, issuerMode),
+			"/test/credentials/applications", nil, urlVars)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		request := &DIDAuthRequest{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), request))
+
+		return request
+	}
+
+	signedApplication := func(t *testing.T, manifestID string, credential *verifiable.Credential,
+		challenge, domain string) []byte {
+		t.Helper()
+
+		vp := &verifiable.Presentation{
+			Context: []string{datamodel.ContextV1},
+			Type:    []string{"VerifiablePresentation"},
+			Holder:  holderDID,
+		}
+
+		if credential != nil {
+			require.NoError(t, vp.SetCredentials(credential))
+		}
+
+		signLDP(t, vp, privKey, holderDID+"#key-1", challenge, domain)
+
+		vpBytes, err := vp.MarshalJSON()
+		require.NoError(t, err)
+
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(vpBytes, &doc))
+
+		doc[cm.ApplicationField] = &cm.CredentialApplication{ID: "9b1deb4d", ManifestID: manifestID}
+		doc["presentation_submission"] = &presexch.PresentationSubmission{
+			DefinitionID: definition.ID,
+			DescriptorMap: []*presexch.InputDescriptorMapping{{
+				ID: "degree", Format: cm.LDPVCFormat, Path: "$.verifiableCredential[0]",
+			}},
+		}
+
+		vpBytes, err = json.Marshal(doc)
+		require.NoError(t, err)
+
+		return vpBytes
+	}
+
+	application := func(t *testing.T, manifestID string, credential *verifiable.Credential) []byte {
+		t.Helper()
+
+		request := applicationRequest(t)
+
+		return signedApplication(t, manifestID, credential, request.Challenge, request.Domain)
+	}
+
+	fulfillment := func(t *testing.T, body []byte) (*CredentialFulfillmentResponse, []*verifiable.Credential) {
+		t.Helper()
+
+		resp := &struct {
+			CredentialFulfillmentResponse
+			Credentials []json.RawMessage `json:"verifiableCredential"`
+		}{}
+		require.NoError(t, json.Unmarshal(body, resp))
+
+		credentials := make([]*verifiable.Credential, 0, len(resp.Credentials))
+
+		for _, vcBytes := range resp.Credentials {
+			vc, err := verifiable.NewUnverifiedCredential(vcBytes)
+			require.NoError(t, err)
+
+			credentials = append(credentials, vc)
+		}
+
+		return &resp.CredentialFulfillmentResponse, credentials
+	}
+
+	apply := func(t *testing.T, vp []byte) (int, []byte) {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(&CredentialApplicationRequest{Presentation: vp})
+		require.NoError(t, err)
+
+		rr := serveHTTPMux(t, getMethodHandler(t, op, credentialApplicationsPath, http.MethodPost, issuerMode),
+			"/test/credentials/applications", reqBytes, urlVars)
+
+		return rr.Code, rr.Body.Bytes()
+	}
+
+	t.Run("test credential application is fulfilled", func(t *testing.T) {
+		request := applicationRequest(t)
+		require.Equal(t, []*DIDAuthQuery{{Type: didAuthQueryType}}, request.Query)
+		require.NotEmpty(t, request.Challenge)
+		require.Equal(t, "http://example.com/test/credentials/applications", request.Domain)
+
+		vp := signedApplication(t, degreeTemplateID, degreeVC, request.Challenge, request.Domain)
+
+		code, body := apply(t, vp)
+		require.Equal(t, http.StatusCreated, code, string(body))
+
+		resp, credentials := fulfillment(t, body)
+		require.Equal(t, []string{"VerifiablePresentation", cm.FulfillmentType}, resp.Type)
+		require.Equal(t, degreeTemplateID, resp.Fulfillment.ManifestID)
+		require.Equal(t, "9b1deb4d", resp.Fulfillment.ApplicationID)
+		require.NotEmpty(t, resp.Fulfillment.ID)
+		require.Equal(t, []*presexch.InputDescriptorMapping{{
+			ID: degreeTemplateID, Format: cm.LDPVCFormat, Path: "$.verifiableCredential[0]",
+		}}, resp.Fulfillment.DescriptorMap)
+		require.Len(t, credentials, 1)
+
+		vc := credentials[0]
+		require.Equal(t, []string{"VerifiableCredential", "UniversityDegreeCredential"}, vc.Types)
+		require.Equal(t, profile.DID, vc.Issuer.ID)
+		require.Len(t, vc.Schemas, 1)
+		require.Equal(t, "https://example.com/schemas/degree.json", vc.Schemas[0].ID)
+		require.Equal(t, schema.JSONSchemaValidator2018, vc.Schemas[0].Type)
+		require.NotEmpty(t, vc.Proofs)
+
+		subject, ok := vc.Subject.(map[string]interface{})
+		require.True(t, ok)
+		require.Equal(t, holderDID, subject["id"])
+		require.Equal(t, map[string]interface{}{"type": "BachelorDegree", "name": "Bachelor of Science and Arts"},
+			subject["degree"])
+
+		record, err := op.issuanceRegistry.GetProfileRecord(profile.Name, vc.ID)
+		require.NoError(t, err)
+		require.Equal(t, profile.DID, record.Issuer)
+
+		// the application isn't accepted again
+		code, body = apply(t, vp)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Contains(t, string(body), "is already used")
+	})
+
+	t.Run("test credential application without inputs", func(t *testing.T) {
+		code, body := apply(t, application(t, "Membership", nil))
+		require.Equal(t, http.StatusCreated, code, string(body))
+
+		_, credentials := fulfillment(t, body)
+		require.Equal(t, []string{"VerifiableCredential", "MembershipCredential"}, credentials[0].Types)
+	})
+
+	t.Run("test invalid credential application", func(t *testing.T) {
+		unsigned, err := json.Marshal(map[string]interface{}{
+			"@context":          []string{datamodel.ContextV1},
+			"type":              "VerifiablePresentation",
+			"holder":            holderDID,
+			cm.ApplicationField: &cm.CredentialApplication{ManifestID: "Membership"},
+		})
+		require.NoError(t, err)
+
+		otherVC := &verifiable.Credential{
+			Context: []string{datamodel.ContextV1, jsonWebSignature2020Context},
+			ID:      "http://example.edu/credentials/1873",
+			Types:   []string{"VerifiableCredential"},
+			Issuer:  verifiable.Issuer{ID: "did:example:university"},
+			Issued:  &issued,
+			Subject: map[string]interface{}{"id": holderDID},
+		}
+
+		signLDP(t, otherVC, privKey, "did:example:university#key-1", "", "")
+
+		unsignedVC := *degreeVC
+		unsignedVC.Proofs = nil
+
+		otherSubjectVC := *otherVC
+		otherSubjectVC.Proofs = nil
+		otherSubjectVC.Subject = map[string]interface{}{"id": "did:example:other"}
+
+		signLDP(t, &otherSubjectVC, privKey, "did:example:university#key-1", "", "")
+
+		// the challenge issued by the refresh request of the profile
+		refreshChallenge, err := op.challenges.Issue(profile.Name, op.refreshServiceURL(profile))
+		require.NoError(t, err)
+
+		tests := []struct {
+			name string
+			vp   []byte
+			err  string
+		}{
+			{"no application", []byte(`{"type": "VerifiablePresentation"}`), "credential_application is missing"},
+			{"unknown manifest", application(t, "unknown", nil), "profile test has no credential manifest unknown"},
+			{"not signed", unsigned, "embedded proof is missing"},
+			{
+				"no challenge", signedApplication(t, "Membership", nil, "", "http://example.com/test/credentials/applications"),
+				"invalid challenge: unknown challenge",
+			},
+			{
+				"challenge of another endpoint", signedApplication(t, degreeTemplateID, degreeVC,
+					refreshChallenge.Value, refreshChallenge.Domain),
+				"invalid challenge: presentation is signed for domain http://example.com/test/credentials/refresh " +
+					"instead of http://example.com/test/credentials/applications",
+			},
+			{
+				"challenge of another domain", signedApplication(t, degreeTemplateID, degreeVC,
+					refreshChallenge.Value, "http://example.com/test/credentials/applications"),
+				"invalid challenge: challenge " + refreshChallenge.Value + " is issued for another domain",
+			},
+			{
+				"unsigned input credential", application(t, degreeTemplateID, &unsignedVC),
+				"input credential http://example.edu/credentials/1872 isn't signed",
+			},
+			{
+				"input credential of another subject", application(t, degreeTemplateID, &otherSubjectVC),
+				"applicant " + holderDID + " is not the subject of input credential",
+			},
+			{
+				"inputs aren't satisfied", application(t, degreeTemplateID, otherVC),
+				"input descriptor degree isn't satisfied: credential doesn't match any schema",
+			},
+		}
+
+		for _, tc := range tests {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				code, body := apply(t, tc.vp)
+				require.Equal(t, http.StatusBadRequest, code)
+				require.Contains(t, string(body), tc.err)
+			})
+		}
+
+		code, body := apply(t, nil)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Contains(t, string(body), "invalid credential application")
+
+		clientIDs := *profile
+		clientIDs.Name = "client"
+		clientIDs.CredentialIDPolicy = vcprofile.CredentialIDClient
+		require.NoError(t, op.profileStore.SaveProfile(&clientIDs))
+
+		reqBytes, err := json.Marshal(&CredentialApplicationRequest{Presentation: application(t, "Membership", nil)})
+		require.NoError(t, err)
+
+		rr := serveHTTPMux(t, getMethodHandler(t, op, credentialApplicationsPath, http.MethodPost, issuerMode), "",
+			reqBytes, map[string]string{profileIDPathParam: "client"})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "profile client requires the client credential IDs")
+	})
+}
@@ -11,6 +11,14 @@ import (
 	"time"
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/cm"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/issuanceregistry"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/issuerregistry"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/oidc4vci"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/vpr"
 )
 
 // CreateCredentialRequest input data for edge service issuer rest api
@@ -24,7 +32,11 @@ type CreateCredentialRequest struct {
 
 // UpdateCredentialStatusRequest request struct for updating vc status
 type UpdateCredentialStatusRequest struct {
-	Credential   string `json:"credential"`
+	Credential string `json:"credential"`
+	// CredentialID of the issued credential, used to look up the credential when it isn't sent
+	CredentialID string `json:"credentialID,omitempty"`
+	// Profile which issued the credential, required with the CredentialID
+	Profile      string `json:"profile,omitempty"`
 	Status       string `json:"status"`
 	StatusReason string `json:"statusReason"`
 }
@@ -47,6 +59,11 @@ type ProfileRequest struct {
 	UNIRegistrar            UNIRegistrar                       `json:"uniRegistrar,omitempty"`
 	DisableVCStatus         bool                               `json:"disableVCStatus"`
 	OverwriteIssuer         bool                               `json:"overwriteIssuer,omitempty"`
+	VCDataModel             string                             `json:"vcDataModel,omitempty"`
+	Webhook                 *vcprofile.Webhook                 `json:"webhook,omitempty"`
+	CredentialIDPolicy      string                             `json:"credentialIDPolicy,omitempty"`
+	RefreshService          bool                               `json:"refreshService,omitempty"`
+	CredentialTemplates     []*vcprofile.CredentialTemplate    `json:"credentialTemplates,omitempty"`
 }
 
 // UNIRegistrar uni-registrar
@@ -88,6 +105,7 @@ type IssueCredentialOptions struct {
 
 // ComposeCredentialRequest for composing and issuing credential.
 type ComposeCredentialRequest struct {
+	CredentialID            string          `json:"credentialID,omitempty"`
 	Issuer                  string          `json:"issuer,omitempty"`
 	Subject                 string          `json:"subject,omitempty"`
 	Types                   []string        `json:"types,omitempty"`
@@ -116,9 +134,33 @@ type CredentialsVerificationRequest struct {
 
 // CredentialsVerificationOptions options for credential verifications.
 type CredentialsVerificationOptions struct {
-	Domain    string   `json:"domain,omitempty"`
-	Challenge string   `json:"challenge,omitempty"`
-	Checks    []string `json:"checks,omitempty"`
+	Domain    string     `json:"domain,omitempty"`
+	Challenge string     `json:"challenge,omitempty"`
+	Checks    []string   `json:"checks,omitempty"`
+	AsOf      *time.Time `json:"asOf,omitempty"`
+	// DetailedReport requests the VerificationReport instead of the check names/failures.
+	DetailedReport bool `json:"detailedReport,omitempty"`
+}
+
+// VerificationReport detailed report of the credential verification.
+type VerificationReport struct {
+	Verified bool           `json:"verified"`
+	Checks   []*CheckReport `json:"checks"`
+}
+
+// CheckReport outcome of the verification check and the data it relied on.
+type CheckReport struct {
+	Check              string    `json:"check"`
+	Status             string    `json:"status"`
+	Started            time.Time `json:"started"`
+	Duration           string    `json:"duration"`
+	Error              string    `json:"error,omitempty"`
+	Violations         []string  `json:"violations,omitempty"`
+	VerificationMethod string    `json:"verificationMethod,omitempty"`
+	DID                string    `json:"did,omitempty"`
+	Issuer             string    `json:"issuer,omitempty"`
+	StatusList         string    `json:"statusList,omitempty"`
+	Warnings           []string  `json:"warnings,omitempty"`
 }
 
 // CredentialsVerificationSuccessResponse resp when credential verification is success.
@@ -133,9 +175,10 @@ type CredentialsVerificationFailResponse struct {
 
 // CredentialsVerificationCheckResult resp containing failure check details.
 type CredentialsVerificationCheckResult struct {
-	Check              string `json:"check,omitempty"`
-	Error              string `json:"error,omitempty"`
-	VerificationMethod string `json:"verificationMethod,omitempty"`
+	Check              string   `json:"check,omitempty"`
+	Error              string   `json:"error,omitempty"`
+	VerificationMethod string   `json:"verificationMethod,omitempty"`
+	Violations         []string `json:"violations,omitempty"`
 }
 
 // VerifyPresentationRequest request for verifying presentation.
@@ -146,14 +189,26 @@ type VerifyPresentationRequest struct {
 
 // VerifyPresentationOptions options for presentation verifications.
 type VerifyPresentationOptions struct {
-	Domain    string   `json:"domain,omitempty"`
-	Challenge string   `json:"challenge,omitempty"`
-	Checks    []string `json:"checks,omitempty"`
+	Domain    string     `json:"domain,omitempty"`
+	Challenge string     `json:"challenge,omitempty"`
+	Checks    []string   `json:"checks,omitempty"`
+	AsOf      *time.Time `json:"asOf,omitempty"`
+	// PresentationDefinition the presentation submission is evaluated against.
+	PresentationDefinition *presexch.PresentationDefinition `json:"presentationDefinition,omitempty"`
+	// Profile is the verifier profile with the presentation definition, used if the definition isn't passed.
+	Profile string `json:"profile,omitempty"`
+	// HolderBindingExemptTypes are the types of the credentials which aren't bound to the subject
+	// and are skipped by the holder binding check.
+	HolderBindingExemptTypes []string `json:"holderBindingExemptTypes,omitempty"`
+	// PresentationSubmission is the submission passed outside of the presentation, it's evaluated
+	// instead of the presentation_submission of the presentation.
+	PresentationSubmission *presexch.PresentationSubmission `json:"presentationSubmission,omitempty"`
 }
 
 // VerifyPresentationSuccessResponse resp when presentation verification is success.
 type VerifyPresentationSuccessResponse struct {
-	Checks []string `json:"checks,omitempty"`
+	Checks           []string                     `json:"checks,omitempty"`
+	InputDescriptors []*presexch.DescriptorResult `json:"inputDescriptors,omitempty"`
 }
 
 // VerifyPresentationFailureResponse resp when presentation verification is failed.
@@ -163,9 +218,210 @@ type VerifyPresentationFailureResponse struct {
 
 // VerifyPresentationCheckResult resp containing failure check details.
 type VerifyPresentationCheckResult struct {
-	Check              string `json:"check,omitempty"`
-	Error              string `json:"error,omitempty"`
-	VerificationMethod string `json:"verificationMethod,omitempty"`
+	Check              string                       `json:"check,omitempty"`
+	Error              string                       `json:"error,omitempty"`
+	VerificationMethod string                       `json:"verificationMethod,omitempty"`
+	Violations         []string                     `json:"violations,omitempty"`
+	InputDescriptors   []*presexch.DescriptorResult `json:"inputDescriptors,omitempty"`
+	Credentials        []*CredentialCheckResult     `json:"credentials,omitempty"`
+}
+
+// CredentialCheckResult failure check details of the credential embedded in the presentation.
+type CredentialCheckResult struct {
+	ID         string   `json:"id,omitempty"`
+	Error      string   `json:"error"`
+	Violations []string `json:"violations,omitempty"`
+}
+
+// BatchVerificationRequest request for verifying credentials and presentations in batch.
+type BatchVerificationRequest struct {
+	Items []*BatchVerificationItem `json:"items"`
+}
+
+// BatchVerificationItem is the credential or presentation to verify along with the verification options.
+type BatchVerificationItem struct {
+	Credential   json.RawMessage `json:"verifiableCredential,omitempty"`
+	Presentation json.RawMessage `json:"verifiablePresentation,omitempty"`
+	Opts         json.RawMessage `json:"options,omitempty"`
+}
+
+// BatchVerificationResponse resp containing the verification results in the order of the request items.
+type BatchVerificationResponse struct {
+	Results []*BatchVerificationResult `json:"results"`
+}
+
+// BatchVerificationResult verification result of the batch item, Result is the response
+// the credential or presentation verification endpoint would return for the item.
+type BatchVerificationResult struct {
+	Verified bool        `json:"verified"`
+	Result   interface{} `json:"result"`
+}
+
+// BatchIssueCredentialRequest request for issuing credentials in batch.
+type BatchIssueCredentialRequest struct {
+	Items []*BatchIssueCredentialItem `json:"items"`
+}
+
+// BatchIssueCredentialItem is the credential to issue along with the issue options or the compose request
+// of the credential.
+type BatchIssueCredentialItem struct {
+	Credential json.RawMessage           `json:"credential,omitempty"`
+	Opts       *IssueCredentialOptions   `json:"options,omitempty"`
+	Compose    *ComposeCredentialRequest `json:"compose,omitempty"`
+}
+
+// BatchIssueCredentialResponse resp containing the issued credentials in the order of the request items.
+type BatchIssueCredentialResponse struct {
+	Results []*BatchIssueCredentialResult `json:"results"`
+}
+
+// BatchIssueCredentialResult issued credential of the batch item or the reason it wasn't issued.
+type BatchIssueCredentialResult struct {
+	Credential *verifiable.Credential `json:"credential,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// IssuedCredentialsResponse records of the credentials issued by the profile.
+type IssuedCredentialsResponse struct {
+	Records []*issuanceregistry.Record `json:"records"`
+	// Next is the offset of the next page, it isn't set if there are no more records
+	Next int `json:"next,omitempty"`
+}
+
+// DIDAuthRequest is the request of the DID authentication presentation the holder sends to refresh the credential,
+// the presentation proof must have the challenge and domain of the request.
+type DIDAuthRequest struct {
+	Query     []*DIDAuthQuery `json:"query"`
+	Challenge string          `json:"challenge"`
+	Domain    string          `json:"domain,omitempty"`
+}
+
+// DIDAuthQuery query of the DID authentication request.
+type DIDAuthQuery struct {
+	Type string `json:"type"`
+}
+
+// RefreshCredentialRequest request for refreshing the credential, the presentation of the credential is signed by
+// the credential subject.
+type RefreshCredentialRequest struct {
+	Presentation json.RawMessage `json:"verifiablePresentation"`
+}
+
+// ReissueCredentialRequest request for reissuing the credential issued by the profile, the replacement keeps
+// the claims of the Credential (or gets the Subject when the credential is looked up by CredentialID) unless
+// Subject is set and expires at ExpirationDate (or when the replaced credential expires). The replaced credential
+// gets the Status (revoked or superseded).
+type ReissueCredentialRequest struct {
+	Credential     json.RawMessage `json:"credential,omitempty"`
+	CredentialID   string          `json:"credentialID,omitempty"`
+	ReplacementID  string          `json:"replacementID,omitempty"`
+	Subject        interface{}     `json:"credentialSubject,omitempty"`
+	ExpirationDate *time.Time      `json:"expirationDate,omitempty"`
+	Status         string          `json:"status,omitempty"`
+	StatusReason   string          `json:"statusReason,omitempty"`
+}
+
+// CredentialOfferRequest request for offering the credential of the profile template to the wallet, the offered
+// credential has the claims of the request. The wallet redeems the offer with the transaction code (if set)
+// passed to the holder out of band.
+type CredentialOfferRequest struct {
+	TemplateID     string          `json:"templateID"`
+	Claims         json.RawMessage `json:"claims,omitempty"`
+	ExpirationDate *time.Time      `json:"expirationDate,omitempty"`
+	TxCode         string          `json:"txCode,omitempty"`
+}
+
+// CredentialOfferResponse is the credential offer passed to the wallet by value or by reference (OfferURI).
+type CredentialOfferResponse struct {
+	Offer    *oidc4vci.CredentialOffer `json:"credentialOffer"`
+	OfferURI string                    `json:"credentialOfferURI"`
+}
+
+// CredentialManifestsResponse credential manifests of the profile templates.
+type CredentialManifestsResponse struct {
+	Manifests []*cm.CredentialManifest `json:"manifests"`
+}
+
+// CredentialApplicationRequest request for issuing the credentials of the manifest, the presentation has
+// the credential application and the inputs of the manifest and is signed by the applicant.
+type CredentialApplicationRequest struct {
+	Presentation json.RawMessage `json:"verifiablePresentation"`
+}
+
+// CredentialFulfillmentResponse is the presentation of the credentials issued for the application.
+type CredentialFulfillmentResponse struct {
+	Context     []string                  `json:"@context"`
+	Type        []string                  `json:"type"`
+	Fulfillment *cm.CredentialFulfillment `json:"credential_fulfillment"`
+	Credentials []*verifiable.Credential  `json:"verifiableCredential"`
+}
+
+// AuthorizationRequestRequest creates the OpenID4VP authorization request of the verifier profile, the presentation
+// definition of the profile is requested if it isn't passed.
+type AuthorizationRequestRequest struct {
+	PresentationDefinition *presexch.PresentationDefinition `json:"presentationDefinition,omitempty"`
+	// Checks of the presentation, the proof and presentation definition checks are always run.
+	Checks []string `json:"checks,omitempty"`
+}
+
+// AuthorizationRequestResponse is the authorization request passed to the wallet, the result of the transaction
+// is retrieved with the transaction ID.
+type AuthorizationRequestResponse struct {
+	TransactionID        string    `json:"transactionID"`
+	AuthorizationRequest string    `json:"authorizationRequest"`
+	RequestURI           string    `json:"requestURI"`
+	Expires              time.Time `json:"expires"`
+}
+
+// JobRequest request for submitting the asynchronous job, Request is the request of the job type
+// (e.g. BatchIssueCredentialRequest of the issueCredentials job issued with the Profile).
+type JobRequest struct {
+	Type    string          `json:"type"`
+	Profile string          `json:"profile,omitempty"`
+	Request json.RawMessage `json:"request"`
+}
+
+// JobResponse resp containing the asynchronous job status and its result once completed.
+type JobResponse struct {
+	ID       string          `json:"id"`
+	Type     string          `json:"type"`
+	Status   string          `json:"status"`
+	Attempts int             `json:"attempts"`
+	Result   json.RawMessage `json:"result,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Created  time.Time       `json:"created"`
+	Updated  time.Time       `json:"updated"`
+}
+
+// ChallengeRequest request for issuing presentation challenge.
+type ChallengeRequest struct {
+	// Profile is the verifier profile the challenge is issued for.
+	Profile string `json:"profile,omitempty"`
+	// Domain the presentation proof is bound to.
+	Domain string `json:"domain,omitempty"`
+}
+
+// VerifierProfileRequest verifier mode profile request
+type VerifierProfileRequest struct {
+	Name                   string                           `json:"name"`
+	PresentationDefinition *presexch.PresentationDefinition `json:"presentationDefinition,omitempty"`
+	// DID of the verifier signing the OpenID4VP request objects with the DID key.
+	DID           string `json:"did,omitempty"`
+	DIDPrivateKey string `json:"didPrivateKey,omitempty"`
+	DIDKeyType    string `json:"didKeyType,omitempty"`
+}
+
+// TrustedIssuerRequest registers the issuer trusted by the verifier
+type TrustedIssuerRequest struct {
+	DID             string     `json:"did"`
+	CredentialTypes []string   `json:"credentialTypes,omitempty"`
+	ValidFrom       *time.Time `json:"validFrom,omitempty"`
+	ValidUntil      *time.Time `json:"validUntil,omitempty"`
+}
+
+// TrustedIssuersResponse lists the issuers trusted by the verifier
+type TrustedIssuersResponse struct {
+	Issuers []*issuerregistry.TrustedIssuer `json:"issuers"`
 }
 
 // ErrorResponse to send error message in the response
@@ -199,3 +455,42 @@ type SignPresentationOptions struct {
 	Challenge          string     `json:"challenge,omitempty"`
 	Domain             string     `json:"domain,omitempty"`
 }
+
+// WalletCredentialRequest request for adding the credential to the holder wallet.
+type WalletCredentialRequest struct {
+	Credential json.RawMessage `json:"credential"`
+}
+
+// WalletCredential is the credential kept in the holder wallet, the ID is the ID of the wallet entry.
+type WalletCredential struct {
+	ID         string          `json:"id"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+// WalletCredentialsResponse resp containing the wallet credentials.
+type WalletCredentialsResponse struct {
+	Credentials []*WalletCredential `json:"credentials"`
+}
+
+// WalletPresentationRequest request for the presentation of the wallet credentials selected by the verifiable
+// presentation request or the DIF presentation definition. The presentation is signed with the options, the challenge
+// and domain of the presentation request take precedence over the options. The credentials are selected among
+// the wallet credentials with CredentialIDs (the wallet credential IDs chosen by the holder) if set.
+type WalletPresentationRequest struct {
+	PresentationRequest    *vpr.Request                     `json:"presentationRequest,omitempty"`
+	PresentationDefinition *presexch.PresentationDefinition `json:"presentationDefinition,omitempty"`
+	CredentialIDs          []string                         `json:"credentialIDs,omitempty"`
+	Opts                   *SignPresentationOptions         `json:"options,omitempty"`
+}
+
+// WalletPresentationResponse resp containing the signed presentation, the presentation submission maps
+// the presentation credentials to the input descriptors of the presentation definition.
+type WalletPresentationResponse struct {
+	Presentation           *verifiable.Presentation         `json:"verifiablePresentation"`
+	PresentationSubmission *presexch.PresentationSubmission `json:"presentationSubmission,omitempty"`
+}
+
+// DIDCachePurgeResponse resp containing the number of the purged DID cache entries.
+type DIDCachePurgeResponse struct {
+	Purged int `json:"purged"`
+}
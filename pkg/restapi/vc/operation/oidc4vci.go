@@ -0,0 +1,631 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	log "github.com/sirupsen/logrus"
+	"github.com/trustbloc/edge-core/pkg/storage"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/datamodel"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/oidc4vci"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/schema"
+	cslstatus "github.com/trustbloc/edge-service/pkg/doc/vc/status/csl"
+)
+
+const (
+	offerIDPathParam = "offerID"
+
+	// form parameters of the token request
+	grantTypeParam         = "grant_type"
+	preAuthorizedCodeParam = "pre-authorized_code"
+	txCodeParam            = "tx_code"
+
+	bearerPrefix = oidc4vci.BearerTokenType + " "
+
+	credentialOfferExpiry = 15 * time.Minute
+	accessTokenExpiry     = 5 * time.Minute
+)
+
+// IssuerMetadata swagger:route GET /.well-known/openid-credential-issuer/{id} issuer issuerMetadataReq
+//
+// Retrieves the OpenID4VCI credential issuer metadata of the profile with the credential configurations
+// of the profile credential templates.
+//
+// Responses:
+//    default: genericError
+//        200: issuerMetadataRes
+func (o *Operation) issuerMetadataHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.oidc4vciProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	configurations := make(map[string]*oidc4vci.CredentialConfiguration, len(profile.CredentialTemplates))
+
+	for _, template := range profile.CredentialTemplates {
+		configuration := &oidc4vci.CredentialConfiguration{
+			Format:                      oidc4vci.LDPVCFormat,
+			CryptographicBindingMethods: []string{oidc4vci.DIDBindingMethod},
+			CredentialSigningAlgs:       []string{profile.SignatureType},
+			ProofTypes: map[string]*oidc4vci.ProofType{
+				oidc4vci.JWTProofType: {SigningAlgs: oidc4vci.ProofSigningAlgs()},
+			},
+			CredentialDefinition: &oidc4vci.CredentialDefinition{
+				Context: append([]string{baseContext(profile)}, template.Context...),
+				Types:   template.Types,
+			},
+		}
+
+		if template.Name != "" {
+			configuration.Display = []*oidc4vci.Display{{Name: template.Name}}
+		}
+
+		configurations[template.ID] = configuration
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, &oidc4vci.IssuerMetadata{
+		CredentialIssuer:         o.credentialIssuer(profile),
+		CredentialEndpoint:       o.oidc4vciURL(oidc4vciCredentialPath, profile),
+		TokenEndpoint:            o.oidc4vciURL(oidc4vciTokenPath, profile),
+		Display:                  []*oidc4vci.Display{{Name: profile.Name}},
+		CredentialConfigurations: configurations,
+	})
+}
+
+// AuthServerMetadata swagger:route GET /.well-known/oauth-authorization-server/{id} issuer authServerMetadataReq
+//
+// Retrieves the metadata of the authorization server issuing the access tokens for the pre-authorized codes
+// of the profile credential offers.
+//
+// Responses:
+//    default: genericError
+//        200: authServerMetadataRes
+func (o *Operation) authorizationServerMetadataHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.oidc4vciProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, &oidc4vci.AuthorizationServerMetadata{
+		Issuer:                            o.credentialIssuer(profile),
+		TokenEndpoint:                     o.oidc4vciURL(oidc4vciTokenPath, profile),
+		GrantTypes:                        []string{oidc4vci.PreAuthorizedCodeGrantType},
+		PreAuthorizedGrantAnonymousAccess: true,
+	})
+}
+
+// CreateCredentialOffer swagger:route POST /{id}/openid4vci/offers issuer createCredentialOfferReq
+//
+// Offers the credential of the profile template with the claims to the wallet with the pre-authorized code.
+//
+// Responses:
+//    default: genericError
+//        201: credentialOfferRes
+func (o *Operation) createCredentialOfferHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.oidc4vciProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	request := &CredentialOfferRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	if credentialTemplate(profile, request.TemplateID) == nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("profile %s has no credential template %s",
+			profile.Name, request.TemplateID))
+
+		return
+	}
+
+	// the wallets don't supply the credential IDs
+	if profile.CredentialIDPolicy == vcprofile.CredentialIDClient {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("profile %s requires the client credential IDs",
+			profile.Name))
+
+		return
+	}
+
+	if len(request.Claims) > 0 {
+		if err := json.Unmarshal(request.Claims, &map[string]interface{}{}); err != nil {
+			o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+
+				": claims must be an object: %s", err.Error()))
+
+			return
+		}
+	}
+
+	session := &oidc4vci.Session{
+		Profile:         profile.Name,
+		ConfigurationID: request.TemplateID,
+		Claims:          request.Claims,
+		ExpirationDate:  request.ExpirationDate,
+		TxCode:          request.TxCode,
+	}
+
+	if err := o.issuanceSessions.CreateSession(session); err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to create credential offer:"+
+			" %s", err.Error()))
+
+		return
+	}
+
+	offerURL := o.HostURL + strings.NewReplacer(
+		"{"+profileIDPathParam+"}", profile.Name,
+		"{"+offerIDPathParam+"}", session.ID,
+	).Replace(credentialOfferPath)
+
+	rw.WriteHeader(http.StatusCreated)
+	o.writeResponse(rw, &CredentialOfferResponse{
+		Offer:    o.credentialOffer(profile, session),
+		OfferURI: oidc4vci.OfferURIScheme + "?credential_offer_uri=" + url.QueryEscape(offerURL),
+	})
+}
+
+// CredentialOffer swagger:route GET /{id}/openid4vci/offers/{offerID} issuer credentialOfferReq
+//
+// Retrieves the credential offer passed to the wallet by reference.
+//
+// Responses:
+//    default: genericError
+//        200: credentialOfferRes
+func (o *Operation) credentialOfferHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.oidc4vciProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	offerID := mux.Vars(req)[offerIDPathParam]
+
+	session, err := o.issuanceSessions.GetSession(offerID)
+	if err != nil && !errors.Is(err, storage.ErrValueNotFound) {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	if err != nil || session.Profile != profile.Name || session.State != oidc4vci.StateOffered {
+		o.writeErrorResponse(rw, http.StatusNotFound, fmt.Sprintf("credential offer %s not found", offerID))
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, o.credentialOffer(profile, session))
+}
+
+// Token swagger:route POST /{id}/openid4vci/token issuer oidc4vciTokenReq
+//
+// Issues the access token and nonce for the pre-authorized code of the credential offer, the code is
+// accepted once.
+//
+// Responses:
+//    default: oauthErrorRes
+//        200: oidc4vciTokenRes
+func (o *Operation) oidc4vciTokenHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.oidc4vciProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	if err := req.ParseForm(); err != nil {
+		o.writeOAuthError(rw, http.StatusBadRequest, &oidc4vci.ErrorResponse{
+			Error: oidc4vci.ErrorInvalidRequest, Description: err.Error(),
+		})
+
+		return
+	}
+
+	if grantType := req.PostForm.Get(grantTypeParam); grantType != oidc4vci.PreAuthorizedCodeGrantType {
+		o.writeOAuthError(rw, http.StatusBadRequest, &oidc4vci.ErrorResponse{
+			Error: oidc4vci.ErrorUnsupportedGrantType, Description: fmt.Sprintf("unsupported grant type %s", grantType),
+		})
+
+		return
+	}
+
+	session, err := o.issuanceSessions.RedeemCode(profile.Name, req.PostForm.Get(preAuthorizedCodeParam),
+		req.PostForm.Get(txCodeParam))
+	if err != nil {
+		if errors.Is(err, oidc4vci.ErrInvalidGrant) {
+			o.writeOAuthError(rw, http.StatusBadRequest, &oidc4vci.ErrorResponse{
+				Error: oidc4vci.ErrorInvalidGrant, Description: err.Error(),
+			})
+
+			return
+		}
+
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	expiresIn := int(o.issuanceSessions.TokenExpiry().Seconds())
+
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, &oidc4vci.TokenResponse{
+		AccessToken:     session.AccessToken,
+		TokenType:       oidc4vci.BearerTokenType,
+		ExpiresIn:       expiresIn,
+		CNonce:          session.CNonce,
+		CNonceExpiresIn: expiresIn,
+	})
+}
+
+// Credential swagger:route POST /{id}/openid4vci/credential issuer oidc4vciCredentialReq
+//
+// Issues the offered credential bound to the DID of the proof of possession, the access token is accepted
+// for one credential.
+//
+// Responses:
+//    default: oauthErrorRes
+//        200: oidc4vciCredentialRes
+func (o *Operation) oidc4vciCredentialHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.oidc4vciProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	token := req.Header.Get("Authorization")
+	if !strings.HasPrefix(token, bearerPrefix) {
+		o.writeOAuthError(rw, http.StatusUnauthorized, &oidc4vci.ErrorResponse{
+			Error: oidc4vci.ErrorInvalidToken, Description: "bearer access token is required",
+		})
+
+		return
+	}
+
+	session, err := o.issuanceSessions.Authorize(profile.Name, strings.TrimPrefix(token, bearerPrefix))
+	if err != nil {
+		o.writeOAuthError(rw, http.StatusUnauthorized, &oidc4vci.ErrorResponse{
+			Error: oidc4vci.ErrorInvalidToken, Description: err.Error(),
+		})
+
+		return
+	}
+
+	request := &oidc4vci.CredentialRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeOAuthError(rw, http.StatusBadRequest, &oidc4vci.ErrorResponse{
+			Error: oidc4vci.ErrorInvalidRequest, Description: err.Error(),
+		})
+
+		return
+	}
+
+	if errResp := checkCredentialRequest(request, session); errResp != nil {
+		o.writeOAuthError(rw, http.StatusBadRequest, errResp)
+
+		return
+	}
+
+	holderDID, err := o.verifyCredentialProof(request, session, profile)
+	if err != nil {
+		o.writeProofError(rw, session, err)
+
+		return
+	}
+
+	o.issueOfferedCredential(rw, profile, session, holderDID)
+}
+
+// issueOfferedCredential composes the offered credential from the template, signs and records it
+func (o *Operation) issueOfferedCredential(rw http.ResponseWriter, profile *vcprofile.DataProfile,
+	session *oidc4vci.Session, holderDID string) {
+	template := credentialTemplate(profile, session.ConfigurationID)
+	if template == nil {
+		o.writeOAuthError(rw, http.StatusBadRequest, &oidc4vci.ErrorResponse{
+			Error:       oidc4vci.ErrorUnknownCredentialConfiguration,
+			Description: fmt.Sprintf("profile %s has no credential template %s", profile.Name, session.ConfigurationID),
+		})
+
+		return
+	}
+
+	credential, err := o.composeTemplateCredential(profile, template, holderDID, session.Claims,
+		session.ExpirationDate)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to compose credential: %s",
+			err.Error()))
+
+		return
+	}
+
+	signedVC, status, err := o.signTemplateCredential(profile, credential)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	if err := o.issuanceSessions.Complete(session, signedVC.ID); err != nil {
+		o.writeOAuthError(rw, http.StatusUnauthorized, &oidc4vci.ErrorResponse{
+			Error: oidc4vci.ErrorInvalidToken, Description: err.Error(),
+		})
+
+		return
+	}
+
+	if err := o.recordIssued(profile, &issuedCredential{credential: signedVC, status: status}); err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	vcBytes, err := signedVC.MarshalJSON()
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, &oidc4vci.CredentialResponse{
+		Format:          oidc4vci.LDPVCFormat,
+		Credential:      vcBytes,
+		CNonce:          session.CNonce,
+		CNonceExpiresIn: int(o.issuanceSessions.TokenExpiry().Seconds()),
+	})
+}
+
+// composeTemplateCredential composes the credential of the profile template with the claims issued
+// to the holder DID
+func (o *Operation) composeTemplateCredential(profile *vcprofile.DataProfile, template *vcprofile.CredentialTemplate,
+	holderDID string, claims json.RawMessage, expirationDate *time.Time) (*verifiable.Credential, error) {
+	now := time.Now().UTC()
+
+	credential, err := buildCredential(&ComposeCredentialRequest{
+		Subject:        holderDID,
+		Types:          template.Types,
+		IssuanceDate:   &now,
+		ExpirationDate: expirationDate,
+		Claims:         claims,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ctx := range template.Context {
+		credential.Context = appendContext(credential.Context, ctx)
+	}
+
+	if template.Schema != "" {
+		credential.Schemas = []verifiable.TypedID{{ID: template.Schema, Type: schema.JSONSchemaValidator2018}}
+	}
+
+	if err := o.assignCredentialID(profile, credential); err != nil {
+		return nil, err
+	}
+
+	if profile.VCDataModel == datamodel.Version2 {
+		datamodel.ToV2(credential)
+	}
+
+	return credential, nil
+}
+
+// signTemplateCredential allocates the status of the composed credential and signs it with the profile key
+func (o *Operation) signTemplateCredential(profile *vcprofile.DataProfile,
+	credential *verifiable.Credential) (*verifiable.Credential, *cslstatus.StatusID, error) {
+	var status *cslstatus.StatusID
+
+	if !profile.DisableVCStatus {
+		statuses, err := o.addCredentialStatus(credential)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to add credential status: %w", err)
+		}
+
+		status = statuses[0]
+	}
+
+	updateContext(credential, profile)
+	updateIssuer(credential, profile)
+	o.addRefreshService(credential, profile)
+
+	signedVC, err := o.crypto.SignCredential(profile, credential)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign credential: %w", err)
+	}
+
+	return signedVC, status, nil
+}
+
+// verifyCredentialProof verifies the proof of possession of the credential request has the current nonce
+// of the session and returns the DID of the proof
+func (o *Operation) verifyCredentialProof(request *oidc4vci.CredentialRequest, session *oidc4vci.Session,
+	profile *vcprofile.DataProfile) (string, error) {
+	fetcher := verifiable.NewDIDKeyResolver(o.vdri).PublicKeyFetcher()
+
+	holderDID, nonce, err := oidc4vci.VerifyProof(request.Proof, fetcher, o.credentialIssuer(profile), time.Now(),
+		o.clockSkew)
+	if err != nil {
+		return "", err
+	}
+
+	if err := session.CheckNonce(nonce); err != nil {
+		return "", err
+	}
+
+	return holderDID, nil
+}
+
+// writeProofError writes the proof error with the new nonce of the session to be used by the next proof
+func (o *Operation) writeProofError(rw http.ResponseWriter, session *oidc4vci.Session, err error) {
+	renewErr := o.issuanceSessions.RenewNonce(session)
+	if errors.Is(renewErr, oidc4vci.ErrInvalidToken) {
+		o.writeOAuthError(rw, http.StatusUnauthorized, &oidc4vci.ErrorResponse{
+			Error: oidc4vci.ErrorInvalidToken, Description: renewErr.Error(),
+		})
+
+		return
+	}
+
+	if renewErr != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, renewErr.Error())
+
+		return
+	}
+
+	code := oidc4vci.ErrorInvalidProof
+	if errors.Is(err, oidc4vci.ErrInvalidNonce) {
+		code = oidc4vci.ErrorInvalidNonce
+	}
+
+	o.writeOAuthError(rw, http.StatusBadRequest, &oidc4vci.ErrorResponse{
+		Error:           code,
+		Description:     err.Error(),
+		CNonce:          session.CNonce,
+		CNonceExpiresIn: int(o.issuanceSessions.TokenExpiry().Seconds()),
+	})
+}
+
+// checkCredentialRequest checks the credential request is for the offered credential
+func checkCredentialRequest(request *oidc4vci.CredentialRequest, session *oidc4vci.Session) *oidc4vci.ErrorResponse {
+	switch {
+	case request.CredentialConfigurationID != "":
+		if request.CredentialConfigurationID != session.ConfigurationID {
+			return &oidc4vci.ErrorResponse{
+				Error:       oidc4vci.ErrorUnknownCredentialConfiguration,
+				Description: fmt.Sprintf("credential %s isn't offered", request.CredentialConfigurationID),
+			}
+		}
+	case request.Format != "":
+		if request.Format != oidc4vci.LDPVCFormat {
+			return &oidc4vci.ErrorResponse{
+				Error:       oidc4vci.ErrorUnsupportedCredentialFormat,
+				Description: fmt.Sprintf("unsupported credential format %s", request.Format),
+			}
+		}
+	default:
+		return &oidc4vci.ErrorResponse{
+			Error:       oidc4vci.ErrorInvalidRequest,
+			Description: "credential_configuration_id or format is required",
+		}
+	}
+
+	return nil
+}
+
+// oidc4vciProfile returns the issuer profile of the request
+func (o *Operation) oidc4vciProfile(rw http.ResponseWriter, req *http.Request) (*vcprofile.DataProfile, bool) {
+	profileID := mux.Vars(req)[profileIDPathParam]
+
+	profile, err := o.profileStore.GetProfile(profileID)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusNotFound, fmt.Sprintf("invalid issuer profile - id=%s: err=%s",
+			profileID, err.Error()))
+
+		return nil, false
+	}
+
+	return profile, true
+}
+
+// credentialOffer returns the credential offer of the session
+func (o *Operation) credentialOffer(profile *vcprofile.DataProfile,
+	session *oidc4vci.Session) *oidc4vci.CredentialOffer {
+	grant := &oidc4vci.PreAuthorizedCodeGrant{PreAuthorizedCode: session.PreAuthorizedCode}
+
+	if session.TxCode != "" {
+		grant.TxCode = &oidc4vci.TxCode{InputMode: "text", Length: len(session.TxCode)}
+
+		if strings.Trim(session.TxCode, "0123456789") == "" {
+			grant.TxCode.InputMode = "numeric"
+		}
+	}
+
+	return &oidc4vci.CredentialOffer{
+		CredentialIssuer:           o.credentialIssuer(profile),
+		CredentialConfigurationIDs: []string{session.ConfigurationID},
+		Grants: map[string]*oidc4vci.PreAuthorizedCodeGrant{
+			oidc4vci.PreAuthorizedCodeGrantType: grant,
+		},
+	}
+}
+
+// credentialIssuer returns the credential issuer identifier of the profile
+func (o *Operation) credentialIssuer(profile *vcprofile.DataProfile) string {
+	return o.HostURL + "/" + profile.Name
+}
+
+func (o *Operation) oidc4vciURL(path string, profile *vcprofile.DataProfile) string {
+	return o.HostURL + strings.Replace(path, "{"+profileIDPathParam+"}", profile.Name, 1)
+}
+
+func (o *Operation) writeOAuthError(rw http.ResponseWriter, status int, resp interface{}) {
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(status)
+
+	if err := json.NewEncoder(rw).Encode(resp); err != nil {
+		log.Errorf("Unable to send error message, %s", err)
+	}
+}
+
+func credentialTemplate(profile *vcprofile.DataProfile, id string) *vcprofile.CredentialTemplate {
+	for _, template := range profile.CredentialTemplates {
+		if template.ID == id {
+			return template
+		}
+	}
+
+	return nil
+}
+
+// baseContext returns the base context of the VC data model of the profile
+func baseContext(profile *vcprofile.DataProfile) string {
+	if profile.VCDataModel == datamodel.Version2 {
+		return datamodel.ContextV2
+	}
+
+	return datamodel.ContextV1
+}
+
+// validateCredentialTemplates checks the templates have unique IDs and the credential types
+func validateCredentialTemplates(templates []*vcprofile.CredentialTemplate) error {
+	ids := make(map[string]bool, len(templates))
+
+	for _, template := range templates {
+		switch {
+		case template == nil || template.ID == "":
+			return errors.New("credential template ID is required")
+		case ids[template.ID]:
+			return fmt.Errorf("duplicate credential template %s", template.ID)
+		case !containsString(template.Types, "VerifiableCredential"):
+			return fmt.Errorf("credential template %s types must include VerifiableCredential", template.ID)
+		}
+
+		if template.PresentationDefinition != nil {
+			if err := template.PresentationDefinition.Validate(); err != nil {
+				return fmt.Errorf("credential template %s: invalid presentation definition: %w", template.ID, err)
+			}
+		}
+
+		ids[template.ID] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,450 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/composite/ecdhes"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jwt"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
+	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage/memstore"
+
+	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/oidc4vci"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
+)
+
+const degreeTemplateID = "UniversityDegree"
+
+func TestOIDC4VCIPreAuthorizedCodeFlow(t *testing.T) {
+	defer setTestContexts(t)()
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI: &vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+			return createJWKDIDDoc(t, didID, pubKey), nil
+		}},
+		Crypto:  &cryptomock.Crypto{},
+		HostURL: "http://example.com",
+	})
+	require.NoError(t, err)
+
+	profile := getTestProfile()
+	profile.SignatureRepresentation = verifiable.SignatureJWS
+	profile.SignatureType = vccrypto.JSONWebSignature2020
+	profile.CredentialIDPolicy = vcprofile.CredentialIDUUID
+	profile.CredentialTemplates = []*vcprofile.CredentialTemplate{{
+		ID:      degreeTemplateID,
+		Name:    "University Degree",
+		Types:   []string{"VerifiableCredential", "UniversityDegreeCredential"},
+		Context: []string{examplesV1Context},
+	}}
+
+	require.NoError(t, op.profileStore.SaveProfile(profile))
+
+	urlVars := map[string]string{profileIDPathParam: profile.Name}
+	issuer := "http://example.com/test"
+
+	offer := func(t *testing.T, request *CredentialOfferRequest) *CredentialOfferResponse {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		rr := serveHTTPMux(t, getHandler(t, op, credentialOffersPath, issuerMode), "/test/openid4vci/offers",
+			reqBytes, urlVars)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		resp := &CredentialOfferResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+
+		return resp
+	}
+
+	t.Run("issue offered credential", func(t *testing.T) {
+		claims := []byte(`{"name":"Jayden Doe","degree":{"type":"BachelorDegree","name":"Bachelor of Science"}}`)
+
+		resp := offer(t, &CredentialOfferRequest{TemplateID: degreeTemplateID, Claims: claims})
+		require.Equal(t, issuer, resp.Offer.CredentialIssuer)
+		require.Equal(t, []string{degreeTemplateID}, resp.Offer.CredentialConfigurationIDs)
+		require.True(t, strings.HasPrefix(resp.OfferURI, oidc4vci.OfferURIScheme+"?credential_offer_uri="))
+
+		grant := resp.Offer.Grants[oidc4vci.PreAuthorizedCodeGrantType]
+		require.NotNil(t, grant)
+		require.Nil(t, grant.TxCode)
+
+		// the wallet gets the offer by reference
+		offerURI, err := url.QueryUnescape(strings.TrimPrefix(resp.OfferURI,
+			oidc4vci.OfferURIScheme+"?credential_offer_uri="))
+		require.NoError(t, err)
+
+		offerID := offerURI[strings.LastIndex(offerURI, "/")+1:]
+
+		rr := serveHTTPMux(t, getHandler(t, op, credentialOfferPath, issuerMode), offerURI, nil,
+			map[string]string{profileIDPathParam: profile.Name, offerIDPathParam: offerID})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Contains(t, rr.Body.String(), grant.PreAuthorizedCode)
+
+		token := redeemCode(t, op, grant.PreAuthorizedCode, "")
+		require.Equal(t, oidc4vci.BearerTokenType, token.TokenType)
+		require.NotEmpty(t, token.CNonce)
+
+		// the offer isn't available after the code is redeemed
+		rr = serveHTTPMux(t, getHandler(t, op, credentialOfferPath, issuerMode), offerURI, nil,
+			map[string]string{profileIDPathParam: profile.Name, offerIDPathParam: offerID})
+		require.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = requestCredential(t, op, token.AccessToken, &oidc4vci.CredentialRequest{
+			CredentialConfigurationID: degreeTemplateID,
+			Proof:                     createProofJWT(t, privKey, holderDID+"#key-1", issuer, token.CNonce),
+		})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+		credResp := &oidc4vci.CredentialResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), credResp))
+		require.Equal(t, oidc4vci.LDPVCFormat, credResp.Format)
+		require.NotEqual(t, token.CNonce, credResp.CNonce)
+
+		credential, err := verifiable.NewUnverifiedCredential(credResp.Credential)
+		require.NoError(t, err)
+		require.Contains(t, credential.Types, "UniversityDegreeCredential")
+		require.Contains(t, credential.Context, examplesV1Context)
+		require.Equal(t, profile.DID, credential.Issuer.ID)
+		require.NotNil(t, credential.Status)
+		require.Len(t, credential.Proofs, 1)
+
+		subject, ok := credential.Subject.(map[string]interface{})
+		require.True(t, ok)
+		require.Equal(t, holderDID, subject["id"])
+		require.Equal(t, "Jayden Doe", subject["name"])
+
+		_, err = op.issuanceRegistry.GetProfileRecord(profile.Name, credential.ID)
+		require.NoError(t, err)
+
+		// the access token is accepted for one credential
+		rr = requestCredential(t, op, token.AccessToken, &oidc4vci.CredentialRequest{
+			CredentialConfigurationID: degreeTemplateID,
+			Proof:                     createProofJWT(t, privKey, holderDID+"#key-1", issuer, credResp.CNonce),
+		})
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		require.Contains(t, rr.Body.String(), oidc4vci.ErrorInvalidToken)
+	})
+
+	t.Run("transaction code", func(t *testing.T) {
+		resp := offer(t, &CredentialOfferRequest{TemplateID: degreeTemplateID, TxCode: "493536"})
+
+		grant := resp.Offer.Grants[oidc4vci.PreAuthorizedCodeGrantType]
+		require.Equal(t, &oidc4vci.TxCode{InputMode: "numeric", Length: 6}, grant.TxCode)
+
+		rr := serveToken(t, op, url.Values{
+			grantTypeParam:         {oidc4vci.PreAuthorizedCodeGrantType},
+			preAuthorizedCodeParam: {grant.PreAuthorizedCode},
+		})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), oidc4vci.ErrorInvalidGrant)
+
+		redeemCode(t, op, grant.PreAuthorizedCode, "493536")
+
+		// the code is redeemed once
+		rr = serveToken(t, op, url.Values{
+			grantTypeParam:         {oidc4vci.PreAuthorizedCodeGrantType},
+			preAuthorizedCodeParam: {grant.PreAuthorizedCode},
+			txCodeParam:            {"493536"},
+		})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "pre-authorized code is already used")
+	})
+
+	t.Run("invalid proof renews nonce", func(t *testing.T) {
+		resp := offer(t, &CredentialOfferRequest{TemplateID: degreeTemplateID})
+		token := redeemCode(t, op, resp.Offer.Grants[oidc4vci.PreAuthorizedCodeGrantType].PreAuthorizedCode, "")
+
+		rr := requestCredential(t, op, token.AccessToken, &oidc4vci.CredentialRequest{
+			CredentialConfigurationID: degreeTemplateID,
+			Proof:                     createProofJWT(t, privKey, holderDID+"#key-1", issuer, "invalid"),
+		})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		errResp := &oidc4vci.ErrorResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), errResp))
+		require.Equal(t, oidc4vci.ErrorInvalidNonce, errResp.Error)
+		require.NotEmpty(t, errResp.CNonce)
+		require.NotEqual(t, token.CNonce, errResp.CNonce)
+
+		rr = requestCredential(t, op, token.AccessToken, &oidc4vci.CredentialRequest{
+			CredentialConfigurationID: degreeTemplateID,
+			Proof:                     createProofJWT(t, privKey, holderDID+"#key-1", "http://other.com", errResp.CNonce),
+		})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), oidc4vci.ErrorInvalidProof)
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), errResp))
+
+		rr = requestCredential(t, op, token.AccessToken, &oidc4vci.CredentialRequest{
+			Format: oidc4vci.LDPVCFormat,
+			Proof:  createProofJWT(t, privKey, holderDID+"#key-1", issuer, errResp.CNonce),
+		})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+
+	t.Run("invalid credential request", func(t *testing.T) {
+		resp := offer(t, &CredentialOfferRequest{TemplateID: degreeTemplateID})
+		token := redeemCode(t, op, resp.Offer.Grants[oidc4vci.PreAuthorizedCodeGrantType].PreAuthorizedCode, "")
+
+		tests := []struct {
+			name    string
+			request *oidc4vci.CredentialRequest
+			err     string
+		}{
+			{"no configuration", &oidc4vci.CredentialRequest{}, oidc4vci.ErrorInvalidRequest},
+			{
+				"not offered", &oidc4vci.CredentialRequest{CredentialConfigurationID: "other"},
+				oidc4vci.ErrorUnknownCredentialConfiguration,
+			},
+			{
+				"unsupported format", &oidc4vci.CredentialRequest{Format: "jwt_vc_json"},
+				oidc4vci.ErrorUnsupportedCredentialFormat,
+			},
+			{"no proof", &oidc4vci.CredentialRequest{Format: oidc4vci.LDPVCFormat}, oidc4vci.ErrorInvalidProof},
+		}
+
+		for _, tc := range tests {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				rr := requestCredential(t, op, token.AccessToken, tc.request)
+				require.Equal(t, http.StatusBadRequest, rr.Code)
+				require.Contains(t, rr.Body.String(), tc.err)
+			})
+		}
+
+		rr := requestCredential(t, op, "", &oidc4vci.CredentialRequest{})
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		require.Contains(t, rr.Body.String(), "bearer access token is required")
+
+		rr = requestCredential(t, op, "invalid", &oidc4vci.CredentialRequest{})
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		require.Contains(t, rr.Body.String(), "unknown access token")
+	})
+
+	t.Run("invalid token request", func(t *testing.T) {
+		rr := serveToken(t, op, url.Values{grantTypeParam: {"authorization_code"}})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), oidc4vci.ErrorUnsupportedGrantType)
+
+		rr = serveToken(t, op, url.Values{
+			grantTypeParam:         {oidc4vci.PreAuthorizedCodeGrantType},
+			preAuthorizedCodeParam: {"unknown"},
+		})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "unknown pre-authorized code")
+	})
+
+	t.Run("invalid offer request", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			request string
+			err     string
+		}{
+			{"invalid request", `invalid`, invalidRequestErrMsg},
+			{"unknown template", `{"templateID":"other"}`, "profile test has no credential template other"},
+			{"invalid claims", `{"templateID":"UniversityDegree","claims":["a"]}`, "claims must be an object"},
+		}
+
+		for _, tc := range tests {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				rr := serveHTTPMux(t, getHandler(t, op, credentialOffersPath, issuerMode), "/test/openid4vci/offers",
+					[]byte(tc.request), urlVars)
+				require.Equal(t, http.StatusBadRequest, rr.Code)
+				require.Contains(t, rr.Body.String(), tc.err)
+			})
+		}
+
+		rr := serveHTTPMux(t, getHandler(t, op, credentialOfferPath, issuerMode), "/test/openid4vci/offers/abc",
+			nil, map[string]string{profileIDPathParam: profile.Name, offerIDPathParam: "abc"})
+		require.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = serveHTTPMux(t, getHandler(t, op, credentialOffersPath, issuerMode), "/invalid/openid4vci/offers",
+			nil, map[string]string{profileIDPathParam: "invalid"})
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid issuer profile")
+	})
+
+	t.Run("client credential ID policy", func(t *testing.T) {
+		other := getTestProfile()
+		other.Name = "other"
+		other.CredentialIDPolicy = vcprofile.CredentialIDClient
+		other.CredentialTemplates = profile.CredentialTemplates
+
+		require.NoError(t, op.profileStore.SaveProfile(other))
+
+		rr := serveHTTPMux(t, getHandler(t, op, credentialOffersPath, issuerMode), "/other/openid4vci/offers",
+			[]byte(`{"templateID":"UniversityDegree"}`), map[string]string{profileIDPathParam: other.Name})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "requires the client credential IDs")
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		rr := serveHTTPMux(t, getHandler(t, op, issuerMetadataEndpoint, issuerMode),
+			"/.well-known/openid-credential-issuer/test", nil, urlVars)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		metadata := &oidc4vci.IssuerMetadata{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), metadata))
+		require.Equal(t, issuer, metadata.CredentialIssuer)
+		require.Equal(t, issuer+"/openid4vci/credential", metadata.CredentialEndpoint)
+		require.Equal(t, issuer+"/openid4vci/token", metadata.TokenEndpoint)
+
+		configuration := metadata.CredentialConfigurations[degreeTemplateID]
+		require.NotNil(t, configuration)
+		require.Equal(t, oidc4vci.LDPVCFormat, configuration.Format)
+		require.Equal(t, profile.CredentialTemplates[0].Types, configuration.CredentialDefinition.Types)
+		require.Equal(t, "University Degree", configuration.Display[0].Name)
+
+		rr = serveHTTPMux(t, getHandler(t, op, authorizationServerEndpoint, issuerMode),
+			"/.well-known/oauth-authorization-server/test", nil, urlVars)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		asMetadata := &oidc4vci.AuthorizationServerMetadata{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), asMetadata))
+		require.Equal(t, issuer+"/openid4vci/token", asMetadata.TokenEndpoint)
+		require.Equal(t, []string{oidc4vci.PreAuthorizedCodeGrantType}, asMetadata.GrantTypes)
+	})
+}
+
+func TestValidateCredentialTemplates(t *testing.T) {
+	types := []string{"VerifiableCredential"}
+
+	require.NoError(t, validateCredentialTemplates(nil))
+	require.NoError(t, validateCredentialTemplates([]*vcprofile.CredentialTemplate{{ID: "a", Types: types}}))
+
+	err := validateCredentialTemplates([]*vcprofile.CredentialTemplate{{Types: types}})
+	require.EqualError(t, err, "credential template ID is required")
+
+	err = validateCredentialTemplates([]*vcprofile.CredentialTemplate{{ID: "a", Types: types}, {ID: "a", Types: types}})
+	require.EqualError(t, err, "duplicate credential template a")
+
+	err = validateCredentialTemplates([]*vcprofile.CredentialTemplate{{ID: "a", Types: []string{"Other"}}})
+	require.EqualError(t, err, "credential template a types must include VerifiableCredential")
+
+	err = validateCredentialTemplates([]*vcprofile.CredentialTemplate{{
+		ID: "a", Types: types, PresentationDefinition: &presexch.PresentationDefinition{ID: "pd"},
+	}})
+	require.EqualError(t, err, "credential template a: invalid presentation definition: presentation definition"+
+		" must have input descriptors")
+}
+
+func serveToken(t *testing.T, op *Operation, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, "/test/openid4vci/token", strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rr := httptest.NewRecorder()
+	getHandler(t, op, oidc4vciTokenPath, issuerMode).Handle().ServeHTTP(rr,
+		mux.SetURLVars(req, map[string]string{profileIDPathParam: "test"}))
+
+	return rr
+}
+
+func redeemCode(t *testing.T, op *Operation, code, txCode string) *oidc4vci.TokenResponse {
+	t.Helper()
+
+	rr := serveToken(t, op, url.Values{
+		grantTypeParam:         {oidc4vci.PreAuthorizedCodeGrantType},
+		preAuthorizedCodeParam: {code},
+		txCodeParam:            {txCode},
+	})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+	token := &oidc4vci.TokenResponse{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), token))
+
+	return token
+}
+
+func requestCredential(t *testing.T, op *Operation, accessToken string,
+	request *oidc4vci.CredentialRequest) *httptest.ResponseRecorder {
+	t.Helper()
+
+	reqBytes, err := json.Marshal(request)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/test/openid4vci/credential", bytes.NewBuffer(reqBytes))
+	require.NoError(t, err)
+
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	rr := httptest.NewRecorder()
+	getHandler(t, op, oidc4vciCredentialPath, issuerMode).Handle().ServeHTTP(rr,
+		mux.SetURLVars(req, map[string]string{profileIDPathParam: "test"}))
+
+	return rr
+}
+
+// createProofJWT returns the EdDSA proof of possession of the key for the credential issuer
+func createProofJWT(t *testing.T, privKey []byte, kid, audience, nonce string) *oidc4vci.Proof {
+	t.Helper()
+
+	claims := map[string]interface{}{"aud": audience, "iat": time.Now().Unix(), "nonce": nonce}
+
+	token, err := jwt.NewSigned(claims, jose.Headers{jose.HeaderKeyID: kid, jose.HeaderType: oidc4vci.ProofJWTType},
+		&proofSigner{signer: getEd25519TestSigner(privKey)})
+	require.NoError(t, err)
+
+	s, err := token.Serialize(false)
+	require.NoError(t, err)
+
+	return &oidc4vci.Proof{ProofType: oidc4vci.JWTProofType, JWT: s}
+}
+
+type proofSigner struct {
+	signer *ed25519TestSigner
+}
+
+func (s *proofSigner) Sign(data []byte) ([]byte, error) {
+	return s.signer.Sign(data)
+}
+
+func (s *proofSigner) Headers() jose.Headers {
+	return jose.Headers{jose.HeaderAlgorithm: "EdDSA"}
+}
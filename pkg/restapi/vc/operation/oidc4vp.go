@@ -0,0 +1,361 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"github.com/trustbloc/edge-core/pkg/storage"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/oidc4vp"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
+)
+
+const (
+	transactionIDPathParam = "transactionID"
+	requestIDPathParam     = "requestID"
+
+	// form parameters of the authorization response
+	vpTokenParam                = "vp_token"
+	presentationSubmissionParam = "presentation_submission"
+	stateParam                  = "state"
+	errorParam                  = "error"
+	errorDescriptionParam       = "error_description"
+)
+
+// CreateAuthorizationRequest swagger:route POST /verifier/{id}/openid4vp/requests verifier authorizationRequestReq
+//
+// Creates the OpenID4VP authorization request of the verifier profile passed to the wallet, the signed request
+// object is passed by reference.
+//
+// Responses:
+//    default: genericError
+//        201: authorizationRequestRes
+func (o *Operation) createAuthorizationRequestHandler(rw http.ResponseWriter, req *http.Request) {
+	profileID := mux.Vars(req)[profileIDPathParam]
+
+	profile, err := o.profileStore.GetVerifierProfile(profileID)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid verifier profile - id=%s: err=%s",
+			profileID, err.Error()))
+
+		return
+	}
+
+	if profile.DID == "" {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("verifier profile %s has no DID to sign"+
+			" the request objects", profile.Name))
+
+		return
+	}
+
+	request := &AuthorizationRequestRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	definition, err := o.getPresentationDefinition(&VerifyPresentationOptions{
+		PresentationDefinition: request.PresentationDefinition,
+		Profile:                profile.Name,
+	})
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	// the nonce of the request object is the challenge of the presentation proof for the verifier DID
+	c, err := o.challenges.Issue(profile.Name, profile.DID)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	tx := &oidc4vp.Transaction{
+		Profile:                profile.Name,
+		ClientID:               profile.DID,
+		Nonce:                  c.Value,
+		PresentationDefinition: definition,
+		Checks:                 presentationRequestChecks(request.Checks),
+		Expires:                c.Expires,
+	}
+
+	if err := o.presentationRequests.Create(tx); err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to create authorization"+
+			" request: %s", err.Error()))
+
+		return
+	}
+
+	requestURI := o.HostURL + strings.NewReplacer(
+		"{"+profileIDPathParam+"}", profile.Name,
+		"{"+requestIDPathParam+"}", tx.RequestID,
+	).Replace(requestObjectPath)
+
+	rw.WriteHeader(http.StatusCreated)
+	o.writeResponse(rw, &AuthorizationRequestResponse{
+		TransactionID: tx.ID,
+		AuthorizationRequest: oidc4vp.AuthorizationRequestScheme + "?" + url.Values{
+			"client_id":   {profile.DID},
+			"request_uri": {requestURI},
+		}.Encode(),
+		RequestURI: requestURI,
+		Expires:    tx.Expires,
+	})
+}
+
+// AuthorizationRequest swagger:route GET /verifier/{id}/openid4vp/requests/{transactionID} verifier authzRequestReq
+//
+// Retrieves the state of the authorization request with the verification result and the presentation of the
+// wallet response, the transaction ID is known to the verifier only.
+//
+// Responses:
+//    default: genericError
+//        200: authzRequestRes
+func (o *Operation) authorizationRequestHandler(rw http.ResponseWriter, req *http.Request) {
+	profileID := mux.Vars(req)[profileIDPathParam]
+	txID := mux.Vars(req)[transactionIDPathParam]
+
+	tx, ok := o.presentationRequest(rw, profileID, txID, o.presentationRequests.Get)
+	if !ok {
+		return
+	}
+
+	// the nonce of the request isn't disclosed
+	tx.Nonce = ""
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, tx)
+}
+
+// RequestObject swagger:route GET /verifier/{id}/openid4vp/objects/{requestID} verifier requestObjectReq
+//
+// Retrieves the request object of the pending authorization request signed with the DID key of the verifier.
+//
+// Responses:
+//    default: genericError
+//        200: requestObjectRes
+func (o *Operation) requestObjectHandler(rw http.ResponseWriter, req *http.Request) {
+	profileID := mux.Vars(req)[profileIDPathParam]
+	requestID := mux.Vars(req)[requestIDPathParam]
+
+	tx, ok := o.presentationRequest(rw, profileID, requestID, o.presentationRequests.GetRequest)
+	if !ok {
+		return
+	}
+
+	if tx.State != oidc4vp.StatePending || time.Now().After(tx.Expires) {
+		o.writeErrorResponse(rw, http.StatusNotFound, fmt.Sprintf("authorization request %s not found", requestID))
+
+		return
+	}
+
+	profile, err := o.profileStore.GetVerifierProfile(tx.Profile)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	now := time.Now()
+	responseURI := o.HostURL + strings.Replace(authorizationResponsePath, "{"+profileIDPathParam+"}", profile.Name, 1)
+
+	requestObject, err := o.crypto.SignJWT(profile, &oidc4vp.RequestObject{
+		Issuer:                 tx.ClientID,
+		Audience:               oidc4vp.SelfIssuedAudience,
+		IssuedAt:               now.Unix(),
+		Expiry:                 tx.Expires.Unix(),
+		ClientID:               tx.ClientID,
+		ClientIDScheme:         oidc4vp.ClientIDSchemeDID,
+		ResponseType:           oidc4vp.ResponseTypeVPToken,
+		ResponseMode:           oidc4vp.ResponseModeDirectPost,
+		ResponseURI:            responseURI,
+		Nonce:                  tx.Nonce,
+		State:                  tx.RequestID,
+		PresentationDefinition: tx.PresentationDefinition,
+		ClientMetadata: &oidc4vp.ClientMetadata{VPFormats: map[string]*oidc4vp.VPFormat{
+			oidc4vp.LDPVPFormat: {ProofTypes: []string{
+				crypto.Ed25519Signature2018, crypto.JSONWebSignature2020, crypto.DataIntegrityProof,
+			}},
+		}},
+	}, oidc4vp.RequestObjectType)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to sign request object: %s",
+			err.Error()))
+
+		return
+	}
+
+	rw.Header().Set("Content-Type", oidc4vp.RequestObjectContentType)
+	rw.WriteHeader(http.StatusOK)
+
+	if _, err := rw.Write([]byte(requestObject)); err != nil {
+		log.Errorf("Failed to write request object: %s", err.Error())
+	}
+}
+
+// AuthorizationResponse swagger:route POST /verifier/{id}/openid4vp/response verifier authorizationResponseReq
+//
+// Accepts the direct_post authorization response of the wallet once, the vp_token is verified with the nonce
+// of the request as the challenge and the verifier DID as the domain of the presentation proof.
+//
+// Responses:
+//    default: oauthErrorRes
+//        200: emptyRes
+func (o *Operation) authorizationResponseHandler(rw http.ResponseWriter, req *http.Request) {
+	profileID := mux.Vars(req)[profileIDPathParam]
+
+	if err := req.ParseForm(); err != nil {
+		o.writeOAuthError(rw, http.StatusBadRequest, &oidc4vp.ErrorResponse{
+			Error: oidc4vp.ErrorInvalidRequest, Description: err.Error(),
+		})
+
+		return
+	}
+
+	walletErr := req.PostForm.Get(errorParam)
+	vpToken := req.PostForm.Get(vpTokenParam)
+
+	if walletErr == "" && vpToken == "" {
+		o.writeOAuthError(rw, http.StatusBadRequest, &oidc4vp.ErrorResponse{
+			Error: oidc4vp.ErrorInvalidRequest, Description: "vp_token is required",
+		})
+
+		return
+	}
+
+	var submission *presexch.PresentationSubmission
+
+	if s := req.PostForm.Get(presentationSubmissionParam); s != "" {
+		submission = &presexch.PresentationSubmission{}
+
+		if err := json.Unmarshal([]byte(s), submission); err != nil {
+			o.writeOAuthError(rw, http.StatusBadRequest, &oidc4vp.ErrorResponse{
+				Error: oidc4vp.ErrorInvalidRequest, Description: "invalid presentation_submission: " + err.Error(),
+			})
+
+			return
+		}
+	}
+
+	tx, err := o.presentationRequests.Receive(profileID, req.PostForm.Get(stateParam))
+	if err != nil {
+		if errors.Is(err, oidc4vp.ErrInvalidTransaction) {
+			o.writeOAuthError(rw, http.StatusBadRequest, &oidc4vp.ErrorResponse{
+				Error: oidc4vp.ErrorInvalidRequest, Description: err.Error(),
+			})
+
+			return
+		}
+
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	verified := false
+
+	if walletErr != "" {
+		tx.Error = strings.TrimSuffix(walletErr+": "+req.PostForm.Get(errorDescriptionParam), ": ")
+	} else {
+		verified = o.verifyAuthorizationResponse(tx, []byte(vpToken), submission)
+	}
+
+	if err := o.presentationRequests.Complete(tx, verified); err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, struct{}{})
+}
+
+// verifyAuthorizationResponse verifies the vp_token of the transaction and records the verification result
+func (o *Operation) verifyAuthorizationResponse(tx *oidc4vp.Transaction, vpToken []byte,
+	submission *presexch.PresentationSubmission) bool {
+	status, resp := o.verifyPresentation(&VerifyPresentationRequest{
+		Presentation: vpToken,
+		Opts: &VerifyPresentationOptions{
+			Challenge:              tx.Nonce,
+			Domain:                 tx.ClientID,
+			Checks:                 tx.Checks,
+			PresentationDefinition: tx.PresentationDefinition,
+			Profile:                tx.Profile,
+			PresentationSubmission: submission,
+		},
+	})
+
+	if result, err := json.Marshal(resp); err == nil {
+		tx.Result = result
+	}
+
+	if status != http.StatusOK {
+		tx.Error = "presentation verification failed"
+
+		return false
+	}
+
+	tx.Presentation = vpToken
+
+	// the presentations secured as JWTs are kept as strings
+	if !json.Valid(vpToken) {
+		tx.Presentation, _ = json.Marshal(string(vpToken)) // nolint: errcheck
+	}
+
+	return true
+}
+
+// presentationRequest returns the transaction of the verifier profile looked up by the transaction or request ID
+func (o *Operation) presentationRequest(rw http.ResponseWriter, profileID, id string,
+	get func(string) (*oidc4vp.Transaction, error)) (*oidc4vp.Transaction, bool) {
+	tx, err := get(id)
+	if err != nil && !errors.Is(err, storage.ErrValueNotFound) {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return nil, false
+	}
+
+	if err != nil || tx.Profile != profileID {
+		o.writeErrorResponse(rw, http.StatusNotFound, fmt.Sprintf("authorization request %s not found", id))
+
+		return nil, false
+	}
+
+	return tx, true
+}
+
+// presentationRequestChecks returns the checks of the presentation, the proof check verifies the nonce
+// of the request and the presentation definition check the submission, they can't be skipped
+func presentationRequestChecks(checks []string) []string {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	var required []string
+
+	for _, check := range []string{proofCheck, presentationDefinitionCheck} {
+		if !containsString(checks, check) {
+			required = append(required, check)
+		}
+	}
+
+	return append(required, checks...)
+}
@@ -0,0 +1,398 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/google/tink/go/keyset"
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/composite/ecdhes"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
+	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage/memstore"
+
+	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/datamodel"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/oidc4vp"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
+)
+
+const verifierDID = "did:example:76e12ec712ebc6f1c221ebfeb1f"
+
+func TestOIDC4VPFlow(t *testing.T) {
+	defer setTestContexts(t)()
+
+	holderPubKey, holderPrivKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	verifierPubKey, verifierPrivKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI: &vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+			if didID == verifierDID {
+				return createDIDDoc(didID, verifierPubKey), nil
+			}
+
+			return createJWKDIDDoc(t, didID, holderPubKey), nil
+		}},
+		Crypto:  &cryptomock.Crypto{},
+		HostURL: "http://example.com",
+	})
+	require.NoError(t, err)
+
+	definition := &presexch.PresentationDefinition{
+		ID: "32f54163-7166-48f1-93d8-ff217bdb0653",
+		InputDescriptors: []*presexch.InputDescriptor{{
+			ID: "subject",
+			Constraints: &presexch.Constraints{Fields: []*presexch.Field{{
+				Path:   []string{"$.credentialSubject.id"},
+				Filter: json.RawMessage(`{"const": "` + holderDID + `"}`),
+			}}},
+		}},
+	}
+
+	profileHandler := getHandler(t, op, verifierProfileEndpoint, verifierMode)
+
+	t.Run("create verifier profiles", func(t *testing.T) {
+		reqBytes, err := json.Marshal(&VerifierProfileRequest{
+			Name:                   "verifier",
+			PresentationDefinition: definition,
+			DID:                    verifierDID,
+			DIDPrivateKey:          base58.Encode(verifierPrivKey),
+			DIDKeyType:             vccrypto.Ed25519KeyType,
+		})
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, profileHandler.Handle(), http.MethodPost, verifierProfileEndpoint, reqBytes)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		profile, err := op.profileStore.GetVerifierProfile("verifier")
+		require.NoError(t, err)
+		require.Equal(t, verifierDID, profile.DID)
+		require.Equal(t, verifierDID+"#key-1", profile.Creator)
+
+		reqBytes, err = json.Marshal(&VerifierProfileRequest{Name: "other", DIDPrivateKey: "abc"})
+		require.NoError(t, err)
+
+		rr = serveHTTP(t, profileHandler.Handle(), http.MethodPost, verifierProfileEndpoint, reqBytes)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "missing DID of the private key")
+
+		reqBytes, err = json.Marshal(&VerifierProfileRequest{Name: "other", PresentationDefinition: definition})
+		require.NoError(t, err)
+
+		rr = serveHTTP(t, profileHandler.Handle(), http.MethodPost, verifierProfileEndpoint, reqBytes)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	})
+
+	issued := time.Now().UTC().Truncate(time.Second)
+
+	credential := &verifiable.Credential{
+		Context: []string{datamodel.ContextV1},
+		ID:      "http://example.edu/credentials/1872",
+		Types:   []string{"VerifiableCredential"},
+		Issuer:  verifiable.Issuer{ID: "did:example:issuer"},
+		Issued:  &issued,
+		Subject: map[string]interface{}{"id": holderDID},
+	}
+
+	signLDP(t, credential, holderPrivKey, "did:example:issuer#key-1", "", "")
+
+	presentation := func(t *testing.T, challenge, domain string) string {
+		t.Helper()
+
+		vp, err := credential.Presentation()
+		require.NoError(t, err)
+
+		vp.Holder = holderDID
+
+		signLDP(t, vp, holderPrivKey, holderDID+"#key-1", challenge, domain)
+
+		vpBytes, err := vp.MarshalJSON()
+		require.NoError(t, err)
+
+		return string(vpBytes)
+	}
+
+	submission, err := json.Marshal(&presexch.PresentationSubmission{
+		DefinitionID: definition.ID,
+		DescriptorMap: []*presexch.InputDescriptorMapping{{
+			ID: "subject", Format: oidc4vp.LDPVPFormat, Path: "$",
+			PathNested: &presexch.InputDescriptorMapping{
+				ID: "subject", Format: "ldp_vc", Path: "$.verifiableCredential[0]",
+			},
+		}},
+	})
+	require.NoError(t, err)
+
+	t.Run("test presentation is verified", func(t *testing.T) {
+		created := createAuthorizationRequest(t, op, "verifier", &AuthorizationRequestRequest{})
+		reqID := path.Base(created.RequestURI)
+		require.Equal(t, "http://example.com/verifier/verifier/openid4vp/objects/"+reqID, created.RequestURI)
+		require.NotEqual(t, created.TransactionID, reqID)
+
+		authzRequest, err := url.Parse(created.AuthorizationRequest)
+		require.NoError(t, err)
+		require.Equal(t, "openid4vp", authzRequest.Scheme)
+		require.Equal(t, verifierDID, authzRequest.Query().Get("client_id"))
+		require.Equal(t, created.RequestURI, authzRequest.Query().Get("request_uri"))
+
+		requestObject := getRequestObject(t, op, "verifier", reqID, verifierPubKey)
+		require.Equal(t, verifierDID, requestObject.ClientID)
+		require.Equal(t, oidc4vp.ClientIDSchemeDID, requestObject.ClientIDScheme)
+		require.Equal(t, oidc4vp.ResponseModeDirectPost, requestObject.ResponseMode)
+		require.Equal(t, "http://example.com/verifier/verifier/openid4vp/response", requestObject.ResponseURI)
+		require.Equal(t, reqID, requestObject.State)
+		require.Equal(t, definition.ID, requestObject.PresentationDefinition.ID)
+		require.NotEmpty(t, requestObject.Nonce)
+
+		rr := postAuthorizationResponse(t, op, "verifier", url.Values{
+			vpTokenParam:                {presentation(t, requestObject.Nonce, requestObject.ClientID)},
+			presentationSubmissionParam: {string(submission)},
+			stateParam:                  {requestObject.State},
+		})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		tx := getAuthorizationRequest(t, op, "verifier", created.TransactionID)
+		require.Equal(t, oidc4vp.StateVerified, tx.State, string(tx.Result))
+		require.NotEmpty(t, tx.Presentation)
+		require.NotNil(t, tx.Completed)
+		require.Empty(t, tx.Nonce)
+
+		// the state passed to the wallet isn't the handle of the transaction
+		rr = serveHTTPMux(t, getHandler(t, op, authorizationRequestPath, verifierMode), "", nil,
+			map[string]string{profileIDPathParam: "verifier", transactionIDPathParam: reqID})
+		require.Equal(t, http.StatusNotFound, rr.Code)
+
+		// the request object is not served and the response is rejected once responded
+		rr = serveHTTPMux(t, getHandler(t, op, requestObjectPath, verifierMode), "", nil,
+			map[string]string{profileIDPathParam: "verifier", requestIDPathParam: reqID})
+		require.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = postAuthorizationResponse(t, op, "verifier", url.Values{
+			vpTokenParam:                {presentation(t, requestObject.Nonce, requestObject.ClientID)},
+			presentationSubmissionParam: {string(submission)},
+			stateParam:                  {requestObject.State},
+		})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "is already responded")
+	})
+
+	t.Run("test presentation with another nonce fails", func(t *testing.T) {
+		created := createAuthorizationRequest(t, op, "verifier", &AuthorizationRequestRequest{})
+
+		rr := postAuthorizationResponse(t, op, "verifier", url.Values{
+			vpTokenParam:                {presentation(t, "other", verifierDID)},
+			presentationSubmissionParam: {string(submission)},
+			stateParam:                  {path.Base(created.RequestURI)},
+		})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		tx := getAuthorizationRequest(t, op, "verifier", created.TransactionID)
+		require.Equal(t, oidc4vp.StateFailed, tx.State)
+		require.Equal(t, "presentation verification failed", tx.Error)
+		require.Empty(t, tx.Presentation)
+		require.Contains(t, string(tx.Result), "invalid challenge")
+	})
+
+	t.Run("test wallet error response", func(t *testing.T) {
+		created := createAuthorizationRequest(t, op, "verifier", &AuthorizationRequestRequest{})
+
+		rr := postAuthorizationResponse(t, op, "verifier", url.Values{
+			errorParam:            {"access_denied"},
+			errorDescriptionParam: {"user declined"},
+			stateParam:            {path.Base(created.RequestURI)},
+		})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		tx := getAuthorizationRequest(t, op, "verifier", created.TransactionID)
+		require.Equal(t, oidc4vp.StateFailed, tx.State)
+		require.Equal(t, "access_denied: user declined", tx.Error)
+	})
+
+	t.Run("test invalid authorization response", func(t *testing.T) {
+		created := createAuthorizationRequest(t, op, "verifier", &AuthorizationRequestRequest{})
+		reqID := path.Base(created.RequestURI)
+
+		tests := []struct {
+			name    string
+			profile string
+			form    url.Values
+			err     string
+		}{
+			{"no vp_token", "verifier", url.Values{stateParam: {reqID}}, "vp_token is required"},
+			{
+				"invalid submission", "verifier",
+				url.Values{vpTokenParam: {"{}"}, presentationSubmissionParam: {"invalid"}},
+				"invalid presentation_submission",
+			},
+			{"unknown state", "verifier", url.Values{vpTokenParam: {"{}"}, stateParam: {"abc"}}, "unknown state abc"},
+			{
+				"transaction ID as state", "verifier",
+				url.Values{vpTokenParam: {"{}"}, stateParam: {created.TransactionID}}, "unknown state",
+			},
+			{
+				"another verifier", "other", url.Values{vpTokenParam: {"{}"}, stateParam: {reqID}},
+				"is issued by another verifier",
+			},
+		}
+
+		for _, tc := range tests {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				rr := postAuthorizationResponse(t, op, tc.profile, tc.form)
+				require.Equal(t, http.StatusBadRequest, rr.Code)
+
+				errResp := &oidc4vp.ErrorResponse{}
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), errResp))
+				require.Equal(t, oidc4vp.ErrorInvalidRequest, errResp.Error)
+				require.Contains(t, errResp.Description, tc.err)
+			})
+		}
+
+		// the transaction is still pending
+		require.Equal(t, oidc4vp.StatePending, getAuthorizationRequest(t, op, "verifier", created.TransactionID).State)
+	})
+
+	t.Run("test invalid authorization request", func(t *testing.T) {
+		handler := getHandler(t, op, authorizationRequestsPath, verifierMode)
+
+		rr := serveHTTPMux(t, handler, "", []byte("{}"), map[string]string{profileIDPathParam: "unknown"})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid verifier profile - id=unknown")
+
+		rr = serveHTTPMux(t, handler, "", []byte("{}"), map[string]string{profileIDPathParam: "other"})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "verifier profile other has no DID to sign the request objects")
+
+		rr = serveHTTPMux(t, handler, "", []byte("invalid"), map[string]string{profileIDPathParam: "verifier"})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), invalidRequestErrMsg)
+
+		created := createAuthorizationRequest(t, op, "verifier", &AuthorizationRequestRequest{
+			Checks: []string{"status"},
+		})
+
+		tx := getAuthorizationRequest(t, op, "verifier", created.TransactionID)
+		require.Equal(t, []string{proofCheck, presentationDefinitionCheck, "status"}, tx.Checks)
+
+		for h, param := range map[string]string{
+			authorizationRequestPath: created.TransactionID,
+			requestObjectPath:        path.Base(created.RequestURI),
+		} {
+			rr = serveHTTPMux(t, getHandler(t, op, h, verifierMode), "", nil,
+				map[string]string{profileIDPathParam: "other", transactionIDPathParam: param,
+					requestIDPathParam: param})
+			require.Equal(t, http.StatusNotFound, rr.Code)
+
+			rr = serveHTTPMux(t, getHandler(t, op, h, verifierMode), "", nil,
+				map[string]string{profileIDPathParam: "verifier", transactionIDPathParam: "unknown",
+					requestIDPathParam: "unknown"})
+			require.Equal(t, http.StatusNotFound, rr.Code)
+			require.Contains(t, rr.Body.String(), "authorization request unknown not found")
+		}
+	})
+}
+
+func createAuthorizationRequest(t *testing.T, op *Operation, profile string,
+	request *AuthorizationRequestRequest) *AuthorizationRequestResponse {
+	t.Helper()
+
+	reqBytes, err := json.Marshal(request)
+	require.NoError(t, err)
+
+	rr := serveHTTPMux(t, getHandler(t, op, authorizationRequestsPath, verifierMode), "", reqBytes,
+		map[string]string{profileIDPathParam: profile})
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	resp := &AuthorizationRequestResponse{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+
+	return resp
+}
+
+func getAuthorizationRequest(t *testing.T, op *Operation, profile, txID string) *oidc4vp.Transaction {
+	t.Helper()
+
+	rr := serveHTTPMux(t, getHandler(t, op, authorizationRequestPath, verifierMode), "", nil,
+		map[string]string{profileIDPathParam: profile, transactionIDPathParam: txID})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	tx := &oidc4vp.Transaction{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), tx))
+
+	return tx
+}
+
+// getRequestObject returns the claims of the request object verified with the public key of the verifier
+func getRequestObject(t *testing.T, op *Operation, profile, reqID string, pubKey []byte) *oidc4vp.RequestObject {
+	t.Helper()
+
+	rr := serveHTTPMux(t, getHandler(t, op, requestObjectPath, verifierMode), "", nil,
+		map[string]string{profileIDPathParam: profile, requestIDPathParam: reqID})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, oidc4vp.RequestObjectContentType, rr.Header().Get("Content-Type"))
+
+	jws, err := jose.ParseJWS(rr.Body.String(), jose.NewCompositeAlgSigVerifier(jose.AlgSignatureVerifier{
+		Alg: "EdDSA",
+		Verifier: jose.SignatureVerifierFunc(func(_ jose.Headers, _, signingInput, signature []byte) error {
+			return verifier.NewEd25519SignatureVerifier().Verify(
+				&verifier.PublicKey{Type: "Ed25519VerificationKey2018", Value: pubKey}, signingInput, signature)
+		}),
+	}))
+	require.NoError(t, err)
+	require.Equal(t, oidc4vp.RequestObjectType, jws.ProtectedHeaders[jose.HeaderType])
+	require.Equal(t, verifierDID+"#key-1", jws.ProtectedHeaders[jose.HeaderKeyID])
+
+	requestObject := &oidc4vp.RequestObject{}
+	require.NoError(t, json.Unmarshal(jws.Payload, requestObject))
+
+	return requestObject
+}
+
+func postAuthorizationResponse(t *testing.T, op *Operation, profile string,
+	form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, "/verifier/"+profile+"/openid4vp/response",
+		strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rr := httptest.NewRecorder()
+	getHandler(t, op, authorizationResponsePath, verifierMode).Handle().ServeHTTP(rr,
+		mux.SetURLVars(req, map[string]string{profileIDPathParam: profile}))
+
+	return rr
+}
@@ -9,7 +9,15 @@ package operation
 import (
 	"time"
 
+	vcchallenge "github.com/trustbloc/edge-service/pkg/doc/vc/challenge"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/cm"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/didconfig"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/issuerregistry"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/oidc4vci"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/oidc4vp"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
 	cslstatus "github.com/trustbloc/edge-service/pkg/doc/vc/status/csl"
+	"github.com/trustbloc/edge-service/pkg/vdri/cache"
 )
 
 // genericError model
@@ -75,6 +83,375 @@ type composeCredentialReq struct { // nolint: unused,deadcode
 	Params ComposeCredentialRequest
 }
 
+// batchIssueCredentialReq model
+//
+// swagger:parameters batchIssueCredentialReq
+type batchIssueCredentialReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: body
+	Params BatchIssueCredentialRequest
+}
+
+// batchIssueCredentialRes model
+//
+// swagger:response batchIssueCredentialRes
+type batchIssueCredentialRes struct { // nolint: unused,deadcode
+	// in: body
+	BatchIssueCredentialResponse
+}
+
+// issuedCredentialsReq model
+//
+// swagger:parameters issuedCredentialsReq
+type issuedCredentialsReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// credential subject ID
+	//
+	// in: query
+	Subject string `json:"subject"`
+
+	// credential type
+	//
+	// in: query
+	Type string `json:"type"`
+
+	// offset of the page
+	//
+	// in: query
+	Offset int `json:"offset"`
+
+	// number of the records scanned
+	//
+	// in: query
+	Limit int `json:"limit"`
+}
+
+// issuedCredentialsRes model
+//
+// swagger:response issuedCredentialsRes
+type issuedCredentialsRes struct { // nolint: unused,deadcode
+	// in: body
+	IssuedCredentialsResponse
+}
+
+// credentialRefreshReq model
+//
+// swagger:parameters credentialRefreshReq
+type credentialRefreshReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+// didAuthRes model
+//
+// swagger:response didAuthRes
+type didAuthRes struct { // nolint: unused,deadcode
+	// in: body
+	DIDAuthRequest
+}
+
+// refreshCredentialReq model
+//
+// swagger:parameters refreshCredentialReq
+type refreshCredentialReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: body
+	Params RefreshCredentialRequest
+}
+
+// reissueCredentialReq model
+//
+// swagger:parameters reissueCredentialReq
+type reissueCredentialReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: body
+	Params ReissueCredentialRequest
+}
+
+// issuerMetadataReq model
+//
+// swagger:parameters issuerMetadataReq
+type issuerMetadataReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+// issuerMetadataRes model
+//
+// swagger:response issuerMetadataRes
+type issuerMetadataRes struct { // nolint: unused,deadcode
+	// in: body
+	oidc4vci.IssuerMetadata
+}
+
+// authServerMetadataReq model
+//
+// swagger:parameters authServerMetadataReq
+type authServerMetadataReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+// authServerMetadataRes model
+//
+// swagger:response authServerMetadataRes
+type authServerMetadataRes struct { // nolint: unused,deadcode
+	// in: body
+	oidc4vci.AuthorizationServerMetadata
+}
+
+// createCredentialOfferReq model
+//
+// swagger:parameters createCredentialOfferReq
+type createCredentialOfferReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: body
+	Params CredentialOfferRequest
+}
+
+// credentialOfferReq model
+//
+// swagger:parameters credentialOfferReq
+type credentialOfferReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// offer ID
+	//
+	// in: path
+	// required: true
+	OfferID string `json:"offerID"`
+}
+
+// credentialOfferRes model
+//
+// swagger:response credentialOfferRes
+type credentialOfferRes struct { // nolint: unused,deadcode
+	// in: body
+	CredentialOfferResponse
+}
+
+// oidc4vciTokenReq model
+//
+// swagger:parameters oidc4vciTokenReq
+type oidc4vciTokenReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: formData
+	// required: true
+	GrantType string `json:"grant_type"`
+
+	// in: formData
+	// required: true
+	PreAuthorizedCode string `json:"pre-authorized_code"`
+
+	// in: formData
+	TxCode string `json:"tx_code"`
+}
+
+// oidc4vciTokenRes model
+//
+// swagger:response oidc4vciTokenRes
+type oidc4vciTokenRes struct { // nolint: unused,deadcode
+	// in: body
+	oidc4vci.TokenResponse
+}
+
+// oidc4vciCredentialReq model
+//
+// swagger:parameters oidc4vciCredentialReq
+type oidc4vciCredentialReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// bearer access token
+	//
+	// in: header
+	// required: true
+	Authorization string `json:"Authorization"`
+
+	// in: body
+	Params oidc4vci.CredentialRequest
+}
+
+// oidc4vciCredentialRes model
+//
+// swagger:response oidc4vciCredentialRes
+type oidc4vciCredentialRes struct { // nolint: unused,deadcode
+	// in: body
+	oidc4vci.CredentialResponse
+}
+
+// oauthErrorRes model
+//
+// swagger:response oauthErrorRes
+type oauthErrorRes struct { // nolint: unused,deadcode
+	// in: body
+	oidc4vci.ErrorResponse
+}
+
+// credentialManifestsReq model
+//
+// swagger:parameters credentialManifestsReq
+type credentialManifestsReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+// credentialManifestsRes model
+//
+// swagger:response credentialManifestsRes
+type credentialManifestsRes struct { // nolint: unused,deadcode
+	// in: body
+	CredentialManifestsResponse
+}
+
+// credentialManifestReq model
+//
+// swagger:parameters credentialManifestReq
+type credentialManifestReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// manifest ID (credential template ID)
+	//
+	// in: path
+	// required: true
+	ManifestID string `json:"manifestID"`
+}
+
+// credentialManifestRes model
+//
+// swagger:response credentialManifestRes
+type credentialManifestRes struct { // nolint: unused,deadcode
+	// in: body
+	cm.CredentialManifest
+}
+
+// credentialApplicationRequestReq model
+//
+// swagger:parameters credentialApplicationRequestReq
+type credentialApplicationRequestReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+// credentialApplicationReq model
+//
+// swagger:parameters credentialApplicationReq
+type credentialApplicationReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: body
+	Params CredentialApplicationRequest
+}
+
+// credentialFulfillmentRes model
+//
+// swagger:response credentialFulfillmentRes
+type credentialFulfillmentRes struct { // nolint: unused,deadcode
+	// in: body
+	CredentialFulfillmentResponse
+}
+
+// didConfigurationReq model
+//
+// swagger:parameters didConfigurationReq
+type didConfigurationReq struct { // nolint: unused,deadcode
+}
+
+// didConfigurationRes model
+//
+// swagger:response didConfigurationRes
+type didConfigurationRes struct { // nolint: unused,deadcode
+	// in: body
+	didconfig.Configuration
+}
+
+// submitJobReq model
+//
+// swagger:parameters submitJobReq
+type submitJobReq struct { // nolint: unused,deadcode
+	// in: body
+	Params JobRequest
+}
+
+// getJobReq model
+//
+// swagger:parameters getJobReq
+type getJobReq struct { // nolint: unused,deadcode
+	// job ID
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+// jobRes model
+//
+// swagger:response jobRes
+type jobRes struct { // nolint: unused,deadcode
+	// in: body
+	JobResponse
+}
+
 // verifiableCredentialRes model contains the verifiable credential
 //
 // swagger:response verifiableCredentialRes
@@ -118,6 +495,14 @@ type verifyCredentialFailureResp struct { // nolint: unused,deadcode
 	Checks []*CredentialsVerificationCheckResult `json:"checks,omitempty"`
 }
 
+// verifyCredentialReportResp model
+//
+// swagger:response verifyCredentialReportResp
+type verifyCredentialReportResp struct { // nolint: unused,deadcode
+	// in: body
+	VerificationReport
+}
+
 // verifyCredentialReq model
 //
 // swagger:parameters verifyPresentationReq
@@ -255,3 +640,338 @@ type signPresentationReq struct { // nolint: unused,deadcode
 type signPresentationRes struct { // nolint: unused,deadcode
 	// in: body
 }
+
+// addWalletCredentialReq model
+//
+// swagger:parameters addWalletCredentialReq
+type addWalletCredentialReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: body
+	Params WalletCredentialRequest
+}
+
+// walletCredentialsReq model
+//
+// swagger:parameters walletCredentialsReq
+type walletCredentialsReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// credential type
+	//
+	// in: query
+	Type string `json:"type"`
+
+	// credential issuer ID
+	//
+	// in: query
+	Issuer string `json:"issuer"`
+
+	// credential subject ID
+	//
+	// in: query
+	Subject string `json:"subject"`
+}
+
+// walletCredentialReq model
+//
+// swagger:parameters walletCredentialReq deleteWalletCredentialReq
+type walletCredentialReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// wallet credential ID
+	//
+	// in: path
+	// required: true
+	CredentialID string `json:"credentialID"`
+}
+
+// walletCredentialRes model
+//
+// swagger:response walletCredentialRes
+type walletCredentialRes struct { // nolint: unused,deadcode
+	// in: body
+	WalletCredential
+}
+
+// walletCredentialsRes model
+//
+// swagger:response walletCredentialsRes
+type walletCredentialsRes struct { // nolint: unused,deadcode
+	// in: body
+	WalletCredentialsResponse
+}
+
+// walletPresentationReq model
+//
+// swagger:parameters walletPresentationReq
+type walletPresentationReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: body
+	Params WalletPresentationRequest
+}
+
+// walletPresentationRes model
+//
+// swagger:response walletPresentationRes
+type walletPresentationRes struct { // nolint: unused,deadcode
+	// in: body
+	WalletPresentationResponse
+}
+
+// trustedIssuerReq model
+//
+// swagger:parameters trustedIssuerReq
+type trustedIssuerReq struct { // nolint: unused,deadcode
+	// in: body
+	Params TrustedIssuerRequest
+}
+
+// retrieveTrustedIssuerReq model
+//
+// swagger:parameters retrieveTrustedIssuerReq deleteTrustedIssuerReq
+type retrieveTrustedIssuerReq struct { // nolint: unused,deadcode
+	// issuer DID
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+// trustedIssuerRes model
+//
+// swagger:response trustedIssuerRes
+type trustedIssuerRes struct { // nolint: unused,deadcode
+	// in: body
+	issuerregistry.TrustedIssuer
+}
+
+// listTrustedIssuersReq model
+//
+// swagger:parameters listTrustedIssuersReq
+type listTrustedIssuersReq struct { // nolint: unused,deadcode
+}
+
+// trustedIssuersRes model
+//
+// swagger:response trustedIssuersRes
+type trustedIssuersRes struct { // nolint: unused,deadcode
+	// in: body
+	TrustedIssuersResponse
+}
+
+// verifierProfileReq model
+//
+// swagger:parameters verifierProfileReq
+type verifierProfileReq struct { // nolint: unused,deadcode
+	// in: body
+	Params VerifierProfileRequest
+}
+
+// retrieveVerifierProfileReq model
+//
+// swagger:parameters retrieveVerifierProfileReq
+type retrieveVerifierProfileReq struct { // nolint: unused,deadcode
+	// profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+// verifierProfileRes model
+//
+// swagger:response verifierProfileRes
+type verifierProfileRes struct { // nolint: unused,deadcode
+	// in: body
+	vcprofile.VerifierProfile
+}
+
+// challengeReq model
+//
+// swagger:parameters challengeReq
+type challengeReq struct { // nolint: unused,deadcode
+	// in: body
+	Params ChallengeRequest
+}
+
+// challengeRes model
+//
+// swagger:response challengeRes
+type challengeRes struct { // nolint: unused,deadcode
+	// in: body
+	vcchallenge.Challenge
+}
+
+// authorizationRequestReq model
+//
+// swagger:parameters authorizationRequestReq
+type authorizationRequestReq struct { // nolint: unused,deadcode
+	// verifier profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: body
+	Params AuthorizationRequestRequest
+}
+
+// authorizationRequestRes model
+//
+// swagger:response authorizationRequestRes
+type authorizationRequestRes struct { // nolint: unused,deadcode
+	// in: body
+	AuthorizationRequestResponse
+}
+
+// authzRequestReq model
+//
+// swagger:parameters authzRequestReq
+type authzRequestReq struct { // nolint: unused,deadcode
+	// verifier profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// transaction ID
+	//
+	// in: path
+	// required: true
+	TransactionID string `json:"transactionID"`
+}
+
+// requestObjectReq model
+//
+// swagger:parameters requestObjectReq
+type requestObjectReq struct { // nolint: unused,deadcode
+	// verifier profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// request ID (the state of the authorization request)
+	//
+	// in: path
+	// required: true
+	RequestID string `json:"requestID"`
+}
+
+// authzRequestRes model
+//
+// swagger:response authzRequestRes
+type authzRequestRes struct { // nolint: unused,deadcode
+	// in: body
+	oidc4vp.Transaction
+}
+
+// requestObjectRes model
+//
+// swagger:response requestObjectRes
+type requestObjectRes struct { // nolint: unused,deadcode
+	// signed request object (application/oauth-authz-req+jwt)
+	//
+	// in: body
+	RequestObject string
+}
+
+// authorizationResponseReq model
+//
+// swagger:parameters authorizationResponseReq
+type authorizationResponseReq struct { // nolint: unused,deadcode
+	// verifier profile
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+
+	// in: formData
+	VPToken string `json:"vp_token"`
+
+	// in: formData
+	PresentationSubmission string `json:"presentation_submission"`
+
+	// in: formData
+	// required: true
+	State string `json:"state"`
+
+	// in: formData
+	Error string `json:"error"`
+
+	// in: formData
+	ErrorDescription string `json:"error_description"`
+}
+
+// batchVerificationReq model
+//
+// swagger:parameters batchVerificationReq
+type batchVerificationReq struct { // nolint: unused,deadcode
+	// in: body
+	Params BatchVerificationRequest
+}
+
+// batchVerificationRes model
+//
+// swagger:response batchVerificationRes
+type batchVerificationRes struct { // nolint: unused,deadcode
+	// in: body
+	BatchVerificationResponse
+}
+
+// didCacheMetricsReq model
+//
+// swagger:parameters didCacheMetricsReq
+type didCacheMetricsReq struct { // nolint: unused,deadcode
+}
+
+// didCacheMetricsRes model
+//
+// swagger:response didCacheMetricsRes
+type didCacheMetricsRes struct { // nolint: unused,deadcode
+	// in: body
+	cache.Metrics
+}
+
+// purgeDIDCacheReq model
+//
+// swagger:parameters purgeDIDCacheReq
+type purgeDIDCacheReq struct { // nolint: unused,deadcode
+}
+
+// purgeDIDCacheEntryReq model
+//
+// swagger:parameters purgeDIDCacheEntryReq
+type purgeDIDCacheEntryReq struct { // nolint: unused,deadcode
+	// DID
+	//
+	// in: path
+	// required: true
+	ID string `json:"id"`
+}
+
+// purgeDIDCacheRes model
+//
+// swagger:response purgeDIDCacheRes
+type purgeDIDCacheRes struct { // nolint: unused,deadcode
+	// in: body
+	DIDCachePurgeResponse
+}
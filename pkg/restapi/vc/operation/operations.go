@@ -19,6 +19,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/btcsuite/btcutil/base58"
@@ -27,10 +28,16 @@ import (
 	ariescrypto "github.com/hyperledger/aries-framework-go/pkg/crypto"
 	ariesdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ecdsasecp256k1signature2019"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/jsonwebsignature2020"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
 	ariesstorage "github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/piprate/json-gold/ld"
 	log "github.com/sirupsen/logrus"
 	"github.com/trustbloc/edge-core/pkg/storage"
 	"github.com/trustbloc/edv/pkg/restapi/edv/edverrors"
@@ -40,10 +47,25 @@ import (
 
 	"github.com/trustbloc/edge-service/internal/cryptosetup"
 	"github.com/trustbloc/edge-service/pkg/client/uniregistrar"
+	"github.com/trustbloc/edge-service/pkg/doc/jsonld"
+	vcchallenge "github.com/trustbloc/edge-service/pkg/doc/vc/challenge"
 	"github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/dataintegrity"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/datamodel"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/didconfig"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/issuanceregistry"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/issuerregistry"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/oidc4vci"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/oidc4vp"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
 	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/schema"
 	cslstatus "github.com/trustbloc/edge-service/pkg/doc/vc/status/csl"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/wallet"
+	"github.com/trustbloc/edge-service/pkg/internal/common/fetch"
 	"github.com/trustbloc/edge-service/pkg/internal/common/support"
+	"github.com/trustbloc/edge-service/pkg/job"
+	"github.com/trustbloc/edge-service/pkg/vdri/cache"
 )
 
 const (
@@ -59,27 +81,77 @@ const (
 	holderProfileEndpoint             = "/holder/profile"
 	getHolderProfileEndpoint          = holderProfileEndpoint + "/" + "{" + profileIDPathParam + "}"
 	signPresentationEndpoint          = "/" + "{" + profileIDPathParam + "}" + "/prove/presentations"
+	walletCredentialsEndpoint         = "/" + "{" + profileIDPathParam + "}" + "/wallet/credentials"
+	walletCredentialEndpoint          = walletCredentialsEndpoint + "/{" + walletCredentialIDPathParam + "}"
+	walletPresentationsEndpoint       = "/" + "{" + profileIDPathParam + "}" + "/wallet/presentations"
 	storeCredentialEndpoint           = "/store"
 	retrieveCredentialEndpoint        = "/retrieve"
 	credentialStatusEndpoint          = credentialStatus + "/{id}"
 	credentialsBasePath               = "/" + "{" + profileIDPathParam + "}" + "/credentials"
 	issueCredentialPath               = credentialsBasePath + "/issueCredential"
 	composeAndIssueCredentialPath     = credentialsBasePath + "/composeAndIssueCredential"
+	issueCredentialsPath              = credentialsBasePath + "/issueCredentials"
+	issuedCredentialsPath             = credentialsBasePath + "/issued"
+	credentialRefreshPath             = credentialsBasePath + "/refresh"
+	credentialReissuePath             = credentialsBasePath + "/reissue"
+	credentialManifestsPath           = credentialsBasePath + "/manifests"
+	credentialManifestPath            = credentialManifestsPath + "/" + "{" + manifestIDPathParam + "}"
+	credentialApplicationsPath        = credentialsBasePath + "/applications"
+	jobsEndpoint                      = "/jobs"
+	jobEndpoint                       = jobsEndpoint + "/{id}"
 	kmsBasePath                       = "/kms"
 	generateKeypairPath               = kmsBasePath + "/generatekeypair"
 	credentialVerificationsEndpoint   = "/verifications"
 	verifierBasePath                  = "/verifier"
 	credentialsVerificationEndpoint   = verifierBasePath + "/credentials"
 	presentationsVerificationEndpoint = verifierBasePath + "/presentations"
+	trustedIssuersEndpoint            = verifierBasePath + "/trustedissuers"
+	getTrustedIssuerEndpoint          = trustedIssuersEndpoint + "/{id}"
+	verifierProfileEndpoint           = verifierBasePath + "/profile"
+	getVerifierProfileEndpoint        = verifierProfileEndpoint + "/" + "{" + profileIDPathParam + "}"
+	challengesEndpoint                = verifierBasePath + "/challenges"
+	batchVerificationEndpoint         = verifierBasePath + "/batch"
+	didCacheEndpoint                  = "/admin/didcache"
+	didCacheEntryEndpoint             = didCacheEndpoint + "/{id}"
+	didConfigurationEndpoint          = didconfig.WellKnownPath
+	issuerMetadataEndpoint            = oidc4vci.IssuerMetadataPath + "/" + "{" + profileIDPathParam + "}"
+	authorizationServerEndpoint       = oidc4vci.AuthorizationServerMetadataPath + "/" + "{" + profileIDPathParam + "}"
+	oidc4vciBasePath                  = "/" + "{" + profileIDPathParam + "}" + "/openid4vci"
+	credentialOffersPath              = oidc4vciBasePath + "/offers"
+	credentialOfferPath               = credentialOffersPath + "/" + "{" + offerIDPathParam + "}"
+	oidc4vciTokenPath                 = oidc4vciBasePath + "/token"
+	oidc4vciCredentialPath            = oidc4vciBasePath + "/credential"
+	oidc4vpBasePath                   = verifierBasePath + "/" + "{" + profileIDPathParam + "}" + "/openid4vp"
+	authorizationRequestsPath         = oidc4vpBasePath + "/requests"
+	authorizationRequestPath          = authorizationRequestsPath + "/" + "{" + transactionIDPathParam + "}"
+	requestObjectPath                 = oidc4vpBasePath + "/objects/" + "{" + requestIDPathParam + "}"
+	authorizationResponsePath         = oidc4vpBasePath + "/response"
 
 	successMsg = "success"
 	cslSize    = 50
 
+	defaultChallengeExpiry = 5 * time.Minute
+
 	invalidRequestErrMsg = "Invalid request"
 
+	// verification report check status
+	checkPassed = "passed"
+	checkFailed = "failed"
+
+	jwtPartsCount = 3
+
 	// credential verification checks
-	proofCheck  = "proof"
-	statusCheck = "status"
+	proofCheck        = "proof"
+	statusCheck       = "status"
+	expiryCheck       = "expiry"
+	validityCheck     = "validity"
+	issuerCheck       = "issuer"
+	schemaCheck       = "schema"
+	linkedDomainCheck = "linkedDomain"
+
+	// presentation verification checks
+	presentationDefinitionCheck = "presentationDefinition"
+	holderBindingCheck          = "holderBinding"
 
 	// supported proof purpose
 	assertionMethod      = "assertionMethod"
@@ -106,6 +178,7 @@ const (
 var signatureKeyTypeMap = map[string]string{
 	crypto.Ed25519Signature2018: crypto.Ed25519VerificationKey2018,
 	crypto.JSONWebSignature2020: crypto.JwsVerificationKey2020,
+	crypto.DataIntegrityProof:   crypto.Ed25519VerificationKey2018,
 }
 
 var errProfileNotFound = errors.New("specified profile ID does not exist")
@@ -122,7 +195,7 @@ type Handler interface {
 }
 
 type vcStatusManager interface {
-	CreateStatusID() (*verifiable.TypedID, error)
+	CreateStatusIDs(n int) ([]*cslstatus.StatusID, error)
 	UpdateVCStatus(v *verifiable.Credential, profile *vcprofile.DataProfile, status, statusReason string) error
 	GetCSL(id string) (*cslstatus.CSL, error)
 }
@@ -144,6 +217,21 @@ type httpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// offlineHTTPClient refuses the requests, e.g. fetching status lists and schemas in the offline mode
+type offlineHTTPClient struct{}
+
+func (c *offlineHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("network access is disabled in the offline mode: %s", req.URL)
+}
+
+func newHTTPClient(config *Config) httpClient {
+	if config.Offline {
+		return &offlineHTTPClient{}
+	}
+
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: config.TLSConfig}}
+}
+
 type didBlocClient interface {
 	CreateDID(domain string, opts ...didclient.CreateDIDOption) (*ariesdid.Doc, error)
 }
@@ -159,7 +247,7 @@ func New(config *Config) (*Operation, error) {
 		return nil, err
 	}
 
-	c := crypto.New(config.KeyManager, config.Crypto)
+	c := crypto.New(config.KeyManager, config.Crypto, crypto.WithDocumentLoader(config.DocumentLoader))
 
 	vcStatusManager, err := cslstatus.New(config.StoreProvider, config.HostURL+credentialStatus, cslSize, c)
 	if err != nil {
@@ -178,8 +266,41 @@ func New(config *Config) (*Operation, error) {
 		return nil, err
 	}
 
+	httpClient := newHTTPClient(config)
+
+	challengeExpiry := config.ChallengeExpiry
+	if challengeExpiry == 0 {
+		challengeExpiry = defaultChallengeExpiry
+	}
+
+	schemaOpts := []schema.Option{schema.WithTLSConfig(config.TLSConfig),
+		schema.WithAllowedHosts(config.SchemaAllowedHosts...)}
+	if config.Offline {
+		schemaOpts = append(schemaOpts, schema.WithHTTPClient(httpClient))
+	}
+
+	// the webhooks and the linked domains are taken from the profile requests and the DID documents, so they're
+	// only requested at the public (or allowed) https hosts
+	webhookClient, linkedDomainClient := httpClient, httpClient
+	if !config.Offline {
+		webhookClient = fetch.New(fetch.WithTLSConfig(config.TLSConfig),
+			fetch.WithAllowedHosts(config.WebhookAllowedHosts...))
+		linkedDomainClient = fetch.New(fetch.WithTLSConfig(config.TLSConfig))
+	}
+
+	for id, s := range config.PinnedSchemas {
+		schemaOpts = append(schemaOpts, schema.WithSchema(id, s))
+	}
+
 	svc := &Operation{
 		profileStore:         vcprofile.New(credentialStore),
+		issuerRegistry:       issuerregistry.New(credentialStore),
+		issuanceRegistry:     issuanceregistry.New(credentialStore),
+		didConfig:            didconfig.New(credentialStore),
+		challenges:           vcchallenge.New(credentialStore, challengeExpiry),
+		issuanceSessions:     oidc4vci.New(credentialStore, credentialOfferExpiry, accessTokenExpiry),
+		presentationRequests: oidc4vp.New(credentialStore),
+		wallet:               wallet.New(credentialStore),
 		edvClient:            config.EDVClient,
 		kms:                  config.KeyManager,
 		vdri:                 config.VDRI,
@@ -189,12 +310,29 @@ func New(config *Config) (*Operation, error) {
 		vcStatusManager:      vcStatusManager,
 		didBlocClient:        didclient.New(didclient.WithTLSConfig(config.TLSConfig)),
 		domain:               config.Domain,
-		httpClient:           &http.Client{Transport: &http.Transport{TLSClientConfig: config.TLSConfig}},
+		httpClient:           httpClient,
+		linkedDomainClient:   linkedDomainClient,
 		HostURL:              config.HostURL,
 		uniRegistrarClient:   uniregistrar.New(uniregistrar.WithTLSConfig(config.TLSConfig)),
 		macKeyHandle:         kh,
 		macCrypto:            config.Crypto,
 		vcIDIndexNameEncoded: vcIDIndexNameMACEncoded,
+		documentLoader:       config.DocumentLoader,
+		dataIntegrity:        dataintegrity.New(dataintegrity.WithDocumentLoader(config.DocumentLoader)),
+		clockSkew:            config.ClockSkew,
+		schemaValidator:      schema.New(schemaOpts...),
+		batchConcurrency:     config.BatchConcurrency,
+		didCache:             config.DIDCache,
+		jobs: job.New(credentialStore,
+			append([]job.Option{job.WithHTTPClient(webhookClient)}, config.JobOptions...)...),
+		webhookAllowedHosts: config.WebhookAllowedHosts,
+		reissueMutex:        &sync.Mutex{},
+	}
+
+	svc.registerJobs()
+
+	if err := svc.jobs.Start(); err != nil {
+		return nil, fmt.Errorf("failed to resume jobs: %w", err)
 	}
 
 	return svc, nil
@@ -213,21 +351,38 @@ func prepareCredentialStore(config *Config) (storage.Store, error) {
 
 // Config defines configuration for vcs operations
 type Config struct {
-	StoreProvider      storage.Provider
-	KMSSecretsProvider ariesstorage.Provider
-	EDVClient          EDVClient
-	KeyManager         keyManager
-	VDRI               vdriapi.Registry
-	HostURL            string
-	Domain             string
-	Mode               string
-	TLSConfig          *tls.Config
-	Crypto             ariescrypto.Crypto
+	StoreProvider       storage.Provider
+	KMSSecretsProvider  ariesstorage.Provider
+	EDVClient           EDVClient
+	KeyManager          keyManager
+	VDRI                vdriapi.Registry
+	HostURL             string
+	Domain              string
+	Mode                string
+	TLSConfig           *tls.Config
+	Crypto              ariescrypto.Crypto
+	DocumentLoader      ld.DocumentLoader
+	ClockSkew           time.Duration
+	PinnedSchemas       map[string][]byte
+	SchemaAllowedHosts  []string
+	WebhookAllowedHosts []string
+	ChallengeExpiry     time.Duration
+	BatchConcurrency    int
+	DIDCache            *cache.Registry
+	Offline             bool
+	JobOptions          []job.Option
 }
 
 // Operation defines handlers for Edge service
 type Operation struct {
 	profileStore         *vcprofile.Profile
+	issuerRegistry       *issuerregistry.Registry
+	issuanceRegistry     *issuanceregistry.Registry
+	didConfig            *didconfig.Store
+	challenges           *vcchallenge.Manager
+	issuanceSessions     *oidc4vci.Manager
+	presentationRequests *oidc4vp.Manager
+	wallet               *wallet.Registry
 	edvClient            EDVClient
 	kms                  keyManager
 	vdri                 vdriapi.Registry
@@ -238,11 +393,21 @@ type Operation struct {
 	didBlocClient        didBlocClient
 	domain               string
 	httpClient           httpClient
+	linkedDomainClient   httpClient
 	HostURL              string
 	uniRegistrarClient   uniRegistrarClient
 	macKeyHandle         *keyset.Handle
 	macCrypto            ariescrypto.Crypto
 	vcIDIndexNameEncoded string
+	documentLoader       ld.DocumentLoader
+	dataIntegrity        *dataintegrity.Suite
+	clockSkew            time.Duration
+	schemaValidator      *schema.Validator
+	batchConcurrency     int
+	didCache             *cache.Registry
+	jobs                 *job.Manager
+	reissueMutex         *sync.Mutex
+	webhookAllowedHosts  []string
 }
 
 // GetRESTHandlers get all controller API handler available for this service
@@ -275,6 +440,33 @@ func (o *Operation) verifierHandlers() []Handler {
 		support.NewHTTPHandler(credentialsVerificationEndpoint, http.MethodPost, o.verifyCredentialHandler),
 		support.NewHTTPHandler(presentationsVerificationEndpoint, http.MethodPost,
 			o.verifyPresentationHandler),
+
+		// trusted issuer registry
+		support.NewHTTPHandler(trustedIssuersEndpoint, http.MethodPost, o.saveTrustedIssuerHandler),
+		support.NewHTTPHandler(trustedIssuersEndpoint, http.MethodGet, o.listTrustedIssuersHandler),
+		support.NewHTTPHandler(getTrustedIssuerEndpoint, http.MethodGet, o.getTrustedIssuerHandler),
+		support.NewHTTPHandler(getTrustedIssuerEndpoint, http.MethodDelete, o.deleteTrustedIssuerHandler),
+
+		// verifier profile
+		support.NewHTTPHandler(verifierProfileEndpoint, http.MethodPost, o.createVerifierProfileHandler),
+		support.NewHTTPHandler(getVerifierProfileEndpoint, http.MethodGet, o.getVerifierProfileHandler),
+
+		// presentation challenges
+		support.NewHTTPHandler(challengesEndpoint, http.MethodPost, o.issueChallengeHandler),
+
+		// batch verification
+		support.NewHTTPHandler(batchVerificationEndpoint, http.MethodPost, o.verifyBatchHandler),
+
+		// OpenID4VP
+		support.NewHTTPHandler(authorizationRequestsPath, http.MethodPost, o.createAuthorizationRequestHandler),
+		support.NewHTTPHandler(authorizationRequestPath, http.MethodGet, o.authorizationRequestHandler),
+		support.NewHTTPHandler(requestObjectPath, http.MethodGet, o.requestObjectHandler),
+		support.NewHTTPHandler(authorizationResponsePath, http.MethodPost, o.authorizationResponseHandler),
+
+		// DID cache administration
+		support.NewHTTPHandler(didCacheEndpoint, http.MethodGet, o.didCacheMetricsHandler),
+		support.NewHTTPHandler(didCacheEndpoint, http.MethodDelete, o.purgeDIDCacheHandler),
+		support.NewHTTPHandler(didCacheEntryEndpoint, http.MethodDelete, o.purgeDIDCacheEntryHandler),
 	}
 }
 
@@ -296,6 +488,32 @@ func (o *Operation) issuerHandlers() []Handler {
 		support.NewHTTPHandler(generateKeypairPath, http.MethodGet, o.generateKeypairHandler),
 		support.NewHTTPHandler(issueCredentialPath, http.MethodPost, o.issueCredentialHandler),
 		support.NewHTTPHandler(composeAndIssueCredentialPath, http.MethodPost, o.composeAndIssueCredentialHandler),
+		support.NewHTTPHandler(issueCredentialsPath, http.MethodPost, o.issueCredentialsHandler),
+		support.NewHTTPHandler(issuedCredentialsPath, http.MethodGet, o.issuedCredentialsHandler),
+		support.NewHTTPHandler(credentialRefreshPath, http.MethodGet, o.credentialRefreshRequestHandler),
+		support.NewHTTPHandler(credentialRefreshPath, http.MethodPost, o.refreshCredentialHandler),
+		support.NewHTTPHandler(credentialReissuePath, http.MethodPost, o.reissueCredentialHandler),
+
+		// asynchronous jobs
+		support.NewHTTPHandler(jobsEndpoint, http.MethodPost, o.submitJobHandler),
+		support.NewHTTPHandler(jobEndpoint, http.MethodGet, o.getJobHandler),
+
+		// linked domains
+		support.NewHTTPHandler(didConfigurationEndpoint, http.MethodGet, o.didConfigurationHandler),
+
+		// OpenID4VCI pre-authorized code flow
+		support.NewHTTPHandler(issuerMetadataEndpoint, http.MethodGet, o.issuerMetadataHandler),
+		support.NewHTTPHandler(authorizationServerEndpoint, http.MethodGet, o.authorizationServerMetadataHandler),
+		support.NewHTTPHandler(credentialOffersPath, http.MethodPost, o.createCredentialOfferHandler),
+		support.NewHTTPHandler(credentialOfferPath, http.MethodGet, o.credentialOfferHandler),
+		support.NewHTTPHandler(oidc4vciTokenPath, http.MethodPost, o.oidc4vciTokenHandler),
+		support.NewHTTPHandler(oidc4vciCredentialPath, http.MethodPost, o.oidc4vciCredentialHandler),
+
+		// DIF credential manifest
+		support.NewHTTPHandler(credentialManifestsPath, http.MethodGet, o.credentialManifestsHandler),
+		support.NewHTTPHandler(credentialManifestPath, http.MethodGet, o.credentialManifestHandler),
+		support.NewHTTPHandler(credentialApplicationsPath, http.MethodGet, o.credentialApplicationRequestHandler),
+		support.NewHTTPHandler(credentialApplicationsPath, http.MethodPost, o.credentialApplicationHandler),
 	}
 }
 
@@ -305,6 +523,13 @@ func (o *Operation) holderHandlers() []Handler {
 		support.NewHTTPHandler(holderProfileEndpoint, http.MethodPost, o.createHolderProfileHandler),
 		support.NewHTTPHandler(getHolderProfileEndpoint, http.MethodGet, o.getHolderProfileHandler),
 		support.NewHTTPHandler(signPresentationEndpoint, http.MethodPost, o.signPresentationHandler),
+
+		// holder wallet
+		support.NewHTTPHandler(walletCredentialsEndpoint, http.MethodPost, o.addWalletCredentialHandler),
+		support.NewHTTPHandler(walletCredentialsEndpoint, http.MethodGet, o.walletCredentialsHandler),
+		support.NewHTTPHandler(walletCredentialEndpoint, http.MethodGet, o.walletCredentialHandler),
+		support.NewHTTPHandler(walletCredentialEndpoint, http.MethodDelete, o.deleteWalletCredentialHandler),
+		support.NewHTTPHandler(walletPresentationsEndpoint, http.MethodPost, o.walletPresentationHandler),
 	}
 }
 
@@ -374,7 +599,11 @@ func (o *Operation) checkVCStatus(vclID, vcID string) (*VerifyCredentialResponse
 }
 
 func (o *Operation) sendHTTPRequest(req *http.Request, status int) ([]byte, error) {
-	resp, err := o.httpClient.Do(req)
+	return sendHTTPRequest(o.httpClient, req, status)
+}
+
+func sendHTTPRequest(client httpClient, req *http.Request, status int) ([]byte, error) {
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -415,13 +644,24 @@ func (o *Operation) updateCredentialStatusHandler(rw http.ResponseWriter, req *h
 		return
 	}
 
-	// TODO https://github.com/trustbloc/edge-service/issues/208 credential is bundled into string type - update
-	//  this to json.RawMessage
-	vc, err := o.parseAndVerifyVC([]byte(data.Credential))
-	if err != nil {
-		o.writeErrorResponse(rw, http.StatusBadRequest,
-			fmt.Sprintf("unable to unmarshal the VC: %s", err.Error()))
-		return
+	var vc *verifiable.Credential
+
+	if data.Credential == "" && data.CredentialID != "" {
+		// the credential is looked up in the issuance registry of the profile
+		vc, err = o.issuedCredentialByID(data.Profile, data.CredentialID)
+		if err != nil {
+			o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else {
+		// TODO https://github.com/trustbloc/edge-service/issues/208 credential is bundled into string type - update
+		//  this to json.RawMessage
+		vc, err = o.parseAndVerifyVC([]byte(data.Credential))
+		if err != nil {
+			o.writeErrorResponse(rw, http.StatusBadRequest,
+				fmt.Sprintf("unable to unmarshal the VC: %s", err.Error()))
+			return
+		}
 	}
 
 	// get profile
@@ -469,30 +709,47 @@ func (o *Operation) createIssuerProfileHandler(rw http.ResponseWriter, req *http
 		return
 	}
 
-	profile, err := o.createIssuerProfile(&data)
-	if err != nil {
+	if err := o.checkWebhook(data.Webhook); err != nil {
 		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
 
 		return
 	}
 
-	err = o.profileStore.SaveProfile(profile)
+	profile, err := o.createAndSaveIssuerProfile(&data)
 	if err != nil {
 		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
 
 		return
 	}
 
-	// create the vault associated with the profile
-	_, err = o.edvClient.CreateDataVault(&models.DataVaultConfiguration{ReferenceID: profile.Name})
+	rw.WriteHeader(http.StatusCreated)
+	o.writeResponse(rw, withoutWebhookSecret(profile))
+}
+
+// createAndSaveIssuerProfile creates the issuer profile, saves it and creates the vault associated with it
+func (o *Operation) createAndSaveIssuerProfile(pr *ProfileRequest) (*vcprofile.DataProfile, error) {
+	profile, err := o.createIssuerProfile(pr)
 	if err != nil {
-		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+		return nil, err
+	}
 
-		return
+	if err := o.profileStore.SaveProfile(profile); err != nil {
+		return nil, err
 	}
 
-	rw.WriteHeader(http.StatusCreated)
-	o.writeResponse(rw, profile)
+	// link the profile DID to the domain of the profile URI, the profiles without an https origin aren't linked
+	if _, err := didconfig.Origin(profile.URI); err == nil {
+		if err := o.didConfig.AddProfile(profile.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	// create the vault associated with the profile
+	if _, err := o.edvClient.CreateDataVault(&models.DataVaultConfiguration{ReferenceID: profile.Name}); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
 }
 
 // RetrieveIssuerProfile swagger:route GET /profile/{id} issuer retrieveProfileReq
@@ -518,7 +775,20 @@ func (o *Operation) getIssuerProfileHandler(rw http.ResponseWriter, req *http.Re
 		return
 	}
 
-	o.writeResponse(rw, profileResponseJSON)
+	o.writeResponse(rw, withoutWebhookSecret(profileResponseJSON))
+}
+
+// withoutWebhookSecret returns the copy of the profile without the webhook secret, the secret is only known to
+// the profile creator and the webhook
+func withoutWebhookSecret(profile *vcprofile.DataProfile) *vcprofile.DataProfile {
+	if profile.Webhook == nil {
+		return profile
+	}
+
+	p := *profile
+	p.Webhook = &vcprofile.Webhook{URL: profile.Webhook.URL}
+
+	return &p
 }
 
 // StoreVerifiableCredential swagger:route POST /store issuer storeCredentialReq
@@ -559,38 +829,50 @@ func (o *Operation) storeCredentialHandler(rw http.ResponseWriter, req *http.Req
 // ToDo: data.Credential and vc seem to contain the same data... do they both need to be passed in?
 // https://github.com/trustbloc/edge-service/issues/265
 func (o *Operation) storeVC(data *StoreVCRequest, vc *verifiable.Credential, rw http.ResponseWriter) {
-	doc, err := o.buildStructuredDoc(data)
+	doc, err := o.buildStructuredDoc([]byte(data.Credential))
 	if err != nil {
 		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
 
 		return
 	}
 
-	encryptedDocument, err := o.buildEncryptedDoc(doc, vc.ID)
+	vcIDAttribute, err := o.indexedAttribute(o.vcIDIndexNameEncoded, vc.ID, true)
 	if err != nil {
 		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
 
 		return
 	}
 
-	_, err = o.edvClient.CreateDocument(data.Profile, &encryptedDocument)
+	encryptedDocument, err := o.buildEncryptedDoc(doc, vcIDAttribute)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
 
-	if err != nil && strings.Contains(err.Error(), edverrors.ErrVaultNotFound.Error()) {
-		// create the new vault for this profile, if it doesn't exist
-		_, err = o.edvClient.CreateDataVault(&models.DataVaultConfiguration{ReferenceID: data.Profile})
-		if err == nil {
-			_, err = o.edvClient.CreateDocument(data.Profile, &encryptedDocument)
-		}
+		return
 	}
 
-	if err != nil {
+	if err = o.createDocument(data.Profile, &encryptedDocument); err != nil {
 		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
 
 		return
 	}
 }
 
-func (o *Operation) buildStructuredDoc(data *StoreVCRequest) (*models.StructuredDocument, error) {
+// createDocument stores the encrypted document in the vault, the vault is created if it doesn't exist
+func (o *Operation) createDocument(vaultID string, encryptedDocument *models.EncryptedDocument) error {
+	_, err := o.edvClient.CreateDocument(vaultID, encryptedDocument)
+
+	if err != nil && strings.Contains(err.Error(), edverrors.ErrVaultNotFound.Error()) {
+		// create the new vault for this profile, if it doesn't exist
+		_, err = o.edvClient.CreateDataVault(&models.DataVaultConfiguration{ReferenceID: vaultID})
+		if err == nil {
+			_, err = o.edvClient.CreateDocument(vaultID, encryptedDocument)
+		}
+	}
+
+	return err
+}
+
+func (o *Operation) buildStructuredDoc(credentialBytes []byte) (*models.StructuredDocument, error) {
 	edvDocID, err := generateEDVCompatibleID()
 	if err != nil {
 		return nil, err
@@ -600,8 +882,6 @@ func (o *Operation) buildStructuredDoc(data *StoreVCRequest) (*models.Structured
 	doc.ID = edvDocID
 	doc.Content = make(map[string]interface{})
 
-	credentialBytes := []byte(data.Credential)
-
 	var credentialJSONRawMessage json.RawMessage = credentialBytes
 
 	doc.Content["message"] = credentialJSONRawMessage
@@ -609,8 +889,9 @@ func (o *Operation) buildStructuredDoc(data *StoreVCRequest) (*models.Structured
 	return &doc, nil
 }
 
+// buildEncryptedDoc encrypts the structured document and indexes it by the attributes
 func (o *Operation) buildEncryptedDoc(structuredDoc *models.StructuredDocument,
-	vcID string) (models.EncryptedDocument, error) {
+	attributes ...models.IndexedAttribute) (models.EncryptedDocument, error) {
 	marshalledStructuredDoc, err := json.Marshal(structuredDoc)
 	if err != nil {
 		return models.EncryptedDocument{}, err
@@ -626,23 +907,10 @@ func (o *Operation) buildEncryptedDoc(structuredDoc *models.StructuredDocument,
 		return models.EncryptedDocument{}, err
 	}
 
-	vcIDMAC, err := o.macCrypto.ComputeMAC([]byte(vcID), o.macKeyHandle)
-	if err != nil {
-		return models.EncryptedDocument{}, err
-	}
-
-	vcIDIndexValueEncoded := base64.URLEncoding.EncodeToString(vcIDMAC)
-
-	indexedAttribute := models.IndexedAttribute{
-		Name:   o.vcIDIndexNameEncoded,
-		Value:  vcIDIndexValueEncoded,
-		Unique: true,
-	}
-
 	indexedAttributeCollection := models.IndexedAttributeCollection{
 		Sequence:          0,
 		HMAC:              models.IDTypePair{},
-		IndexedAttributes: []models.IndexedAttribute{indexedAttribute},
+		IndexedAttributes: attributes,
 	}
 
 	indexedAttributeCollections := []models.IndexedAttributeCollection{indexedAttributeCollection}
@@ -657,6 +925,20 @@ func (o *Operation) buildEncryptedDoc(structuredDoc *models.StructuredDocument,
 	return encryptedDocument, nil
 }
 
+// indexedAttribute returns the attribute indexing the MAC of the value under the encoded index name
+func (o *Operation) indexedAttribute(indexNameEncoded, value string, unique bool) (models.IndexedAttribute, error) {
+	indexValueEncoded, err := o.computeMAC(value)
+	if err != nil {
+		return models.IndexedAttribute{}, err
+	}
+
+	return models.IndexedAttribute{
+		Name:   indexNameEncoded,
+		Value:  indexValueEncoded,
+		Unique: unique,
+	}, nil
+}
+
 func generateEDVCompatibleID() (string, error) {
 	randomBytes := make([]byte, 16)
 
@@ -811,7 +1093,9 @@ func (o *Operation) createIssuerProfile(pr *ProfileRequest) (*vcprofile.DataProf
 	return &vcprofile.DataProfile{Name: pr.Name, URI: pr.URI, Created: &created, DID: didID,
 		SignatureType: pr.SignatureType, SignatureRepresentation: pr.SignatureRepresentation, Creator: publicKeyID,
 		DIDPrivateKey: didPrivateKey, DisableVCStatus: pr.DisableVCStatus, OverwriteIssuer: pr.OverwriteIssuer,
-		DIDKeyType: pr.DIDKeyType,
+		DIDKeyType: pr.DIDKeyType, VCDataModel: pr.VCDataModel, Webhook: pr.Webhook,
+		CredentialIDPolicy: pr.CredentialIDPolicy, RefreshService: pr.RefreshService,
+		CredentialTemplates: pr.CredentialTemplates,
 	}, nil
 }
 
@@ -883,6 +1167,10 @@ func validateProfileRequest(pr *ProfileRequest) error {
 		return fmt.Errorf("missing profile name")
 	}
 
+	if err := checkVaultName(pr.Name); err != nil {
+		return err
+	}
+
 	if pr.URI == "" {
 		return fmt.Errorf("missing URI information")
 	}
@@ -896,6 +1184,53 @@ func validateProfileRequest(pr *ProfileRequest) error {
 		return fmt.Errorf("invalid uri: %s", err.Error())
 	}
 
+	if err := datamodel.ValidateVersion(pr.VCDataModel); err != nil {
+		return err
+	}
+
+	if pr.VCDataModel == datamodel.Version2 && pr.SignatureType != crypto.DataIntegrityProof {
+		return fmt.Errorf("VC data model %s requires %s signature type", datamodel.Version2,
+			crypto.DataIntegrityProof)
+	}
+
+	switch pr.CredentialIDPolicy {
+	case "", vcprofile.CredentialIDUUID, vcprofile.CredentialIDURI, vcprofile.CredentialIDClient:
+	default:
+		return fmt.Errorf("unsupported credential ID policy %s", pr.CredentialIDPolicy)
+	}
+
+	return validateCredentialTemplates(pr.CredentialTemplates)
+}
+
+func validateVerifierProfileRequest(request *VerifierProfileRequest) error {
+	if request.Name == "" {
+		return fmt.Errorf("missing profile name")
+	}
+
+	if request.DIDPrivateKey != "" && request.DID == "" {
+		return fmt.Errorf("missing DID of the private key")
+	}
+
+	if request.PresentationDefinition == nil {
+		return nil
+	}
+
+	if err := request.PresentationDefinition.Validate(); err != nil {
+		return fmt.Errorf("invalid presentation definition: %w", err)
+	}
+
+	return nil
+}
+
+func validateTrustedIssuerRequest(request *TrustedIssuerRequest) error {
+	if request.DID == "" {
+		return fmt.Errorf("missing issuer DID")
+	}
+
+	if request.ValidFrom != nil && request.ValidUntil != nil && request.ValidUntil.Before(*request.ValidFrom) {
+		return fmt.Errorf("validUntil is before validFrom")
+	}
+
 	return nil
 }
 
@@ -912,6 +1247,10 @@ func validateRequest(profileName, vcID string) error {
 		return fmt.Errorf("missing profile name")
 	}
 
+	if err := checkVaultName(profileName); err != nil {
+		return err
+	}
+
 	if vcID == "" {
 		return fmt.Errorf("missing verifiable credential ID")
 	}
@@ -977,16 +1316,24 @@ func (o *Operation) issueCredentialHandler(rw http.ResponseWriter, req *http.Req
 	}
 
 	// validate the VC (ignore the proof)
-	credential, _, err := verifiable.NewCredential(cred.Credential, verifiable.WithDisabledProofCheck())
+	credential, err := parseCredentialToIssue(cred.Credential, profile, o.withDocumentLoader()...)
 	if err != nil {
 		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("failed to validate credential: %s", err.Error()))
 
 		return
 	}
 
+	if err := o.assignCredentialID(profile, credential); err != nil {
+		o.writeErrorResponse(rw, credentialIDErrorStatus(err), err.Error())
+
+		return
+	}
+
+	var status *cslstatus.StatusID
+
 	if !profile.DisableVCStatus {
 		// set credential status
-		credential.Status, err = o.vcStatusManager.CreateStatusID()
+		statuses, err := o.addCredentialStatus(credential)
 		if err != nil {
 			o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to add credential status:"+
 				" %s", err.Error()))
@@ -994,7 +1341,7 @@ func (o *Operation) issueCredentialHandler(rw http.ResponseWriter, req *http.Req
 			return
 		}
 
-		credential.Context = append(credential.Context, cslstatus.Context)
+		status = statuses[0]
 	}
 
 	// update context
@@ -1003,6 +1350,9 @@ func (o *Operation) issueCredentialHandler(rw http.ResponseWriter, req *http.Req
 	// update credential issuer
 	updateIssuer(credential, profile)
 
+	// add the refresh service
+	o.addRefreshService(credential, profile)
+
 	// sign the credential
 	signedVC, err := o.crypto.SignCredential(profile, credential, getIssuerSigningOpts(cred.Opts)...)
 	if err != nil {
@@ -1012,6 +1362,13 @@ func (o *Operation) issueCredentialHandler(rw http.ResponseWriter, req *http.Req
 		return
 	}
 
+	// record the issued credential
+	if err := o.recordIssued(profile, &issuedCredential{credential: signedVC, status: status}); err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
 	rw.WriteHeader(http.StatusCreated)
 	o.writeResponse(rw, signedVC)
 }
@@ -1053,9 +1410,21 @@ func (o *Operation) composeAndIssueCredentialHandler(rw http.ResponseWriter, req
 		return
 	}
 
+	if err := o.assignCredentialID(profile, credential); err != nil {
+		o.writeErrorResponse(rw, credentialIDErrorStatus(err), err.Error())
+
+		return
+	}
+
+	if profile.VCDataModel == datamodel.Version2 {
+		datamodel.ToV2(credential)
+	}
+
+	var status *cslstatus.StatusID
+
 	if !profile.DisableVCStatus {
 		// set credential status
-		credential.Status, err = o.vcStatusManager.CreateStatusID()
+		statuses, err := o.addCredentialStatus(credential)
 		if err != nil {
 			o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to add credential status:"+
 				" %s", err.Error()))
@@ -1063,7 +1432,7 @@ func (o *Operation) composeAndIssueCredentialHandler(rw http.ResponseWriter, req
 			return
 		}
 
-		credential.Context = append(credential.Context, cslstatus.Context)
+		status = statuses[0]
 	}
 
 	// update context
@@ -1072,6 +1441,9 @@ func (o *Operation) composeAndIssueCredentialHandler(rw http.ResponseWriter, req
 	// update credential issuer
 	updateIssuer(credential, profile)
 
+	// add the refresh service
+	o.addRefreshService(credential, profile)
+
 	// prepare signing options from request options
 	opts, err := getComposeSigningOpts(&composeCredReq)
 	if err != nil {
@@ -1090,6 +1462,13 @@ func (o *Operation) composeAndIssueCredentialHandler(rw http.ResponseWriter, req
 		return
 	}
 
+	// record the issued credential
+	if err := o.recordIssued(profile, &issuedCredential{credential: signedVC, status: status}); err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
 	// response
 	rw.WriteHeader(http.StatusCreated)
 	o.writeResponse(rw, signedVC)
@@ -1101,6 +1480,7 @@ func buildCredential(composeCredReq *ComposeCredentialRequest) (*verifiable.Cred
 
 	// set credential data
 	credential.Context = []string{"https://www.w3.org/2018/credentials/v1"}
+	credential.ID = composeCredReq.CredentialID
 	credential.Issued = composeCredReq.IssuanceDate
 	credential.Expired = composeCredReq.ExpirationDate
 
@@ -1249,7 +1629,7 @@ func (o *Operation) generateKeypairHandler(rw http.ResponseWriter, req *http.Req
 // nolint dupl
 // VerifyCredential swagger:route POST /verifier/credentials verifier verifyCredentialReq
 //
-// Verifies a credential.
+// Verifies a credential, the detailed verification report is returned if requested in the options.
 //
 // Responses:
 //    default: genericError
@@ -1266,11 +1646,17 @@ func (o *Operation) verifyCredentialHandler(rw http.ResponseWriter, req *http.Re
 		return
 	}
 
+	status, resp := o.verifyCredential(&verificationReq)
+
+	rw.WriteHeader(status)
+	o.writeResponse(rw, resp)
+}
+
+// verifyCredential runs the requested checks and returns the response status code and body
+func (o *Operation) verifyCredential(verificationReq *CredentialsVerificationRequest) (int, interface{}) {
 	vc, err := verifiable.NewUnverifiedCredential(verificationReq.Credential)
 	if err != nil {
-		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
-
-		return
+		return http.StatusBadRequest, &ErrorResponse{Message: fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error())}
 	}
 
 	checks := []string{proofCheck}
@@ -1280,54 +1666,104 @@ func (o *Operation) verifyCredentialHandler(rw http.ResponseWriter, req *http.Re
 		checks = verificationReq.Opts.Checks
 	}
 
+	if verificationReq.Opts != nil && verificationReq.Opts.DetailedReport {
+		report := o.verificationReport(checks, vc, verificationReq)
+		if !report.Verified {
+			return http.StatusBadRequest, report
+		}
+
+		return http.StatusOK, report
+	}
+
 	var result []CredentialsVerificationCheckResult
 
 	for _, val := range checks {
-		switch val {
-		case proofCheck:
-			err := o.validateCredentialProof(verificationReq.Credential, verificationReq.Opts)
-			if err != nil {
-				result = append(result, CredentialsVerificationCheckResult{
-					Check: val,
-					Error: err.Error(),
-				})
-			}
-		case statusCheck:
-			failureMessage := ""
-			if vc.Status != nil && vc.Status.ID != "" {
-				ver, err := o.checkVCStatus(vc.Status.ID, vc.ID)
-
-				if err != nil {
-					failureMessage = fmt.Sprintf("failed to fetch the status : %s", err.Error())
-				} else if !ver.Verified {
-					failureMessage = ver.Message
-				}
+		if err := o.checkCredential(val, vc, verificationReq); err != nil {
+			checkResult := CredentialsVerificationCheckResult{
+				Check:      val,
+				Error:      err.Error(),
+				Violations: schemaViolations(err),
 			}
 
-			if failureMessage != "" {
-				result = append(result, CredentialsVerificationCheckResult{
-					Check: val,
-					Error: failureMessage,
-				})
+			if val == proofCheck {
+				checkResult.VerificationMethod = credentialVerificationMethod(verificationReq.Credential, vc)
 			}
-		default:
-			result = append(result, CredentialsVerificationCheckResult{
-				Check: val,
-				Error: "check not supported",
-			})
+
+			result = append(result, checkResult)
 		}
 	}
 
-	if len(result) == 0 {
-		rw.WriteHeader(http.StatusOK)
-		o.writeResponse(rw, &CredentialsVerificationSuccessResponse{
-			Checks: checks,
-		})
-	} else {
-		rw.WriteHeader(http.StatusBadRequest)
-		o.writeResponse(rw, &CredentialsVerificationFailResponse{
+	if len(result) != 0 {
+		return http.StatusBadRequest, &CredentialsVerificationFailResponse{
 			Checks: result,
-		})
+		}
+	}
+
+	return http.StatusOK, &CredentialsVerificationSuccessResponse{
+		Checks: checks,
+	}
+}
+
+// verificationReport runs the checks and returns the detailed verification report
+func (o *Operation) verificationReport(checks []string, vc *verifiable.Credential,
+	verificationReq *CredentialsVerificationRequest) *VerificationReport {
+	report := &VerificationReport{Verified: true}
+
+	for _, check := range checks {
+		checkReport := o.reportCredentialCheck(check, vc, verificationReq)
+		if checkReport.Status == checkFailed {
+			report.Verified = false
+		}
+
+		report.Checks = append(report.Checks, checkReport)
+	}
+
+	return report
+}
+
+// reportCredentialCheck runs the check and reports its outcome along with the data the check relied on
+func (o *Operation) reportCredentialCheck(check string, vc *verifiable.Credential,
+	verificationReq *CredentialsVerificationRequest) *CheckReport {
+	report := &CheckReport{Check: check, Status: checkPassed, Started: time.Now().UTC()}
+
+	err := o.checkCredential(check, vc, verificationReq)
+
+	report.Duration = time.Since(report.Started).String()
+
+	if err != nil {
+		report.Status = checkFailed
+		report.Error = err.Error()
+		report.Violations = schemaViolations(err)
+	}
+
+	describeCredentialCheck(report, vc, verificationReq.Credential)
+
+	return report
+}
+
+// describeCredentialCheck adds the data the check relied on and the warnings to the check report
+func describeCredentialCheck(report *CheckReport, vc *verifiable.Credential, vcBytes []byte) {
+	switch report.Check {
+	case proofCheck:
+		report.VerificationMethod = credentialVerificationMethod(vcBytes, vc)
+		report.DID = strings.Split(report.VerificationMethod, "#")[0]
+		report.Issuer = vc.Issuer.ID
+	case issuerCheck, linkedDomainCheck:
+		report.Issuer = vc.Issuer.ID
+	case statusCheck:
+		if vc.Status == nil || vc.Status.ID == "" {
+			report.Warnings = append(report.Warnings, "no status present")
+		} else {
+			report.StatusList = vc.Status.ID
+		}
+	case expiryCheck, validityCheck:
+		if validUntil, err := datamodel.ValidUntil(vc); err == nil && validUntil == nil {
+			report.Warnings = append(report.Warnings, "no expiration date present")
+		}
+	case schemaCheck:
+		if len(vc.Schemas) == 0 {
+			report.Warnings = append(report.Warnings, "no credential schema present")
+		}
 	}
 }
 
@@ -1350,55 +1786,72 @@ func (o *Operation) verifyPresentationHandler(rw http.ResponseWriter, req *http.
 		return
 	}
 
+	status, resp := o.verifyPresentation(&verificationReq)
+
+	rw.WriteHeader(status)
+	o.writeResponse(rw, resp)
+}
+
+// verifyPresentation runs the requested checks and returns the response status code and body
+func (o *Operation) verifyPresentation(verificationReq *VerifyPresentationRequest) (int, interface{}) {
 	checks := []string{proofCheck}
 
+	// presentation definition is evaluated by default if the verifier requested it
+	if opts := verificationReq.Opts; opts != nil && (opts.PresentationDefinition != nil || opts.Profile != "") {
+		checks = append(checks, presentationDefinitionCheck)
+	}
+
 	// if req contains checks, then override the default checks
 	if verificationReq.Opts != nil && len(verificationReq.Opts.Checks) != 0 {
 		checks = verificationReq.Opts.Checks
 	}
 
-	var result []VerifyPresentationCheckResult
+	var (
+		result      []VerifyPresentationCheckResult
+		descriptors []*presexch.DescriptorResult
+	)
 
 	for _, val := range checks {
-		switch val {
-		case proofCheck:
-			err := o.validatePresentationProof(verificationReq.Presentation, verificationReq.Opts)
-			if err != nil {
-				result = append(result, VerifyPresentationCheckResult{
-					Check: val,
-					Error: err.Error(),
-				})
-			}
-		default:
+		var err error
+
+		if val == presentationDefinitionCheck {
+			descriptors, err = o.evaluatePresentationDefinition(verificationReq)
+		} else {
+			err = o.checkPresentation(val, verificationReq)
+		}
+
+		if err != nil {
 			result = append(result, VerifyPresentationCheckResult{
-				Check: val,
-				Error: "check not supported",
+				Check:            val,
+				Error:            err.Error(),
+				Violations:       schemaViolations(err),
+				InputDescriptors: descriptors,
+				Credentials:      credentialResults(err),
 			})
 		}
 	}
 
-	if len(result) == 0 {
-		rw.WriteHeader(http.StatusOK)
-		o.writeResponse(rw, &VerifyPresentationSuccessResponse{
-			Checks: checks,
-		})
-	} else {
-		rw.WriteHeader(http.StatusBadRequest)
-		o.writeResponse(rw, &VerifyPresentationFailureResponse{
+	if len(result) != 0 {
+		return http.StatusBadRequest, &VerifyPresentationFailureResponse{
 			Checks: result,
-		})
+		}
+	}
+
+	return http.StatusOK, &VerifyPresentationSuccessResponse{
+		Checks:           checks,
+		InputDescriptors: descriptors,
 	}
 }
 
-// CreateHolderProfile swagger:route POST /holder/profile holder holderProfileReq
+// IssueChallenge swagger:route POST /verifier/challenges verifier challengeReq
 //
-// Creates holder profile.
+// Issues the challenge to be used in the presentation proof, the challenge is accepted once.
 //
 // Responses:
 //    default: genericError
-//        201: holderProfileRes
-func (o *Operation) createHolderProfileHandler(rw http.ResponseWriter, req *http.Request) {
-	request := &HolderProfileRequest{}
+//        201: challengeRes
+func (o *Operation) issueChallengeHandler(rw http.ResponseWriter, req *http.Request) {
+	request := &ChallengeRequest{}
 
 	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
 		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
@@ -1406,40 +1859,268 @@ func (o *Operation) createHolderProfileHandler(rw http.ResponseWriter, req *http
 		return
 	}
 
-	if err := validateHolderProfileRequest(request); err != nil {
-		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+	if request.Profile != "" {
+		if _, err := o.profileStore.GetVerifierProfile(request.Profile); err != nil {
+			o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid verifier profile - id=%s: err=%s",
+				request.Profile, err.Error()))
 
-		return
+			return
+		}
 	}
 
-	profile, err := o.profileStore.GetHolderProfile(request.Name)
-	if err != nil && !errors.Is(err, storage.ErrValueNotFound) {
-		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+	c, err := o.challenges.Issue(request.Profile, request.Domain)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
 
 		return
 	}
 
-	if profile != nil {
-		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("profile %s already exists", profile.Name))
+	rw.WriteHeader(http.StatusCreated)
+	o.writeResponse(rw, c)
+}
+
+// CreateVerifierProfile swagger:route POST /verifier/profile verifier verifierProfileReq
+//
+// Creates verifier profile.
+//
+// Responses:
+//    default: genericError
+//        201: verifierProfileRes
+func (o *Operation) createVerifierProfileHandler(rw http.ResponseWriter, req *http.Request) {
+	request := &VerifierProfileRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
 
 		return
 	}
 
-	profile, err = o.createHolderProfile(request)
-	if err != nil {
+	if err := validateVerifierProfileRequest(request); err != nil {
 		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
 
 		return
 	}
 
-	err = o.profileStore.SaveHolderProfile(profile)
-	if err != nil {
+	profile, err := o.profileStore.GetVerifierProfile(request.Name)
+	if err != nil && !errors.Is(err, storage.ErrValueNotFound) {
 		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
 
 		return
 	}
 
-	rw.WriteHeader(http.StatusCreated)
+	if profile != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("profile %s already exists", profile.Name))
+
+		return
+	}
+
+	created := time.Now().UTC()
+
+	profile = &vcprofile.VerifierProfile{
+		Name:                   request.Name,
+		PresentationDefinition: request.PresentationDefinition,
+		Created:                &created,
+	}
+
+	// the DID signs the OpenID4VP request objects of the profile
+	if request.DID != "" {
+		profile.DID, profile.Creator, profile.DIDPrivateKey, err = o.createProfile(request.DIDKeyType, "",
+			request.DID, request.DIDPrivateKey, UNIRegistrar{})
+		if err != nil {
+			o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+			return
+		}
+
+		profile.DIDKeyType = request.DIDKeyType
+	}
+
+	err = o.profileStore.SaveVerifierProfile(profile)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	o.writeResponse(rw, profile)
+}
+
+// RetrieveVerifierProfile swagger:route GET /verifier/profile/{id} verifier retrieveVerifierProfileReq
+//
+// Retrieves verifier profile.
+//
+// Responses:
+//    default: genericError
+//        200: verifierProfileRes
+func (o *Operation) getVerifierProfileHandler(rw http.ResponseWriter, req *http.Request) {
+	profileID := mux.Vars(req)[profileIDPathParam]
+
+	profile, err := o.profileStore.GetVerifierProfile(profileID)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	o.writeResponse(rw, profile)
+}
+
+// SaveTrustedIssuer swagger:route POST /verifier/trustedissuers verifier trustedIssuerReq
+//
+// Registers trusted issuer or replaces existing registration.
+//
+// Responses:
+//    default: genericError
+//        201: trustedIssuerRes
+func (o *Operation) saveTrustedIssuerHandler(rw http.ResponseWriter, req *http.Request) {
+	request := &TrustedIssuerRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	if err := validateTrustedIssuerRequest(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	created := time.Now().UTC()
+
+	issuer := &issuerregistry.TrustedIssuer{
+		DID:             request.DID,
+		CredentialTypes: request.CredentialTypes,
+		ValidFrom:       request.ValidFrom,
+		ValidUntil:      request.ValidUntil,
+		Created:         &created,
+	}
+
+	if err := o.issuerRegistry.SaveIssuer(issuer); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	o.writeResponse(rw, issuer)
+}
+
+// RetrieveTrustedIssuer swagger:route GET /verifier/trustedissuers/{id} verifier retrieveTrustedIssuerReq
+//
+// Retrieves trusted issuer.
+//
+// Responses:
+//    default: genericError
+//        200: trustedIssuerRes
+func (o *Operation) getTrustedIssuerHandler(rw http.ResponseWriter, req *http.Request) {
+	did := mux.Vars(req)["id"]
+
+	issuer, err := o.issuerRegistry.GetIssuer(did)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	o.writeResponse(rw, issuer)
+}
+
+// ListTrustedIssuers swagger:route GET /verifier/trustedissuers verifier listTrustedIssuersReq
+//
+// Lists trusted issuers.
+//
+// Responses:
+//    default: genericError
+//        200: trustedIssuersRes
+func (o *Operation) listTrustedIssuersHandler(rw http.ResponseWriter, req *http.Request) {
+	issuers, err := o.issuerRegistry.ListIssuers()
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	o.writeResponse(rw, &TrustedIssuersResponse{Issuers: issuers})
+}
+
+// DeleteTrustedIssuer swagger:route DELETE /verifier/trustedissuers/{id} verifier deleteTrustedIssuerReq
+//
+// Removes trusted issuer.
+//
+// Responses:
+//    default: genericError
+//        200: emptyRes
+func (o *Operation) deleteTrustedIssuerHandler(rw http.ResponseWriter, req *http.Request) {
+	did := mux.Vars(req)["id"]
+
+	if err := o.issuerRegistry.DeleteIssuer(did); err != nil {
+		if errors.Is(err, storage.ErrValueNotFound) {
+			o.writeErrorResponse(rw, http.StatusNotFound, fmt.Sprintf("trusted issuer %s not found", did))
+
+			return
+		}
+
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// CreateHolderProfile swagger:route POST /holder/profile holder holderProfileReq
+//
+// Creates holder profile.
+//
+// Responses:
+//    default: genericError
+//        201: holderProfileRes
+func (o *Operation) createHolderProfileHandler(rw http.ResponseWriter, req *http.Request) {
+	request := &HolderProfileRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	if err := validateHolderProfileRequest(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	profile, err := o.profileStore.GetHolderProfile(request.Name)
+	if err != nil && !errors.Is(err, storage.ErrValueNotFound) {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if profile != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("profile %s already exists", profile.Name))
+
+		return
+	}
+
+	profile, err = o.createHolderProfile(request)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	err = o.profileStore.SaveHolderProfile(profile)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
 	o.writeResponse(rw, profile)
 }
 
@@ -1494,14 +2175,17 @@ func (o *Operation) signPresentationHandler(rw http.ResponseWriter, req *http.Re
 		return
 	}
 
-	presentation, err := verifiable.NewPresentation(presReq.Presentation,
-		verifiable.WithDisabledPresentationProofCheck())
+	presentation, err := parseUnverifiedPresentation(presReq.Presentation)
 	if err != nil {
 		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
 
 		return
 	}
 
+	if profile.SignatureType == crypto.DataIntegrityProof && !isV2Presentation(presentation) {
+		presentation.Context = appendContext(presentation.Context, dataintegrity.Context)
+	}
+
 	// sign presentation
 	signedVP, err := o.crypto.SignPresentation(profile, presentation, getPresentationSigningOpts(presReq.Opts)...)
 	if err != nil {
@@ -1599,15 +2283,417 @@ func (o *Operation) validatePresentationProof(vpByte []byte, opts *VerifyPresent
 		return err
 	}
 
+	// challenge must be issued by the service and is accepted only once to prevent presentation replay
+	if opts.Challenge != "" {
+		if err := o.challenges.Use(opts.Challenge, opts.Profile, opts.Domain); err != nil {
+			return fmt.Errorf("invalid challenge : %w", err)
+		}
+	}
+
+	return nil
+}
+
+// checkCredential runs the given verification check against the credential
+func (o *Operation) checkCredential(check string, vc *verifiable.Credential,
+	verificationReq *CredentialsVerificationRequest) error {
+	var asOf *time.Time
+	if verificationReq.Opts != nil {
+		asOf = verificationReq.Opts.AsOf
+	}
+
+	switch check {
+	case proofCheck:
+		return o.validateCredentialProof(verificationReq.Credential, verificationReq.Opts)
+	case statusCheck:
+		return o.validateCredentialStatus(vc)
+	case expiryCheck, validityCheck:
+		return o.validateCredentialValidity(vc, asOf)
+	case issuerCheck:
+		return o.validateCredentialIssuer(vc, asOf)
+	case schemaCheck:
+		return o.schemaValidator.Validate(vc)
+	case linkedDomainCheck:
+		return o.validateLinkedDomain(vc, asOf)
+	default:
+		return errors.New("check not supported")
+	}
+}
+
+// checkPresentation runs the given verification check against the presentation
+func (o *Operation) checkPresentation(check string, verificationReq *VerifyPresentationRequest) error {
+	var asOf *time.Time
+	if verificationReq.Opts != nil {
+		asOf = verificationReq.Opts.AsOf
+	}
+
+	switch check {
+	case proofCheck:
+		return o.validatePresentationProof(verificationReq.Presentation, verificationReq.Opts)
+	case statusCheck:
+		return o.validatePresentationCredentials(verificationReq.Presentation, o.validateCredentialStatus)
+	case expiryCheck, validityCheck:
+		return o.validatePresentationCredentials(verificationReq.Presentation, func(vc *verifiable.Credential) error {
+			return o.validateCredentialValidity(vc, asOf)
+		})
+	case issuerCheck:
+		return o.validatePresentationCredentials(verificationReq.Presentation, func(vc *verifiable.Credential) error {
+			return o.validateCredentialIssuer(vc, asOf)
+		})
+	case schemaCheck:
+		return o.validatePresentationCredentials(verificationReq.Presentation, o.schemaValidator.Validate)
+	case linkedDomainCheck:
+		return o.validatePresentationCredentials(verificationReq.Presentation, func(vc *verifiable.Credential) error {
+			return o.validateLinkedDomain(vc, asOf)
+		})
+	case holderBindingCheck:
+		return o.validateHolderBinding(verificationReq.Presentation, verificationReq.Opts)
+	default:
+		return errors.New("check not supported")
+	}
+}
+
+// evaluatePresentationDefinition evaluates the presentation submission against the presentation definition
+// passed in the request or stored in the verifier profile
+func (o *Operation) evaluatePresentationDefinition(
+	verificationReq *VerifyPresentationRequest) ([]*presexch.DescriptorResult, error) {
+	definition, err := o.getPresentationDefinition(verificationReq.Opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*presexch.DescriptorResult
+
+	if opts := verificationReq.Opts; opts.PresentationSubmission != nil {
+		results, err = definition.EvaluateSubmission(verificationReq.Presentation, opts.PresentationSubmission)
+	} else {
+		results, err = definition.Evaluate(verificationReq.Presentation)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var unsatisfied []string
+
+	for _, r := range results {
+		if !r.Satisfied {
+			unsatisfied = append(unsatisfied, r.ID)
+		}
+	}
+
+	if len(unsatisfied) != 0 {
+		return results, fmt.Errorf("presentation doesn't satisfy input descriptors: %s",
+			strings.Join(unsatisfied, ", "))
+	}
+
+	return results, nil
+}
+
+func (o *Operation) getPresentationDefinition(
+	opts *VerifyPresentationOptions) (*presexch.PresentationDefinition, error) {
+	if opts != nil && opts.PresentationDefinition != nil {
+		if err := opts.PresentationDefinition.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid presentation definition: %w", err)
+		}
+
+		return opts.PresentationDefinition, nil
+	}
+
+	if opts == nil || opts.Profile == "" {
+		return nil, errors.New("presentation definition is missing")
+	}
+
+	profile, err := o.profileStore.GetVerifierProfile(opts.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid verifier profile - id=%s: err=%s", opts.Profile, err.Error())
+	}
+
+	if profile.PresentationDefinition == nil {
+		return nil, fmt.Errorf("verifier profile %s has no presentation definition", opts.Profile)
+	}
+
+	return profile.PresentationDefinition, nil
+}
+
+// schemaViolations returns the credentialSubject schema violations if the check failed because of them
+func schemaViolations(err error) []string {
+	validationErr := &schema.ValidationError{}
+	if errors.As(err, &validationErr) {
+		return validationErr.Violations
+	}
+
+	return nil
+}
+
+func (o *Operation) validateCredentialStatus(vc *verifiable.Credential) error {
+	if vc.Status == nil || vc.Status.ID == "" {
+		return nil
+	}
+
+	ver, err := o.checkVCStatus(vc.Status.ID, vc.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the status : %s", err.Error())
+	}
+
+	if !ver.Verified {
+		return errors.New(ver.Message)
+	}
+
+	return nil
+}
+
+// validateCredentialIssuer checks that the credential issuer is trusted for the credential type
+func (o *Operation) validateCredentialIssuer(vc *verifiable.Credential, asOf *time.Time) error {
+	verificationTime := time.Now()
+	if asOf != nil {
+		verificationTime = *asOf
+	}
+
+	return o.issuerRegistry.CheckIssuer(vc.Issuer.ID, vc.Types, verificationTime)
+}
+
+// validateCredentialValidity checks the credential validity period (issuanceDate/expirationDate or
+// validFrom/validUntil) against the verification time, tolerating the configured clock skew
+func (o *Operation) validateCredentialValidity(vc *verifiable.Credential, asOf *time.Time) error {
+	verificationTime := time.Now()
+	if asOf != nil {
+		verificationTime = *asOf
+	}
+
+	validFrom, err := datamodel.ValidFrom(vc)
+	if err != nil {
+		return err
+	}
+
+	if validFrom != nil && verificationTime.Add(o.clockSkew).Before(*validFrom) {
+		return fmt.Errorf("credential is not valid before %s", validFrom.Format(time.RFC3339))
+	}
+
+	validUntil, err := datamodel.ValidUntil(vc)
+	if err != nil {
+		return err
+	}
+
+	if validUntil != nil && verificationTime.Add(-o.clockSkew).After(*validUntil) {
+		return fmt.Errorf("credential expired on %s", validUntil.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// validatePresentationCredentials applies the check to every credential embedded in the presentation,
+// failed credentials are reported by their ID in credentialsError
+func (o *Operation) validatePresentationCredentials(vpBytes []byte, check func(*verifiable.Credential) error) error {
+	vp, err := parseUnverifiedPresentation(vpBytes)
+	if err != nil {
+		return err
+	}
+
+	credErr := &credentialsError{}
+
+	for _, cred := range vp.Credentials() {
+		vcBytes, err := credentialBytes(cred)
+		if err != nil {
+			return err
+		}
+
+		vc, err := verifiable.NewUnverifiedCredential(vcBytes)
+		if err != nil {
+			return err
+		}
+
+		if err := check(vc); err != nil {
+			credErr.errs = append(credErr.errs, err)
+			credErr.results = append(credErr.results, &CredentialCheckResult{
+				ID:         vc.ID,
+				Error:      err.Error(),
+				Violations: schemaViolations(err),
+			})
+		}
+	}
+
+	if len(credErr.results) != 0 {
+		return credErr
+	}
+
+	return nil
+}
+
+// validateHolderBinding checks that the presenter is the subject of every credential in the presentation,
+// the presenter is identified by the DID of the proof verification method or by the presentation holder
+func (o *Operation) validateHolderBinding(vpBytes []byte, opts *VerifyPresentationOptions) error {
+	vp, err := parseUnverifiedPresentation(vpBytes)
+	if err != nil {
+		return err
+	}
+
+	presenter, err := presenterDID(vp)
+	if err != nil {
+		return err
+	}
+
+	var exemptTypes []string
+	if opts != nil {
+		exemptTypes = opts.HolderBindingExemptTypes
+	}
+
+	return o.validatePresentationCredentials(vpBytes, func(vc *verifiable.Credential) error {
+		for _, t := range vc.Types {
+			if containsString(exemptTypes, t) {
+				return nil
+			}
+		}
+
+		ids := subjectIDs(vc.Subject)
+		if len(ids) == 0 {
+			return errors.New("credential subject id is missing")
+		}
+
+		if !containsString(ids, presenter) {
+			return fmt.Errorf("presenter %s is not the credential subject", presenter)
+		}
+
+		return nil
+	})
+}
+
+// presenterDID returns the DID of the presentation signer or the presentation holder if it isn't signed
+func presenterDID(vp *verifiable.Presentation) (string, error) {
+	for _, proof := range vp.Proofs {
+		method := proofVerificationMethod(proof)
+		if method == "" {
+			continue
+		}
+
+		signer := strings.Split(method, "#")[0]
+
+		if vp.Holder != "" && vp.Holder != signer {
+			return "", fmt.Errorf("presentation holder %s is not the signer %s", vp.Holder, signer)
+		}
+
+		return signer, nil
+	}
+
+	if vp.Holder == "" {
+		return "", errors.New("presentation is neither signed nor has a holder")
+	}
+
+	return vp.Holder, nil
+}
+
+// proofVerificationMethod returns the verification method (or creator) of the linked data proof
+func proofVerificationMethod(proof verifiable.Proof) string {
+	if method, ok := proof["verificationMethod"].(string); ok {
+		return method
+	}
+
+	method, _ := proof["creator"].(string) // nolint: errcheck
+
+	return method
+}
+
+// credentialVerificationMethod returns the verification method of the first credential proof
+// or the key ID of the JWT credential
+func credentialVerificationMethod(vcBytes []byte, vc *verifiable.Credential) string {
+	if len(vc.Proofs) != 0 {
+		return proofVerificationMethod(vc.Proofs[0])
+	}
+
+	parts := strings.Split(strings.Trim(string(vcBytes), `"`), ".")
+	if len(parts) != jwtPartsCount {
+		return ""
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ""
+	}
+
+	header := struct {
+		KeyID string `json:"kid"`
+	}{}
+
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return ""
+	}
+
+	return header.KeyID
+}
+
+// subjectIDs returns the IDs of the credential subjects
+func subjectIDs(subject verifiable.Subject) []string {
+	switch s := subject.(type) {
+	case string:
+		return []string{s}
+	case map[string]interface{}:
+		if id, ok := s["id"].(string); ok && id != "" {
+			return []string{id}
+		}
+	case []interface{}:
+		var ids []string
+
+		for _, e := range s {
+			ids = append(ids, subjectIDs(e)...)
+		}
+
+		return ids
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// credentialsError is returned when the check fails for any of the presentation credentials
+type credentialsError struct {
+	errs    []error
+	results []*CredentialCheckResult
+}
+
+// Unwrap returns the error of the first failed credential
+func (e *credentialsError) Unwrap() error {
+	return e.errs[0]
+}
+
+func (e *credentialsError) Error() string {
+	errs := make([]string, len(e.results))
+
+	for i, r := range e.results {
+		errs[i] = fmt.Sprintf("credential %s: %s", r.ID, r.Error)
+	}
+
+	return strings.Join(errs, "; ")
+}
+
+func credentialResults(err error) []*CredentialCheckResult {
+	credErr := &credentialsError{}
+	if errors.As(err, &credErr) {
+		return credErr.results
+	}
+
 	return nil
 }
 
 func (o *Operation) parseAndVerifyVC(vcBytes []byte) (*verifiable.Credential, error) {
+	if dataintegrity.HasProof(vcBytes) || datamodel.IsV2Document(vcBytes) {
+		return o.parseAndVerifyDataIntegrityVC(vcBytes)
+	}
+
 	vc, _, err := verifiable.NewCredential(
 		vcBytes,
-		verifiable.WithPublicKeyFetcher(
-			verifiable.NewDIDKeyResolver(o.vdri).PublicKeyFetcher(),
-		),
+		o.withDocumentLoader(
+			verifiable.WithPublicKeyFetcher(
+				verifiable.NewDIDKeyResolver(o.vdri).PublicKeyFetcher(),
+			),
+		)...,
 	)
 
 	if err != nil {
@@ -1618,12 +2704,18 @@ func (o *Operation) parseAndVerifyVC(vcBytes []byte) (*verifiable.Credential, er
 }
 
 func (o *Operation) parseAndVerifyVCStrictMode(vcBytes []byte) (*verifiable.Credential, error) {
+	if dataintegrity.HasProof(vcBytes) || datamodel.IsV2Document(vcBytes) {
+		return o.parseAndVerifyDataIntegrityVC(vcBytes, verifiable.WithStrictValidation())
+	}
+
 	vc, _, err := verifiable.NewCredential(
 		vcBytes,
-		verifiable.WithPublicKeyFetcher(
-			verifiable.NewDIDKeyResolver(o.vdri).PublicKeyFetcher(),
-		),
-		verifiable.WithStrictValidation(),
+		o.withDocumentLoader(
+			verifiable.WithPublicKeyFetcher(
+				verifiable.NewDIDKeyResolver(o.vdri).PublicKeyFetcher(),
+			),
+			verifiable.WithStrictValidation(),
+		)...,
 	)
 
 	if err != nil {
@@ -1634,13 +2726,7 @@ func (o *Operation) parseAndVerifyVCStrictMode(vcBytes []byte) (*verifiable.Cred
 }
 
 func (o *Operation) parseAndVerifyVP(vpBytes []byte) (*verifiable.Presentation, error) {
-	vp, err := verifiable.NewPresentation(
-		vpBytes,
-		verifiable.WithPresPublicKeyFetcher(
-			verifiable.NewDIDKeyResolver(o.vdri).PublicKeyFetcher(),
-		),
-	)
-
+	vp, err := o.parsePresentation(vpBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -1662,20 +2748,190 @@ func (o *Operation) parseAndVerifyVP(vpBytes []byte) (*verifiable.Presentation,
 	return vp, nil
 }
 
-func (o *Operation) queryVault(vaultID, vcID string) ([]string, error) {
-	vcIDMAC, err := o.macCrypto.ComputeMAC([]byte(vcID), o.macKeyHandle)
+// parseAndVerifyDataIntegrityVC verifies data integrity proofs of the credential and parses it
+// according to its data model version. The proofs must be made by the issuer with its assertion method,
+// proofs of other types in the proof set are verified by the credential parser.
+func (o *Operation) parseAndVerifyDataIntegrityVC(vcBytes []byte,
+	opts ...verifiable.CredentialOpt) (*verifiable.Credential, error) {
+	vc, err := o.parseDataIntegrityVC(vcBytes, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.dataIntegrity.Verify(vcBytes, verifiable.NewDIDKeyResolver(o.vdri).PublicKeyFetcher(),
+		dataintegrity.WithController(vc.Issuer.ID),
+		dataintegrity.WithVerificationMethodCheck(o.verificationMethodCheck(assertionMethod)))
+	if err != nil {
+		return nil, err
+	}
+
+	return vc, nil
+}
+
+// parseDataIntegrityVC parses the credential and verifies the proofs of the proof set other than data integrity ones
+func (o *Operation) parseDataIntegrityVC(vcBytes []byte,
+	opts ...verifiable.CredentialOpt) (*verifiable.Credential, error) {
+	unsecuredVC, hasOtherProofs, err := dataintegrity.RemoveProofs(vcBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if datamodel.IsV2Document(vcBytes) {
+		if hasOtherProofs {
+			return nil, fmt.Errorf("VC data model %s credentials support %s proofs only", datamodel.Version2,
+				dataintegrity.ProofType)
+		}
+
+		vc, err := verifiable.NewUnverifiedCredential(vcBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := datamodel.Validate(vc); err != nil {
+			return nil, fmt.Errorf("invalid credential: %w", err)
+		}
+
+		return vc, nil
+	}
+
+	if hasOtherProofs {
+		_, _, err = verifiable.NewCredential(unsecuredVC, o.withDocumentLoader(append(opts,
+			verifiable.WithPublicKeyFetcher(verifiable.NewDIDKeyResolver(o.vdri).PublicKeyFetcher()))...)...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// data integrity proofs are verified by the data integrity suite
+	opts = append(opts, verifiable.WithDisabledProofCheck())
+
+	vc, _, err := verifiable.NewCredential(vcBytes, o.withDocumentLoader(opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return vc, nil
+}
+
+// parsePresentation parses the presentation and verifies its proof. Data integrity proofs are verified by
+// the eddsa-2022 suite and must be made by the holder, other proofs are left to the presentation parser.
+func (o *Operation) parsePresentation(vpBytes []byte) (*verifiable.Presentation, error) {
+	fetcher := verifiable.NewDIDKeyResolver(o.vdri).PublicKeyFetcher()
+
+	if !dataintegrity.HasProof(vpBytes) && !datamodel.IsV2Document(vpBytes) {
+		return verifiable.NewPresentation(vpBytes, o.withPresDocumentLoader(verifiable.WithPresPublicKeyFetcher(fetcher))...)
+	}
+
+	vp, err := parseUnverifiedPresentation(vpBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.dataIntegrity.Verify(vpBytes, fetcher, dataintegrity.WithController(vp.Holder),
+		dataintegrity.WithVerificationMethodCheck(o.verificationMethodCheck("")))
+	if err != nil {
+		return nil, err
+	}
+
+	unsecuredVP, hasOtherProofs, err := dataintegrity.RemoveProofs(vpBytes)
 	if err != nil {
 		return nil, err
 	}
 
-	vcIDIndexValueEncoded := base64.URLEncoding.EncodeToString(vcIDMAC)
+	if hasOtherProofs {
+		if datamodel.IsV2Document(vpBytes) {
+			return nil, fmt.Errorf("VC data model %s presentations support %s proofs only", datamodel.Version2,
+				dataintegrity.ProofType)
+		}
+
+		_, err = verifiable.NewPresentation(unsecuredVP, o.withPresDocumentLoader(
+			verifiable.WithPresPublicKeyFetcher(fetcher))...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return vp, nil
+}
+
+// verificationMethodCheck returns the check of the proof verification method authorized by the DID document
+// of its controller for the proof purpose, the purpose must be the expected one if it's set
+func (o *Operation) verificationMethodCheck(expectedPurpose string) func(string, string) error {
+	return func(verificationMethod, purpose string) error {
+		if expectedPurpose != "" && purpose != expectedPurpose {
+			return fmt.Errorf("proof purpose %s is not %s", purpose, expectedPurpose)
+		}
+
+		didDoc, err := o.vdri.Resolve(strings.Split(verificationMethod, "#")[0])
+		if err != nil {
+			return fmt.Errorf("failed to resolve DID of verification method %s: %w", verificationMethod, err)
+		}
+
+		return checkVerificationRelationship(didDoc, verificationMethod, purpose)
+	}
+}
+
+// checkVerificationRelationship checks the DID document lists the verification method for the proof purpose,
+// the DID documents without verification relationships (public keys only) authorize any of their keys
+func checkVerificationRelationship(didDoc *ariesdid.Doc, verificationMethod, purpose string) error {
+	relationships := map[string][]ariesdid.VerificationMethod{
+		assertionMethod:      didDoc.AssertionMethod,
+		authentication:       didDoc.Authentication,
+		capabilityDelegation: didDoc.CapabilityDelegation,
+		capabilityInvocation: didDoc.CapabilityInvocation,
+	}
+
+	methods, ok := relationships[purpose]
+	if !ok {
+		return fmt.Errorf("unsupported proof purpose: %s", purpose)
+	}
+
+	declared := false
+
+	for _, m := range relationships {
+		declared = declared || len(m) > 0
+	}
+
+	if !declared {
+		return nil
+	}
+
+	for _, m := range methods {
+		if m.PublicKey.ID == verificationMethod || didDoc.ID+m.PublicKey.ID == verificationMethod {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("verification method %s is not authorized for %s", verificationMethod, purpose)
+}
+
+func (o *Operation) queryVault(vaultID, vcID string) ([]string, error) {
+	return o.queryVaultIndex(vaultID, o.vcIDIndexNameEncoded, vcID)
+}
+
+// queryVaultIndex returns the URLs of the vault documents with the MAC of the value in the encoded index
+func (o *Operation) queryVaultIndex(vaultID, indexNameEncoded, value string) ([]string, error) {
+	indexValueEncoded, err := o.computeMAC(value)
+	if err != nil {
+		return nil, err
+	}
 
 	return o.edvClient.QueryVault(vaultID, &models.Query{
-		Name:  o.vcIDIndexNameEncoded,
-		Value: vcIDIndexValueEncoded,
+		Name:  indexNameEncoded,
+		Value: indexValueEncoded,
 	})
 }
 
+// computeMAC returns the base64 URL encoded MAC of the EDV index name or value
+func (o *Operation) computeMAC(data string) (string, error) {
+	mac, err := o.macCrypto.ComputeMAC([]byte(data), o.macKeyHandle)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.URLEncoding.EncodeToString(mac), nil
+}
+
 func (o *Operation) retrieveCredential(rw http.ResponseWriter, profileName string, docURLs []string) {
 	var retrievedVC []byte
 
@@ -1878,8 +3134,107 @@ func updateIssuer(credential *verifiable.Credential, profile *vcprofile.DataProf
 
 func updateContext(credential *verifiable.Credential, profile *vcprofile.DataProfile) {
 	if profile.SignatureType == crypto.JSONWebSignature2020 {
-		credential.Context = append(credential.Context, jsonWebSignature2020Context)
+		credential.Context = appendContext(credential.Context, jsonWebSignature2020Context)
+	}
+
+	// data integrity terms are part of the VC Data Model 2.0 base context
+	if profile.SignatureType == crypto.DataIntegrityProof && !datamodel.IsV2(credential) {
+		credential.Context = appendContext(credential.Context, dataintegrity.Context)
+	}
+}
+
+func appendContext(context []string, ctx string) []string {
+	for _, c := range context {
+		if c == ctx {
+			return context
+		}
 	}
+
+	return append(context, ctx)
+}
+
+// withDocumentLoader adds the configured JSON-LD document loader to the credential options, the loader is used
+// to compact the credential and to canonicalize it for the linked data proof verification
+func (o *Operation) withDocumentLoader(opts ...verifiable.CredentialOpt) []verifiable.CredentialOpt {
+	if o.documentLoader != nil {
+		opts = append(opts, verifiable.WithJSONLDDocumentLoader(o.documentLoader),
+			verifiable.WithEmbeddedSignatureSuites(o.ldpSuites()...))
+	}
+
+	return opts
+}
+
+// withPresDocumentLoader adds the linked data proof suites using the configured JSON-LD document loader
+// to the presentation options
+func (o *Operation) withPresDocumentLoader(opts ...verifiable.PresentationOpt) []verifiable.PresentationOpt {
+	if o.documentLoader != nil {
+		opts = append(opts, verifiable.WithPresEmbeddedSignatureSuites(o.ldpSuites()...))
+	}
+
+	return opts
+}
+
+// ldpSuites returns the linked data proof suites canonicalizing the documents with the configured JSON-LD
+// document loader
+func (o *Operation) ldpSuites() []verifier.SignatureSuite {
+	return []verifier.SignatureSuite{
+		jsonld.VerifierSuite(ed25519signature2018.New(
+			suite.WithVerifier(ed25519signature2018.NewPublicKeyVerifier())), o.documentLoader),
+		jsonld.VerifierSuite(jsonwebsignature2020.New(
+			suite.WithVerifier(jsonwebsignature2020.NewPublicKeyVerifier())), o.documentLoader),
+		jsonld.VerifierSuite(ecdsasecp256k1signature2019.New(
+			suite.WithVerifier(ecdsasecp256k1signature2019.NewPublicKeyVerifier())), o.documentLoader),
+	}
+}
+
+// parseCredentialToIssue validates the credential to be issued (ignoring the proof)
+func parseCredentialToIssue(vcBytes []byte, profile *vcprofile.DataProfile,
+	opts ...verifiable.CredentialOpt) (*verifiable.Credential, error) {
+	if !datamodel.IsV2Document(vcBytes) {
+		credential, _, err := verifiable.NewCredential(vcBytes, append(opts, verifiable.WithDisabledProofCheck())...)
+
+		return credential, err
+	}
+
+	if profile.SignatureType != crypto.DataIntegrityProof {
+		return nil, fmt.Errorf("VC data model %s credentials require %s signature type", datamodel.Version2,
+			crypto.DataIntegrityProof)
+	}
+
+	credential, err := verifiable.NewUnverifiedCredential(vcBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := datamodel.Validate(credential); err != nil {
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+// credentialBytes returns JSON of the credential embedded into the presentation
+func credentialBytes(cred interface{}) ([]byte, error) {
+	// credentials in JWT format are already decoded by the presentation parser
+	if vcBytes, ok := cred.([]byte); ok {
+		return vcBytes, nil
+	}
+
+	return json.Marshal(cred)
+}
+
+// parseUnverifiedPresentation parses the presentation without checking its proof
+func parseUnverifiedPresentation(vpBytes []byte) (*verifiable.Presentation, error) {
+	if datamodel.IsV2Document(vpBytes) {
+		// presentation JSON schema of the parser is bound to the VC Data Model 1.1 base context
+		return verifiable.NewUnverifiedPresentation(vpBytes)
+	}
+
+	return verifiable.NewPresentation(vpBytes, verifiable.WithDisabledPresentationProofCheck())
+}
+
+func isV2Presentation(vp *verifiable.Presentation) bool {
+	return len(vp.Context) > 0 && vp.Context[0] == datamodel.ContextV2
 }
 
 func validateIssueCredOptions(options *IssueCredentialOptions) error {
@@ -36,6 +36,7 @@ import (
 	kmsmock "github.com/hyperledger/aries-framework-go/pkg/mock/kms/legacykms"
 	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
 	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/piprate/json-gold/ld"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"github.com/trustbloc/edge-core/pkg/storage"
@@ -45,11 +46,17 @@ import (
 	didmethodoperation "github.com/trustbloc/trustbloc-did-method/pkg/restapi/didmethod/operation"
 
 	"github.com/trustbloc/edge-service/pkg/client/uniregistrar"
+	vcchallenge "github.com/trustbloc/edge-service/pkg/doc/vc/challenge"
 	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/dataintegrity"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/datamodel"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/issuerregistry"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
 	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
 	cslstatus "github.com/trustbloc/edge-service/pkg/doc/vc/status/csl"
 	"github.com/trustbloc/edge-service/pkg/internal/mock/didbloc"
 	"github.com/trustbloc/edge-service/pkg/internal/mock/edv"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/jsonld"
 	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
 )
 
@@ -390,6 +397,32 @@ func TestNew(t *testing.T) {
 		require.Equal(t, testCreateStoreErr, err)
 		require.Nil(t, op)
 	})
+	t.Run("test offline mode refuses network access", func(t *testing.T) {
+		kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+		require.NoError(t, err)
+
+		op, err := New(&Config{
+			Crypto:             &cryptomock.Crypto{},
+			StoreProvider:      memstore.NewProvider(),
+			KMSSecretsProvider: mem.NewProvider(),
+			KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+			VDRI:               &vdrimock.MockVDRIRegistry{},
+			Offline:            true,
+		})
+		require.NoError(t, err)
+
+		reqBytes, err := json.Marshal(&CredentialsVerificationRequest{
+			Credential: []byte(validVC),
+			Opts:       &CredentialsVerificationOptions{Checks: []string{statusCheck}},
+		})
+		require.NoError(t, err)
+
+		handler := getHandler(t, op, credentialsVerificationEndpoint, verifierMode)
+
+		rr := serveHTTP(t, handler.Handle(), http.MethodPost, credentialsVerificationEndpoint, reqBytes)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "network access is disabled in the offline mode")
+	})
 }
 
 func TestUpdateCredentialStatusHandler(t *testing.T) {
@@ -821,6 +854,24 @@ func testCreateProfileHandler(t *testing.T, mode string) {
 
 		require.Equal(t, errResp.Message, "missing profile name")
 	})
+	t.Run("invalid webhook", func(t *testing.T) {
+		for webhook, msg := range map[string]string{
+			"http://example.com/webhook":   "only https URLs are supported",
+			"https://127.0.0.1:8080/hooks": "host 127.0.0.1:8080 is internal",
+		} {
+			prBytes, err := json.Marshal(ProfileRequest{Name: "webhook", URI: "https://example.com/credentials",
+				SignatureType: vccrypto.Ed25519Signature2018, Webhook: &vcprofile.Webhook{URL: webhook}})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, createProfileEndpoint, bytes.NewBuffer(prBytes))
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			createProfileHandler.Handle().ServeHTTP(rr, req)
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+			require.Contains(t, rr.Body.String(), "invalid webhook URL "+webhook+": "+msg)
+		}
+	})
 	t.Run("create profile error by passing invalid request", func(t *testing.T) {
 		req, err := http.NewRequest(http.MethodPost, createProfileEndpoint, bytes.NewBuffer([]byte("")))
 		require.NoError(t, err)
@@ -957,6 +1008,24 @@ func TestGetProfileHandler(t *testing.T) {
 		require.Equal(t, profileResponse.Name, profile.Name)
 		require.Equal(t, profileResponse.URI, profile.URI)
 	})
+	t.Run("get profile without webhook secret", func(t *testing.T) {
+		profile := getTestProfile()
+		profile.Name = "webhook"
+		profile.Webhook = &vcprofile.Webhook{URL: "https://example.com/webhook", Secret: "secret"}
+		require.NoError(t, op.profileStore.SaveProfile(profile))
+
+		rr := serveHTTPMux(t, getProfileHandler, "/profile/webhook", nil, map[string]string{"id": "webhook"})
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.NotContains(t, rr.Body.String(), "secret")
+
+		profileResponse := &vcprofile.DataProfile{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), profileResponse))
+		require.Equal(t, "https://example.com/webhook", profileResponse.Webhook.URL)
+
+		saved, err := op.profileStore.GetProfile("webhook")
+		require.NoError(t, err)
+		require.Equal(t, "secret", saved.Webhook.Secret)
+	})
 	t.Run("get profile error, bad request", func(t *testing.T) {
 		req, err := http.NewRequest(http.MethodGet,
 			"/profile/"+notFoundID,
@@ -1630,6 +1699,17 @@ func TestOperation_validateProfileRequest(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "missing profile name")
 	})
+	t.Run("reserved profile name", func(t *testing.T) {
+		profile := getProfileRequest()
+		profile.Name = walletVaultPrefix + "alice"
+		err := validateProfileRequest(profile)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "profile name wallet-alice is reserved")
+
+		err = validateRequest(profile.Name, "http://example.edu/credentials/1872")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "profile name wallet-alice is reserved")
+	})
 	t.Run("missing URI ", func(t *testing.T) {
 		profile := getProfileRequest()
 		profile.URI = ""
@@ -1651,6 +1731,31 @@ func TestOperation_validateProfileRequest(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "invalid uri")
 	})
+	t.Run("VC data model version", func(t *testing.T) {
+		profile := getProfileRequest()
+		profile.VCDataModel = "3.0"
+		err := validateProfileRequest(profile)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported VC data model version")
+
+		profile.VCDataModel = datamodel.Version2
+		err = validateProfileRequest(profile)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "VC data model 2.0 requires DataIntegrityProof signature type")
+
+		profile.SignatureType = vccrypto.DataIntegrityProof
+		require.NoError(t, validateProfileRequest(profile))
+	})
+	t.Run("credential ID policy", func(t *testing.T) {
+		profile := getProfileRequest()
+		profile.CredentialIDPolicy = "invalid"
+		err := validateProfileRequest(profile)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported credential ID policy invalid")
+
+		profile.CredentialIDPolicy = vcprofile.CredentialIDURI
+		require.NoError(t, validateProfileRequest(profile))
+	})
 }
 
 func TestOperation_GetRESTHandlers(t *testing.T) {
@@ -3026,7 +3131,7 @@ func TestCredentialVerifications(t *testing.T) {
 	}
 }
 
-func TestVerifyPresentation(t *testing.T) {
+func TestVerificationReport(t *testing.T) {
 	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
 	require.NoError(t, err)
 
@@ -3035,219 +3140,1129 @@ func TestVerifyPresentation(t *testing.T) {
 		StoreProvider:      memstore.NewProvider(),
 		KMSSecretsProvider: mem.NewProvider(),
 		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
-		VDRI:               &vdrimock.MockVDRIRegistry{},
+		VDRI:               &vdrimock.MockVDRIRegistry{ResolveErr: errors.New("resolve error")},
 	})
 	require.NoError(t, err)
 
-	endpoint := presentationsVerificationEndpoint
-	verificationsHandler := getHandler(t, op, endpoint, verifierMode)
+	cslBytes, err := json.Marshal(&cslstatus.CSL{ID: "https://example.gov/status/24"})
+	require.NoError(t, err)
 
-	t.Run("presentation verification - success", func(t *testing.T) {
-		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
-		require.NoError(t, err)
+	op.httpClient = &mockHTTPClient{doFunc: func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(cslBytes))}, nil
+	}}
 
-		didID := "did:test:EiBNfNRaz1Ll8BjVsbNv-fWc7K_KIoPuW8GFCh1_Tz_Iuw=="
+	handler := getHandler(t, op, credentialsVerificationEndpoint, verifierMode)
 
-		didDoc := createDIDDoc(didID, pubKey)
-		verificationMethod := didDoc.PublicKey[0].ID
+	verifyCredential := func(t *testing.T, vc string, opts *CredentialsVerificationOptions) *httptest.ResponseRecorder {
+		t.Helper()
 
-		kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+		reqBytes, err := json.Marshal(&CredentialsVerificationRequest{Credential: []byte(vc), Opts: opts})
 		require.NoError(t, err)
 
-		op, err := New(&Config{
-			Crypto:             &cryptomock.Crypto{},
-			StoreProvider:      memstore.NewProvider(),
-			KMSSecretsProvider: mem.NewProvider(),
-			KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
-			VDRI:               &vdrimock.MockVDRIRegistry{ResolveValue: didDoc},
-		})
-		require.NoError(t, err)
+		return serveHTTP(t, handler.Handle(), http.MethodPost, credentialsVerificationEndpoint, reqBytes)
+	}
 
-		op.didBlocClient = &didbloc.Client{CreateDIDValue: didDoc}
+	verificationMethod := "did:trustbloc:testnet.trustbloc.local:EiBpn9XWyJlGpny_ViTH75fi43ThiIlGUyc1rQEb3VgreQ==#key-1"
 
-		// verify credential
-		handler := getHandler(t, op, endpoint, verifierMode)
+	t.Run("test detailed report - success", func(t *testing.T) {
+		rr := verifyCredential(t, validVCWithProof, &CredentialsVerificationOptions{
+			Checks:         []string{statusCheck, expiryCheck, schemaCheck},
+			DetailedReport: true,
+		})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
 
-		vReq := &VerifyPresentationRequest{
-			Presentation: getSignedVP(t, privKey, validVC, verificationMethod, domain, challenge),
-			Opts: &VerifyPresentationOptions{
-				Checks:    []string{proofCheck},
-				Challenge: challenge,
-				Domain:    domain,
-			},
-		}
+		report := &VerificationReport{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), report))
+		require.True(t, report.Verified)
+		require.Equal(t, 3, len(report.Checks))
 
-		vReqBytes, err := json.Marshal(vReq)
-		require.NoError(t, err)
+		for _, c := range report.Checks {
+			require.Equal(t, checkPassed, c.Status)
+			require.False(t, c.Started.IsZero())
+			require.NotEmpty(t, c.Duration)
+		}
 
-		rr := serveHTTP(t, handler.Handle(), http.MethodPost, endpoint, vReqBytes)
+		require.Equal(t, "https://example.gov/status/24", report.Checks[0].StatusList)
+		require.Empty(t, report.Checks[0].Warnings)
+		require.Equal(t, []string{"no expiration date present"}, report.Checks[1].Warnings)
+		require.Equal(t, []string{"no credential schema present"}, report.Checks[2].Warnings)
 
-		require.Equal(t, http.StatusOK, rr.Code)
+		rr = verifyCredential(t, validVCWithoutStatus, &CredentialsVerificationOptions{
+			Checks:         []string{statusCheck},
+			DetailedReport: true,
+		})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
 
-		verificationResp := &VerifyPresentationSuccessResponse{}
-		err = json.Unmarshal(rr.Body.Bytes(), &verificationResp)
-		require.NoError(t, err)
-		require.Equal(t, 1, len(verificationResp.Checks))
-		require.Equal(t, proofCheck, verificationResp.Checks[0])
+		report = &VerificationReport{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), report))
+		require.Empty(t, report.Checks[0].StatusList)
+		require.Equal(t, []string{"no status present"}, report.Checks[0].Warnings)
 	})
 
-	t.Run("presentation verification - request doesn't contain checks", func(t *testing.T) {
-		req := &VerifyPresentationRequest{
-			Presentation: []byte(vpWithoutProof),
-		}
+	t.Run("test detailed report - failure", func(t *testing.T) {
+		rr := verifyCredential(t, validVCWithProof, &CredentialsVerificationOptions{
+			Checks:         []string{proofCheck, issuerCheck, statusCheck},
+			DetailedReport: true,
+		})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
 
-		reqBytes, err := json.Marshal(req)
-		require.NoError(t, err)
+		report := &VerificationReport{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), report))
+		require.False(t, report.Verified)
+		require.Equal(t, 3, len(report.Checks))
 
-		rr := serveHTTP(t, verificationsHandler.Handle(), http.MethodPost, endpoint, reqBytes)
+		require.Equal(t, proofCheck, report.Checks[0].Check)
+		require.Equal(t, checkFailed, report.Checks[0].Status)
+		require.Contains(t, report.Checks[0].Error, "proof validation error")
+		require.Equal(t, verificationMethod, report.Checks[0].VerificationMethod)
+		require.Equal(t, strings.Split(verificationMethod, "#")[0], report.Checks[0].DID)
+		require.Equal(t, "did:example:76e12ec712ebc6f1c221ebfeb1f", report.Checks[0].Issuer)
 
+		require.Equal(t, issuerCheck, report.Checks[1].Check)
+		require.Equal(t, checkFailed, report.Checks[1].Status)
+		require.Equal(t, "did:example:76e12ec712ebc6f1c221ebfeb1f", report.Checks[1].Issuer)
+
+		require.Equal(t, checkPassed, report.Checks[2].Status)
+	})
+
+	t.Run("test compatibility response - verification method of the failed proof", func(t *testing.T) {
+		rr := verifyCredential(t, validVCWithProof, nil)
 		require.Equal(t, http.StatusBadRequest, rr.Code)
 
-		// verify that the default check was performed
-		verificationResp := &VerifyPresentationFailureResponse{}
-		err = json.Unmarshal(rr.Body.Bytes(), &verificationResp)
-		require.NoError(t, err)
+		verificationResp := &CredentialsVerificationFailResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), verificationResp))
 		require.Equal(t, 1, len(verificationResp.Checks))
 		require.Equal(t, proofCheck, verificationResp.Checks[0].Check)
-		require.Equal(t, "proof validation error : embedded proof is missing", verificationResp.Checks[0].Error)
+		require.Equal(t, verificationMethod, verificationResp.Checks[0].VerificationMethod)
 	})
 
-	t.Run("presentation verification - proof check failure", func(t *testing.T) {
-		// no proof in VC
-		req := &VerifyPresentationRequest{
-			Presentation: []byte(vpWithoutProof),
-			Opts: &VerifyPresentationOptions{
-				Checks: []string{proofCheck},
-			},
-		}
+	t.Run("test JWT credential verification method", func(t *testing.T) {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA","kid":"did:example:abc#key-1"}`))
 
-		reqBytes, err := json.Marshal(req)
-		require.NoError(t, err)
+		require.Equal(t, "did:example:abc#key-1",
+			credentialVerificationMethod([]byte(`"`+header+`.e30.c2ln"`), &verifiable.Credential{}))
+		require.Empty(t, credentialVerificationMethod([]byte(`"e30.e30"`), &verifiable.Credential{}))
+		require.Empty(t, credentialVerificationMethod([]byte(`"!!.e30.c2ln"`), &verifiable.Credential{}))
+		require.Empty(t, credentialVerificationMethod([]byte(`"c2ln.e30.c2ln"`), &verifiable.Credential{}))
+	})
+}
 
-		rr := serveHTTP(t, verificationsHandler.Handle(), http.MethodPost, endpoint, reqBytes)
+func TestValidityPeriodCheck(t *testing.T) {
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
 
-		require.Equal(t, http.StatusBadRequest, rr.Code)
+	op, err := New(&Config{
+		Crypto:             &cryptomock.Crypto{},
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI:               &vdrimock.MockVDRIRegistry{},
+		ClockSkew:          time.Minute,
+	})
+	require.NoError(t, err)
 
-		verificationResp := &VerifyPresentationFailureResponse{}
-		err = json.Unmarshal(rr.Body.Bytes(), &verificationResp)
-		require.NoError(t, err)
-		require.Equal(t, 1, len(verificationResp.Checks))
-		require.Equal(t, proofCheck, verificationResp.Checks[0].Check)
-		require.Equal(t, "proof validation error : embedded proof is missing", verificationResp.Checks[0].Error)
+	vcHandler := getHandler(t, op, credentialsVerificationEndpoint, verifierMode)
+	vpHandler := getHandler(t, op, presentationsVerificationEndpoint, verifierMode)
 
-		// proof validation error (DID not found)
-		req = &VerifyPresentationRequest{
-			Presentation: []byte(validVCWithProof),
-			Opts: &VerifyPresentationOptions{
-				Checks: []string{proofCheck},
-			},
-		}
+	expiredVC := strings.Replace(validVCWithoutStatus, `"issuanceDate": "2010-01-01T19:23:24Z"`,
+		`"issuanceDate": "2010-01-01T19:23:24Z", "expirationDate": "2011-01-01T19:23:24Z"`, 1)
 
-		reqBytes, err = json.Marshal(req)
+	verifyCredential := func(t *testing.T, vc string, opts *CredentialsVerificationOptions) *httptest.ResponseRecorder {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(&CredentialsVerificationRequest{Credential: []byte(vc), Opts: opts})
 		require.NoError(t, err)
 
-		rr = serveHTTP(t, verificationsHandler.Handle(), http.MethodPost, endpoint, reqBytes)
+		return serveHTTP(t, vcHandler.Handle(), http.MethodPost, credentialsVerificationEndpoint, reqBytes)
+	}
+
+	t.Run("credential verification - success", func(t *testing.T) {
+		rr := verifyCredential(t, validVCWithoutStatus, &CredentialsVerificationOptions{
+			Checks: []string{expiryCheck, validityCheck},
+		})
+		require.Equal(t, http.StatusOK, rr.Code)
 
+		verificationResp := &CredentialsVerificationSuccessResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), verificationResp))
+		require.Equal(t, []string{expiryCheck, validityCheck}, verificationResp.Checks)
+	})
+
+	t.Run("credential verification - expired", func(t *testing.T) {
+		rr := verifyCredential(t, expiredVC, &CredentialsVerificationOptions{Checks: []string{expiryCheck}})
 		require.Equal(t, http.StatusBadRequest, rr.Code)
 
-		verificationResp = &VerifyPresentationFailureResponse{}
-		err = json.Unmarshal(rr.Body.Bytes(), &verificationResp)
-		require.NoError(t, err)
+		verificationResp := &CredentialsVerificationFailResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), verificationResp))
 		require.Equal(t, 1, len(verificationResp.Checks))
-		require.Equal(t, proofCheck, verificationResp.Checks[0].Check)
-		require.Contains(t, verificationResp.Checks[0].Error, "proof validation error")
+		require.Equal(t, expiryCheck, verificationResp.Checks[0].Check)
+		require.Equal(t, "credential expired on 2011-01-01T19:23:24Z", verificationResp.Checks[0].Error)
 	})
 
-	t.Run("presentation verification - invalid check", func(t *testing.T) {
-		invalidCheckName := "invalidCheckName"
-
-		req := &VerifyPresentationRequest{
-			Presentation: []byte(validVP),
-			Opts: &VerifyPresentationOptions{
-				Checks: []string{invalidCheckName},
-			},
-		}
+	t.Run("credential verification - as of the given time", func(t *testing.T) {
+		asOf := time.Date(2010, 6, 1, 0, 0, 0, 0, time.UTC)
 
-		reqBytes, err := json.Marshal(req)
-		require.NoError(t, err)
+		rr := verifyCredential(t, expiredVC, &CredentialsVerificationOptions{
+			Checks: []string{validityCheck},
+			AsOf:   &asOf,
+		})
+		require.Equal(t, http.StatusOK, rr.Code)
 
-		rr := serveHTTP(t, verificationsHandler.Handle(), http.MethodPost, endpoint, reqBytes)
+		asOf = time.Date(2009, 6, 1, 0, 0, 0, 0, time.UTC)
 
+		rr = verifyCredential(t, expiredVC, &CredentialsVerificationOptions{
+			Checks: []string{validityCheck},
+			AsOf:   &asOf,
+		})
 		require.Equal(t, http.StatusBadRequest, rr.Code)
-		verificationResp := &VerifyPresentationFailureResponse{}
-		err = json.Unmarshal(rr.Body.Bytes(), &verificationResp)
-		require.NoError(t, err)
-		require.Equal(t, 1, len(verificationResp.Checks))
-		require.Equal(t, invalidCheckName, verificationResp.Checks[0].Check)
-		require.Equal(t, "check not supported", verificationResp.Checks[0].Error)
+		require.Contains(t, rr.Body.String(), "credential is not valid before 2010-01-01T19:23:24Z")
 	})
 
-	t.Run("presentation verification - invalid json input", func(t *testing.T) {
-		rr := serveHTTP(t, verificationsHandler.Handle(), http.MethodPost, endpoint,
-			[]byte("invalid input"))
+	t.Run("credential verification - clock skew", func(t *testing.T) {
+		expiry := time.Date(2011, 1, 1, 19, 23, 24, 0, time.UTC)
+
+		asOf := expiry.Add(30 * time.Second)
+		rr := verifyCredential(t, expiredVC, &CredentialsVerificationOptions{
+			Checks: []string{expiryCheck},
+			AsOf:   &asOf,
+		})
+		require.Equal(t, http.StatusOK, rr.Code)
 
+		asOf = expiry.Add(2 * time.Minute)
+		rr = verifyCredential(t, expiredVC, &CredentialsVerificationOptions{
+			Checks: []string{expiryCheck},
+			AsOf:   &asOf,
+		})
 		require.Equal(t, http.StatusBadRequest, rr.Code)
-		require.Contains(t, rr.Body.String(), "Invalid request")
 	})
 
-	t.Run("presentation verification - invalid challenge and domain", func(t *testing.T) {
-		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
-		require.NoError(t, err)
+	t.Run("credential verification - VC data model 2.0", func(t *testing.T) {
+		vc := `{
+			"@context": ["https://www.w3.org/ns/credentials/v2"],
+			"id": "http://example.edu/credentials/1872",
+			"type": "VerifiableCredential",
+			"issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+			"validFrom": "2010-01-01T19:23:24Z",
+			"validUntil": "2011-01-01T19:23:24Z",
+			"credentialSubject": {"id": "did:example:ebfeb1f712ebc6f1c276e12ec21"}
+		}`
 
-		didID := "did:test:xyz"
+		rr := verifyCredential(t, vc, &CredentialsVerificationOptions{Checks: []string{expiryCheck}})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "credential expired on 2011-01-01T19:23:24Z")
 
-		didDoc := createDIDDoc(didID, pubKey)
-		verificationMethod := didDoc.PublicKey[0].ID
+		rr = verifyCredential(t, strings.Replace(vc, `"validUntil": "2011-01-01T19:23:24Z"`,
+			`"validUntil": "tomorrow"`, 1), &CredentialsVerificationOptions{Checks: []string{expiryCheck}})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid validUntil")
+	})
 
-		kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
-		require.NoError(t, err)
+	t.Run("presentation verification", func(t *testing.T) {
+		vp := strings.Replace(vpWithoutProof, `"issuanceDate": "2010-01-01T19:23:24Z"`,
+			`"issuanceDate": "2010-01-01T19:23:24Z", "expirationDate": "2011-01-01T19:23:24Z"`, 1)
 
-		op, err := New(&Config{
-			Crypto:             &cryptomock.Crypto{},
-			StoreProvider:      memstore.NewProvider(),
-			KMSSecretsProvider: mem.NewProvider(),
-			KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
-			VDRI:               &vdrimock.MockVDRIRegistry{ResolveValue: didDoc},
+		reqBytes, err := json.Marshal(&VerifyPresentationRequest{
+			Presentation: []byte(vp),
+			Opts:         &VerifyPresentationOptions{Checks: []string{expiryCheck}},
 		})
 		require.NoError(t, err)
 
-		op.didBlocClient = &didbloc.Client{CreateDIDValue: didDoc}
+		rr := serveHTTP(t, vpHandler.Handle(), http.MethodPost, presentationsVerificationEndpoint, reqBytes)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
 
-		// verify credential
-		handler := getHandler(t, op, endpoint, verifierMode)
+		verificationResp := &VerifyPresentationFailureResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), verificationResp))
+		require.Equal(t, 1, len(verificationResp.Checks))
+		require.Equal(t, expiryCheck, verificationResp.Checks[0].Check)
+		require.Equal(t, "credential http://example.edu/credentials/1872: credential expired on 2011-01-01T19:23:24Z",
+			verificationResp.Checks[0].Error)
 
-		vReq := &VerifyPresentationRequest{
-			Presentation: getSignedVP(t, privKey, validVC, verificationMethod, domain, uuid.New().String()),
-			Opts: &VerifyPresentationOptions{
-				Checks:    []string{proofCheck},
-				Domain:    domain,
-				Challenge: challenge,
-			},
-		}
+		asOf := time.Date(2010, 6, 1, 0, 0, 0, 0, time.UTC)
 
-		vReqBytes, err := json.Marshal(vReq)
+		reqBytes, err = json.Marshal(&VerifyPresentationRequest{
+			Presentation: []byte(vp),
+			Opts:         &VerifyPresentationOptions{Checks: []string{validityCheck}, AsOf: &asOf},
+		})
 		require.NoError(t, err)
 
-		rr := serveHTTP(t, handler.Handle(), http.MethodPost, endpoint, vReqBytes)
+		rr = serveHTTP(t, vpHandler.Handle(), http.MethodPost, presentationsVerificationEndpoint, reqBytes)
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+}
 
-		require.Equal(t, http.StatusBadRequest, rr.Code)
-		require.Contains(t, rr.Body.String(), "invalid challenge in the proof")
+func TestPresentationCredentialStatus(t *testing.T) {
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
 
-		vReq = &VerifyPresentationRequest{
-			Presentation: getSignedVP(t, privKey, validVC, verificationMethod, "invalid-domain", challenge),
-			Opts: &VerifyPresentationOptions{
-				Checks:    []string{proofCheck},
-				Domain:    domain,
-				Challenge: challenge,
-			},
-		}
+	op, err := New(&Config{
+		Crypto:             &cryptomock.Crypto{},
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI:               &vdrimock.MockVDRIRegistry{},
+	})
+	require.NoError(t, err)
 
-		vReqBytes, err = json.Marshal(vReq)
-		require.NoError(t, err)
+	vpHandler := getHandler(t, op, presentationsVerificationEndpoint, verifierMode)
 
-		rr = serveHTTP(t, handler.Handle(), http.MethodPost, endpoint, vReqBytes)
+	// presentation with credentials 1872, 1873 and 1874
+	var vpDoc map[string]interface{}
 
-		require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.NoError(t, json.Unmarshal([]byte(vpWithoutProof), &vpDoc))
+
+	creds, ok := vpDoc["verifiableCredential"].([]interface{})
+	require.True(t, ok)
+
+	for _, id := range []string{"http://example.edu/credentials/1873", "http://example.edu/credentials/1874"} {
+		cred := make(map[string]interface{})
+		for k, v := range creds[0].(map[string]interface{}) {
+			cred[k] = v
+		}
+
+		cred["id"] = id
+		creds = append(creds, cred)
+	}
+
+	vpDoc["verifiableCredential"] = creds
+
+	vp, err := json.Marshal(vpDoc)
+	require.NoError(t, err)
+
+	setRevoked := func(t *testing.T, ids ...string) {
+		t.Helper()
+
+		csl := &cslstatus.CSL{ID: "https://example.gov/status/24"}
+		for _, id := range ids {
+			csl.VC = append(csl.VC, strings.ReplaceAll(validVCStatus, "#ID", id))
+		}
+
+		cslBytes, err := json.Marshal(csl)
+		require.NoError(t, err)
+
+		op.httpClient = &mockHTTPClient{doFunc: func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(cslBytes))}, nil
+		}}
+	}
+
+	verifyPresentation := func(t *testing.T) *httptest.ResponseRecorder {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(&VerifyPresentationRequest{
+			Presentation: vp,
+			Opts:         &VerifyPresentationOptions{Checks: []string{statusCheck}},
+		})
+		require.NoError(t, err)
+
+		return serveHTTP(t, vpHandler.Handle(), http.MethodPost, presentationsVerificationEndpoint, reqBytes)
+	}
+
+	t.Run("test success - no credential is revoked", func(t *testing.T) {
+		setRevoked(t, "http://example.edu/credentials/1875")
+
+		rr := verifyPresentation(t)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+
+	t.Run("test failure - revoked credentials are reported by ID", func(t *testing.T) {
+		setRevoked(t, "http://example.edu/credentials/1872", "http://example.edu/credentials/1874")
+
+		rr := verifyPresentation(t)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		verificationResp := &VerifyPresentationFailureResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), verificationResp))
+		require.Equal(t, 1, len(verificationResp.Checks))
+		require.Equal(t, statusCheck, verificationResp.Checks[0].Check)
+
+		results := verificationResp.Checks[0].Credentials
+		require.Equal(t, 2, len(results))
+		require.Equal(t, "http://example.edu/credentials/1872", results[0].ID)
+		require.Contains(t, results[0].Error, "Revoked")
+		require.Equal(t, "http://example.edu/credentials/1874", results[1].ID)
+		require.Contains(t, results[1].Error, "Revoked")
+	})
+
+	t.Run("test failure - error fetching status", func(t *testing.T) {
+		op.httpClient = &mockHTTPClient{doErr: errors.New("connection refused")}
+
+		rr := verifyPresentation(t)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "credential http://example.edu/credentials/1873: "+
+			"failed to fetch the status : connection refused")
+	})
+}
+
+func TestHolderBindingCheck(t *testing.T) {
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		Crypto:             &cryptomock.Crypto{},
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI:               &vdrimock.MockVDRIRegistry{},
+	})
+	require.NoError(t, err)
+
+	vpHandler := getHandler(t, op, presentationsVerificationEndpoint, verifierMode)
+
+	const (
+		subject = `"holder": "did:example:ebfeb1f712ebc6f1c276e12ec21"`
+		other   = `"holder": "did:example:other"`
+		proof   = `"proof": {
+			"type": "Ed25519Signature2018",
+			"verificationMethod": "did:example:ebfeb1f712ebc6f1c276e12ec21#key-1"
+		}`
+	)
+
+	verifyPresentation := func(t *testing.T, vp string, opts *VerifyPresentationOptions) *httptest.ResponseRecorder {
+		t.Helper()
+
+		if opts == nil {
+			opts = &VerifyPresentationOptions{}
+		}
+
+		opts.Checks = []string{holderBindingCheck}
+
+		reqBytes, err := json.Marshal(&VerifyPresentationRequest{Presentation: []byte(vp), Opts: opts})
+		require.NoError(t, err)
+
+		return serveHTTP(t, vpHandler.Handle(), http.MethodPost, presentationsVerificationEndpoint, reqBytes)
+	}
+
+	t.Run("test success - holder is the credential subject", func(t *testing.T) {
+		rr := verifyPresentation(t, vpWithoutProof, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+
+	t.Run("test success - signer is the credential subject", func(t *testing.T) {
+		rr := verifyPresentation(t, strings.Replace(vpWithoutProof, subject, proof, 1), nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+
+	t.Run("test success - credential type is exempt", func(t *testing.T) {
+		rr := verifyPresentation(t, strings.Replace(vpWithoutProof, subject, other, 1),
+			&VerifyPresentationOptions{HolderBindingExemptTypes: []string{"VerifiableCredential"}})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+
+	t.Run("test failure - holder isn't the credential subject", func(t *testing.T) {
+		rr := verifyPresentation(t, strings.Replace(vpWithoutProof, subject, other, 1), nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		verificationResp := &VerifyPresentationFailureResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), verificationResp))
+		require.Equal(t, 1, len(verificationResp.Checks))
+		require.Equal(t, holderBindingCheck, verificationResp.Checks[0].Check)
+		require.Equal(t, []*CredentialCheckResult{{
+			ID:    "http://example.edu/credentials/1872",
+			Error: "presenter did:example:other is not the credential subject",
+		}}, verificationResp.Checks[0].Credentials)
+	})
+
+	t.Run("test failure - credential subject id is missing", func(t *testing.T) {
+		rr := verifyPresentation(t, strings.Replace(vpWithoutProof,
+			`"id": "did:example:ebfeb1f712ebc6f1c276e12ec21"`, `"name": "Jayden Doe"`, 1), nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "credential subject id is missing")
+	})
+
+	t.Run("test failure - presenter is unknown", func(t *testing.T) {
+		rr := verifyPresentation(t, strings.Replace(vpWithoutProof, subject+",", "", 1), nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "presentation is neither signed nor has a holder")
+
+		rr = verifyPresentation(t, strings.Replace(vpWithoutProof, subject, other+", "+proof, 1), nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(),
+			"presentation holder did:example:other is not the signer did:example:ebfeb1f712ebc6f1c276e12ec21")
+	})
+}
+
+func TestTrustedIssuers(t *testing.T) {
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		Crypto:             &cryptomock.Crypto{},
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI:               &vdrimock.MockVDRIRegistry{},
+	})
+	require.NoError(t, err)
+
+	saveHandler := getHandler(t, op, trustedIssuersEndpoint, verifierMode)
+	getIssuerHandler := getHandler(t, op, getTrustedIssuerEndpoint, verifierMode)
+	vcHandler := getHandler(t, op, credentialsVerificationEndpoint, verifierMode)
+	vpHandler := getHandler(t, op, presentationsVerificationEndpoint, verifierMode)
+
+	issuerDID := "did:example:76e12ec712ebc6f1c221ebfeb1f"
+
+	saveIssuer := func(t *testing.T, req *TrustedIssuerRequest) *httptest.ResponseRecorder {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		return serveHTTP(t, saveHandler.Handle(), http.MethodPost, trustedIssuersEndpoint, reqBytes)
+	}
+
+	verifyCredential := func(t *testing.T, opts *CredentialsVerificationOptions) *httptest.ResponseRecorder {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(&CredentialsVerificationRequest{
+			Credential: []byte(validVCWithoutStatus),
+			Opts:       opts,
+		})
+		require.NoError(t, err)
+
+		return serveHTTP(t, vcHandler.Handle(), http.MethodPost, credentialsVerificationEndpoint, reqBytes)
+	}
+
+	t.Run("issuer check - issuer is not registered", func(t *testing.T) {
+		rr := verifyCredential(t, &CredentialsVerificationOptions{Checks: []string{issuerCheck}})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		verificationResp := &CredentialsVerificationFailResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), verificationResp))
+		require.Equal(t, 1, len(verificationResp.Checks))
+		require.Equal(t, issuerCheck, verificationResp.Checks[0].Check)
+		require.Equal(t, "issuer "+issuerDID+" is not trusted", verificationResp.Checks[0].Error)
+	})
+
+	t.Run("save trusted issuer - validation errors", func(t *testing.T) {
+		rr := serveHTTP(t, saveHandler.Handle(), http.MethodPost, trustedIssuersEndpoint, []byte("invalid"))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), invalidRequestErrMsg)
+
+		rr = saveIssuer(t, &TrustedIssuerRequest{})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "missing issuer DID")
+
+		validFrom := time.Now()
+		validUntil := validFrom.Add(-time.Hour)
+
+		rr = saveIssuer(t, &TrustedIssuerRequest{DID: issuerDID, ValidFrom: &validFrom, ValidUntil: &validUntil})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "validUntil is before validFrom")
+	})
+
+	t.Run("issuer check - credential type is not allowed", func(t *testing.T) {
+		rr := saveIssuer(t, &TrustedIssuerRequest{DID: issuerDID, CredentialTypes: []string{"PermanentResidentCard"}})
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		rr = verifyCredential(t, &CredentialsVerificationOptions{Checks: []string{issuerCheck}})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "is not trusted for credential types [VerifiableCredential]")
+	})
+
+	t.Run("issuer check - success", func(t *testing.T) {
+		validUntil := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		rr := saveIssuer(t, &TrustedIssuerRequest{
+			DID:             issuerDID,
+			CredentialTypes: []string{"VerifiableCredential"},
+			ValidUntil:      &validUntil,
+		})
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		rr = serveHTTPMux(t, getIssuerHandler, getTrustedIssuerEndpoint, nil, map[string]string{"id": issuerDID})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		issuer := &issuerregistry.TrustedIssuer{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), issuer))
+		require.Equal(t, issuerDID, issuer.DID)
+		require.Equal(t, []string{"VerifiableCredential"}, issuer.CredentialTypes)
+		require.NotNil(t, issuer.Created)
+
+		rr = verifyCredential(t, &CredentialsVerificationOptions{Checks: []string{issuerCheck}})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		asOf := validUntil.Add(time.Hour)
+
+		rr = verifyCredential(t, &CredentialsVerificationOptions{Checks: []string{issuerCheck}, AsOf: &asOf})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "is not trusted after 2030-01-01T00:00:00Z")
+	})
+
+	t.Run("issuer check - presentation", func(t *testing.T) {
+		reqBytes, err := json.Marshal(&VerifyPresentationRequest{
+			Presentation: []byte(vpWithoutProof),
+			Opts:         &VerifyPresentationOptions{Checks: []string{issuerCheck}},
+		})
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, vpHandler.Handle(), http.MethodPost, presentationsVerificationEndpoint, reqBytes)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		vp := strings.Replace(vpWithoutProof, issuerDID, "did:example:untrusted", 1)
+
+		reqBytes, err = json.Marshal(&VerifyPresentationRequest{
+			Presentation: []byte(vp),
+			Opts:         &VerifyPresentationOptions{Checks: []string{issuerCheck}},
+		})
+		require.NoError(t, err)
+
+		rr = serveHTTP(t, vpHandler.Handle(), http.MethodPost, presentationsVerificationEndpoint, reqBytes)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(),
+			"credential http://example.edu/credentials/1872: issuer did:example:untrusted is not trusted")
+	})
+
+	t.Run("retrieve trusted issuer - not found", func(t *testing.T) {
+		rr := serveHTTPMux(t, getIssuerHandler, getTrustedIssuerEndpoint, nil,
+			map[string]string{"id": "did:example:unknown"})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("list and delete trusted issuers", func(t *testing.T) {
+		listIssuersHandler := getMethodHandler(t, op, trustedIssuersEndpoint, http.MethodGet, verifierMode)
+		deleteIssuerHandler := getMethodHandler(t, op, getTrustedIssuerEndpoint, http.MethodDelete, verifierMode)
+
+		listIssuers := func(t *testing.T) []*issuerregistry.TrustedIssuer {
+			t.Helper()
+
+			rr := serveHTTP(t, listIssuersHandler.Handle(), http.MethodGet, trustedIssuersEndpoint, nil)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			resp := &TrustedIssuersResponse{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+
+			return resp.Issuers
+		}
+
+		rr := saveIssuer(t, &TrustedIssuerRequest{DID: "did:example:other"})
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		issuers := listIssuers(t)
+		require.Len(t, issuers, 2)
+		require.Equal(t, issuerDID, issuers[0].DID)
+		require.Equal(t, "did:example:other", issuers[1].DID)
+
+		rr = serveHTTPMux(t, deleteIssuerHandler, getTrustedIssuerEndpoint, nil, map[string]string{"id": issuerDID})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		issuers = listIssuers(t)
+		require.Len(t, issuers, 1)
+		require.Equal(t, "did:example:other", issuers[0].DID)
+
+		rr = verifyCredential(t, &CredentialsVerificationOptions{Checks: []string{issuerCheck}})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "issuer "+issuerDID+" is not trusted")
+
+		rr = serveHTTPMux(t, deleteIssuerHandler, getTrustedIssuerEndpoint, nil, map[string]string{"id": issuerDID})
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), "trusted issuer "+issuerDID+" not found")
+	})
+}
+
+func TestSchemaCheck(t *testing.T) {
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	schemaID := "https://example.com/schemas/person.json"
+
+	op, err := New(&Config{
+		Crypto:             &cryptomock.Crypto{},
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI:               &vdrimock.MockVDRIRegistry{},
+		PinnedSchemas: map[string][]byte{
+			schemaID: []byte(`{"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}`),
+		},
+	})
+	require.NoError(t, err)
+
+	vcHandler := getHandler(t, op, credentialsVerificationEndpoint, verifierMode)
+	vpHandler := getHandler(t, op, presentationsVerificationEndpoint, verifierMode)
+
+	vc := strings.Replace(validVCWithoutStatus, `"issuanceDate"`,
+		`"credentialSchema": {"id": "`+schemaID+`", "type": "JsonSchemaValidator2018"}, "issuanceDate"`, 1)
+
+	t.Run("credential verification - schema violations", func(t *testing.T) {
+		reqBytes, err := json.Marshal(&CredentialsVerificationRequest{
+			Credential: []byte(vc),
+			Opts:       &CredentialsVerificationOptions{Checks: []string{schemaCheck}},
+		})
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, vcHandler.Handle(), http.MethodPost, credentialsVerificationEndpoint, reqBytes)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		verificationResp := &CredentialsVerificationFailResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), verificationResp))
+		require.Equal(t, 1, len(verificationResp.Checks))
+		require.Equal(t, schemaCheck, verificationResp.Checks[0].Check)
+		require.Contains(t, verificationResp.Checks[0].Error, "credentialSubject doesn't match schema "+schemaID)
+		require.Equal(t, []string{"credentialSubject: name is required"}, verificationResp.Checks[0].Violations)
+	})
+
+	t.Run("credential verification - success", func(t *testing.T) {
+		validSubjectVC := strings.Replace(vc, `"id": "did:example:ebfeb1f712ebc6f1c276e12ec21"`,
+			`"id": "did:example:ebfeb1f712ebc6f1c276e12ec21", "name": "Jayden Doe"`, 1)
+
+		reqBytes, err := json.Marshal(&CredentialsVerificationRequest{
+			Credential: []byte(validSubjectVC),
+			Opts:       &CredentialsVerificationOptions{Checks: []string{schemaCheck}},
+		})
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, vcHandler.Handle(), http.MethodPost, credentialsVerificationEndpoint, reqBytes)
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("presentation verification - schema violations", func(t *testing.T) {
+		vp := strings.Replace(vpWithoutProof, `"issuanceDate"`,
+			`"credentialSchema": {"id": "`+schemaID+`", "type": "JsonSchemaValidator2018"}, "issuanceDate"`, 1)
+
+		reqBytes, err := json.Marshal(&VerifyPresentationRequest{
+			Presentation: []byte(vp),
+			Opts:         &VerifyPresentationOptions{Checks: []string{schemaCheck}},
+		})
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, vpHandler.Handle(), http.MethodPost, presentationsVerificationEndpoint, reqBytes)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		verificationResp := &VerifyPresentationFailureResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), verificationResp))
+		require.Equal(t, 1, len(verificationResp.Checks))
+		require.Equal(t, []string{"credentialSubject: name is required"}, verificationResp.Checks[0].Violations)
+	})
+}
+
+func TestPresentationExchange(t *testing.T) {
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		Crypto:             &cryptomock.Crypto{},
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI:               &vdrimock.MockVDRIRegistry{},
+	})
+	require.NoError(t, err)
+
+	profileHandler := getHandler(t, op, verifierProfileEndpoint, verifierMode)
+	getProfileHandler := getHandler(t, op, getVerifierProfileEndpoint, verifierMode)
+	vpHandler := getHandler(t, op, presentationsVerificationEndpoint, verifierMode)
+
+	definition := &presexch.PresentationDefinition{
+		ID: "32f54163-7166-48f1-93d8-ff217bdb0653",
+		InputDescriptors: []*presexch.InputDescriptor{{
+			ID: "degree",
+			Constraints: &presexch.Constraints{Fields: []*presexch.Field{{
+				Path:   []string{"$.issuer.id"},
+				Filter: json.RawMessage(`{"const": "did:example:76e12ec712ebc6f1c221ebfeb1f"}`),
+			}}},
+		}},
+	}
+
+	vp := strings.Replace(vpWithoutProof, `"type": "VerifiablePresentation",`, `"type": "VerifiablePresentation",
+		"presentation_submission": {
+			"definition_id": "32f54163-7166-48f1-93d8-ff217bdb0653",
+			"descriptor_map": [{"id": "degree", "format": "ldp_vc", "path": "$.verifiableCredential[0]"}]
+		},`, 1)
+
+	verifyPresentation := func(t *testing.T, vp string, opts *VerifyPresentationOptions) *httptest.ResponseRecorder {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(&VerifyPresentationRequest{Presentation: []byte(vp), Opts: opts})
+		require.NoError(t, err)
+
+		return serveHTTP(t, vpHandler.Handle(), http.MethodPost, presentationsVerificationEndpoint, reqBytes)
+	}
+
+	t.Run("create verifier profile", func(t *testing.T) {
+		reqBytes, err := json.Marshal(&VerifierProfileRequest{Name: "verifier", PresentationDefinition: definition})
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, profileHandler.Handle(), http.MethodPost, verifierProfileEndpoint, reqBytes)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		rr = serveHTTP(t, profileHandler.Handle(), http.MethodPost, verifierProfileEndpoint, reqBytes)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "profile verifier already exists")
+
+		rr = serveHTTPMux(t, getProfileHandler, getVerifierProfileEndpoint, nil,
+			map[string]string{profileIDPathParam: "verifier"})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		profile := &vcprofile.VerifierProfile{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), profile))
+		require.Equal(t, definition.ID, profile.PresentationDefinition.ID)
+
+		rr = serveHTTPMux(t, getProfileHandler, getVerifierProfileEndpoint, nil,
+			map[string]string{profileIDPathParam: "unknown"})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("create verifier profile - invalid request", func(t *testing.T) {
+		rr := serveHTTP(t, profileHandler.Handle(), http.MethodPost, verifierProfileEndpoint, []byte("invalid"))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), invalidRequestErrMsg)
+
+		rr = serveHTTP(t, profileHandler.Handle(), http.MethodPost, verifierProfileEndpoint, []byte(`{}`))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "missing profile name")
+
+		reqBytes, err := json.Marshal(&VerifierProfileRequest{Name: "invalid",
+			PresentationDefinition: &presexch.PresentationDefinition{}})
+		require.NoError(t, err)
+
+		rr = serveHTTP(t, profileHandler.Handle(), http.MethodPost, verifierProfileEndpoint, reqBytes)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid presentation definition")
+	})
+
+	t.Run("presentation verification - definition is satisfied", func(t *testing.T) {
+		for _, opts := range []*VerifyPresentationOptions{
+			{PresentationDefinition: definition, Checks: []string{presentationDefinitionCheck}},
+			{Profile: "verifier", Checks: []string{presentationDefinitionCheck}},
+		} {
+			rr := verifyPresentation(t, vp, opts)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			verificationResp := &VerifyPresentationSuccessResponse{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), verificationResp))
+			require.Equal(t, []string{presentationDefinitionCheck}, verificationResp.Checks)
+			require.Equal(t, []*presexch.DescriptorResult{{ID: "degree", Satisfied: true}},
+				verificationResp.InputDescriptors)
+		}
+	})
+
+	t.Run("presentation verification - definition is evaluated by default", func(t *testing.T) {
+		rr := verifyPresentation(t, strings.Replace(vp, "did:example:76e12ec712ebc6f1c221ebfeb1f", "did:example:other", 1),
+			&VerifyPresentationOptions{Profile: "verifier"})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		verificationResp := &VerifyPresentationFailureResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), verificationResp))
+		require.Equal(t, 2, len(verificationResp.Checks))
+		require.Equal(t, proofCheck, verificationResp.Checks[0].Check)
+
+		result := verificationResp.Checks[1]
+		require.Equal(t, presentationDefinitionCheck, result.Check)
+		require.Equal(t, "presentation doesn't satisfy input descriptors: degree", result.Error)
+		require.Equal(t, []*presexch.DescriptorResult{{ID: "degree",
+			Error: "field $.issuer.id doesn't match the filter"}}, result.InputDescriptors)
+	})
+
+	t.Run("presentation verification - definition errors", func(t *testing.T) {
+		tests := []struct {
+			opts *VerifyPresentationOptions
+			vp   string
+			err  string
+		}{
+			{opts: &VerifyPresentationOptions{Checks: []string{presentationDefinitionCheck}}, vp: vp,
+				err: "presentation definition is missing"},
+			{opts: &VerifyPresentationOptions{Profile: "unknown"}, vp: vp, err: "invalid verifier profile"},
+			{opts: &VerifyPresentationOptions{PresentationDefinition: &presexch.PresentationDefinition{}}, vp: vp,
+				err: "invalid presentation definition"},
+			{opts: &VerifyPresentationOptions{PresentationDefinition: definition}, vp: vpWithoutProof,
+				err: "presentation_submission is missing"},
+		}
+
+		for _, tc := range tests {
+			rr := verifyPresentation(t, tc.vp, tc.opts)
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.err)
+		}
+
+		reqBytes, err := json.Marshal(&VerifierProfileRequest{Name: "no-definition"})
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, profileHandler.Handle(), http.MethodPost, verifierProfileEndpoint, reqBytes)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		rr = verifyPresentation(t, vp, &VerifyPresentationOptions{Profile: "no-definition"})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "verifier profile no-definition has no presentation definition")
+	})
+}
+
+func TestChallenges(t *testing.T) {
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		Crypto:             &cryptomock.Crypto{},
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI:               &vdrimock.MockVDRIRegistry{},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, op.profileStore.SaveVerifierProfile(&vcprofile.VerifierProfile{Name: "verifier"}))
+
+	challengeHandler := getHandler(t, op, challengesEndpoint, verifierMode)
+
+	t.Run("test issue challenge - success", func(t *testing.T) {
+		reqBytes, err := json.Marshal(&ChallengeRequest{Profile: "verifier", Domain: domain})
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, challengeHandler.Handle(), http.MethodPost, challengesEndpoint, reqBytes)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		c := &vcchallenge.Challenge{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), c))
+		require.NotEmpty(t, c.Value)
+		require.Equal(t, "verifier", c.Verifier)
+		require.Equal(t, domain, c.Domain)
+		require.True(t, c.Expires.After(time.Now()))
+
+		require.NoError(t, op.challenges.Use(c.Value, "verifier", domain))
+	})
+
+	t.Run("test issue challenge - invalid request", func(t *testing.T) {
+		rr := serveHTTP(t, challengeHandler.Handle(), http.MethodPost, challengesEndpoint, []byte("invalid json"))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), invalidRequestErrMsg)
+
+		reqBytes, err := json.Marshal(&ChallengeRequest{Profile: "unknown"})
+		require.NoError(t, err)
+
+		rr = serveHTTP(t, challengeHandler.Handle(), http.MethodPost, challengesEndpoint, reqBytes)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid verifier profile - id=unknown")
+	})
+}
+
+func TestVerifyPresentation(t *testing.T) {
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		Crypto:             &cryptomock.Crypto{},
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI:               &vdrimock.MockVDRIRegistry{},
+	})
+	require.NoError(t, err)
+
+	endpoint := presentationsVerificationEndpoint
+	verificationsHandler := getHandler(t, op, endpoint, verifierMode)
+
+	t.Run("presentation verification - success", func(t *testing.T) {
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		didID := "did:test:EiBNfNRaz1Ll8BjVsbNv-fWc7K_KIoPuW8GFCh1_Tz_Iuw=="
+
+		didDoc := createDIDDoc(didID, pubKey)
+		verificationMethod := didDoc.PublicKey[0].ID
+
+		kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+		require.NoError(t, err)
+
+		op, err := New(&Config{
+			Crypto:             &cryptomock.Crypto{},
+			StoreProvider:      memstore.NewProvider(),
+			KMSSecretsProvider: mem.NewProvider(),
+			KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+			VDRI:               &vdrimock.MockVDRIRegistry{ResolveValue: didDoc},
+		})
+		require.NoError(t, err)
+
+		op.didBlocClient = &didbloc.Client{CreateDIDValue: didDoc}
+
+		// verify credential
+		handler := getHandler(t, op, endpoint, verifierMode)
+
+		c, err := op.challenges.Issue("", domain)
+		require.NoError(t, err)
+
+		vReq := &VerifyPresentationRequest{
+			Presentation: getSignedVP(t, privKey, validVC, verificationMethod, domain, c.Value),
+			Opts: &VerifyPresentationOptions{
+				Checks:    []string{proofCheck},
+				Challenge: c.Value,
+				Domain:    domain,
+			},
+		}
+
+		vReqBytes, err := json.Marshal(vReq)
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, handler.Handle(), http.MethodPost, endpoint, vReqBytes)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		verificationResp := &VerifyPresentationSuccessResponse{}
+		err = json.Unmarshal(rr.Body.Bytes(), &verificationResp)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(verificationResp.Checks))
+		require.Equal(t, proofCheck, verificationResp.Checks[0])
+
+		// replayed presentation is rejected
+		rr = serveHTTP(t, handler.Handle(), http.MethodPost, endpoint, vReqBytes)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "is already used")
+	})
+
+	t.Run("presentation verification - request doesn't contain checks", func(t *testing.T) {
+		req := &VerifyPresentationRequest{
+			Presentation: []byte(vpWithoutProof),
+		}
+
+		reqBytes, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, verificationsHandler.Handle(), http.MethodPost, endpoint, reqBytes)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		// verify that the default check was performed
+		verificationResp := &VerifyPresentationFailureResponse{}
+		err = json.Unmarshal(rr.Body.Bytes(), &verificationResp)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(verificationResp.Checks))
+		require.Equal(t, proofCheck, verificationResp.Checks[0].Check)
+		require.Equal(t, "proof validation error : embedded proof is missing", verificationResp.Checks[0].Error)
+	})
+
+	t.Run("presentation verification - proof check failure", func(t *testing.T) {
+		// no proof in VC
+		req := &VerifyPresentationRequest{
+			Presentation: []byte(vpWithoutProof),
+			Opts: &VerifyPresentationOptions{
+				Checks: []string{proofCheck},
+			},
+		}
+
+		reqBytes, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, verificationsHandler.Handle(), http.MethodPost, endpoint, reqBytes)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		verificationResp := &VerifyPresentationFailureResponse{}
+		err = json.Unmarshal(rr.Body.Bytes(), &verificationResp)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(verificationResp.Checks))
+		require.Equal(t, proofCheck, verificationResp.Checks[0].Check)
+		require.Equal(t, "proof validation error : embedded proof is missing", verificationResp.Checks[0].Error)
+
+		// proof validation error (DID not found)
+		req = &VerifyPresentationRequest{
+			Presentation: []byte(validVCWithProof),
+			Opts: &VerifyPresentationOptions{
+				Checks: []string{proofCheck},
+			},
+		}
+
+		reqBytes, err = json.Marshal(req)
+		require.NoError(t, err)
+
+		rr = serveHTTP(t, verificationsHandler.Handle(), http.MethodPost, endpoint, reqBytes)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		verificationResp = &VerifyPresentationFailureResponse{}
+		err = json.Unmarshal(rr.Body.Bytes(), &verificationResp)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(verificationResp.Checks))
+		require.Equal(t, proofCheck, verificationResp.Checks[0].Check)
+		require.Contains(t, verificationResp.Checks[0].Error, "proof validation error")
+	})
+
+	t.Run("presentation verification - invalid check", func(t *testing.T) {
+		invalidCheckName := "invalidCheckName"
+
+		req := &VerifyPresentationRequest{
+			Presentation: []byte(validVP),
+			Opts: &VerifyPresentationOptions{
+				Checks: []string{invalidCheckName},
+			},
+		}
+
+		reqBytes, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, verificationsHandler.Handle(), http.MethodPost, endpoint, reqBytes)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		verificationResp := &VerifyPresentationFailureResponse{}
+		err = json.Unmarshal(rr.Body.Bytes(), &verificationResp)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(verificationResp.Checks))
+		require.Equal(t, invalidCheckName, verificationResp.Checks[0].Check)
+		require.Equal(t, "check not supported", verificationResp.Checks[0].Error)
+	})
+
+	t.Run("presentation verification - invalid json input", func(t *testing.T) {
+		rr := serveHTTP(t, verificationsHandler.Handle(), http.MethodPost, endpoint,
+			[]byte("invalid input"))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "Invalid request")
+	})
+
+	t.Run("presentation verification - invalid challenge and domain", func(t *testing.T) {
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		didID := "did:test:xyz"
+
+		didDoc := createDIDDoc(didID, pubKey)
+		verificationMethod := didDoc.PublicKey[0].ID
+
+		kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+		require.NoError(t, err)
+
+		op, err := New(&Config{
+			Crypto:             &cryptomock.Crypto{},
+			StoreProvider:      memstore.NewProvider(),
+			KMSSecretsProvider: mem.NewProvider(),
+			KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+			VDRI:               &vdrimock.MockVDRIRegistry{ResolveValue: didDoc},
+		})
+		require.NoError(t, err)
+
+		op.didBlocClient = &didbloc.Client{CreateDIDValue: didDoc}
+
+		// verify credential
+		handler := getHandler(t, op, endpoint, verifierMode)
+
+		vReq := &VerifyPresentationRequest{
+			Presentation: getSignedVP(t, privKey, validVC, verificationMethod, domain, uuid.New().String()),
+			Opts: &VerifyPresentationOptions{
+				Checks:    []string{proofCheck},
+				Domain:    domain,
+				Challenge: challenge,
+			},
+		}
+
+		vReqBytes, err := json.Marshal(vReq)
+		require.NoError(t, err)
+
+		rr := serveHTTP(t, handler.Handle(), http.MethodPost, endpoint, vReqBytes)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid challenge in the proof")
+
+		vReq = &VerifyPresentationRequest{
+			Presentation: getSignedVP(t, privKey, validVC, verificationMethod, "invalid-domain", challenge),
+			Opts: &VerifyPresentationOptions{
+				Checks:    []string{proofCheck},
+				Domain:    domain,
+				Challenge: challenge,
+			},
+		}
+
+		vReqBytes, err = json.Marshal(vReq)
+		require.NoError(t, err)
+
+		rr = serveHTTP(t, handler.Handle(), http.MethodPost, endpoint, vReqBytes)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
 		require.Contains(t, rr.Body.String(), "invalid domain in the proof")
 
 		// fail when proof has challenge and no challenge in the options
@@ -3798,6 +4813,297 @@ func TestGetPublicKeyID(t *testing.T) {
 	})
 }
 
+func TestDataIntegrityCredentials(t *testing.T) {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI: &vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+			return createDIDDoc(didID, pubKey), nil
+		}},
+		Crypto:         &cryptomock.Crypto{},
+		DocumentLoader: jsonld.DocumentLoader(),
+	})
+	require.NoError(t, err)
+
+	profile := getTestProfile()
+	profile.Name = "v2"
+	profile.SignatureType = vccrypto.DataIntegrityProof
+	profile.DIDKeyType = vccrypto.Ed25519KeyType
+	profile.DIDPrivateKey = base58.Encode(privKey)
+	profile.Creator = "did:test:abc#key-1"
+	profile.VCDataModel = datamodel.Version2
+	profile.DisableVCStatus = true
+
+	require.NoError(t, op.profileStore.SaveProfile(profile))
+
+	v1Profile := *profile
+	v1Profile.Name = "v1"
+	v1Profile.VCDataModel = ""
+
+	require.NoError(t, op.profileStore.SaveProfile(&v1Profile))
+
+	composeHandler := getHandler(t, op, composeAndIssueCredentialPath, issuerMode)
+	issueHandler := getHandler(t, op, issueCredentialPath, issuerMode)
+	verifyHandler := getHandler(t, op, credentialsVerificationEndpoint, verifierMode)
+
+	issueDate := time.Now().UTC()
+	expiryDate := issueDate.AddDate(1, 0, 0)
+
+	composeReq, err := json.Marshal(&ComposeCredentialRequest{
+		Issuer:         "did:test:abc",
+		Subject:        "did:example:oleh394sqwnlk223823ln",
+		Types:          []string{"VerifiableCredential", "UniversityDegreeCredential"},
+		IssuanceDate:   &issueDate,
+		ExpirationDate: &expiryDate,
+		Claims:         []byte(`{"name":"John Doe"}`),
+	})
+	require.NoError(t, err)
+
+	verifyCredential := func(t *testing.T, vc []byte) *httptest.ResponseRecorder {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(&CredentialsVerificationRequest{Credential: vc})
+		require.NoError(t, err)
+
+		return serveHTTP(t, verifyHandler.Handle(), http.MethodPost, credentialsVerificationEndpoint, reqBytes)
+	}
+
+	t.Run("compose and issue VC data model 2.0 credential", func(t *testing.T) {
+		rr := serveHTTPMux(t, composeHandler, "/v2/credentials/composeAndIssueCredential", composeReq,
+			map[string]string{profileIDPathParam: profile.Name})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		var vc map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &vc))
+		require.Equal(t, datamodel.ContextV2, vc["@context"].([]interface{})[0])
+		require.Equal(t, issueDate.Format(time.RFC3339), vc["validFrom"])
+		require.Equal(t, expiryDate.Format(time.RFC3339), vc["validUntil"])
+		require.NotContains(t, vc, "issuanceDate")
+		require.NotContains(t, vc, "expirationDate")
+
+		proof := vc["proof"].(map[string]interface{})
+		require.Equal(t, vccrypto.DataIntegrityProof, proof["type"])
+		require.Equal(t, "eddsa-2022", proof["cryptosuite"])
+
+		rr = verifyCredential(t, rr.Body.Bytes())
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		// tampered credential
+		vc["credentialSubject"].(map[string]interface{})["name"] = "Jane Doe"
+
+		vcBytes, err := json.Marshal(vc)
+		require.NoError(t, err)
+
+		rr = verifyCredential(t, vcBytes)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "signature doesn't match")
+	})
+
+	t.Run("compose and issue VC data model 1.1 credential with data integrity proof", func(t *testing.T) {
+		rr := serveHTTPMux(t, composeHandler, "/v1/credentials/composeAndIssueCredential", composeReq,
+			map[string]string{profileIDPathParam: v1Profile.Name})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		vc, err := verifiable.NewUnverifiedCredential(rr.Body.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, []string{datamodel.ContextV1, dataintegrity.Context}, vc.Context)
+		require.NotNil(t, vc.Issued)
+		require.Equal(t, vccrypto.DataIntegrityProof, vc.Proofs[0]["type"])
+
+		signedVC := rr.Body.Bytes()
+
+		rr = verifyCredential(t, signedVC)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		// proofs of other types next to the data integrity proof are verified too
+		var vcMap map[string]interface{}
+		require.NoError(t, json.Unmarshal(signedVC, &vcMap))
+
+		vcMap["proof"] = []interface{}{vcMap["proof"], map[string]interface{}{
+			"type":               "Ed25519Signature2018",
+			"created":            "2020-05-01T10:00:00Z",
+			"verificationMethod": "did:test:abc#key-1",
+			"proofPurpose":       "assertionMethod",
+			"jws":                "eyJhbGciOiJFZERTQSIsImI2NCI6ZmFsc2UsImNyaXQiOlsiYjY0Il19..c2lnbmF0dXJl",
+		}}
+
+		vcWithProofSet, err := json.Marshal(vcMap)
+		require.NoError(t, err)
+
+		rr = verifyCredential(t, vcWithProofSet)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "check embedded proof")
+	})
+
+	t.Run("issue VC data model 2.0 credential", func(t *testing.T) {
+		vc := `{
+			"@context": ["https://www.w3.org/ns/credentials/v2"],
+			"id": "http://example.edu/credentials/1872",
+			"type": "VerifiableCredential",
+			"issuer": "did:example:76e12ec712ebc6f1c221ebfeb1f",
+			"validFrom": "2020-01-01T19:23:24Z",
+			"credentialSubject": {"id": "did:example:ebfeb1f712ebc6f1c276e12ec21"}
+		}`
+
+		reqBytes, err := json.Marshal(&IssueCredentialRequest{Credential: []byte(vc)})
+		require.NoError(t, err)
+
+		rr := serveHTTPMux(t, issueHandler, "/v2/credentials/issueCredential", reqBytes,
+			map[string]string{profileIDPathParam: profile.Name})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		signedVC, err := verifiable.NewUnverifiedCredential(rr.Body.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, "did:example:76e12ec712ebc6f1c221ebfeb1f", signedVC.Issuer.ID)
+		require.Equal(t, vccrypto.DataIntegrityProof, signedVC.Proofs[0]["type"])
+
+		// the proof isn't made by the issuer of the credential
+		rr = verifyCredential(t, rr.Body.Bytes())
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(),
+			"verification method did:test:abc#key-1 is not controlled by did:example:76e12ec712ebc6f1c221ebfeb1f")
+
+		// profile with signature suite not supporting VC data model 2.0
+		ldProfile := getTestProfile()
+		ldProfile.Name = "ld"
+		require.NoError(t, op.profileStore.SaveProfile(ldProfile))
+
+		rr = serveHTTPMux(t, issueHandler, "/ld/credentials/issueCredential", reqBytes,
+			map[string]string{profileIDPathParam: ldProfile.Name})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "VC data model 2.0 credentials require DataIntegrityProof")
+
+		// invalid VC data model 2.0 credential
+		reqBytes, err = json.Marshal(&IssueCredentialRequest{Credential: []byte(strings.Replace(vc,
+			`"validFrom"`, `"issuanceDate"`, 1))})
+		require.NoError(t, err)
+
+		rr = serveHTTPMux(t, issueHandler, "/v2/credentials/issueCredential", reqBytes,
+			map[string]string{profileIDPathParam: profile.Name})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "replaced by validFrom and validUntil")
+	})
+
+	t.Run("verify VC data model 2.0 credential without data integrity proof", func(t *testing.T) {
+		rr := verifyCredential(t, []byte(`{
+			"@context": ["https://www.w3.org/ns/credentials/v2"],
+			"type": "VerifiableCredential",
+			"issuer": "did:test:abc",
+			"credentialSubject": {"id": "did:example:ebfeb1f712ebc6f1c276e12ec21"}
+		}`))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "data integrity proof is missing")
+	})
+
+	t.Run("sign and verify VC data model 2.0 presentation", func(t *testing.T) {
+		holderProfile := &vcprofile.HolderProfile{
+			Name:          "holder",
+			DID:           "did:test:abc",
+			SignatureType: vccrypto.DataIntegrityProof,
+			Creator:       "did:test:abc#key-1",
+			DIDKeyType:    vccrypto.Ed25519KeyType,
+			DIDPrivateKey: base58.Encode(privKey),
+		}
+
+		require.NoError(t, op.profileStore.SaveHolderProfile(holderProfile))
+
+		rr := serveHTTPMux(t, composeHandler, "/v2/credentials/composeAndIssueCredential", composeReq,
+			map[string]string{profileIDPathParam: profile.Name})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		vp := `{
+			"@context": ["https://www.w3.org/ns/credentials/v2"],
+			"type": "VerifiablePresentation",
+			"holder": "did:test:abc",
+			"verifiableCredential": [` + rr.Body.String() + `]
+		}`
+
+		c, err := op.challenges.Issue("", domain)
+		require.NoError(t, err)
+
+		signReq, err := json.Marshal(&SignPresentationRequest{Presentation: []byte(vp),
+			Opts: &SignPresentationOptions{Challenge: c.Value, Domain: domain}})
+		require.NoError(t, err)
+
+		rr = serveHTTPMux(t, getHandler(t, op, signPresentationEndpoint, holderMode),
+			"/holder/prove/presentations", signReq, map[string]string{profileIDPathParam: holderProfile.Name})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		verifyReq, err := json.Marshal(&VerifyPresentationRequest{Presentation: rr.Body.Bytes(),
+			Opts: &VerifyPresentationOptions{Challenge: c.Value, Domain: domain}})
+		require.NoError(t, err)
+
+		verifyPresentationHandler := getHandler(t, op, presentationsVerificationEndpoint, verifierMode)
+
+		rr = serveHTTP(t, verifyPresentationHandler.Handle(), http.MethodPost, presentationsVerificationEndpoint,
+			verifyReq)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		// replayed presentation is rejected
+		rr = serveHTTP(t, verifyPresentationHandler.Handle(), http.MethodPost, presentationsVerificationEndpoint,
+			verifyReq)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "is already used")
+
+		// challenge not issued by the verifier is rejected
+		signReq, err = json.Marshal(&SignPresentationRequest{Presentation: []byte(vp),
+			Opts: &SignPresentationOptions{Challenge: challenge, Domain: domain}})
+		require.NoError(t, err)
+
+		rr = serveHTTPMux(t, getHandler(t, op, signPresentationEndpoint, holderMode),
+			"/holder/prove/presentations", signReq, map[string]string{profileIDPathParam: holderProfile.Name})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		verifyReq, err = json.Marshal(&VerifyPresentationRequest{Presentation: rr.Body.Bytes(),
+			Opts: &VerifyPresentationOptions{Challenge: challenge, Domain: domain}})
+		require.NoError(t, err)
+
+		rr = serveHTTP(t, verifyPresentationHandler.Handle(), http.MethodPost, presentationsVerificationEndpoint,
+			verifyReq)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "unknown challenge "+challenge)
+
+		verifyReq, err = json.Marshal(&VerifyPresentationRequest{Presentation: []byte(vp)})
+		require.NoError(t, err)
+
+		rr = serveHTTP(t, verifyPresentationHandler.Handle(), http.MethodPost, presentationsVerificationEndpoint,
+			verifyReq)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "data integrity proof is missing")
+	})
+}
+
+func TestCheckVerificationRelationship(t *testing.T) {
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	didDoc := createDIDDoc("did:test:abc", pubKey)
+
+	// DID documents without verification relationships authorize their public keys
+	require.NoError(t, checkVerificationRelationship(didDoc, "did:test:abc#key-1", assertionMethod))
+
+	didDoc.Authentication = []did.VerificationMethod{{PublicKey: didDoc.PublicKey[0]}}
+	didDoc.AssertionMethod = []did.VerificationMethod{{PublicKey: did.PublicKey{ID: "#key-2"}}}
+
+	require.NoError(t, checkVerificationRelationship(didDoc, "did:test:abc#key-1", authentication))
+	require.NoError(t, checkVerificationRelationship(didDoc, "did:test:abc#key-2", assertionMethod))
+
+	err = checkVerificationRelationship(didDoc, "did:test:abc#key-1", assertionMethod)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "verification method did:test:abc#key-1 is not authorized for assertionMethod")
+
+	err = checkVerificationRelationship(didDoc, "did:test:abc#key-1", "keyAgreement")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unsupported proof purpose: keyAgreement")
+}
+
 func serveHTTP(t *testing.T, handler http.HandlerFunc, method, path string, req []byte) *httptest.ResponseRecorder {
 	httpReq, err := http.NewRequest(
 		method,
@@ -3858,6 +5164,76 @@ func handlerLookup(t *testing.T, op *Operation, lookup, mode string) Handler {
 	return nil
 }
 
+// failingTransport fails any network call
+type failingTransport struct{}
+
+func (t *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("unexpected network call %s", req.URL)
+}
+
+func TestLinkedDataProofsWithDocumentLoader(t *testing.T) {
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = &failingTransport{}
+
+	defer func() { http.DefaultTransport = defaultTransport }()
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	newOperation := func(loader ld.DocumentLoader) *Operation {
+		op, err := New(&Config{
+			StoreProvider:      memstore.NewProvider(),
+			KMSSecretsProvider: mem.NewProvider(),
+			KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+			VDRI: &vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+				return createDIDDoc(didID, pubKey), nil
+			}},
+			Crypto:         &cryptomock.Crypto{},
+			DocumentLoader: loader,
+		})
+		require.NoError(t, err)
+
+		return op
+	}
+
+	op := newOperation(jsonld.DocumentLoader())
+
+	profile := getTestProfile()
+	profile.DIDKeyType = vccrypto.Ed25519KeyType
+	profile.DIDPrivateKey = base58.Encode(privKey)
+	profile.Creator = "did:test:abc#key-1"
+	profile.DisableVCStatus = true
+
+	require.NoError(t, op.profileStore.SaveProfile(profile))
+
+	issueReq, err := json.Marshal(&IssueCredentialRequest{Credential: []byte(validVCWithoutStatus)})
+	require.NoError(t, err)
+
+	rr := serveHTTPMux(t, getHandler(t, op, issueCredentialPath, issuerMode), "/test/credentials/issueCredential",
+		issueReq, map[string]string{profileIDPathParam: profile.Name})
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	require.Contains(t, rr.Body.String(), "Ed25519Signature2018")
+
+	verifyReq, err := json.Marshal(&CredentialsVerificationRequest{Credential: rr.Body.Bytes()})
+	require.NoError(t, err)
+
+	t.Run("test proof is verified with the contexts of the loader", func(t *testing.T) {
+		rr := serveHTTP(t, getHandler(t, op, credentialsVerificationEndpoint, verifierMode).Handle(),
+			http.MethodPost, credentialsVerificationEndpoint, verifyReq)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+
+	t.Run("test proof is verified with the remote contexts without loader", func(t *testing.T) {
+		rr := serveHTTP(t, getHandler(t, newOperation(nil), credentialsVerificationEndpoint, verifierMode).Handle(),
+			http.MethodPost, credentialsVerificationEndpoint, verifyReq)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "loading remote context failed")
+	})
+}
+
 func getTestProfile() *vcprofile.DataProfile {
 	return &vcprofile.DataProfile{
 		Name:          "test",
@@ -4016,8 +5392,18 @@ type mockVCStatusManager struct {
 	getCSLErr           error
 }
 
-func (m *mockVCStatusManager) CreateStatusID() (*verifiable.TypedID, error) {
-	return m.createStatusIDValue, m.createStatusIDErr
+func (m *mockVCStatusManager) CreateStatusIDs(n int) ([]*cslstatus.StatusID, error) {
+	if m.createStatusIDErr != nil {
+		return nil, m.createStatusIDErr
+	}
+
+	statusIDs := make([]*cslstatus.StatusID, n)
+
+	for i := range statusIDs {
+		statusIDs[i] = &cslstatus.StatusID{Status: m.createStatusIDValue, Index: i}
+	}
+
+	return statusIDs, nil
 }
 
 func (m *mockVCStatusManager) UpdateVCStatus(v *verifiable.Credential, profile *vcprofile.DataProfile,
@@ -4032,9 +5418,14 @@ func (m *mockVCStatusManager) GetCSL(id string) (*cslstatus.CSL, error) {
 type mockHTTPClient struct {
 	doValue *http.Response
 	doErr   error
+	doFunc  func(req *http.Request) (*http.Response, error)
 }
 
 func (m *mockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if m.doFunc != nil {
+		return m.doFunc(req)
+	}
+
 	return m.doValue, m.doErr
 }
 
@@ -4118,12 +5509,18 @@ type mockCredentialStatusManager struct {
 	CreateErr error
 }
 
-func (m *mockCredentialStatusManager) CreateStatusID() (*verifiable.TypedID, error) {
+func (m *mockCredentialStatusManager) CreateStatusIDs(n int) ([]*cslstatus.StatusID, error) {
 	if m.CreateErr != nil {
 		return nil, m.CreateErr
 	}
 
-	return nil, nil
+	statusIDs := make([]*cslstatus.StatusID, n)
+
+	for i := range statusIDs {
+		statusIDs[i] = &cslstatus.StatusID{}
+	}
+
+	return statusIDs, nil
 }
 
 func (m *mockCredentialStatusManager) UpdateVCStatus(v *verifiable.Credential,
@@ -0,0 +1,376 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/cm"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/datamodel"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/dataintegrity"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	cslstatus "github.com/trustbloc/edge-service/pkg/doc/vc/status/csl"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/vpr"
+)
+
+// WalletPresentation swagger:route POST /{id}/wallet/presentations holder walletPresentationReq
+//
+// Assembles the presentation of the holder wallet credentials requested by the verifiable presentation request
+// (QueryByExample, DIDAuth) or the DIF presentation definition, one credential is presented for each query or
+// input descriptor and the expired or revoked credentials are skipped. The presentation is signed by the holder
+// with the challenge and domain of the request.
+//
+// Responses:
+//    default: genericError
+//        201: walletPresentationRes
+func (o *Operation) walletPresentationHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.walletProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	request := &WalletPresentationRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	if err := validateWalletPresentationRequest(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	credentials, err := o.walletCredentials(profile.Name, &walletQuery{})
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to query wallet: %s",
+			err.Error()))
+
+		return
+	}
+
+	credentials, err = chosenCredentials(credentials, request.CredentialIDs)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	var (
+		selected   []json.RawMessage
+		submission *presexch.PresentationSubmission
+	)
+
+	usable := o.usableCredentialCheck()
+
+	if request.PresentationRequest != nil {
+		selected, err = selectRequestedCredentials(request.PresentationRequest, credentials, usable)
+	} else {
+		selected, submission, err = selectDefinitionCredentials(request.PresentationDefinition, credentials, usable)
+	}
+
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errWalletCredentialStatus) {
+			status = http.StatusInternalServerError
+		}
+
+		o.writeErrorResponse(rw, status, err.Error())
+
+		return
+	}
+
+	vp, err := buildWalletPresentation(profile, selected)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	signedVP, err := o.crypto.SignPresentation(profile, vp,
+		getPresentationSigningOpts(walletPresentationOpts(request))...)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to sign presentation:"+
+			" %s", err.Error()))
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	o.writeResponse(rw, &WalletPresentationResponse{Presentation: signedVP, PresentationSubmission: submission})
+}
+
+func validateWalletPresentationRequest(request *WalletPresentationRequest) error {
+	switch {
+	case request.PresentationRequest != nil && request.PresentationDefinition != nil:
+		return errors.New("either presentation request or presentation definition must be provided, not both")
+	case request.PresentationRequest != nil:
+		return request.PresentationRequest.Validate()
+	case request.PresentationDefinition != nil:
+		return request.PresentationDefinition.Validate()
+	default:
+		return errors.New("presentation request or presentation definition is required")
+	}
+}
+
+// selectRequestedCredentials returns the first usable wallet credential matching each credential query of
+// the request, DIDAuth only request selects no credentials
+func selectRequestedCredentials(request *vpr.Request, credentials []*WalletCredential,
+	usable usableCredentialFunc) ([]json.RawMessage, error) {
+	var selected []json.RawMessage
+
+	selectedIDs := make(map[string]bool)
+
+	for _, query := range request.CredentialQueries() {
+		credential, err := firstUsableCredential(credentials, usable, func(c *WalletCredential) bool {
+			return query.Match(c.Credential)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if credential == nil {
+			if query.Required {
+				return nil, fmt.Errorf("no wallet credential satisfies the required credential query: %s",
+					query.Reason)
+			}
+
+			continue
+		}
+
+		if !selectedIDs[credential.ID] {
+			selectedIDs[credential.ID] = true
+			selected = append(selected, credential.Credential)
+		}
+	}
+
+	return selected, nil
+}
+
+// selectDefinitionCredentials returns the first usable wallet credential satisfying each input descriptor of
+// the definition and the submission mapping the descriptors to the presentation credentials
+func selectDefinitionCredentials(definition *presexch.PresentationDefinition, credentials []*WalletCredential,
+	usable usableCredentialFunc) ([]json.RawMessage, *presexch.PresentationSubmission, error) {
+	var selected []json.RawMessage
+
+	submission := &presexch.PresentationSubmission{
+		ID:           uuidURNPrefix + uuid.New().String(),
+		DefinitionID: definition.ID,
+	}
+
+	// index of the wallet credential in the presentation
+	indexes := make(map[string]int)
+
+	for _, descriptor := range definition.InputDescriptors {
+		credential, err := firstUsableCredential(credentials, usable, func(c *WalletCredential) bool {
+			return descriptor.Match(c.Credential) == nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if credential == nil {
+			return nil, nil, fmt.Errorf("no wallet credential satisfies input descriptor %s", descriptor.ID)
+		}
+
+		index, ok := indexes[credential.ID]
+		if !ok {
+			index = len(selected)
+			indexes[credential.ID] = index
+			selected = append(selected, credential.Credential)
+		}
+
+		submission.DescriptorMap = append(submission.DescriptorMap, &presexch.InputDescriptorMapping{
+			ID: descriptor.ID, Format: cm.LDPVCFormat, Path: fmt.Sprintf("$.verifiableCredential[%d]", index),
+		})
+	}
+
+	return selected, submission, nil
+}
+
+// usableCredentialFunc tells whether the wallet credential can be presented
+type usableCredentialFunc func(credential *WalletCredential) (bool, error)
+
+// errWalletCredentialStatus is returned when the status of the wallet credential can't be checked
+var errWalletCredentialStatus = errors.New("failed to check wallet credential status")
+
+// usableCredentialCheck returns the check of the wallet credentials which aren't expired and have no status update
+// (e.g. revocation), the status of each credential is fetched once
+func (o *Operation) usableCredentialCheck() usableCredentialFunc {
+	checked := make(map[string]bool)
+
+	return func(credential *WalletCredential) (bool, error) {
+		if usable, ok := checked[credential.ID]; ok {
+			return usable, nil
+		}
+
+		vc, err := verifiable.NewUnverifiedCredential(credential.Credential)
+		if err != nil {
+			return false, fmt.Errorf("invalid wallet credential %s: %w", credential.ID, err)
+		}
+
+		validUntil, err := datamodel.ValidUntil(vc)
+		if err != nil {
+			return false, fmt.Errorf("invalid wallet credential %s: %w", credential.ID, err)
+		}
+
+		usable := validUntil == nil || time.Now().Before(*validUntil)
+
+		if usable && vc.Status != nil && vc.Status.ID != "" {
+			updated, statusErr := o.hasStatusUpdate(vc.Status.ID, vc.ID)
+			if statusErr != nil {
+				return false, fmt.Errorf("%w %s: %s", errWalletCredentialStatus, credential.ID, statusErr.Error())
+			}
+
+			usable = !updated
+		}
+
+		checked[credential.ID] = usable
+
+		return usable, nil
+	}
+}
+
+// hasStatusUpdate fetches the status list of the credential and tells whether it has a status update
+// (e.g. revocation) of the credential
+func (o *Operation) hasStatusUpdate(listID, vcID string) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, listID, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := o.sendHTTPRequest(req, http.StatusOK)
+	if err != nil {
+		return false, err
+	}
+
+	var csl cslstatus.CSL
+	if err := json.Unmarshal(resp, &csl); err != nil {
+		return false, fmt.Errorf("failed to unmarshal resp to csl: %w", err)
+	}
+
+	return csl.HasEntry(vcID), nil
+}
+
+// firstUsableCredential returns the first usable wallet credential matching the query, nil if there is none
+func firstUsableCredential(credentials []*WalletCredential, usable usableCredentialFunc,
+	match func(*WalletCredential) bool) (*WalletCredential, error) {
+	for _, credential := range credentials {
+		if !match(credential) {
+			continue
+		}
+
+		ok, err := usable(credential)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return credential, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// chosenCredentials returns the wallet credentials chosen by the holder, all the wallet credentials are returned
+// if none is chosen
+func chosenCredentials(credentials []*WalletCredential, ids []string) ([]*WalletCredential, error) {
+	if len(ids) == 0 {
+		return credentials, nil
+	}
+
+	chosen := make([]*WalletCredential, 0, len(ids))
+
+	for _, id := range ids {
+		var credential *WalletCredential
+
+		for _, c := range credentials {
+			if c.ID == id {
+				credential = c
+
+				break
+			}
+		}
+
+		if credential == nil {
+			return nil, fmt.Errorf("wallet credential %s not found", id)
+		}
+
+		chosen = append(chosen, credential)
+	}
+
+	return chosen, nil
+}
+
+// buildWalletPresentation returns the unsigned presentation of the credentials held by the profile DID
+func buildWalletPresentation(profile *vcprofile.HolderProfile,
+	credentials []json.RawMessage) (*verifiable.Presentation, error) {
+	vp := &verifiable.Presentation{
+		Context: []string{datamodel.ContextV1},
+		ID:      uuidURNPrefix + uuid.New().String(),
+		Type:    []string{verifiablePresentationType},
+		Holder:  profile.DID,
+	}
+
+	if profile.SignatureType == crypto.DataIntegrityProof {
+		vp.Context = appendContext(vp.Context, dataintegrity.Context)
+	}
+
+	if len(credentials) == 0 {
+		return vp, nil
+	}
+
+	vcs := make([]interface{}, len(credentials))
+
+	for i, credential := range credentials {
+		vcs[i] = []byte(credential)
+	}
+
+	if err := vp.SetCredentials(vcs...); err != nil {
+		return nil, fmt.Errorf("failed to set presentation credentials: %w", err)
+	}
+
+	return vp, nil
+}
+
+// walletPresentationOpts returns the signing options of the request, the challenge and domain of the presentation
+// request take precedence, the holder proves the control of the DID by default
+func walletPresentationOpts(request *WalletPresentationRequest) *SignPresentationOptions {
+	opts := &SignPresentationOptions{}
+
+	if request.Opts != nil {
+		*opts = *request.Opts
+	}
+
+	if pr := request.PresentationRequest; pr != nil {
+		if pr.Challenge != "" {
+			opts.Challenge = pr.Challenge
+		}
+
+		if pr.Domain != "" {
+			opts.Domain = pr.Domain
+		}
+	}
+
+	if opts.ProofPurpose == "" {
+		opts.ProofPurpose = authentication
+	}
+
+	return opts
+}
@@ -0,0 +1,350 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/presexch"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	cslstatus "github.com/trustbloc/edge-service/pkg/doc/vc/status/csl"
+)
+
+const walletPresentationDefinition = `{
+  "id": "32f54163-7166-48f1-93d8-ff217bdb0653",
+  "input_descriptors": [{
+    "id": "degree",
+    "constraints": {"fields": [{"path": ["$.type"], "filter": {"type": "array",
+      "contains": {"const": "UniversityDegreeCredential"}}}]}
+  }, {
+    "id": "alumni",
+    "constraints": {"fields": [{"path": ["$.type"], "filter": {"type": "array",
+      "contains": {"const": "AlumniCredential"}}}]}
+  }, {
+    "id": "issuer",
+    "constraints": {"fields": [{"path": ["$.issuer"],
+      "filter": {"type": "string", "const": "did:example:76e12ec712ebc6f1c221ebfeb1f"}}]}
+  }]
+}`
+
+func TestWalletPresentation(t *testing.T) {
+	defer setTestContexts(t)()
+
+	edvClient := newMemEDVClient()
+	op := newWalletOperation(t, edvClient)
+
+	require.NoError(t, op.profileStore.SaveHolderProfile(&vcprofile.HolderProfile{
+		Name:          walletHolder,
+		DID:           holderDID,
+		DIDKeyType:    vccrypto.Ed25519KeyType,
+		SignatureType: vccrypto.Ed25519Signature2018,
+		Creator:       holderDID + "#" + base64.RawURLEncoding.EncodeToString([]byte("key-1")),
+	}))
+
+	degree := createWalletCredential("http://example.edu/credentials/1", walletIssuer1, holderDID,
+		"UniversityDegreeCredential")
+	alumni := createWalletCredential("http://example.edu/credentials/2", walletIssuer2, holderDID,
+		"AlumniCredential")
+
+	addWalletCredential(t, op, walletHolder, degree, http.StatusCreated)
+	alumniCredential := addWalletCredential(t, op, walletHolder, alumni, http.StatusCreated)
+
+	t.Run("presentation request", func(t *testing.T) {
+		resp := walletPresentation(t, op, walletHolder, `{"presentationRequest": {
+			"query": [
+				{"type": "DIDAuth"},
+				{"type": "QueryByExample", "credentialQuery": [
+					{"example": {"type": "UniversityDegreeCredential"}, "required": true},
+					{"example": {"type": "PermanentResidentCard"}},
+					{"example": {"credentialSubject": {"id": "`+holderDID+`"}}}
+				]}
+			],
+			"challenge": "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+			"domain": "example.com"
+		}, "options": {"domain": "other.com", "proofPurpose": "assertionMethod"}}`, http.StatusCreated)
+
+		vp := presentationJSON(t, resp)
+		require.Equal(t, holderDID, vp["holder"])
+		// a single credential is presented for each query, the alumni credential isn't disclosed
+		require.Len(t, vp["verifiableCredential"], 1)
+		require.Nil(t, resp.PresentationSubmission)
+
+		proof, ok := vp["proof"].(map[string]interface{})
+		require.True(t, ok)
+		require.Equal(t, "3fa85f64-5717-4562-b3fc-2c963f66afa6", proof["challenge"])
+		require.Equal(t, "example.com", proof["domain"])
+		require.Equal(t, "assertionMethod", proof["proofPurpose"])
+	})
+
+	t.Run("credentials chosen by the holder", func(t *testing.T) {
+		resp := walletPresentation(t, op, walletHolder, `{"presentationRequest": {"query": [
+			{"type": "QueryByExample", "credentialQuery": {"example": {"credentialSubject": {"id": "`+holderDID+`"}}}}
+		]}, "credentialIDs": ["`+alumniCredential.ID+`"]}`, http.StatusCreated)
+
+		vp := presentationJSON(t, resp)
+
+		vcs, ok := vp["verifiableCredential"].([]interface{})
+		require.True(t, ok)
+		require.Len(t, vcs, 1)
+
+		vc, ok := vcs[0].(map[string]interface{})
+		require.True(t, ok)
+		require.Equal(t, "http://example.edu/credentials/2", vc["id"])
+
+		rr := walletPresentationResponse(t, op, walletHolder, `{"presentationRequest": {"query": [
+			{"type": "DIDAuth"}]}, "credentialIDs": ["unknown"]}`)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "wallet credential unknown not found")
+	})
+
+	t.Run("DIDAuth request", func(t *testing.T) {
+		resp := walletPresentation(t, op, walletHolder, `{"presentationRequest": {
+			"query": [{"type": "DIDAuth"}], "challenge": "abc"
+		}, "options": {"domain": "example.com"}}`, http.StatusCreated)
+
+		vp := presentationJSON(t, resp)
+		require.Equal(t, holderDID, vp["holder"])
+		require.Empty(t, vp["verifiableCredential"])
+
+		proof, ok := vp["proof"].(map[string]interface{})
+		require.True(t, ok)
+		require.Equal(t, "abc", proof["challenge"])
+		require.Equal(t, "example.com", proof["domain"])
+		require.Equal(t, authentication, proof["proofPurpose"])
+	})
+
+	t.Run("presentation definition", func(t *testing.T) {
+		resp := walletPresentation(t, op, walletHolder, `{"presentationDefinition": `+
+			walletPresentationDefinition+`, "options": {"challenge": "abc"}}`, http.StatusCreated)
+
+		require.NotNil(t, resp.PresentationSubmission)
+		require.Equal(t, "32f54163-7166-48f1-93d8-ff217bdb0653", resp.PresentationSubmission.DefinitionID)
+		require.Len(t, resp.PresentationSubmission.DescriptorMap, 3)
+		// the degree credential satisfies the degree and the issuer descriptors
+		require.Equal(t, "$.verifiableCredential[0]", resp.PresentationSubmission.DescriptorMap[0].Path)
+		require.Equal(t, "$.verifiableCredential[1]", resp.PresentationSubmission.DescriptorMap[1].Path)
+		require.Equal(t, "$.verifiableCredential[0]", resp.PresentationSubmission.DescriptorMap[2].Path)
+
+		definition := &presexch.PresentationDefinition{}
+		require.NoError(t, json.Unmarshal([]byte(walletPresentationDefinition), definition))
+
+		results, err := definition.EvaluateSubmission(resp.Presentation, resp.PresentationSubmission)
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+
+		vp := presentationJSON(t, resp)
+		require.Equal(t, holderDID, vp["holder"])
+		require.Len(t, vp["verifiableCredential"], 2)
+	})
+
+	t.Run("unsatisfied requests", func(t *testing.T) {
+		rr := walletPresentationResponse(t, op, walletHolder, `{"presentationRequest": {"query": [
+			{"type": "QueryByExample", "credentialQuery": {"reason": "residence", "required": true,
+				"example": {"type": "PermanentResidentCard"}}}
+		]}}`)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(),
+			"no wallet credential satisfies the required credential query: residence")
+
+		rr = walletPresentationResponse(t, op, walletHolder, `{"presentationDefinition": {"id": "def",
+			"input_descriptors": [{"id": "prc", "constraints": {"fields": [{"path": ["$.type"],
+				"filter": {"type": "array", "contains": {"const": "PermanentResidentCard"}}}]}}]}}`)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "no wallet credential satisfies input descriptor prc")
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			request string
+			err     string
+		}{
+			{name: "invalid json", request: `{`, err: invalidRequestErrMsg},
+			{name: "empty request", request: `{}`, err: "presentation request or presentation definition is required"},
+			{name: "both requests", request: `{"presentationRequest": {"query": [{"type": "DIDAuth"}]},
+				"presentationDefinition": ` + walletPresentationDefinition + `}`,
+				err: "either presentation request or presentation definition must be provided, not both"},
+			{name: "invalid presentation request", request: `{"presentationRequest": {"query": [{"type": "x"}]}}`,
+				err: "unsupported query type: x"},
+			{name: "invalid presentation definition", request: `{"presentationDefinition": {"id": "def"}}`,
+				err: "presentation definition must have input descriptors"},
+		}
+
+		for _, tc := range tests {
+			rr := walletPresentationResponse(t, op, walletHolder, tc.request)
+			require.Equal(t, http.StatusBadRequest, rr.Code, tc.name)
+			require.Contains(t, rr.Body.String(), tc.err, tc.name)
+		}
+	})
+
+	t.Run("profile not found", func(t *testing.T) {
+		rr := walletPresentationResponse(t, op, "other", `{"presentationRequest": {"query": [{"type": "DIDAuth"}]}}`)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), "holder profile other not found")
+	})
+
+	t.Run("sign presentation error", func(t *testing.T) {
+		require.NoError(t, op.profileStore.SaveHolderProfile(&vcprofile.HolderProfile{
+			Name:          "unsupported",
+			DID:           holderDID,
+			SignatureType: "invalid",
+			Creator:       holderDID + "#" + base64.RawURLEncoding.EncodeToString([]byte("key-1")),
+		}))
+
+		rr := walletPresentationResponse(t, op, "unsupported",
+			`{"presentationRequest": {"query": [{"type": "DIDAuth"}]}}`)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "failed to sign presentation")
+	})
+
+	t.Run("wallet query error", func(t *testing.T) {
+		edvClient.err = errors.New("query error")
+		defer func() { edvClient.err = nil }()
+
+		rr := walletPresentationResponse(t, op, walletHolder,
+			`{"presentationRequest": {"query": [{"type": "DIDAuth"}]}}`)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "failed to query wallet")
+	})
+}
+
+func TestWalletPresentation_UnusableCredentials(t *testing.T) {
+	defer setTestContexts(t)()
+
+	op := newWalletOperation(t, newMemEDVClient())
+
+	require.NoError(t, op.profileStore.SaveHolderProfile(&vcprofile.HolderProfile{
+		Name:          walletHolder,
+		DID:           holderDID,
+		DIDKeyType:    vccrypto.Ed25519KeyType,
+		SignatureType: vccrypto.Ed25519Signature2018,
+		Creator:       holderDID + "#" + base64.RawURLEncoding.EncodeToString([]byte("key-1")),
+	}))
+
+	expired := walletCredentialWith(t, "http://example.edu/credentials/expired",
+		"expirationDate", "2021-03-16T22:37:26.544Z")
+	revoked := walletCredentialWith(t, "http://example.edu/credentials/revoked",
+		"credentialStatus", map[string]string{"id": "http://example.com/status/1", "type": "CredentialStatusList2017"})
+
+	addWalletCredential(t, op, walletHolder, expired, http.StatusCreated)
+	addWalletCredential(t, op, walletHolder, revoked, http.StatusCreated)
+
+	cslBytes, err := json.Marshal(&cslstatus.CSL{ID: "http://example.com/status/1",
+		VC: []string{`{"id": "http://example.edu/credentials/revoked"}`}})
+	require.NoError(t, err)
+
+	statusRequests := 0
+	op.httpClient = &mockHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+		statusRequests++
+
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(cslBytes))}, nil
+	}}
+
+	const request = `{"presentationRequest": {"query": [{"type": "QueryByExample",
+		"credentialQuery": {"reason": "degree", "required": true, "example": {"type": "UniversityDegreeCredential"}}}
+	]}}`
+
+	t.Run("expired and revoked credentials are skipped", func(t *testing.T) {
+		rr := walletPresentationResponse(t, op, walletHolder, request)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "no wallet credential satisfies the required credential query: degree")
+		require.Equal(t, 1, statusRequests)
+
+		rr = walletPresentationResponse(t, op, walletHolder, `{"presentationDefinition": {"id": "def",
+			"input_descriptors": [{"id": "degree", "constraints": {"fields": [{"path": ["$.type"],
+				"filter": {"type": "array", "contains": {"const": "UniversityDegreeCredential"}}}]}}]}}`)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "no wallet credential satisfies input descriptor degree")
+	})
+
+	t.Run("valid credential is presented", func(t *testing.T) {
+		valid := createWalletCredential("http://example.edu/credentials/valid", walletIssuer1, holderDID,
+			"UniversityDegreeCredential")
+		addWalletCredential(t, op, walletHolder, valid, http.StatusCreated)
+
+		resp := walletPresentation(t, op, walletHolder, request, http.StatusCreated)
+
+		vp := presentationJSON(t, resp)
+
+		vcs, ok := vp["verifiableCredential"].([]interface{})
+		require.True(t, ok)
+		require.Len(t, vcs, 1)
+
+		vc, ok := vcs[0].(map[string]interface{})
+		require.True(t, ok)
+		require.Equal(t, "http://example.edu/credentials/valid", vc["id"])
+	})
+
+	t.Run("status check error", func(t *testing.T) {
+		op.httpClient = &mockHTTPClient{doErr: errors.New("connection refused")}
+
+		rr := walletPresentationResponse(t, op, walletHolder, request)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "failed to check wallet credential status")
+	})
+}
+
+// walletCredentialWith returns the degree wallet credential with the additional field
+func walletCredentialWith(t *testing.T, id, field string, value interface{}) []byte {
+	t.Helper()
+
+	vc := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(createWalletCredential(id, walletIssuer1, holderDID,
+		"UniversityDegreeCredential"), &vc))
+
+	vc[field] = value
+
+	vcBytes, err := json.Marshal(vc)
+	require.NoError(t, err)
+
+	return vcBytes
+}
+
+func walletPresentationResponse(t *testing.T, op *Operation, profile, request string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	return serveHTTPMux(t, getMethodHandler(t, op, walletPresentationsEndpoint, http.MethodPost, holderMode),
+		"/"+profile+"/wallet/presentations", []byte(request), map[string]string{profileIDPathParam: profile})
+}
+
+// walletPresentationResp is the wallet presentation response with the raw presentation
+type walletPresentationResp struct {
+	Presentation           json.RawMessage                  `json:"verifiablePresentation"`
+	PresentationSubmission *presexch.PresentationSubmission `json:"presentationSubmission"`
+}
+
+func walletPresentation(t *testing.T, op *Operation, profile, request string,
+	status int) *walletPresentationResp {
+	t.Helper()
+
+	rr := walletPresentationResponse(t, op, profile, request)
+	require.Equal(t, status, rr.Code, rr.Body.String())
+
+	resp := &walletPresentationResp{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+
+	return resp
+}
+
+func presentationJSON(t *testing.T, resp *walletPresentationResp) map[string]interface{} {
+	t.Helper()
+
+	vp := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(resp.Presentation, &vp))
+
+	return vp
+}
@@ -0,0 +1,285 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+
+	"github.com/trustbloc/edge-service/pkg/doc/jsonld"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/datamodel"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	cslstatus "github.com/trustbloc/edge-service/pkg/doc/vc/status/csl"
+)
+
+const (
+	refreshServiceType = "VerifiableCredentialRefreshService2021"
+	didAuthQueryType   = "DIDAuth"
+)
+
+// CredentialRefreshRequest swagger:route GET /{id}/credentials/refresh issuer credentialRefreshReq
+//
+// Issues the DID authentication request to be answered by the holder refreshing the credential,
+// the challenge of the request is accepted once.
+//
+// Responses:
+//    default: genericError
+//        200: didAuthRes
+func (o *Operation) credentialRefreshRequestHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.refreshProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	// the challenge is scoped to the refresh endpoint so it isn't accepted by the other DID authentication flows
+	c, err := o.challenges.Issue(profile.Name, o.refreshServiceURL(profile))
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, &DIDAuthRequest{
+		Query:     []*DIDAuthQuery{{Type: didAuthQueryType}},
+		Challenge: c.Value,
+		Domain:    c.Domain,
+	})
+}
+
+// RefreshCredential swagger:route POST /{id}/credentials/refresh issuer refreshCredentialReq
+//
+// Reissues the credential presented by its subject with the validity period starting now, the credential
+// keeps its status list entry.
+//
+// Responses:
+//    default: genericError
+//        201: verifiableCredentialRes
+func (o *Operation) refreshCredentialHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.refreshProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	request := &RefreshCredentialRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	credential, status, err := o.credentialToRefresh(profile, request.Presentation)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("failed to refresh credential: %s", err.Error()))
+
+		return
+	}
+
+	if err := refreshValidity(credential); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("failed to refresh credential: %s", err.Error()))
+
+		return
+	}
+
+	signedVC, err := o.crypto.SignCredential(profile, credential)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to sign credential:"+
+			" %s", err.Error()))
+
+		return
+	}
+
+	// record the reissued credential
+	if err := o.recordIssued(profile, &issuedCredential{credential: signedVC, status: status}); err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	o.writeResponse(rw, signedVC)
+}
+
+// refreshProfile returns the issuer profile of the request if it supports the credential refresh
+func (o *Operation) refreshProfile(rw http.ResponseWriter, req *http.Request) (*vcprofile.DataProfile, bool) {
+	profileID := mux.Vars(req)[profileIDPathParam]
+
+	profile, err := o.profileStore.GetProfile(profileID)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid issuer profile - id=%s: err=%s",
+			profileID, err.Error()))
+
+		return nil, false
+	}
+
+	if !profile.RefreshService {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("profile %s doesn't support credential refresh",
+			profileID))
+
+		return nil, false
+	}
+
+	return profile, true
+}
+
+// credentialToRefresh verifies the DID authentication presentation of the credential and returns the credential
+// (without the proof) with the status allocated for it, the credential must be issued by the profile to the
+// presenter and its status must not be updated. The claims presented by the holder are signed again so the
+// credential must carry the verified proof of the profile DID.
+func (o *Operation) credentialToRefresh(profile *vcprofile.DataProfile,
+	vpBytes []byte) (*verifiable.Credential, *cslstatus.StatusID, error) {
+	vp, err := o.parseAndVerifyVP(vpBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid presentation: %w", err)
+	}
+
+	if len(vp.Proofs) == 0 {
+		return nil, nil, errors.New("presentation must be signed by the credential subject")
+	}
+
+	// the challenge is issued by the refresh request and is accepted only once to prevent presentation replay
+	if err := o.useDIDAuthChallenge(vp, profile, o.refreshServiceURL(profile)); err != nil {
+		return nil, nil, err
+	}
+
+	presenter, err := presenterDID(vp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(vp.Credentials()) != 1 {
+		return nil, nil, errors.New("presentation must have the credential to refresh only")
+	}
+
+	vcBytes, err := credentialBytes(vp.Credentials()[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vc, err := o.parseAndVerifyVC(vcBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid credential: %w", err)
+	}
+
+	if err := checkSignedBy(vc, profile.DID); err != nil {
+		return nil, nil, err
+	}
+
+	if !containsString(subjectIDs(vc.Subject), presenter) {
+		return nil, nil, fmt.Errorf("presenter %s is not the credential subject", presenter)
+	}
+
+	record, err := o.issuanceRegistry.GetProfileRecord(profile.Name, vc.ID)
+	if err != nil || vc.Issuer.ID != record.Issuer {
+		return nil, nil, fmt.Errorf("credential %s isn't issued by the profile", vc.ID)
+	}
+
+	vc.Proofs = nil
+
+	if record.Status == nil {
+		return vc, nil, nil
+	}
+
+	if err := o.checkStatusNotUpdated(record.Status.ListID, vc.ID); err != nil {
+		return nil, nil, err
+	}
+
+	vc.Status = &verifiable.TypedID{ID: record.Status.ListID, Type: cslstatus.CredentialStatusType}
+
+	return vc, &cslstatus.StatusID{Status: vc.Status, Index: record.Status.Index}, nil
+}
+
+// checkSignedBy checks the credential has the proofs and all of them are made with the keys of the DID,
+// the proofs are verified when the credential is parsed
+func checkSignedBy(vc *verifiable.Credential, did string) error {
+	if len(vc.Proofs) == 0 {
+		return fmt.Errorf("credential %s has no proof", vc.ID)
+	}
+
+	for _, proof := range vc.Proofs {
+		method, ok := proof["verificationMethod"].(string)
+		if !ok {
+			method, ok = proof["creator"].(string)
+		}
+
+		if !ok || !strings.HasPrefix(method, did+"#") {
+			return fmt.Errorf("credential %s isn't signed by the profile", vc.ID)
+		}
+	}
+
+	return nil
+}
+
+// checkStatusNotUpdated checks the status list of the service has no status update (e.g. revocation)
+// of the credential
+func (o *Operation) checkStatusNotUpdated(listID, vcID string) error {
+	csl, err := o.vcStatusManager.GetCSL(listID)
+	if err != nil {
+		return fmt.Errorf("failed to get credential status: %w", err)
+	}
+
+	if csl.HasEntry(vcID) {
+		return fmt.Errorf("credential %s status is updated", vcID)
+	}
+
+	return nil
+}
+
+// refreshValidity moves the credential validity period to start now, the length of the period is kept
+func refreshValidity(vc *verifiable.Credential) error {
+	validFrom, err := datamodel.ValidFrom(vc)
+	if err != nil {
+		return err
+	}
+
+	validUntil, err := datamodel.ValidUntil(vc)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	if validUntil != nil {
+		if validFrom == nil {
+			return errors.New("credential validity period has no start")
+		}
+
+		until := now.Add(validUntil.Sub(*validFrom))
+		validUntil = &until
+	}
+
+	datamodel.SetValidity(vc, &now, validUntil)
+
+	return nil
+}
+
+// addRefreshService adds the refresh service of the profile (and the context defining its type) to the credential,
+// credentials without an ID can't be looked up when refreshed and don't get one
+func (o *Operation) addRefreshService(credential *verifiable.Credential, profile *vcprofile.DataProfile) {
+	if !profile.RefreshService || credential.ID == "" {
+		return
+	}
+
+	credential.Context = appendContext(credential.Context, jsonld.RefreshServiceV1Context)
+	credential.RefreshService = []verifiable.TypedID{{
+		ID:   o.refreshServiceURL(profile),
+		Type: refreshServiceType,
+	}}
+}
+
+// refreshServiceURL returns the URL of the refresh endpoint of the profile
+func (o *Operation) refreshServiceURL(profile *vcprofile.DataProfile) string {
+	return o.HostURL + strings.Replace(credentialRefreshPath, "{"+profileIDPathParam+"}", profile.Name, 1)
+}
@@ -0,0 +1,343 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/composite/ecdhes"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/jsonwebsignature2020"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
+	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage/memstore"
+
+	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/datamodel"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
+)
+
+const holderDID = "did:example:ebfeb1f712ebc6f1c276e12ec21"
+
+// ldpDocument is the credential or presentation signed with the linked data proof
+type ldpDocument interface {
+	AddLinkedDataProof(context *verifiable.LinkedDataProofContext) error
+}
+
+func TestCredentialRefresh(t *testing.T) {
+	defer setTestContexts(t)()
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI: &vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+			return createJWKDIDDoc(t, didID, pubKey), nil
+		}},
+		Crypto:  &cryptomock.Crypto{},
+		HostURL: "http://example.com",
+	})
+	require.NoError(t, err)
+
+	profile := getTestProfile()
+	profile.SignatureRepresentation = verifiable.SignatureJWS
+	profile.SignatureType = vccrypto.JSONWebSignature2020
+	profile.RefreshService = true
+
+	require.NoError(t, op.profileStore.SaveProfile(profile))
+
+	refreshEndpoint := "/test/credentials/refresh"
+	urlVars := map[string]string{profileIDPathParam: profile.Name}
+
+	didAuthRequest := func(t *testing.T) *DIDAuthRequest {
+		t.Helper()
+
+		rr := serveHTTPMux(t, getHandler(t, op, credentialRefreshPath, issuerMode), refreshEndpoint, nil, urlVars)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		request := &DIDAuthRequest{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), request))
+
+		return request
+	}
+
+	refresh := func(t *testing.T, vp []byte) *httptest.ResponseRecorder {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(&RefreshCredentialRequest{Presentation: vp})
+		require.NoError(t, err)
+
+		return serveHTTPMux(t, getMethodHandler(t, op, credentialRefreshPath, http.MethodPost, issuerMode),
+			refreshEndpoint, reqBytes, urlVars)
+	}
+
+	// the credential issued by the profile expired an hour ago
+	issued := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Second)
+	expired := issued.Add(time.Hour)
+
+	credential := &verifiable.Credential{
+		Context: []string{datamodel.ContextV1},
+		ID:      "http://example.edu/credentials/1872",
+		Types:   []string{"VerifiableCredential"},
+		Issuer:  verifiable.Issuer{ID: profile.DID},
+		Issued:  &issued,
+		Expired: &expired,
+		Subject: map[string]interface{}{"id": holderDID},
+	}
+
+	statuses, err := op.addCredentialStatus(credential)
+	require.NoError(t, err)
+
+	updateContext(credential, profile)
+	op.addRefreshService(credential, profile)
+
+	require.Equal(t, []verifiable.TypedID{{
+		ID:   "http://example.com/test/credentials/refresh",
+		Type: refreshServiceType,
+	}}, credential.RefreshService)
+
+	signLDP(t, credential, privKey, profile.DID+"#key-1", "", "")
+	require.NoError(t, op.recordIssued(profile, &issuedCredential{credential: credential, status: statuses[0]}))
+
+	presentCredential := func(t *testing.T, vc *verifiable.Credential, signer string, request *DIDAuthRequest) []byte {
+		t.Helper()
+
+		vp, err := vc.Presentation()
+		require.NoError(t, err)
+
+		vp.Holder = signer
+
+		signLDP(t, vp, privKey, signer+"#key-1", request.Challenge, request.Domain)
+
+		vpBytes, err := vp.MarshalJSON()
+		require.NoError(t, err)
+
+		return vpBytes
+	}
+
+	presentation := func(t *testing.T, signer string, request *DIDAuthRequest) []byte {
+		t.Helper()
+
+		return presentCredential(t, credential, signer, request)
+	}
+
+	t.Run("refresh credential", func(t *testing.T) {
+		request := didAuthRequest(t)
+		require.Equal(t, []*DIDAuthQuery{{Type: "DIDAuth"}}, request.Query)
+		require.NotEmpty(t, request.Challenge)
+		require.Equal(t, "http://example.com/test/credentials/refresh", request.Domain)
+
+		vp := presentation(t, holderDID, request)
+
+		rr := refresh(t, vp)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		refreshed, err := verifiable.NewUnverifiedCredential(rr.Body.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, credential.ID, refreshed.ID)
+		require.Equal(t, credential.Status.ID, refreshed.Status.ID)
+		require.Len(t, refreshed.RefreshService, 1)
+		require.Equal(t, credential.RefreshService[0].ID, refreshed.RefreshService[0].ID)
+		require.True(t, refreshed.Issued.After(issued))
+		require.Equal(t, time.Hour, refreshed.Expired.Sub(*refreshed.Issued))
+		require.Len(t, refreshed.Proofs, 1)
+
+		record, err := op.issuanceRegistry.GetProfileRecord(profile.Name, credential.ID)
+		require.NoError(t, err)
+		require.True(t, record.Issued.Equal(*refreshed.Issued))
+		require.Equal(t, statuses[0].Index, record.Status.Index)
+
+		// the challenge is accepted once
+		rr = refresh(t, vp)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid challenge")
+	})
+
+	t.Run("challenge of another endpoint", func(t *testing.T) {
+		// the challenge issued by the credential application request of the profile
+		c, err := op.challenges.Issue(profile.Name, op.oidc4vciURL(credentialApplicationsPath, profile))
+		require.NoError(t, err)
+
+		rr := refresh(t, presentation(t, holderDID, &DIDAuthRequest{Challenge: c.Value, Domain: c.Domain}))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid challenge: presentation is signed for domain "+
+			"http://example.com/test/credentials/applications instead of http://example.com/test/credentials/refresh")
+
+		rr = refresh(t, presentation(t, holderDID, &DIDAuthRequest{Challenge: c.Value,
+			Domain: "http://example.com/test/credentials/refresh"}))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid challenge: challenge "+c.Value+" is issued for another domain")
+	})
+
+	t.Run("presenter is not the subject", func(t *testing.T) {
+		rr := refresh(t, presentation(t, "did:example:other", didAuthRequest(t)))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "presenter did:example:other is not the credential subject")
+	})
+
+	t.Run("tampered claims", func(t *testing.T) {
+		tampered := *credential
+		tampered.Subject = map[string]interface{}{"id": holderDID, "degree": "PhD"}
+
+		// the proof of the profile doesn't match the claims
+		rr := refresh(t, presentCredential(t, &tampered, holderDID, didAuthRequest(t)))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid presentation")
+
+		// the claims are signed by the holder instead of the profile
+		tampered.Proofs = nil
+		signLDP(t, &tampered, privKey, holderDID+"#key-1", "", "")
+
+		rr = refresh(t, presentCredential(t, &tampered, holderDID, didAuthRequest(t)))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "credential "+credential.ID+" isn't signed by the profile")
+
+		// the claims aren't signed
+		tampered.Proofs = nil
+
+		rr = refresh(t, presentCredential(t, &tampered, holderDID, didAuthRequest(t)))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "credential "+credential.ID+" has no proof")
+	})
+
+	t.Run("invalid presentation", func(t *testing.T) {
+		rr := refresh(t, []byte(`{}`))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid presentation")
+
+		rr = serveHTTPMux(t, getMethodHandler(t, op, credentialRefreshPath, http.MethodPost, issuerMode),
+			refreshEndpoint, []byte("invalid"), urlVars)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), invalidRequestErrMsg)
+	})
+
+	t.Run("credential status is updated", func(t *testing.T) {
+		// the status of the credential with the ID prefixed by the ID of the credential doesn't match
+		other := *credential
+		other.ID = credential.ID + "0"
+		other.Proofs = nil
+
+		require.NoError(t, op.vcStatusManager.UpdateVCStatus(&other, profile, "revoked", "disciplinary action"))
+
+		rr := refresh(t, presentation(t, holderDID, didAuthRequest(t)))
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		// the status credential is signed with the profile defaults, not the options of the test proof
+		revoked := *credential
+		revoked.Proofs = nil
+
+		require.NoError(t, op.vcStatusManager.UpdateVCStatus(&revoked, profile, "revoked", "disciplinary action"))
+
+		rr = refresh(t, presentation(t, holderDID, didAuthRequest(t)))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "credential "+credential.ID+" status is updated")
+	})
+
+	t.Run("profile without refresh service", func(t *testing.T) {
+		other := getTestProfile()
+		other.Name = "other"
+
+		require.NoError(t, op.profileStore.SaveProfile(other))
+
+		rr := serveHTTPMux(t, getHandler(t, op, credentialRefreshPath, issuerMode), "/other/credentials/refresh",
+			nil, map[string]string{profileIDPathParam: other.Name})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "profile other doesn't support credential refresh")
+
+		rr = serveHTTPMux(t, getHandler(t, op, credentialRefreshPath, issuerMode), "/invalid/credentials/refresh",
+			nil, map[string]string{profileIDPathParam: "invalid"})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid issuer profile")
+	})
+}
+
+func TestRefreshValidity(t *testing.T) {
+	validFrom := time.Now().UTC().Add(-2 * time.Hour)
+	validUntil := validFrom.Add(time.Hour)
+
+	vc := &verifiable.Credential{Context: []string{datamodel.ContextV1}, Issued: &validFrom, Expired: &validUntil}
+
+	require.NoError(t, refreshValidity(vc))
+	require.True(t, vc.Issued.After(validFrom))
+	require.Equal(t, time.Hour, vc.Expired.Sub(*vc.Issued))
+
+	vc = &verifiable.Credential{Context: []string{datamodel.ContextV1}, Issued: &validFrom}
+
+	require.NoError(t, refreshValidity(vc))
+	require.True(t, vc.Issued.After(validFrom))
+	require.Nil(t, vc.Expired)
+
+	vc = &verifiable.Credential{Context: []string{datamodel.ContextV2}, CustomFields: verifiable.CustomFields{
+		"validFrom":  validFrom.Format(time.RFC3339),
+		"validUntil": validUntil.Format(time.RFC3339),
+	}}
+
+	require.NoError(t, refreshValidity(vc))
+
+	from, err := datamodel.ValidFrom(vc)
+	require.NoError(t, err)
+	require.True(t, from.After(validFrom))
+
+	until, err := datamodel.ValidUntil(vc)
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, until.Sub(*from))
+
+	vc = &verifiable.Credential{Context: []string{datamodel.ContextV1}, Expired: &validUntil}
+
+	err = refreshValidity(vc)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "credential validity period has no start")
+}
+
+// createJWKDIDDoc returns the DID document with the Ed25519 public key as JWK, the JsonWebSignature2020 proofs
+// are verified with the JWK keys only
+func createJWKDIDDoc(t *testing.T, didID string, pubKey []byte) *did.Doc {
+	t.Helper()
+
+	jwk := &jose.JWK{}
+	require.NoError(t, jwk.UnmarshalJSON([]byte(`{"kty":"OKP","crv":"Ed25519","x":"`+
+		base64.RawURLEncoding.EncodeToString(pubKey)+`"}`)))
+
+	publicKey, err := did.NewPublicKeyFromJWK(didID+"#key-1", "JwsVerificationKey2020", didID, jwk)
+	require.NoError(t, err)
+
+	return &did.Doc{Context: []string{"https://w3id.org/did/v1"}, ID: didID, PublicKey: []did.PublicKey{*publicKey}}
+}
+
+// signLDP adds the JsonWebSignature2020 proof to the credential or presentation
+func signLDP(t *testing.T, doc ldpDocument, privKey []byte, verificationMethod, challenge, domain string) {
+	t.Helper()
+
+	require.NoError(t, doc.AddLinkedDataProof(&verifiable.LinkedDataProofContext{
+		SignatureType:           vccrypto.JSONWebSignature2020,
+		Suite:                   jsonwebsignature2020.New(suite.WithSigner(getEd25519TestSigner(privKey))),
+		SignatureRepresentation: verifiable.SignatureJWS,
+		VerificationMethod:      verificationMethod,
+		Challenge:               challenge,
+		Domain:                  domain,
+	}))
+}
@@ -0,0 +1,315 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+
+	"github.com/trustbloc/edge-service/pkg/doc/vc/datamodel"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+)
+
+const (
+	revokedStatus    = "revoked"
+	supersededStatus = "superseded"
+	replacesField    = "replaces"
+)
+
+// replacesContext is the inline context defining the replaces property referencing the predecessor
+// of the reissued credential
+var replacesContext = map[string]interface{}{ // nolint: gochecknoglobals
+	replacesField: map[string]interface{}{
+		"@id":   "http://purl.org/dc/terms/replaces",
+		"@type": "@id",
+	},
+}
+
+// ReissueCredential swagger:route POST /{id}/credentials/reissue issuer reissueCredentialReq
+//
+// Reissues the credential issued by the profile with the updated claims or expiration date, the replacement
+// references its predecessor and the predecessor gets the revoked or superseded status.
+//
+// Responses:
+//    default: genericError
+//        201: verifiableCredentialRes
+func (o *Operation) reissueCredentialHandler(rw http.ResponseWriter, req *http.Request) {
+	profileID := mux.Vars(req)[profileIDPathParam]
+
+	profile, err := o.profileStore.GetProfile(profileID)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid issuer profile - id=%s: err=%s",
+			profileID, err.Error()))
+
+		return
+	}
+
+	if profile.DisableVCStatus {
+		o.writeErrorResponse(rw, http.StatusBadRequest,
+			fmt.Sprintf("vc status is disabled for profile %s", profile.Name))
+
+		return
+	}
+
+	request := &ReissueCredentialRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	if request.Status == "" {
+		request.Status = supersededStatus
+	}
+
+	if request.Status != revokedStatus && request.Status != supersededStatus {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid status %s: the replaced credential"+
+			" is either %s or %s", request.Status, revokedStatus, supersededStatus))
+
+		return
+	}
+
+	// the status of the replaced credential is checked and updated by one reissue at a time, the mutex is
+	// local to the instance so the reissues of the same credential must be served by a single instance
+	o.reissueMutex.Lock()
+	defer o.reissueMutex.Unlock()
+
+	replaced, err := o.credentialToReissue(profile, request)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("failed to reissue credential: %s", err.Error()))
+
+		return
+	}
+
+	o.reissue(rw, profile, request, replaced)
+}
+
+// reissue signs the replacement of the credential, records the replacement and updates the status
+// of the replaced credential
+func (o *Operation) reissue(rw http.ResponseWriter, profile *vcprofile.DataProfile,
+	request *ReissueCredentialRequest, replaced *verifiable.Credential) {
+	credential, err := o.replacement(profile, request, replaced)
+	if err != nil {
+		o.writeErrorResponse(rw, credentialIDErrorStatus(err), fmt.Sprintf("failed to reissue credential: %s",
+			err.Error()))
+
+		return
+	}
+
+	statuses, err := o.addCredentialStatus(credential)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to add credential status:"+
+			" %s", err.Error()))
+
+		return
+	}
+
+	updateContext(credential, profile)
+	updateIssuer(credential, profile)
+	o.addRefreshService(credential, profile)
+
+	signedVC, err := o.crypto.SignCredential(profile, credential)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to sign credential:"+
+			" %s", err.Error()))
+
+		return
+	}
+
+	// the replacement is recorded before the status of the replaced credential is updated, a failed update
+	// revokes the replacement and unlinks it from the replaced credential
+	if err := o.recordReissued(profile, replaced.ID, &issuedCredential{credential: signedVC,
+		status: statuses[0]}); err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	if err := o.updateReplacedStatus(profile, request, replaced.ID); err != nil {
+		msg := fmt.Sprintf("failed to update vc status: %s", err.Error())
+
+		if undoErr := o.undoReissued(profile, replaced.ID, signedVC); undoErr != nil {
+			msg += fmt.Sprintf(" (failed to revoke the replacement %s: %s)", signedVC.ID, undoErr.Error())
+		}
+
+		o.writeErrorResponse(rw, http.StatusInternalServerError, msg)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	o.writeResponse(rw, signedVC)
+}
+
+// credentialToReissue returns the credential of the request (or rebuilds the credential with the ID
+// from its issuance record), the credential must be issued by the profile and its status must not be updated
+func (o *Operation) credentialToReissue(profile *vcprofile.DataProfile,
+	request *ReissueCredentialRequest) (*verifiable.Credential, error) {
+	var (
+		vc  *verifiable.Credential
+		err error
+	)
+
+	switch {
+	case len(request.Credential) > 0:
+		vc, err = o.parseAndVerifyVC(request.Credential)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credential: %w", err)
+		}
+	case request.CredentialID != "":
+		if request.Subject == nil {
+			return nil, errors.New("credentialSubject is required to reissue the credential looked up by ID")
+		}
+
+		vc, err = o.issuedCredentialByID(profile.Name, request.CredentialID)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("credential or credentialID is required")
+	}
+
+	record, err := o.issuanceRegistry.GetProfileRecord(profile.Name, vc.ID)
+	if err != nil || vc.Issuer.ID != record.Issuer {
+		return nil, fmt.Errorf("credential %s isn't issued by the profile", vc.ID)
+	}
+
+	if record.Status == nil {
+		return nil, fmt.Errorf("credential %s has no status", vc.ID)
+	}
+
+	if record.ReplacedBy != "" {
+		return nil, fmt.Errorf("credential %s is replaced by %s", vc.ID, record.ReplacedBy)
+	}
+
+	if err := o.checkStatusNotUpdated(record.Status.ListID, vc.ID); err != nil {
+		return nil, err
+	}
+
+	return vc, nil
+}
+
+// replacement returns the replacement of the credential (without the proof and status) referencing its
+// predecessor, the replacement is valid from now
+func (o *Operation) replacement(profile *vcprofile.DataProfile, request *ReissueCredentialRequest,
+	replaced *verifiable.Credential) (*verifiable.Credential, error) {
+	credential := *replaced
+	credential.Proofs = nil
+	credential.Status = nil
+	credential.ID = request.ReplacementID
+
+	if err := o.assignCredentialID(profile, &credential); err != nil {
+		return nil, err
+	}
+
+	if credential.ID == "" {
+		return nil, errors.New("replacement ID is required by the profile")
+	}
+
+	if request.Subject != nil {
+		credential.Subject = request.Subject
+	}
+
+	validUntil, err := datamodel.ValidUntil(replaced)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.ExpirationDate != nil {
+		validUntil = request.ExpirationDate
+	}
+
+	now := time.Now().UTC()
+
+	datamodel.SetValidity(&credential, &now, validUntil)
+
+	credential.CustomContext = appendCustomContext(replaced.CustomContext, replacesContext)
+	credential.CustomFields = make(verifiable.CustomFields, len(replaced.CustomFields)+1)
+
+	for k, v := range replaced.CustomFields {
+		credential.CustomFields[k] = v
+	}
+
+	credential.CustomFields[replacesField] = replaced.ID
+
+	return &credential, nil
+}
+
+// recordReissued records the replacement and links the record of the replaced credential to it
+func (o *Operation) recordReissued(profile *vcprofile.DataProfile, replacedID string, c *issuedCredential) error {
+	record, err := newIssuedRecord(profile, c)
+	if err != nil {
+		return fmt.Errorf("failed to record issued credential: %w", err)
+	}
+
+	replaced, err := o.issuanceRegistry.GetProfileRecord(profile.Name, replacedID)
+	if err != nil {
+		return fmt.Errorf("failed to record issued credential: %w", err)
+	}
+
+	replaced.ReplacedBy = record.ID
+
+	if err := o.issuanceRegistry.SaveRecords(replaced, record); err != nil {
+		return fmt.Errorf("failed to record issued credential: %w", err)
+	}
+
+	return nil
+}
+
+// updateReplacedStatus updates the status of the replaced credential as requested
+func (o *Operation) updateReplacedStatus(profile *vcprofile.DataProfile, request *ReissueCredentialRequest,
+	replacedID string) error {
+	replaced, err := o.issuedCredentialByID(profile.Name, replacedID)
+	if err != nil {
+		return err
+	}
+
+	return o.vcStatusManager.UpdateVCStatus(replaced, profile, request.Status, request.StatusReason)
+}
+
+// undoReissued unlinks the replacement from the record of the replaced credential and revokes
+// the replacement, the replaced credential can be reissued again
+func (o *Operation) undoReissued(profile *vcprofile.DataProfile, replacedID string,
+	replacement *verifiable.Credential) error {
+	replaced, err := o.issuanceRegistry.GetProfileRecord(profile.Name, replacedID)
+	if err != nil {
+		return err
+	}
+
+	replaced.ReplacedBy = ""
+
+	if err := o.issuanceRegistry.SaveRecords(replaced); err != nil {
+		return err
+	}
+
+	return o.vcStatusManager.UpdateVCStatus(replacement, profile, revokedStatus, "reissue failed")
+}
+
+func appendCustomContext(context []interface{}, ctx interface{}) []interface{} {
+	for _, c := range context {
+		if reflect.DeepEqual(c, ctx) {
+			return context
+		}
+	}
+
+	return append(append([]interface{}{}, context...), ctx)
+}
+
+// replacesID returns the ID of the credential replaced by the reissued credential
+func replacesID(credential *verifiable.Credential) string {
+	id, _ := credential.CustomFields[replacesField].(string) // nolint: errcheck
+
+	return id
+}
@@ -0,0 +1,292 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/composite/ecdhes"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
+	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage/memstore"
+
+	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/datamodel"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
+)
+
+func TestReissueCredential(t *testing.T) {
+	defer setTestContexts(t)()
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		VDRI: &vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdri.ResolveOpts) (*did.Doc, error) {
+			return createJWKDIDDoc(t, didID, pubKey), nil
+		}},
+		Crypto:  &cryptomock.Crypto{},
+		HostURL: "http://example.com",
+	})
+	require.NoError(t, err)
+
+	profile := getTestProfile()
+	profile.SignatureRepresentation = verifiable.SignatureJWS
+	profile.SignatureType = vccrypto.JSONWebSignature2020
+	profile.CredentialIDPolicy = vcprofile.CredentialIDUUID
+
+	require.NoError(t, op.profileStore.SaveProfile(profile))
+
+	reissueEndpoint := "/test/credentials/reissue"
+	urlVars := map[string]string{profileIDPathParam: profile.Name}
+
+	reissue := func(t *testing.T, request *ReissueCredentialRequest) *httptest.ResponseRecorder {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		return serveHTTPMux(t, getHandler(t, op, credentialReissuePath, issuerMode), reissueEndpoint, reqBytes,
+			urlVars)
+	}
+
+	issue := func(t *testing.T, id string) *verifiable.Credential {
+		t.Helper()
+
+		issued := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+		expires := issued.Add(24 * time.Hour)
+
+		credential := &verifiable.Credential{
+			Context: []string{datamodel.ContextV1},
+			ID:      id,
+			Types:   []string{"VerifiableCredential"},
+			Issuer:  verifiable.Issuer{ID: profile.DID},
+			Issued:  &issued,
+			Expired: &expires,
+			Subject: map[string]interface{}{"id": holderDID},
+		}
+
+		statuses, err := op.addCredentialStatus(credential)
+		require.NoError(t, err)
+
+		updateContext(credential, profile)
+
+		signLDP(t, credential, privKey, profile.DID+"#key-1", "", "")
+		require.NoError(t, op.recordIssued(profile, &issuedCredential{credential: credential, status: statuses[0]}))
+
+		return credential
+	}
+
+	t.Run("reissue credential", func(t *testing.T) {
+		credential := issue(t, "http://example.edu/credentials/1872")
+
+		vcBytes, err := credential.MarshalJSON()
+		require.NoError(t, err)
+
+		expires := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Second)
+
+		rr := reissue(t, &ReissueCredentialRequest{Credential: vcBytes, ExpirationDate: &expires})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		replacement, err := verifiable.NewUnverifiedCredential(rr.Body.Bytes())
+		require.NoError(t, err)
+		require.NotEqual(t, credential.ID, replacement.ID)
+		require.Equal(t, credential.ID, replacement.CustomFields["replaces"])
+		require.NotNil(t, replacement.Status)
+		require.Equal(t, credential.Subject, replacement.Subject)
+		require.True(t, expires.Equal(*replacement.Expired))
+		require.True(t, replacement.Issued.After(*credential.Issued))
+		require.Len(t, replacement.Proofs, 1)
+
+		record, err := op.issuanceRegistry.GetProfileRecord(profile.Name, replacement.ID)
+		require.NoError(t, err)
+		require.Equal(t, credential.ID, record.Replaces)
+
+		record, err = op.issuanceRegistry.GetProfileRecord(profile.Name, credential.ID)
+		require.NoError(t, err)
+		require.Equal(t, replacement.ID, record.ReplacedBy)
+
+		csl, err := op.vcStatusManager.GetCSL(credential.Status.ID)
+		require.NoError(t, err)
+		require.Len(t, csl.VC, 1)
+		require.Contains(t, csl.VC[0], credential.ID)
+		require.Contains(t, csl.VC[0], supersededStatus)
+
+		// the replaced credential is reissued once
+		rr = reissue(t, &ReissueCredentialRequest{Credential: vcBytes})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "credential "+credential.ID+" is replaced by "+replacement.ID)
+	})
+
+	t.Run("reissue credential by ID", func(t *testing.T) {
+		credential := issue(t, "http://example.edu/credentials/1873")
+		subject := map[string]interface{}{"id": holderDID, "name": "Jayden Doe"}
+
+		rr := reissue(t, &ReissueCredentialRequest{
+			CredentialID:  credential.ID,
+			ReplacementID: "http://example.edu/credentials/1874",
+			Subject:       subject,
+			Status:        revokedStatus,
+			StatusReason:  "name change",
+		})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+		replacement, err := verifiable.NewUnverifiedCredential(rr.Body.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, "http://example.edu/credentials/1874", replacement.ID)
+		require.Equal(t, credential.ID, replacement.CustomFields["replaces"])
+		require.Equal(t, subject, replacement.Subject)
+		require.Nil(t, replacement.Expired)
+
+		csl, err := op.vcStatusManager.GetCSL(credential.Status.ID)
+		require.NoError(t, err)
+		require.Contains(t, csl.VC[len(csl.VC)-1], credential.ID)
+		require.Contains(t, csl.VC[len(csl.VC)-1], "name change")
+
+		// the status of the replaced credential is updated
+		rr = reissue(t, &ReissueCredentialRequest{CredentialID: credential.ID, Subject: subject})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "is replaced by")
+	})
+
+	t.Run("failed status update revokes the replacement", func(t *testing.T) {
+		credential := issue(t, "http://example.edu/credentials/1877")
+
+		statusManager := op.vcStatusManager
+		op.vcStatusManager = &failingStatusManager{vcStatusManager: statusManager, failID: credential.ID}
+
+		defer func() { op.vcStatusManager = statusManager }()
+
+		rr := reissue(t, &ReissueCredentialRequest{
+			CredentialID:  credential.ID,
+			ReplacementID: "http://example.edu/credentials/1878",
+			Subject:       credential.Subject,
+		})
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "failed to update vc status: status error")
+
+		record, err := op.issuanceRegistry.GetProfileRecord(profile.Name, credential.ID)
+		require.NoError(t, err)
+		require.Empty(t, record.ReplacedBy)
+
+		record, err = op.issuanceRegistry.GetProfileRecord(profile.Name, "http://example.edu/credentials/1878")
+		require.NoError(t, err)
+
+		csl, err := statusManager.GetCSL(record.Status.ListID)
+		require.NoError(t, err)
+		require.True(t, csl.HasEntry("http://example.edu/credentials/1878"))
+		require.False(t, csl.HasEntry(credential.ID))
+
+		// the replaced credential can be reissued again
+		op.vcStatusManager = statusManager
+
+		rr = reissue(t, &ReissueCredentialRequest{CredentialID: credential.ID, Subject: credential.Subject})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	})
+
+	t.Run("invalid reissue request", func(t *testing.T) {
+		credential := issue(t, "http://example.edu/credentials/1875")
+
+		tests := []struct {
+			name    string
+			request *ReissueCredentialRequest
+			err     string
+		}{
+			{
+				"invalid status", &ReissueCredentialRequest{CredentialID: credential.ID, Status: "suspended"},
+				"invalid status suspended",
+			},
+			{"no credential", &ReissueCredentialRequest{}, "credential or credentialID is required"},
+			{
+				"no subject", &ReissueCredentialRequest{CredentialID: credential.ID},
+				"credentialSubject is required",
+			},
+			{
+				"not issued by the profile",
+				&ReissueCredentialRequest{CredentialID: "http://example.edu/credentials/1", Subject: holderDID},
+				"failed to get issued credential",
+			},
+			{
+				"invalid credential", &ReissueCredentialRequest{Credential: []byte(`{}`)},
+				"invalid credential",
+			},
+			{
+				"duplicate replacement ID", &ReissueCredentialRequest{
+					CredentialID: credential.ID, ReplacementID: credential.ID, Subject: holderDID,
+				},
+				"duplicate credential ID",
+			},
+		}
+
+		for _, tc := range tests {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				rr := reissue(t, tc.request)
+				require.Equal(t, http.StatusBadRequest, rr.Code)
+				require.Contains(t, rr.Body.String(), tc.err)
+			})
+		}
+
+		rr := serveHTTPMux(t, getHandler(t, op, credentialReissuePath, issuerMode), reissueEndpoint,
+			[]byte("invalid"), urlVars)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), invalidRequestErrMsg)
+	})
+
+	t.Run("profile without credential status", func(t *testing.T) {
+		other := getTestProfile()
+		other.Name = "other"
+		other.DisableVCStatus = true
+
+		require.NoError(t, op.profileStore.SaveProfile(other))
+
+		rr := serveHTTPMux(t, getHandler(t, op, credentialReissuePath, issuerMode), "/other/credentials/reissue",
+			nil, map[string]string{profileIDPathParam: other.Name})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "vc status is disabled for profile other")
+
+		rr = serveHTTPMux(t, getHandler(t, op, credentialReissuePath, issuerMode), "/invalid/credentials/reissue",
+			nil, map[string]string{profileIDPathParam: "invalid"})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid issuer profile")
+	})
+}
+
+// failingStatusManager fails to update the status of the credential with the ID
+type failingStatusManager struct {
+	vcStatusManager
+	failID string
+}
+
+func (m *failingStatusManager) UpdateVCStatus(v *verifiable.Credential, profile *vcprofile.DataProfile,
+	status, statusReason string) error {
+	if v.ID == m.failID {
+		return errors.New("status error")
+	}
+
+	return m.vcStatusManager.UpdateVCStatus(v, profile, status, statusReason)
+}
@@ -0,0 +1,432 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/trustbloc/edge-core/pkg/storage"
+	"github.com/trustbloc/edv/pkg/restapi/edv/edverrors"
+	"github.com/trustbloc/edv/pkg/restapi/edv/models"
+
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+)
+
+const (
+	walletCredentialIDPathParam = "credentialID"
+
+	// the holder wallet is kept in the vault of its own, apart from the vault of the issuer profile with the same name,
+	// the issuer profile names (the names of their vaults) can't have the prefix
+	walletVaultPrefix = "wallet-"
+
+	// names of the wallet document indexes, the names and values are indexed with their MACs
+	walletIndex        = "holderWallet"
+	walletTypeIndex    = "holderWalletType"
+	walletIssuerIndex  = "holderWalletIssuer"
+	walletSubjectIndex = "holderWalletSubject"
+
+	walletTypeQueryParam    = "type"
+	walletIssuerQueryParam  = "issuer"
+	walletSubjectQueryParam = "subject"
+)
+
+// walletQuery filters the wallet credentials, empty fields match any credential
+type walletQuery struct {
+	Type    string
+	Issuer  string
+	Subject string
+}
+
+// AddWalletCredential swagger:route POST /{id}/wallet/credentials holder addWalletCredentialReq
+//
+// Adds the credential to the holder wallet. The credential is verified, encrypted and stored in the EDV,
+// it's indexed by its ID, types, issuer and subjects.
+//
+// Responses:
+//    default: genericError
+//        201: walletCredentialRes
+func (o *Operation) addWalletCredentialHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.walletProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	request := &WalletCredentialRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf(invalidRequestErrMsg+": %s", err.Error()))
+
+		return
+	}
+
+	vc, err := o.parseAndVerifyVC(request.Credential)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid credential: %s", err.Error()))
+
+		return
+	}
+
+	if vc.ID != "" {
+		docIDs, queryErr := o.walletDocIDs(profile.Name, o.vcIDIndexNameEncoded, vc.ID)
+		if queryErr != nil {
+			o.writeErrorResponse(rw, http.StatusInternalServerError, queryErr.Error())
+
+			return
+		}
+
+		if len(docIDs) > 0 {
+			o.writeErrorResponse(rw, http.StatusConflict, fmt.Sprintf("credential %s is already in the wallet", vc.ID))
+
+			return
+		}
+	}
+
+	docID, err := o.addWalletCredential(profile.Name, vc, request.Credential)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to add wallet credential: %s",
+			err.Error()))
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	o.writeResponse(rw, &WalletCredential{ID: docID, Credential: request.Credential})
+}
+
+// WalletCredentials swagger:route GET /{id}/wallet/credentials holder walletCredentialsReq
+//
+// Lists the credentials of the holder wallet, the credentials are filtered by the type, issuer and subject.
+//
+// Responses:
+//    default: genericError
+//        200: walletCredentialsRes
+func (o *Operation) walletCredentialsHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.walletProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	credentials, err := o.walletCredentials(profile.Name, &walletQuery{
+		Type:    req.URL.Query().Get(walletTypeQueryParam),
+		Issuer:  req.URL.Query().Get(walletIssuerQueryParam),
+		Subject: req.URL.Query().Get(walletSubjectQueryParam),
+	})
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to query wallet: %s",
+			err.Error()))
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, &WalletCredentialsResponse{Credentials: credentials})
+}
+
+// WalletCredential swagger:route GET /{id}/wallet/credentials/{credentialID} holder walletCredentialReq
+//
+// Retrieves the credential of the holder wallet.
+//
+// Responses:
+//    default: genericError
+//        200: walletCredentialRes
+func (o *Operation) walletCredentialHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.walletProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	credential, ok := o.walletCredential(rw, profile, mux.Vars(req)[walletCredentialIDPathParam])
+	if !ok {
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.writeResponse(rw, credential)
+}
+
+// DeleteWalletCredential swagger:route DELETE /{id}/wallet/credentials/{credentialID} holder deleteWalletCredentialReq
+//
+// Removes the credential from the holder wallet. The removal is soft: the EDV has no document deletion so the
+// encrypted document stays in the vault and the wallet skips it.
+//
+// Responses:
+//    default: genericError
+//        200: emptyRes
+func (o *Operation) deleteWalletCredentialHandler(rw http.ResponseWriter, req *http.Request) {
+	profile, ok := o.walletProfile(rw, req)
+	if !ok {
+		return
+	}
+
+	credential, ok := o.walletCredential(rw, profile, mux.Vars(req)[walletCredentialIDPathParam])
+	if !ok {
+		return
+	}
+
+	if err := o.wallet.Remove(profile.Name, credential.ID); err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to remove wallet credential: %s",
+			err.Error()))
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// walletProfile returns the holder profile of the wallet request, the error response is written if it fails
+func (o *Operation) walletProfile(rw http.ResponseWriter, req *http.Request) (*vcprofile.HolderProfile, bool) {
+	profileID := mux.Vars(req)[profileIDPathParam]
+
+	profile, err := o.profileStore.GetHolderProfile(profileID)
+	if err != nil {
+		if errors.Is(err, storage.ErrValueNotFound) {
+			o.writeErrorResponse(rw, http.StatusNotFound, fmt.Sprintf("holder profile %s not found", profileID))
+
+			return nil, false
+		}
+
+		o.writeErrorResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid holder profile - id=%s: err=%s",
+			profileID, err.Error()))
+
+		return nil, false
+	}
+
+	return profile, true
+}
+
+// walletCredential returns the credential of the wallet, the error response is written if it fails
+func (o *Operation) walletCredential(rw http.ResponseWriter, profile *vcprofile.HolderProfile,
+	docID string) (*WalletCredential, bool) {
+	removed, err := o.wallet.IsRemoved(profile.Name, docID)
+	if err != nil {
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return nil, false
+	}
+
+	if removed {
+		o.writeErrorResponse(rw, http.StatusNotFound, fmt.Sprintf("wallet credential %s not found", docID))
+
+		return nil, false
+	}
+
+	credential, err := o.retrieveVC(walletVaultID(profile.Name), docID, "retrieving wallet credential")
+	if err != nil {
+		if isEDVNotFound(err) {
+			o.writeErrorResponse(rw, http.StatusNotFound, fmt.Sprintf("wallet credential %s not found", docID))
+
+			return nil, false
+		}
+
+		o.writeErrorResponse(rw, http.StatusInternalServerError, err.Error())
+
+		return nil, false
+	}
+
+	return &WalletCredential{ID: docID, Credential: credential}, true
+}
+
+// addWalletCredential stores the credential in the wallet of the profile and returns the ID of the wallet document
+func (o *Operation) addWalletCredential(profileName string, vc *verifiable.Credential,
+	vcBytes []byte) (string, error) {
+	doc, err := o.buildStructuredDoc(vcBytes)
+	if err != nil {
+		return "", err
+	}
+
+	attributes, err := o.walletIndexedAttributes(profileName, vc)
+	if err != nil {
+		return "", err
+	}
+
+	encryptedDocument, err := o.buildEncryptedDoc(doc, attributes...)
+	if err != nil {
+		return "", err
+	}
+
+	if err = o.createDocument(walletVaultID(profileName), &encryptedDocument); err != nil {
+		return "", err
+	}
+
+	return doc.ID, nil
+}
+
+// walletIndexedAttributes returns the wallet document attributes the credential is searched by, the VC ID
+// isn't unique in the wallet as the removed documents remain in the vault
+func (o *Operation) walletIndexedAttributes(profileName string,
+	vc *verifiable.Credential) ([]models.IndexedAttribute, error) {
+	var attributes []models.IndexedAttribute
+
+	if vc.ID != "" {
+		vcIDAttribute, err := o.indexedAttribute(o.vcIDIndexNameEncoded, vc.ID, false)
+		if err != nil {
+			return nil, err
+		}
+
+		attributes = append(attributes, vcIDAttribute)
+	}
+
+	values := map[string][]string{
+		walletIndex:        {profileName},
+		walletTypeIndex:    vc.Types,
+		walletSubjectIndex: subjectIDs(vc.Subject),
+	}
+
+	if vc.Issuer.ID != "" {
+		values[walletIssuerIndex] = []string{vc.Issuer.ID}
+	}
+
+	for _, name := range []string{walletIndex, walletTypeIndex, walletIssuerIndex, walletSubjectIndex} {
+		nameEncoded, err := o.computeMAC(name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, value := range values[name] {
+			attribute, attributeErr := o.indexedAttribute(nameEncoded, value, false)
+			if attributeErr != nil {
+				return nil, attributeErr
+			}
+
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	return attributes, nil
+}
+
+// walletCredentials returns the credentials of the wallet of the profile matching the query
+func (o *Operation) walletCredentials(profileName string, query *walletQuery) ([]*WalletCredential, error) {
+	filters := map[string]string{
+		walletTypeIndex:    query.Type,
+		walletIssuerIndex:  query.Issuer,
+		walletSubjectIndex: query.Subject,
+	}
+
+	var docIDs []string
+
+	queried := false
+
+	for _, name := range []string{walletTypeIndex, walletIssuerIndex, walletSubjectIndex} {
+		if filters[name] == "" {
+			continue
+		}
+
+		matched, err := o.queryWallet(profileName, name, filters[name])
+		if err != nil {
+			return nil, err
+		}
+
+		docIDs = intersectDocIDs(docIDs, matched, queried)
+		queried = true
+	}
+
+	if !queried {
+		var err error
+
+		docIDs, err = o.queryWallet(profileName, walletIndex, profileName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	credentials := make([]*WalletCredential, 0, len(docIDs))
+
+	for _, docID := range docIDs {
+		credential, err := o.retrieveVC(walletVaultID(profileName), docID, "reading wallet")
+		if err != nil {
+			return nil, err
+		}
+
+		credentials = append(credentials, &WalletCredential{ID: docID, Credential: credential})
+	}
+
+	return credentials, nil
+}
+
+// queryWallet returns the IDs of the wallet documents with the value in the named index
+func (o *Operation) queryWallet(profileName, indexName, value string) ([]string, error) {
+	indexNameEncoded, err := o.computeMAC(indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.walletDocIDs(profileName, indexNameEncoded, value)
+}
+
+// walletDocIDs returns the IDs of the wallet documents with the value in the encoded index,
+// the removed documents are skipped
+func (o *Operation) walletDocIDs(profileName, indexNameEncoded, value string) ([]string, error) {
+	docURLs, err := o.queryVaultIndex(walletVaultID(profileName), indexNameEncoded, value)
+	if err != nil {
+		// the vault is created when the first credential is added
+		if isEDVNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var docIDs []string
+
+	for _, docURL := range docURLs {
+		docID := getDocIDFromURL(docURL)
+
+		removed, removedErr := o.wallet.IsRemoved(profileName, docID)
+		if removedErr != nil {
+			return nil, removedErr
+		}
+
+		if !removed && !containsString(docIDs, docID) {
+			docIDs = append(docIDs, docID)
+		}
+	}
+
+	return docIDs, nil
+}
+
+// intersectDocIDs returns the IDs in both lists, the matched IDs are returned as they are for the first filter
+func intersectDocIDs(docIDs, matched []string, filtered bool) []string {
+	if !filtered {
+		return matched
+	}
+
+	var ids []string
+
+	for _, id := range docIDs {
+		if containsString(matched, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+func walletVaultID(profileName string) string {
+	return walletVaultPrefix + profileName
+}
+
+// checkVaultName checks the vault of the issuer profile isn't in the namespace of the holder wallet vaults
+func checkVaultName(profileName string) error {
+	if strings.HasPrefix(profileName, walletVaultPrefix) {
+		return fmt.Errorf("profile name %s is reserved: the %s prefix is used by the holder wallets", profileName,
+			walletVaultPrefix)
+	}
+
+	return nil
+}
+
+func isEDVNotFound(err error) bool {
+	return strings.Contains(err.Error(), edverrors.ErrVaultNotFound.Error()) ||
+		strings.Contains(err.Error(), edverrors.ErrDocumentNotFound.Error())
+}
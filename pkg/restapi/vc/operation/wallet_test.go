@@ -0,0 +1,403 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto/primitive/composite/ecdhes"
+	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/storage/memstore"
+	mockstorage "github.com/trustbloc/edge-core/pkg/storage/mockstore"
+	"github.com/trustbloc/edv/pkg/restapi/edv/edverrors"
+	"github.com/trustbloc/edv/pkg/restapi/edv/models"
+
+	vccrypto "github.com/trustbloc/edge-service/pkg/doc/vc/crypto"
+	vcprofile "github.com/trustbloc/edge-service/pkg/doc/vc/profile"
+	"github.com/trustbloc/edge-service/pkg/doc/vc/wallet"
+	"github.com/trustbloc/edge-service/pkg/internal/mock/kms"
+)
+
+const (
+	walletIssuer1 = "did:example:76e12ec712ebc6f1c221ebfeb1f"
+	walletIssuer2 = "did:example:c276e12ec21ebfeb1f712ebc6f1"
+	walletHolder  = "holder"
+)
+
+func TestHolderWallet(t *testing.T) {
+	edvClient := newMemEDVClient()
+	op := newWalletOperation(t, edvClient)
+
+	require.NoError(t, op.profileStore.SaveHolderProfile(&vcprofile.HolderProfile{
+		Name:          walletHolder,
+		DIDKeyType:    vccrypto.Ed25519KeyType,
+		SignatureType: vccrypto.Ed25519Signature2018,
+	}))
+
+	degree := createWalletCredential("http://example.edu/credentials/1", walletIssuer1, holderDID,
+		"UniversityDegreeCredential")
+	alumni := createWalletCredential("http://example.edu/credentials/2", walletIssuer2, holderDID,
+		"AlumniCredential")
+	other := createWalletCredential("", walletIssuer1, "did:example:other", "UniversityDegreeCredential")
+
+	ids := make(map[string]string)
+
+	t.Run("add credentials", func(t *testing.T) {
+		for name, vc := range map[string][]byte{"degree": degree, "alumni": alumni, "other": other} {
+			credential := addWalletCredential(t, op, walletHolder, vc, http.StatusCreated)
+			require.NotEmpty(t, credential.ID)
+			require.JSONEq(t, string(vc), string(credential.Credential))
+
+			ids[name] = credential.ID
+		}
+
+		require.Len(t, edvClient.vaults[walletVaultID(walletHolder)], 3)
+
+		rr := serveHTTPMux(t, getMethodHandler(t, op, walletCredentialsEndpoint, http.MethodPost, holderMode),
+			"/holder/wallet/credentials", walletCredentialRequest(t, degree),
+			map[string]string{profileIDPathParam: walletHolder})
+		require.Equal(t, http.StatusConflict, rr.Code)
+		require.Contains(t, rr.Body.String(), "credential http://example.edu/credentials/1 is already in the wallet")
+	})
+
+	t.Run("query credentials", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			query string
+			ids   []string
+		}{
+			{name: "all", ids: []string{ids["degree"], ids["alumni"], ids["other"]}},
+			{name: "type", query: "?type=UniversityDegreeCredential", ids: []string{ids["degree"], ids["other"]}},
+			{name: "base type", query: "?type=VerifiableCredential",
+				ids: []string{ids["degree"], ids["alumni"], ids["other"]}},
+			{name: "issuer", query: "?issuer=" + walletIssuer2, ids: []string{ids["alumni"]}},
+			{name: "subject", query: "?subject=" + holderDID, ids: []string{ids["degree"], ids["alumni"]}},
+			{name: "type and subject", query: "?type=UniversityDegreeCredential&subject=" + holderDID,
+				ids: []string{ids["degree"]}},
+			{name: "no match", query: "?type=AlumniCredential&issuer=" + walletIssuer1},
+		}
+
+		for _, tc := range tests {
+			credentials := listWalletCredentials(t, op, walletHolder, tc.query)
+			require.Len(t, credentials, len(tc.ids), tc.name)
+
+			for _, c := range credentials {
+				require.Contains(t, tc.ids, c.ID, tc.name)
+			}
+		}
+	})
+
+	t.Run("get credential", func(t *testing.T) {
+		rr := getWalletCredential(t, op, walletHolder, ids["degree"], http.MethodGet)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		credential := &WalletCredential{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), credential))
+		require.Equal(t, ids["degree"], credential.ID)
+		require.JSONEq(t, string(degree), string(credential.Credential))
+
+		rr = getWalletCredential(t, op, walletHolder, "unknown", http.MethodGet)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), "wallet credential unknown not found")
+	})
+
+	t.Run("delete credential", func(t *testing.T) {
+		rr := getWalletCredential(t, op, walletHolder, ids["degree"], http.MethodDelete)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		rr = getWalletCredential(t, op, walletHolder, ids["degree"], http.MethodGet)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = getWalletCredential(t, op, walletHolder, ids["degree"], http.MethodDelete)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+
+		credentials := listWalletCredentials(t, op, walletHolder, "?subject="+holderDID)
+		require.Len(t, credentials, 1)
+		require.Equal(t, ids["alumni"], credentials[0].ID)
+
+		// the removed credential is added again
+		credential := addWalletCredential(t, op, walletHolder, degree, http.StatusCreated)
+		require.NotEqual(t, ids["degree"], credential.ID)
+
+		credentials = listWalletCredentials(t, op, walletHolder, "?subject="+holderDID)
+		require.Len(t, credentials, 2)
+	})
+
+	t.Run("test empty wallet", func(t *testing.T) {
+		require.NoError(t, op.profileStore.SaveHolderProfile(&vcprofile.HolderProfile{Name: "empty"}))
+
+		require.Empty(t, listWalletCredentials(t, op, "empty", ""))
+
+		rr := getWalletCredential(t, op, "empty", ids["alumni"], http.MethodGet)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("test invalid requests", func(t *testing.T) {
+		rr := serveHTTPMux(t, getMethodHandler(t, op, walletCredentialsEndpoint, http.MethodGet, holderMode),
+			"/unknown/wallet/credentials", nil, map[string]string{profileIDPathParam: "unknown"})
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		require.Contains(t, rr.Body.String(), "holder profile unknown not found")
+
+		addHandler := getMethodHandler(t, op, walletCredentialsEndpoint, http.MethodPost, holderMode)
+
+		rr = serveHTTPMux(t, addHandler, "/holder/wallet/credentials", []byte("{"),
+			map[string]string{profileIDPathParam: walletHolder})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), invalidRequestErrMsg)
+
+		rr = serveHTTPMux(t, addHandler, "/holder/wallet/credentials",
+			walletCredentialRequest(t, []byte(`{"id": "http://example.edu/credentials/3"}`)),
+			map[string]string{profileIDPathParam: walletHolder})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid credential")
+	})
+
+	t.Run("test EDV errors", func(t *testing.T) {
+		edvClient.err = errors.New("edv error")
+		defer func() { edvClient.err = nil }()
+
+		rr := serveHTTPMux(t, getMethodHandler(t, op, walletCredentialsEndpoint, http.MethodGet, holderMode),
+			"/holder/wallet/credentials", nil, map[string]string{profileIDPathParam: walletHolder})
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "failed to query wallet: edv error")
+
+		rr = serveHTTPMux(t, getMethodHandler(t, op, walletCredentialsEndpoint, http.MethodPost, holderMode),
+			"/holder/wallet/credentials", walletCredentialRequest(t, alumni),
+			map[string]string{profileIDPathParam: walletHolder})
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "edv error")
+
+		rr = getWalletCredential(t, op, walletHolder, ids["alumni"], http.MethodGet)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "edv error")
+	})
+
+	t.Run("test removed store errors", func(t *testing.T) {
+		store := &mockstorage.MockStore{Store: make(map[string][]byte), ErrPut: errors.New("put error")}
+		op.wallet = wallet.New(store)
+
+		rr := getWalletCredential(t, op, walletHolder, ids["alumni"], http.MethodDelete)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "failed to remove wallet credential: put error")
+
+		store.ErrPut = nil
+		require.NoError(t, op.wallet.Remove(walletHolder, ids["other"]))
+		store.ErrGet = errors.New("get error")
+
+		rr = getWalletCredential(t, op, walletHolder, ids["other"], http.MethodGet)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "get error")
+
+		rr = serveHTTPMux(t, getMethodHandler(t, op, walletCredentialsEndpoint, http.MethodGet, holderMode),
+			"/holder/wallet/credentials", nil, map[string]string{profileIDPathParam: walletHolder})
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "get error")
+	})
+}
+
+func newWalletOperation(t *testing.T, edvClient EDVClient) *Operation {
+	t.Helper()
+
+	kh, err := keyset.NewHandle(ecdhes.ECDHES256KWAES256GCMKeyTemplate())
+	require.NoError(t, err)
+
+	op, err := New(&Config{
+		StoreProvider:      memstore.NewProvider(),
+		KMSSecretsProvider: mem.NewProvider(),
+		KeyManager:         &kms.KeyManager{CreateKeyValue: kh},
+		EDVClient:          edvClient,
+		Crypto:             &cryptomock.Crypto{},
+		HostURL:            "http://example.com",
+	})
+	require.NoError(t, err)
+
+	// the mock crypto returns the same MAC for any index, the wallet queries need distinct MACs
+	op.macCrypto = &hashMACCrypto{}
+	op.vcIDIndexNameEncoded, err = op.computeMAC("vcID")
+	require.NoError(t, err)
+
+	return op
+}
+
+func createWalletCredential(id, issuer, subject string, types ...string) []byte {
+	vc := map[string]interface{}{
+		"@context":          []string{"https://www.w3.org/2018/credentials/v1"},
+		"type":              append([]string{"VerifiableCredential"}, types...),
+		"issuer":            issuer,
+		"issuanceDate":      "2020-03-16T22:37:26.544Z",
+		"credentialSubject": map[string]interface{}{"id": subject},
+	}
+
+	if id != "" {
+		vc["id"] = id
+	}
+
+	vcBytes, err := json.Marshal(vc)
+	if err != nil {
+		panic(err)
+	}
+
+	return vcBytes
+}
+
+func walletCredentialRequest(t *testing.T, vc []byte) []byte {
+	t.Helper()
+
+	reqBytes, err := json.Marshal(&WalletCredentialRequest{Credential: vc})
+	require.NoError(t, err)
+
+	return reqBytes
+}
+
+func addWalletCredential(t *testing.T, op *Operation, profile string, vc []byte, status int) *WalletCredential {
+	t.Helper()
+
+	rr := serveHTTPMux(t, getMethodHandler(t, op, walletCredentialsEndpoint, http.MethodPost, holderMode),
+		"/"+profile+"/wallet/credentials", walletCredentialRequest(t, vc),
+		map[string]string{profileIDPathParam: profile})
+	require.Equal(t, status, rr.Code, rr.Body.String())
+
+	credential := &WalletCredential{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), credential))
+
+	return credential
+}
+
+func listWalletCredentials(t *testing.T, op *Operation, profile, query string) []*WalletCredential {
+	t.Helper()
+
+	rr := serveHTTPMux(t, getMethodHandler(t, op, walletCredentialsEndpoint, http.MethodGet, holderMode),
+		"/"+profile+"/wallet/credentials"+query, nil, map[string]string{profileIDPathParam: profile})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	resp := &WalletCredentialsResponse{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+
+	return resp.Credentials
+}
+
+func getWalletCredential(t *testing.T, op *Operation, profile, id, method string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	return serveHTTPMux(t, getMethodHandler(t, op, walletCredentialEndpoint, method, holderMode),
+		"/"+profile+"/wallet/credentials/"+id, nil,
+		map[string]string{profileIDPathParam: profile, walletCredentialIDPathParam: id})
+}
+
+// hashMACCrypto computes the SHA-256 hashes of the data as the MACs
+type hashMACCrypto struct {
+	cryptomock.Crypto
+}
+
+func (c *hashMACCrypto) ComputeMAC(data []byte, kh interface{}) ([]byte, error) {
+	mac := sha256.Sum256(data)
+
+	return mac[:], nil
+}
+
+// memEDVClient keeps the vault documents in memory, the documents are queried by their indexed attributes
+type memEDVClient struct {
+	vaults map[string][]*models.EncryptedDocument
+	err    error
+}
+
+func newMemEDVClient() *memEDVClient {
+	return &memEDVClient{vaults: make(map[string][]*models.EncryptedDocument)}
+}
+
+func (c *memEDVClient) CreateDataVault(config *models.DataVaultConfiguration) (string, error) {
+	if _, ok := c.vaults[config.ReferenceID]; ok {
+		return "", edverrors.ErrDuplicateVault
+	}
+
+	c.vaults[config.ReferenceID] = nil
+
+	return "/encrypted-data-vaults/" + config.ReferenceID, nil
+}
+
+func (c *memEDVClient) CreateDocument(vaultID string, document *models.EncryptedDocument) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+
+	docs, ok := c.vaults[vaultID]
+	if !ok {
+		return "", edverrors.ErrVaultNotFound
+	}
+
+	for _, attribute := range document.IndexedAttributeCollections[0].IndexedAttributes {
+		for _, doc := range docs {
+			for _, a := range doc.IndexedAttributeCollections[0].IndexedAttributes {
+				if a.Name == attribute.Name && a.Value == attribute.Value && (a.Unique || attribute.Unique) {
+					return "", errors.New("unique attribute already exists")
+				}
+			}
+		}
+	}
+
+	c.vaults[vaultID] = append(docs, document)
+
+	return c.docURL(vaultID, document.ID), nil
+}
+
+func (c *memEDVClient) ReadDocument(vaultID, docID string) (*models.EncryptedDocument, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	docs, ok := c.vaults[vaultID]
+	if !ok {
+		return nil, fmt.Errorf("failed to retrieve document: %s", edverrors.ErrVaultNotFound)
+	}
+
+	for _, doc := range docs {
+		if doc.ID == docID {
+			return doc, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to retrieve document: %s", edverrors.ErrDocumentNotFound)
+}
+
+func (c *memEDVClient) QueryVault(vaultID string, query *models.Query) ([]string, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	docs, ok := c.vaults[vaultID]
+	if !ok {
+		return nil, fmt.Errorf("the EDV server returned status code 400 along with the following message: %s",
+			edverrors.ErrVaultNotFound)
+	}
+
+	var docURLs []string
+
+	for _, doc := range docs {
+		for _, a := range doc.IndexedAttributeCollections[0].IndexedAttributes {
+			if a.Name == query.Name && a.Value == query.Value {
+				docURLs = append(docURLs, c.docURL(vaultID, doc.ID))
+
+				break
+			}
+		}
+	}
+
+	return docURLs, nil
+}
+
+func (c *memEDVClient) docURL(vaultID, docID string) string {
+	return "http://edv.example.com/encrypted-data-vaults/" + vaultID + "/docs/" + docID
+}
@@ -0,0 +1,243 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+)
+
+const (
+	defaultTTL         = 5 * time.Minute
+	defaultNegativeTTL = 30 * time.Second
+	defaultMaxSize     = 1000
+
+	// did:method:method-specific-id
+	didParts = 3
+)
+
+// Option configures the caching registry.
+type Option func(opts *Registry)
+
+// WithTTL sets the period the resolved DID documents are cached for, zero TTL disables caching.
+func WithTTL(ttl time.Duration) Option {
+	return func(opts *Registry) {
+		opts.ttl = ttl
+	}
+}
+
+// WithMethodTTL overrides the TTL for the DIDs of the given method (e.g. "key" or "trustbloc").
+func WithMethodTTL(method string, ttl time.Duration) Option {
+	return func(opts *Registry) {
+		opts.methodTTL[method] = ttl
+	}
+}
+
+// WithNegativeTTL sets the period the DIDs which weren't found are cached for, zero TTL disables negative caching.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(opts *Registry) {
+		opts.negativeTTL = ttl
+	}
+}
+
+// WithMaxSize sets the max number of the cached DIDs, the least recently used DIDs are evicted.
+func WithMaxSize(size int) Option {
+	return func(opts *Registry) {
+		opts.maxSize = size
+	}
+}
+
+// Metrics of the DID cache.
+type Metrics struct {
+	Hits         uint64 `json:"hits"`
+	NegativeHits uint64 `json:"negativeHits"`
+	Misses       uint64 `json:"misses"`
+	Evictions    uint64 `json:"evictions"`
+	Size         int    `json:"size"`
+}
+
+// Registry is the vdri registry caching the DID resolutions of the wrapped registry.
+type Registry struct {
+	vdriapi.Registry
+
+	ttl         time.Duration
+	methodTTL   map[string]time.Duration
+	negativeTTL time.Duration
+	maxSize     int
+	now         func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	metrics Metrics
+}
+
+type entry struct {
+	did     string
+	doc     *did.Doc
+	err     error
+	expires time.Time
+}
+
+// New returns new caching registry wrapping the given registry.
+func New(registry vdriapi.Registry, opts ...Option) *Registry {
+	r := &Registry{
+		Registry:    registry,
+		ttl:         defaultTTL,
+		methodTTL:   make(map[string]time.Duration),
+		negativeTTL: defaultNegativeTTL,
+		maxSize:     defaultMaxSize,
+		now:         time.Now,
+		entries:     make(map[string]*list.Element),
+		lru:         list.New(),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Resolve resolves the DID document from the cache or the wrapped registry,
+// resolutions with options aren't cached.
+func (r *Registry) Resolve(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
+	if len(opts) != 0 {
+		return r.Registry.Resolve(didID, opts...)
+	}
+
+	if e, ok := r.get(didID); ok {
+		return e.doc, e.err
+	}
+
+	doc, err := r.Registry.Resolve(didID)
+
+	switch {
+	case err == nil:
+		r.put(&entry{did: didID, doc: doc}, r.methodTTLOrDefault(didID))
+	case errors.Is(err, vdriapi.ErrNotFound):
+		r.put(&entry{did: didID, err: err}, r.negativeTTL)
+	}
+
+	return doc, err
+}
+
+// Purge removes the DID from the cache (e.g. when the DID keys are rotated), returns true if the DID was cached.
+func (r *Registry) Purge(didID string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	elem, ok := r.entries[didID]
+	if ok {
+		r.remove(elem)
+	}
+
+	return ok
+}
+
+// PurgeAll removes all the DIDs from the cache, returns the number of removed DIDs.
+func (r *Registry) PurgeAll() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	size := r.lru.Len()
+
+	r.entries = make(map[string]*list.Element)
+	r.lru.Init()
+
+	return size
+}
+
+// Metrics returns the cache metrics.
+func (r *Registry) Metrics() *Metrics {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	metrics := r.metrics
+	metrics.Size = r.lru.Len()
+
+	return &metrics
+}
+
+func (r *Registry) get(didID string) (*entry, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	elem, ok := r.entries[didID]
+	if !ok {
+		r.metrics.Misses++
+
+		return nil, false
+	}
+
+	e := elem.Value.(*entry) // nolint: errcheck
+
+	if !r.now().Before(e.expires) {
+		r.remove(elem)
+		r.metrics.Misses++
+
+		return nil, false
+	}
+
+	r.lru.MoveToFront(elem)
+
+	if e.err != nil {
+		r.metrics.NegativeHits++
+	} else {
+		r.metrics.Hits++
+	}
+
+	return e, true
+}
+
+func (r *Registry) put(e *entry, ttl time.Duration) {
+	if ttl <= 0 || r.maxSize <= 0 {
+		return
+	}
+
+	e.expires = r.now().Add(ttl)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if elem, ok := r.entries[e.did]; ok {
+		elem.Value = e
+		r.lru.MoveToFront(elem)
+
+		return
+	}
+
+	r.entries[e.did] = r.lru.PushFront(e)
+
+	for r.lru.Len() > r.maxSize {
+		r.remove(r.lru.Back())
+		r.metrics.Evictions++
+	}
+}
+
+func (r *Registry) remove(elem *list.Element) {
+	e := r.lru.Remove(elem).(*entry) // nolint: errcheck
+
+	delete(r.entries, e.did)
+}
+
+func (r *Registry) methodTTLOrDefault(didID string) time.Duration {
+	parts := strings.SplitN(didID, ":", didParts)
+	if len(parts) > 1 {
+		if ttl, ok := r.methodTTL[parts[1]]; ok {
+			return ttl
+		}
+	}
+
+	return r.ttl
+}
@@ -0,0 +1,167 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/stretchr/testify/require"
+)
+
+type mockClock struct {
+	now time.Time
+}
+
+func (c *mockClock) Now() time.Time {
+	return c.now
+}
+
+func newTestRegistry(resolutions map[string]int, opts ...Option) (*Registry, *mockClock) {
+	clock := &mockClock{now: time.Now()}
+
+	r := New(&vdrimock.MockVDRIRegistry{ResolveFunc: func(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
+		resolutions[didID]++
+
+		switch didID {
+		case "did:example:unknown":
+			return nil, fmt.Errorf("resolve: %w", vdriapi.ErrNotFound)
+		case "did:example:error":
+			return nil, errors.New("connection refused")
+		}
+
+		return &did.Doc{ID: didID}, nil
+	}}, opts...)
+
+	r.now = clock.Now
+
+	return r, clock
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	t.Run("test resolved DID is cached until TTL expires", func(t *testing.T) {
+		resolutions := make(map[string]int)
+		r, clock := newTestRegistry(resolutions, WithTTL(time.Minute))
+
+		for i := 0; i < 3; i++ {
+			doc, err := r.Resolve("did:example:123")
+			require.NoError(t, err)
+			require.Equal(t, "did:example:123", doc.ID)
+		}
+
+		require.Equal(t, 1, resolutions["did:example:123"])
+		require.Equal(t, &Metrics{Hits: 2, Misses: 1, Size: 1}, r.Metrics())
+
+		clock.now = clock.now.Add(time.Minute)
+
+		_, err := r.Resolve("did:example:123")
+		require.NoError(t, err)
+		require.Equal(t, 2, resolutions["did:example:123"])
+		require.Equal(t, &Metrics{Hits: 2, Misses: 2, Size: 1}, r.Metrics())
+	})
+
+	t.Run("test method TTL", func(t *testing.T) {
+		resolutions := make(map[string]int)
+		r, clock := newTestRegistry(resolutions, WithTTL(time.Minute), WithMethodTTL("key", time.Hour),
+			WithMethodTTL("web", 0))
+
+		for _, didID := range []string{"did:example:123", "did:key:z6Mk", "did:web:example.com"} {
+			_, err := r.Resolve(didID)
+			require.NoError(t, err)
+		}
+
+		clock.now = clock.now.Add(2 * time.Minute)
+
+		for _, didID := range []string{"did:example:123", "did:key:z6Mk", "did:web:example.com"} {
+			_, err := r.Resolve(didID)
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, 2, resolutions["did:example:123"])
+		require.Equal(t, 1, resolutions["did:key:z6Mk"])
+		require.Equal(t, 2, resolutions["did:web:example.com"])
+	})
+
+	t.Run("test negative caching", func(t *testing.T) {
+		resolutions := make(map[string]int)
+		r, clock := newTestRegistry(resolutions, WithNegativeTTL(time.Second))
+
+		for i := 0; i < 2; i++ {
+			_, err := r.Resolve("did:example:unknown")
+			require.True(t, errors.Is(err, vdriapi.ErrNotFound))
+
+			_, err = r.Resolve("did:example:error")
+			require.EqualError(t, err, "connection refused")
+		}
+
+		require.Equal(t, 1, resolutions["did:example:unknown"])
+		require.Equal(t, 2, resolutions["did:example:error"])
+		require.Equal(t, uint64(1), r.Metrics().NegativeHits)
+
+		clock.now = clock.now.Add(time.Second)
+
+		_, err := r.Resolve("did:example:unknown")
+		require.Error(t, err)
+		require.Equal(t, 2, resolutions["did:example:unknown"])
+	})
+
+	t.Run("test least recently used DIDs are evicted", func(t *testing.T) {
+		resolutions := make(map[string]int)
+		r, _ := newTestRegistry(resolutions, WithMaxSize(2))
+
+		for _, didID := range []string{"did:example:1", "did:example:2", "did:example:1", "did:example:3",
+			"did:example:1", "did:example:2"} {
+			_, err := r.Resolve(didID)
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, map[string]int{"did:example:1": 1, "did:example:2": 2, "did:example:3": 1}, resolutions)
+		require.Equal(t, &Metrics{Hits: 2, Misses: 4, Evictions: 2, Size: 2}, r.Metrics())
+	})
+
+	t.Run("test resolution with options isn't cached", func(t *testing.T) {
+		resolutions := make(map[string]int)
+		r, _ := newTestRegistry(resolutions)
+
+		for i := 0; i < 2; i++ {
+			_, err := r.Resolve("did:example:123", vdriapi.WithNoCache(true))
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, 2, resolutions["did:example:123"])
+		require.Equal(t, 0, r.Metrics().Size)
+	})
+}
+
+func TestRegistry_Purge(t *testing.T) {
+	resolutions := make(map[string]int)
+	r, _ := newTestRegistry(resolutions)
+
+	for _, didID := range []string{"did:example:1", "did:example:2", "did:example:3"} {
+		_, err := r.Resolve(didID)
+		require.NoError(t, err)
+	}
+
+	require.True(t, r.Purge("did:example:1"))
+	require.False(t, r.Purge("did:example:1"))
+
+	_, err := r.Resolve("did:example:1")
+	require.NoError(t, err)
+	require.Equal(t, 2, resolutions["did:example:1"])
+
+	require.Equal(t, 3, r.PurgeAll())
+	require.Equal(t, 0, r.Metrics().Size)
+
+	_, err = r.Resolve("did:example:2")
+	require.NoError(t, err)
+	require.Equal(t, 2, resolutions["did:example:2"])
+}
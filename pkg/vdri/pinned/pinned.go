@@ -0,0 +1,103 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pinned
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+)
+
+var errOffline = errors.New("DID registry is offline")
+
+// Registry is the vdri registry resolving the pinned DID documents, DIDs which aren't pinned are resolved
+// by the fallback registry or aren't found when there is no fallback.
+type Registry struct {
+	docs     map[string]*did.Doc
+	fallback vdriapi.Registry
+}
+
+// New returns new registry of the pinned DID documents, fallback is optional.
+func New(docs []*did.Doc, fallback vdriapi.Registry) *Registry {
+	r := &Registry{docs: make(map[string]*did.Doc), fallback: fallback}
+
+	for _, doc := range docs {
+		r.docs[doc.ID] = doc
+	}
+
+	return r
+}
+
+// Resolve resolves the pinned DID document.
+func (r *Registry) Resolve(didID string, opts ...vdriapi.ResolveOpts) (*did.Doc, error) {
+	if doc, ok := r.docs[didID]; ok {
+		return doc, nil
+	}
+
+	if r.fallback == nil {
+		return nil, fmt.Errorf("DID %s is not pinned: %w", didID, vdriapi.ErrNotFound)
+	}
+
+	return r.fallback.Resolve(didID, opts...)
+}
+
+// Store stores the DID document in the fallback registry.
+func (r *Registry) Store(doc *did.Doc) error {
+	if r.fallback == nil {
+		return errOffline
+	}
+
+	return r.fallback.Store(doc)
+}
+
+// Create creates the DID document in the fallback registry.
+func (r *Registry) Create(method string, opts ...vdriapi.DocOpts) (*did.Doc, error) {
+	if r.fallback == nil {
+		return nil, errOffline
+	}
+
+	return r.fallback.Create(method, opts...)
+}
+
+// Close closes the fallback registry.
+func (r *Registry) Close() error {
+	if r.fallback == nil {
+		return nil
+	}
+
+	return r.fallback.Close()
+}
+
+// ReadDir reads the DID documents from the JSON files of the directory.
+func ReadDir(dir string) ([]*did.Doc, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]*did.Doc, 0, len(paths))
+
+	for _, path := range paths {
+		docBytes, err := ioutil.ReadFile(path) // nolint: gosec
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pinned DID document %s: %w", path, err)
+		}
+
+		doc, err := did.ParseDocument(docBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pinned DID document %s: %w", path, err)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pinned
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdriapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdri"
+	vdrimock "github.com/hyperledger/aries-framework-go/pkg/mock/vdri"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	pinnedDocs := []*did.Doc{{ID: "did:example:issuer"}}
+
+	t.Run("test without fallback", func(t *testing.T) {
+		r := New(pinnedDocs, nil)
+
+		doc, err := r.Resolve("did:example:issuer")
+		require.NoError(t, err)
+		require.Equal(t, "did:example:issuer", doc.ID)
+
+		_, err = r.Resolve("did:example:holder")
+		require.True(t, errors.Is(err, vdriapi.ErrNotFound))
+		require.Contains(t, err.Error(), "DID did:example:holder is not pinned")
+
+		require.Equal(t, errOffline, r.Store(&did.Doc{}))
+
+		_, err = r.Create("example")
+		require.Equal(t, errOffline, err)
+
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("test with fallback", func(t *testing.T) {
+		r := New(pinnedDocs, &vdrimock.MockVDRIRegistry{
+			ResolveValue: &did.Doc{ID: "did:example:holder"},
+			CreateValue:  &did.Doc{ID: "did:example:new"},
+			PutErr:       errors.New("store error"),
+		})
+
+		doc, err := r.Resolve("did:example:issuer")
+		require.NoError(t, err)
+		require.Equal(t, "did:example:issuer", doc.ID)
+
+		doc, err = r.Resolve("did:example:holder")
+		require.NoError(t, err)
+		require.Equal(t, "did:example:holder", doc.ID)
+
+		require.EqualError(t, r.Store(&did.Doc{}), "store error")
+
+		doc, err = r.Create("example")
+		require.NoError(t, err)
+		require.Equal(t, "did:example:new", doc.ID)
+
+		require.NoError(t, r.Close())
+	})
+}
+
+func TestReadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dids")
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "issuer.json"),
+		[]byte(`{"@context": ["https://w3id.org/did/v1"], "id": "did:example:issuer"}`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("pinned DIDs"), 0600))
+
+	docs, err := ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	require.Equal(t, "did:example:issuer", docs[0].ID)
+
+	docs, err = ReadDir(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	require.Empty(t, docs)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "invalid.json"), []byte("{}"), 0600))
+
+	_, err = ReadDir(dir)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid pinned DID document")
+}